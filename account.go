@@ -0,0 +1,151 @@
+package broadcaster
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// accountState keeps account number and sequence used for signing.
+// It is separated from the immutable tx factory settings and every access to it is guarded by the mutex.
+type accountState struct {
+	mu  sync.Mutex
+	num uint64
+	seq uint64
+}
+
+// get returns account number and sequence.
+func (s *accountState) get() (uint64, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.num, s.seq
+}
+
+// sequence returns sequence.
+func (s *accountState) sequence() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.seq
+}
+
+// set sets account number and sequence.
+func (s *accountState) set(num, seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.num, s.seq = num, seq
+}
+
+// setSequence sets sequence.
+func (s *accountState) setSequence(seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq = seq
+}
+
+// incSequence increments sequence.
+func (s *accountState) incSequence() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+}
+
+// factory returns tx factory with the current account number and sequence.
+func (b *broadcaster) factory() tx.Factory {
+	num, seq := b.acc.get()
+
+	return b.txf.WithAccountNumber(num).WithSequence(seq)
+}
+
+// setAccountMissing records whether the account is missing on chain and notifies Config.OnAccountMissing on change.
+func (b *broadcaster) setAccountMissing(missing bool) {
+	var v int32
+	if missing {
+		v = 1
+	}
+
+	if atomic.SwapInt32(&b.accountMissing, v) == v {
+		return
+	}
+
+	if b.cfg.OnAccountMissing != nil {
+		b.cfg.OnAccountMissing(missing)
+	}
+}
+
+// isAccountMissing returns true if the account was missing on the last lookup.
+func (b *broadcaster) isAccountMissing() bool {
+	return atomic.LoadInt32(&b.accountMissing) == 1
+}
+
+// Backoff of account lookup retries on startup.
+const (
+	startupRetryMinBackoff = 500 * time.Millisecond
+	startupRetryMaxBackoff = 5 * time.Second
+)
+
+// initAccount fetches account number and sequence on startup. Transient errors are retried
+// within Config.StartupRetryTimeout. Missing account fails immediately with ErrAccountNotFound
+// unless Config.AllowUnfundedAccount is set.
+func (b *broadcaster) initAccount() error {
+	deadline := b.cfg.clock().Now().Add(b.cfg.StartupRetryTimeout)
+	backoff := startupRetryMinBackoff
+
+	for {
+		err := b.refreshSequence()
+		if err == nil {
+			return nil
+		}
+
+		if isAccountNotFound(err) {
+			if b.cfg.AllowUnfundedAccount {
+				b.acc.set(0, 0)
+				return nil
+			}
+
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, b.From())
+		}
+
+		if b.cfg.clock().Now().Add(backoff).After(deadline) {
+			return err
+		}
+
+		// Replicas started together shouldn't hit the node in lockstep.
+		b.cfg.clock().Sleep(b.jitter.apply(JitterEqual, backoff))
+		if backoff *= 2; backoff > startupRetryMaxBackoff {
+			backoff = startupRetryMaxBackoff
+		}
+	}
+}
+
+// accountNotFoundRegExp matches messages of missing account reported by the auth module and the ante handler,
+// e.g. "fee payer address: decentr1... does not exist" of simulation.
+var accountNotFoundRegExp = regexp.MustCompile(`(account|address:) \S+ (not found|does not exist)`)
+
+// isAccountNotFound returns true if the error is caused by missing account.
+// Other failures, e.g. "404 not found" of a gateway, don't match.
+func isAccountNotFound(err error) bool {
+	if errors.Is(err, sdkerrors.ErrKeyNotFound) || errors.Is(err, sdkerrors.ErrNotFound) {
+		return true
+	}
+
+	// The client converts query errors to gRPC statuses, so the sdk error is lost.
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) && grpcErr.GRPCStatus().Code() == codes.NotFound {
+		return true
+	}
+
+	return accountNotFoundRegExp.MatchString(err.Error())
+}
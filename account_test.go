@@ -0,0 +1,275 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// TestAccountState_Concurrent is a stress test for go test -race: broadcasts, refreshes and getters
+// access the account state at the same time.
+func TestAccountState_Concurrent(t *testing.T) {
+	const (
+		callers   = 4
+		perCaller = 10
+	)
+
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	done := make(chan struct{})
+	var background sync.WaitGroup
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				if err := b.RefreshSequence(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}
+	}()
+	go func() {
+		defer background.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = b.TxFactory().Sequence()
+				_ = b.ClientContext().FromAddress
+				node.NextBlock()
+			}
+		}
+	}()
+
+	errs := make(chan error, callers*perCaller)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perCaller; j++ {
+				// Amounts differ, so txs signed with a sequence taken back by refresh aren't duplicates.
+				msg := sendMsg(key.Address, int64(i*perCaller+j+1))
+				_, err := b.BroadcastContext(context.Background(), []sdk.Msg{msg}, "", broadcaster.BroadcastOptions{})
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(done)
+	background.Wait()
+	close(errs)
+
+	// Refresh may take the sequence back while txs are in mempool, it's the only acceptable failure.
+	var sent uint64
+	for err := range errs {
+		if err != nil {
+			require.Equal(t, broadcaster.ClassSequenceMismatch, broadcaster.Classify(err), err)
+			continue
+		}
+		sent++
+	}
+	require.NotZero(t, sent)
+
+	node.NextBlock()
+	require.NoError(t, b.RefreshSequence())
+	require.Equal(t, sent, node.Sequence(key.Address))
+	require.Equal(t, sent, b.TxFactory().Sequence())
+}
+
+// startupBroadcaster is the part of the broadcaster checked by startup tests.
+type startupBroadcaster interface {
+	Stats() broadcaster.Stats
+	Close() error
+}
+
+// newOnClock calls New and advances the clock whenever it sleeps. It returns the number of sleeps.
+func newOnClock(cfg broadcaster.Config, clock *testutil.FakeClock) (startupBroadcaster, int, error) {
+	type result struct {
+		b   startupBroadcaster
+		err error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		b, err := broadcaster.New(cfg)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		done <- result{b: b}
+	}()
+
+	var sleeps int
+	for {
+		select {
+		case r := <-done:
+			return r.b, sleeps, r.err
+		case <-time.After(10 * time.Millisecond):
+			if clock.Waiters() > 0 {
+				clock.Advance(5 * time.Second)
+				sleeps++
+			}
+		}
+	}
+}
+
+func TestNew_StartupRetry(t *testing.T) {
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	gatewayErr := errors.New("post failed: unexpected response status 404 Not Found")
+
+	tt := []struct {
+		name     string
+		prepare  func(t *testing.T, node *testutil.FakeNode, key testutil.Key, cfg *broadcaster.Config)
+		sleeps   int
+		missing  bool
+		wantErr  error
+		anyError bool
+	}{
+		{
+			name: "transient failures are retried",
+			prepare: func(_ *testing.T, node *testutil.FakeNode, _ testutil.Key, _ *broadcaster.Config) {
+				node.FailNext("abci_query", netErr, netErr)
+			},
+			sleeps: 2,
+		},
+		{
+			name: "not found of a gateway is retried",
+			prepare: func(_ *testing.T, node *testutil.FakeNode, _ testutil.Key, _ *broadcaster.Config) {
+				node.FailNext("abci_query", gatewayErr, gatewayErr)
+			},
+			sleeps: 2,
+		},
+		{
+			name: "retries are limited by timeout",
+			prepare: func(t *testing.T, node *testutil.FakeNode, _ testutil.Key, _ *broadcaster.Config) {
+				node.SetDown(true)
+				t.Cleanup(func() { node.SetDown(false) })
+			},
+			anyError: true,
+		},
+		{
+			name: "missing account fails fast",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key, _ *broadcaster.Config) {
+				removeAccount(t, node, key)
+			},
+			wantErr: broadcaster.ErrAccountNotFound,
+		},
+		{
+			name: "missing account reported by message",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key, _ *broadcaster.Config) {
+				node.HandleQuery(testutil.AccountQueryPath, func([]byte) ([]byte, error) {
+					return nil, sdkerrors.Wrapf(sdkerrors.ErrUnknownAddress, "account %s does not exist", key.Address)
+				})
+				t.Cleanup(func() { node.HandleQuery(testutil.AccountQueryPath, nil) })
+			},
+			wantErr: broadcaster.ErrAccountNotFound,
+		},
+		{
+			name: "missing account is allowed",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key, cfg *broadcaster.Config) {
+				removeAccount(t, node, key)
+				cfg.AllowUnfundedAccount = true
+			},
+			missing: true,
+		},
+	}
+
+	// The chain is shared, every case restores the node when it's done.
+	node, key := newFakeChain(t)
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			clock := testutil.NewFakeClock(time.Now())
+
+			cfg := testConfig(node, key)
+			cfg.Clock = clock
+			cfg.StartupRetryTimeout = 20 * time.Second
+			tc.prepare(t, node, key, &cfg)
+
+			b, sleeps, err := newOnClock(cfg, clock)
+			switch {
+			case tc.wantErr != nil:
+				require.ErrorIs(t, err, tc.wantErr)
+				require.Zero(t, sleeps, "missing account isn't retried")
+				return
+			case tc.anyError:
+				require.Error(t, err)
+				require.NotErrorIs(t, err, broadcaster.ErrAccountNotFound)
+				return
+			}
+			require.NoError(t, err)
+			defer b.Close()
+
+			require.Equal(t, tc.sleeps, sleeps)
+			require.Equal(t, tc.missing, b.Stats().AccountMissing)
+		})
+	}
+}
+
+// removeAccount removes the key's account from the node until the test is done.
+func removeAccount(t *testing.T, node *testutil.FakeNode, key testutil.Key) {
+	t.Helper()
+
+	node.RemoveAccount(key.Address)
+	t.Cleanup(func() { node.AddAccount(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000)) })
+}
+
+func TestBroadcast_AccountNumberChanged(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	broadcast := func() (*broadcaster.BroadcastResult, error) {
+		return b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	}
+
+	res, err := broadcast()
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+
+	// The chain is exported and imported with the new account number.
+	node.SetAccountNumber(key.Address, 42)
+
+	res, err = broadcast()
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+	require.Equal(t, 2, res.Attempts)
+	require.Len(t, res.History, 1)
+	require.Equal(t, "refresh account", res.History[0].Action)
+	require.ErrorIs(t, res.History[0].Err, sdkerrors.ErrUnauthorized)
+
+	// The number is kept for the next txs.
+	res, err = broadcast()
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Attempts)
+
+	// The node of another chain has the same symptom, but isn't retried.
+	node.SetChainID("other-chain")
+	_, err = broadcast()
+	require.ErrorIs(t, err, broadcaster.ErrChainIDMismatch)
+	require.Equal(t, broadcaster.ClassInvalidRequest, broadcaster.Classify(err))
+}
@@ -0,0 +1,190 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Defaults of adaptive gas estimation.
+const (
+	DefaultAdaptiveGasK          = 3
+	DefaultAdaptiveGasAlpha      = 0.1
+	DefaultAdaptiveGasMinSamples = 10
+)
+
+// AdaptiveGas configures estimation of gas from gas used by committed txs. The estimator keeps exponentially
+// weighted mean and variance of gas used per signature, the list of msg type urls of tx. Txs get gas of
+// Mean + K*stddev once their signature has MinSamples samples, they're simulated before.
+type AdaptiveGas struct {
+	// Enabled enables the estimation.
+	Enabled bool
+	// K is a number of standard deviations added to the mean. DefaultAdaptiveGasK is used by default.
+	K float64
+	// Alpha is the weight of a new sample in (0, 1]. DefaultAdaptiveGasAlpha is used by default.
+	Alpha float64
+	// MinSamples is a number of samples required to use the estimate. DefaultAdaptiveGasMinSamples is used by default.
+	MinSamples int
+	// Store persists estimates, so they survive restarts. Estimates are kept in memory only by default.
+	Store GasStore
+}
+
+// Validate validates the adaptive gas config.
+func (g AdaptiveGas) Validate() error {
+	if g.K < 0 || g.MinSamples < 0 {
+		return errors.New("adaptive gas k and min samples should be positive")
+	}
+
+	if g.Alpha < 0 || g.Alpha > 1 {
+		return errors.New("adaptive gas alpha should be in (0, 1]")
+	}
+
+	return nil
+}
+
+// GasEstimate is the learned gas used by txs of a signature.
+type GasEstimate struct {
+	Samples  int     `json:"samples"`
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+}
+
+// add returns the estimate updated with the sample.
+func (e GasEstimate) add(gasUsed uint64, alpha float64) GasEstimate {
+	x := float64(gasUsed)
+	if e.Samples == 0 {
+		return GasEstimate{Samples: 1, Mean: x}
+	}
+
+	diff := x - e.Mean
+	incr := alpha * diff
+
+	return GasEstimate{
+		Samples:  e.Samples + 1,
+		Mean:     e.Mean + incr,
+		Variance: (1 - alpha) * (e.Variance + diff*incr),
+	}
+}
+
+// GasStore persists learned gas estimates by signatures.
+type GasStore interface {
+	// Load returns all estimates.
+	Load(ctx context.Context) (map[string]GasEstimate, error)
+	// Save saves the estimate of the signature.
+	Save(ctx context.Context, signature string, estimate GasEstimate) error
+}
+
+// gasEstimator learns gas used by committed txs.
+type gasEstimator struct {
+	cfg AdaptiveGas
+
+	mu        sync.Mutex
+	estimates map[string]GasEstimate
+}
+
+func newGasEstimator(ctx context.Context, cfg AdaptiveGas) (*gasEstimator, error) {
+	if cfg.K == 0 {
+		cfg.K = DefaultAdaptiveGasK
+	}
+	if cfg.Alpha == 0 {
+		cfg.Alpha = DefaultAdaptiveGasAlpha
+	}
+	if cfg.MinSamples == 0 {
+		cfg.MinSamples = DefaultAdaptiveGasMinSamples
+	}
+
+	e := &gasEstimator{
+		cfg:       cfg,
+		estimates: make(map[string]GasEstimate),
+	}
+
+	if cfg.Store != nil {
+		estimates, err := cfg.Store.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load gas estimates: %w", err)
+		}
+		for k, v := range estimates {
+			e.estimates[k] = v
+		}
+	}
+
+	return e, nil
+}
+
+// estimate returns gas for txs of the signature. It returns false until the signature has enough samples.
+func (e *gasEstimator) estimate(signature string) (uint64, bool) {
+	e.mu.Lock()
+	v, ok := e.estimates[signature]
+	e.mu.Unlock()
+
+	if !ok || v.Samples < e.cfg.MinSamples {
+		return 0, false
+	}
+
+	return uint64(math.Ceil(v.Mean + e.cfg.K*math.Sqrt(v.Variance))), true
+}
+
+// observe adds gas used by tx of the signature and returns the updated estimate.
+func (e *gasEstimator) observe(signature string, gasUsed uint64) GasEstimate {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	v := e.estimates[signature].add(gasUsed, e.cfg.Alpha)
+	e.estimates[signature] = v
+
+	return v
+}
+
+// table returns a copy of the estimates.
+func (e *gasEstimator) table() map[string]GasEstimate {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	out := make(map[string]GasEstimate, len(e.estimates))
+	for k, v := range e.estimates {
+		out[k] = v
+	}
+
+	return out
+}
+
+// gasSignature returns the signature of msgs: their type urls in order.
+func gasSignature(msgs []sdk.Msg) string {
+	urls := make([]string, len(msgs))
+	for i, msg := range msgs {
+		urls[i] = sdk.MsgTypeURL(msg)
+	}
+
+	return strings.Join(urls, ",")
+}
+
+// observeGas feeds the estimator with gas used by the committed tx. Failed txs are skipped,
+// their gas used says nothing about the gas they need.
+func (b *broadcaster) observeGas(ctx context.Context, msgs []sdk.Msg, resp *sdk.TxResponse) {
+	if b.gasEstimator == nil || resp == nil || resp.Code != 0 || resp.GasUsed <= 0 || len(msgs) == 0 {
+		return
+	}
+
+	signature := gasSignature(msgs)
+	estimate := b.gasEstimator.observe(signature, uint64(resp.GasUsed))
+
+	if store := b.cfg.AdaptiveGas.Store; store != nil {
+		if err := store.Save(ctx, signature, estimate); err != nil {
+			b.warnf(ctx, "failed to save gas estimate of %s: %s", signature, err)
+		}
+	}
+}
+
+// GasEstimates returns gas estimates learned by adaptive gas estimation. It's empty when it's disabled.
+func (b *broadcaster) GasEstimates() map[string]GasEstimate {
+	if b.gasEstimator == nil {
+		return nil
+	}
+
+	return b.gasEstimator.table()
+}
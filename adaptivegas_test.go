@@ -0,0 +1,101 @@
+package broadcaster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// repeat returns the history repeated n times.
+func repeat(n int, history ...uint64) []uint64 {
+	var out []uint64
+	for i := 0; i < n; i++ {
+		out = append(out, history...)
+	}
+
+	return out
+}
+
+func TestGasEstimator(t *testing.T) {
+	tt := []struct {
+		name    string
+		history []uint64
+		// min and max bound the estimate, it's missing if both are zero.
+		min, max uint64
+	}{
+		{
+			name:    "not enough samples",
+			history: repeat(9, 100_000),
+		},
+		{
+			name:    "constant",
+			history: repeat(10, 100_000),
+			min:     100_000,
+			max:     100_000,
+		},
+		{
+			name:    "noisy",
+			history: repeat(50, 90_000, 110_000),
+			// Mean is about 100k and stddev is about 10k, so the estimate covers every sample.
+			min: 125_000,
+			max: 135_000,
+		},
+		{
+			name:    "recent step",
+			history: append(repeat(20, 100_000), repeat(30, 150_000)...),
+			// The mean follows the step, the variance after it keeps the estimate above the new level.
+			min: 150_000,
+			max: 185_000,
+		},
+		{
+			name:    "settled step",
+			history: append(repeat(20, 100_000), repeat(60, 150_000)...),
+			min:     150_000,
+			max:     160_000,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := newGasEstimator(context.Background(), AdaptiveGas{Enabled: true})
+			require.NoError(t, err)
+
+			for _, v := range tc.history {
+				e.observe("send", v)
+			}
+			require.Equal(t, len(tc.history), e.table()["send"].Samples)
+
+			gas, ok := e.estimate("send")
+			if tc.min == 0 && tc.max == 0 {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.GreaterOrEqual(t, gas, tc.min)
+			require.LessOrEqual(t, gas, tc.max)
+
+			_, ok = e.estimate("other")
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestGasEstimator_K(t *testing.T) {
+	estimate := func(k float64) uint64 {
+		e, err := newGasEstimator(context.Background(), AdaptiveGas{Enabled: true, K: k, Alpha: 0.5, MinSamples: 2})
+		require.NoError(t, err)
+
+		for _, v := range repeat(10, 90_000, 110_000) {
+			e.observe("send", v)
+		}
+
+		gas, ok := e.estimate("send")
+		require.True(t, ok)
+		return gas
+	}
+
+	require.Less(t, estimate(1), estimate(2))
+	require.Less(t, estimate(2), estimate(5))
+}
@@ -0,0 +1,123 @@
+package broadcaster_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestBroadcast_AlwaysSimulate(t *testing.T) {
+	const gas = 200_000
+
+	tt := []struct {
+		name        string
+		config      bool
+		opts        broadcaster.BroadcastOptions
+		simulations int32
+	}{
+		{
+			name: "disabled",
+			opts: broadcaster.BroadcastOptions{Gas: gas},
+		},
+		{
+			name:        "config",
+			config:      true,
+			opts:        broadcaster.BroadcastOptions{Gas: gas},
+			simulations: 1,
+		},
+		{
+			name:        "option",
+			opts:        broadcaster.BroadcastOptions{Gas: gas, AlwaysSimulate: true},
+			simulations: 1,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			counting := &countingSimulation{FakeNode: node}
+
+			cfg := testConfig(node, key)
+			cfg.RPCClient = counting
+			cfg.AlwaysSimulate = tc.config
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			// The fixed gas is signed, the simulated one is discarded.
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", tc.opts)
+			require.NoError(t, err)
+			requireCommitted(t, node, res.TxHash)
+			require.Equal(t, tc.simulations, atomic.LoadInt32(&counting.simulations))
+
+			committed, _ := node.CommittedTx(res.TxHash)
+			require.EqualValues(t, gas, committed.TxResult.GasWanted)
+
+			// Invalid tx fails before it's broadcast when it's simulated, otherwise it fails on chain.
+			broadcasts := node.Calls("broadcast_tx_sync")
+			res, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 2_000_000_000)}, "", tc.opts)
+			if tc.simulations == 0 {
+				require.NoError(t, err)
+				node.NextBlock()
+				committed, ok := node.CommittedTx(res.TxHash)
+				require.True(t, ok)
+				require.Equal(t, sdkerrors.ErrInsufficientFunds.ABCICode(), committed.TxResult.Code)
+				return
+			}
+			require.ErrorContains(t, err, "insufficient funds")
+			require.Equal(t, broadcasts, node.Calls("broadcast_tx_sync"))
+		})
+	}
+}
+
+func TestBroadcast_AlwaysSimulate_Retry(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		skip := skip
+		t.Run(map[bool]string{false: "simulate", true: "skip"}[skip], func(t *testing.T) {
+			node, key := newFakeChain(t)
+			counting := &countingSimulation{FakeNode: node}
+
+			cfg := testConfig(node, key)
+			cfg.RPCClient = counting
+			cfg.Gas = 200_000
+			cfg.AlwaysSimulate = true
+			cfg.SkipSimulationOnRetry = skip
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			// The first attempt is rejected by the node, so tx is retried.
+			var rejected int32
+			node.OnCheckTx(func(testutil.FakeTx) error {
+				if atomic.CompareAndSwapInt32(&rejected, 0, 1) {
+					return sdkerrors.ErrInsufficientFunds
+				}
+				return nil
+			})
+
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+			require.Equal(t, 2, res.Attempts)
+			requireCommitted(t, node, res.TxHash)
+
+			committed, _ := node.CommittedTx(res.TxHash)
+			require.EqualValues(t, cfg.Gas, committed.TxResult.GasWanted)
+
+			simulations := int32(2)
+			if skip {
+				simulations = 1
+			}
+			require.Equal(t, simulations, atomic.LoadInt32(&counting.simulations))
+		})
+	}
+}
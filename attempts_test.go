@@ -0,0 +1,218 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestBroadcast_AttemptsError(t *testing.T) {
+	node, key := newFakeChain(t)
+	srv := node.Serve()
+	defer srv.Close()
+
+	b, err := broadcaster.New(uriConfig(node, key, srv.URL))
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Another process takes the sequence, then the account runs out of funds.
+	node.SetSequence(key.Address, 1)
+	node.OnCheckTx(func(testutil.FakeTx) error { return sdkerrors.ErrInsufficientFunds })
+
+	_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{Gas: 100000})
+
+	// The terminal error is matched.
+	require.ErrorIs(t, err, sdkerrors.ErrInsufficientFunds)
+	require.NotErrorIs(t, err, sdkerrors.ErrWrongSequence)
+	var txErr *broadcaster.TxError
+	require.ErrorAs(t, err, &txErr)
+	require.Equal(t, sdkerrors.ErrInsufficientFunds.ABCICode(), txErr.Response.Code)
+	require.Equal(t, broadcaster.ClassInsufficientFunds, broadcaster.Classify(err))
+
+	// Every attempt is enumerable with the action taken after it.
+	var attemptsErr *broadcaster.AttemptsError
+	require.ErrorAs(t, err, &attemptsErr)
+	attempts := attemptsErr.Attempts()
+	require.Len(t, attempts, 2)
+
+	require.Equal(t, 1, attempts[0].Attempt)
+	require.Equal(t, srv.URL, attempts[0].NodeURI)
+	require.Equal(t, "fix sequence", attempts[0].Action)
+	require.ErrorIs(t, attempts[0].Err, sdkerrors.ErrWrongSequence)
+
+	require.Equal(t, 2, attempts[1].Attempt)
+	require.Equal(t, srv.URL, attempts[1].NodeURI)
+	require.Empty(t, attempts[1].Action)
+	require.ErrorIs(t, attempts[1].Err, sdkerrors.ErrInsufficientFunds)
+
+	require.Contains(t, err.Error(), "after 2 attempts")
+	require.Contains(t, err.Error(), "attempt 1 on "+srv.URL+" (fix sequence)")
+}
+
+func TestBroadcast_SingleAttemptError(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.OnCheckTx(func(testutil.FakeTx) error { return sdkerrors.ErrInsufficientFunds })
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+		broadcaster.BroadcastOptions{Gas: 100000, DisableAutoRetry: true})
+	require.ErrorIs(t, err, sdkerrors.ErrInsufficientFunds)
+
+	var attemptsErr *broadcaster.AttemptsError
+	require.False(t, errors.As(err, &attemptsErr), "single attempt isn't wrapped")
+}
+
+// countingBroadcasts is the node which counts broadcasts and fails them with err if it's set.
+type countingBroadcasts struct {
+	rpcclient.Client
+	err   error
+	calls int32
+}
+
+func (n *countingBroadcasts) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	atomic.AddInt32(&n.calls, 1)
+	if n.err != nil {
+		return nil, n.err
+	}
+
+	return n.Client.BroadcastTxSync(ctx, tx)
+}
+
+func TestBroadcast_DisableAutoRetry(t *testing.T) {
+	tt := []struct {
+		name  string
+		setup func(t *testing.T, node *testutil.FakeNode, key testutil.Key) *countingBroadcasts
+		gas   uint64
+		class broadcaster.ErrorClass
+	}{
+		{
+			name: "sequence mismatch",
+			setup: func(_ *testing.T, node *testutil.FakeNode, key testutil.Key) *countingBroadcasts {
+				return &countingBroadcasts{Client: &competingWriter{FakeNode: node, address: key.Address, enabled: 1}}
+			},
+			class: broadcaster.ClassSequenceMismatch,
+		},
+		{
+			name: "out of gas",
+			setup: func(_ *testing.T, node *testutil.FakeNode, _ testutil.Key) *countingBroadcasts {
+				return &countingBroadcasts{Client: node}
+			},
+			gas:   1000,
+			class: broadcaster.ClassOutOfGas,
+		},
+		{
+			name: "mempool is full",
+			setup: func(t *testing.T, node *testutil.FakeNode, _ testutil.Key) *countingBroadcasts {
+				// The chain is shared, so txs are rejected only until the case is done.
+				full := int32(1)
+				t.Cleanup(func() { atomic.StoreInt32(&full, 0) })
+				node.OnCheckTx(func(testutil.FakeTx) error {
+					if atomic.LoadInt32(&full) == 1 {
+						return sdkerrors.ErrMempoolIsFull
+					}
+					return nil
+				})
+				return &countingBroadcasts{Client: node}
+			},
+			class: broadcaster.ClassTransient,
+		},
+		{
+			name: "rpc error",
+			setup: func(_ *testing.T, node *testutil.FakeNode, _ testutil.Key) *countingBroadcasts {
+				return &countingBroadcasts{Client: node, err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}}
+			},
+			class: broadcaster.ClassNodeUnavailable,
+		},
+	}
+
+	node, key := newFakeChain(t)
+
+	for _, tc := range tt {
+		tc := tc
+		for _, perCall := range []bool{false, true} {
+			perCall := perCall
+			name := tc.name + "/config"
+			if perCall {
+				name = tc.name + "/option"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				counting := tc.setup(t, node, key)
+
+				cfg := testConfig(node, key)
+				cfg.RPCClient = counting
+				cfg.RetryPolicy.MaxAttempts = 5
+				cfg.RetryPolicy.OutOfGasMultiplier = 10
+				cfg.DisableAutoRetry = !perCall
+
+				b, err := broadcaster.New(cfg)
+				require.NoError(t, err)
+				defer b.Close()
+
+				for i := 1; i <= 2; i++ {
+					res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+						broadcaster.BroadcastOptions{Gas: tc.gas, DisableAutoRetry: perCall})
+					require.Equal(t, tc.class, broadcaster.Classify(err), err)
+					require.EqualValues(t, i, atomic.LoadInt32(&counting.calls))
+					if res != nil {
+						require.LessOrEqual(t, res.Attempts, 1)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestBroadcast_DisableAutoRetry_CorrectsSequence(t *testing.T) {
+	node, key := newFakeChain(t)
+	writer := &competingWriter{FakeNode: node, address: key.Address}
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = writer
+	cfg.DisableAutoRetry = true
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	broadcast := func(gas uint64) (*broadcaster.BroadcastResult, error) {
+		return b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{Gas: gas})
+	}
+
+	// The mismatch in CheckTx isn't retried, but the next call uses the expected sequence.
+	writer.write()
+	_, err = broadcast(100000)
+	require.Equal(t, broadcaster.ClassSequenceMismatch, broadcaster.Classify(err))
+	require.Equal(t, 1, node.Calls("broadcast_tx_sync"))
+
+	res, err := broadcast(100000)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Attempts)
+	requireCommitted(t, node, res.TxHash)
+
+	// The mismatch in simulation fails before broadcasting, the sequence is corrected as well.
+	writer.write()
+	_, err = broadcast(0)
+	require.Equal(t, broadcaster.ClassSequenceMismatch, broadcaster.Classify(err))
+	require.Equal(t, 2, node.Calls("broadcast_tx_sync"))
+
+	res, err = broadcast(0)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Attempts)
+	requireCommitted(t, node, res.TxHash)
+}
@@ -0,0 +1,263 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestexported "github.com/cosmos/cosmos-sdk/x/auth/vesting/exported"
+)
+
+// DefaultBalanceHysteresis is the default margin above a threshold the balance should reach to leave its state.
+const DefaultBalanceHysteresis = 0.1
+
+// BalanceWatch configures watching of the account's spendable balance. The balance is sampled every Interval
+// and compared with the thresholds. A threshold is exceeded when any of its denoms is below it.
+type BalanceWatch struct {
+	// Interval is an interval of sampling the balance. Watching is disabled when it's zero.
+	Interval time.Duration
+	// LowThreshold is the balance below which it's considered low.
+	LowThreshold sdk.Coins
+	// CriticalThreshold is the balance below which it's considered critical.
+	CriticalThreshold sdk.Coins
+	// Hysteresis is a fraction of a threshold the balance should exceed it by to leave the state,
+	// so the state doesn't flap around the threshold. DefaultBalanceHysteresis is used by default.
+	Hysteresis float64
+	// RejectBroadcasts makes broadcasts fail with ErrBalanceTooLow while the balance is critical.
+	RejectBroadcasts bool
+	// OnChange is called when the balance state changes.
+	OnChange func(state BalanceState, balance sdk.Coins)
+}
+
+// Validate validates the balance watch config.
+func (w BalanceWatch) Validate() error {
+	if w.Interval < 0 || w.Hysteresis < 0 {
+		return errors.New("balance watch interval and hysteresis should be positive")
+	}
+
+	if err := w.LowThreshold.Validate(); err != nil {
+		return fmt.Errorf("invalid low balance threshold: %w", err)
+	}
+
+	if err := w.CriticalThreshold.Validate(); err != nil {
+		return fmt.Errorf("invalid critical balance threshold: %w", err)
+	}
+
+	return nil
+}
+
+// BalanceState is a state of the account's balance.
+type BalanceState int
+
+const (
+	// BalanceOK is balance above the thresholds.
+	BalanceOK BalanceState = iota
+	// BalanceLow is balance below the low threshold.
+	BalanceLow
+	// BalanceCritical is balance below the critical threshold.
+	BalanceCritical
+)
+
+// String implements fmt.Stringer.
+func (s BalanceState) String() string {
+	switch s {
+	case BalanceLow:
+		return "low"
+	case BalanceCritical:
+		return "critical"
+	default:
+		return "ok"
+	}
+}
+
+// balanceWatcher samples the balance in background and detects its state.
+type balanceWatcher struct {
+	cfg     BalanceWatch
+	clock   Clock
+	jitter  *jitterSource
+	balance func(ctx context.Context) (sdk.Coins, error)
+
+	mu      sync.Mutex
+	state   BalanceState
+	current sdk.Coins
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newBalanceWatcher(
+	cfg BalanceWatch, clock Clock, jitter *jitterSource, balance func(ctx context.Context) (sdk.Coins, error),
+) *balanceWatcher {
+	if cfg.Hysteresis == 0 {
+		cfg.Hysteresis = DefaultBalanceHysteresis
+	}
+
+	w := &balanceWatcher{
+		cfg:     cfg,
+		clock:   clock,
+		jitter:  jitter,
+		balance: balance,
+
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w
+}
+
+func (w *balanceWatcher) run() {
+	defer close(w.done)
+
+	for {
+		// Up to a tenth of the interval is added, so replicas don't sample in lockstep.
+		timer := w.clock.NewTimer(w.cfg.Interval + w.jitter.apply(JitterFull, w.cfg.Interval/10))
+
+		select {
+		case <-w.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+			w.sample()
+		}
+	}
+}
+
+func (w *balanceWatcher) sample() {
+	ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Interval)
+	defer cancel()
+
+	// Unavailable node says nothing about the balance, so failed samples are skipped.
+	balance, err := w.balance(ctx)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	next := w.next(balance)
+	changed := next != w.state
+	w.state, w.current = next, balance
+	w.mu.Unlock()
+
+	if changed && w.cfg.OnChange != nil {
+		w.cfg.OnChange(next, balance)
+	}
+}
+
+// next returns the state of the balance. Thresholds of the current state are raised by the hysteresis.
+// w.mu should be held by caller.
+func (w *balanceWatcher) next(balance sdk.Coins) BalanceState {
+	low, critical := w.cfg.LowThreshold, w.cfg.CriticalThreshold
+	if w.state >= BalanceLow {
+		low = raise(low, w.cfg.Hysteresis)
+	}
+	if w.state == BalanceCritical {
+		critical = raise(critical, w.cfg.Hysteresis)
+	}
+
+	switch {
+	case isBelow(balance, critical):
+		return BalanceCritical
+	case isBelow(balance, low):
+		return BalanceLow
+	default:
+		return BalanceOK
+	}
+}
+
+// get returns the balance state and the last sampled balance.
+func (w *balanceWatcher) get() (BalanceState, sdk.Coins) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.state, w.current
+}
+
+// close stops the watcher and waits for it to exit.
+func (w *balanceWatcher) close() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+// isBelow returns true if balance of any denom of the threshold is below it.
+func isBelow(balance, threshold sdk.Coins) bool {
+	return !threshold.Empty() && !balance.IsAllGTE(threshold)
+}
+
+// raise returns coins increased by the fraction.
+func raise(coins sdk.Coins, fraction float64) sdk.Coins {
+	factor := sdk.OneDec().Add(sdk.MustNewDecFromStr(fmt.Sprintf("%.6f", fraction)))
+
+	out := make(sdk.Coins, len(coins))
+	for i, v := range coins {
+		out[i] = sdk.NewCoin(v.Denom, v.Amount.ToDec().Mul(factor).TruncateInt())
+	}
+
+	return out
+}
+
+// GetSpendableBalance returns balances of the address which could be spent at the latest block.
+// Coins of vesting accounts which are still locked at the latest block time are excluded.
+func (b *broadcaster) GetSpendableBalance(ctx context.Context, address sdk.AccAddress) (sdk.Coins, error) {
+	balance, err := b.balance(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := authtypes.NewQueryClient(b.ctx).Account(ctx, &authtypes.QueryAccountRequest{
+		Address: address.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account: %w", err)
+	}
+
+	var acc authtypes.AccountI
+	if err := b.ctx.InterfaceRegistry.UnpackAny(res.Account, &acc); err != nil {
+		return nil, fmt.Errorf("failed to unpack account: %w", err)
+	}
+
+	vacc, ok := acc.(vestexported.VestingAccount)
+	if !ok {
+		return balance, nil
+	}
+
+	block, err := b.LatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return spendable(balance, vacc.LockedCoins(block.Time)), nil
+}
+
+// spendable returns balance without locked coins. Denoms with locked coins exceeding the balance are omitted.
+func spendable(balance, locked sdk.Coins) sdk.Coins {
+	out := sdk.NewCoins()
+	for _, v := range balance {
+		if amount := v.Amount.Sub(locked.AmountOf(v.Denom)); amount.IsPositive() {
+			out = out.Add(sdk.NewCoin(v.Denom, amount))
+		}
+	}
+
+	return out
+}
+
+// checkBalance returns ErrBalanceTooLow if the balance is critical and broadcasts should be rejected.
+func (b *broadcaster) checkBalance() error {
+	if b.balanceWatch == nil || !b.cfg.BalanceWatch.RejectBroadcasts {
+		return nil
+	}
+
+	if state, balance := b.balanceWatch.get(); state == BalanceCritical {
+		return fmt.Errorf("%w: %s is below critical threshold %s", ErrBalanceTooLow, balance, b.cfg.BalanceWatch.CriticalThreshold)
+	}
+
+	return nil
+}
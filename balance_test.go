@@ -0,0 +1,211 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingtypes "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestBalanceWatch(t *testing.T) {
+	const interval = 10 * time.Second
+
+	node, key := newFakeChain(t)
+	clock := testutil.NewFakeClock(time.Now())
+
+	type change struct {
+		state   broadcaster.BalanceState
+		balance sdk.Coins
+	}
+	changes := make(chan change, 10)
+
+	cfg := testConfig(node, key)
+	cfg.Clock = clock
+	cfg.BalanceWatch = broadcaster.BalanceWatch{
+		Interval:          interval,
+		LowThreshold:      sdk.NewCoins(sdk.NewInt64Coin(testDenom, 1000)),
+		CriticalThreshold: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 100)),
+		RejectBroadcasts:  true,
+		OnChange: func(state broadcaster.BalanceState, balance sdk.Coins) {
+			changes <- change{state: state, balance: balance}
+		},
+	}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+
+	// sample lets the watcher sample the balance of the amount once. The watcher's next timer means the sample is done.
+	sample := func(amount int64) {
+		t.Helper()
+
+		node.SetBalance(key.Address, sdk.NewInt64Coin(testDenom, amount))
+		clock.BlockUntil(1)
+		clock.Advance(interval + interval/10)
+		clock.BlockUntil(1)
+
+		require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(testDenom, amount)), b.Stats().Balance)
+	}
+	requireChange := func(state broadcaster.BalanceState, amount int64) {
+		t.Helper()
+
+		require.Equal(t, change{state: state, balance: sdk.NewCoins(sdk.NewInt64Coin(testDenom, amount))}, <-changes)
+		require.Equal(t, state, b.Stats().BalanceState)
+	}
+	broadcast := func() error {
+		_, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+		return err
+	}
+
+	sample(1_000_000)
+	require.Equal(t, broadcaster.BalanceOK, b.Stats().BalanceState)
+	require.Empty(t, changes)
+
+	sample(500)
+	requireChange(broadcaster.BalanceLow, 500)
+	require.NoError(t, broadcast())
+
+	// The balance should exceed the threshold by the hysteresis to leave the state.
+	sample(1050)
+	require.Empty(t, changes)
+	require.Equal(t, broadcaster.BalanceLow, b.Stats().BalanceState)
+
+	sample(50)
+	requireChange(broadcaster.BalanceCritical, 50)
+	broadcasts := node.Calls("broadcast_tx_sync")
+	require.ErrorIs(t, broadcast(), broadcaster.ErrBalanceTooLow)
+	require.Equal(t, broadcasts, node.Calls("broadcast_tx_sync"))
+
+	sample(105)
+	require.Empty(t, changes)
+	require.ErrorIs(t, broadcast(), broadcaster.ErrBalanceTooLow)
+
+	sample(200)
+	requireChange(broadcaster.BalanceLow, 200)
+
+	sample(2000)
+	requireChange(broadcaster.BalanceOK, 2000)
+	require.NoError(t, broadcast())
+
+	// The watcher stops on close.
+	b.Close()
+	require.Zero(t, clock.Waiters())
+}
+
+func TestBalanceWatch_Validate(t *testing.T) {
+	cfg := validConfig()
+	cfg.BalanceWatch = broadcaster.BalanceWatch{Interval: -time.Second}
+	require.ErrorContains(t, cfg.Validate(), "balance watch interval")
+
+	cfg.BalanceWatch = broadcaster.BalanceWatch{
+		Interval:     time.Second,
+		LowThreshold: sdk.Coins{sdk.Coin{Denom: testDenom, Amount: sdk.NewInt(-1)}},
+	}
+	require.ErrorContains(t, cfg.Validate(), "invalid low balance threshold")
+}
+
+func TestGetSpendableBalance(t *testing.T) {
+	const hour = int64(time.Hour / time.Second)
+
+	start := testutil.FakeGenesisTime
+	vesting := sdk.NewCoins(sdk.NewInt64Coin(testDenom, 1000), sdk.NewInt64Coin("ustake", 400))
+	balance := []sdk.Coin{sdk.NewInt64Coin(testDenom, 1500), sdk.NewInt64Coin("ustake", 400)}
+
+	type point struct {
+		at   time.Duration
+		want sdk.Coins
+	}
+
+	tt := []struct {
+		name    string
+		account func(base *authtypes.BaseAccount) authtypes.AccountI
+		points  []point
+	}{
+		{
+			name: "continuous",
+			account: func(base *authtypes.BaseAccount) authtypes.AccountI {
+				return vestingtypes.NewContinuousVestingAccount(base, vesting, start.Unix(), start.Unix()+100*hour)
+			},
+			points: []point{
+				{at: 0, want: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 500))},
+				{at: 25 * time.Hour, want: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 750), sdk.NewInt64Coin("ustake", 100))},
+				{at: 50 * time.Hour, want: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 1000), sdk.NewInt64Coin("ustake", 200))},
+				{at: 100 * time.Hour, want: sdk.NewCoins(balance...)},
+				{at: 200 * time.Hour, want: sdk.NewCoins(balance...)},
+			},
+		},
+		{
+			name: "delayed",
+			account: func(base *authtypes.BaseAccount) authtypes.AccountI {
+				return vestingtypes.NewDelayedVestingAccount(base, vesting, start.Unix()+10*hour)
+			},
+			points: []point{
+				{at: 0, want: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 500))},
+				{at: 5 * time.Hour, want: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 500))},
+				{at: 10*time.Hour - time.Second, want: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 500))},
+				{at: 10 * time.Hour, want: sdk.NewCoins(balance...)},
+			},
+		},
+		{
+			name: "not vesting",
+			account: func(base *authtypes.BaseAccount) authtypes.AccountI {
+				return base
+			},
+			points: []point{
+				{at: 0, want: sdk.NewCoins(balance...)},
+				{at: 100 * time.Hour, want: sdk.NewCoins(balance...)},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			operator := testutil.NewKey(t.Name() + "/vesting")
+			node.PutAccount(tc.account(authtypes.NewBaseAccountWithAddress(operator.Address)), balance...)
+
+			b, err := broadcaster.New(testConfig(node, key))
+			require.NoError(t, err)
+			defer b.Close()
+
+			for _, p := range tc.points {
+				node.NextBlockAt(start.Add(p.at))
+
+				got, err := b.GetSpendableBalance(context.Background(), operator.Address)
+				require.NoError(t, err)
+				require.Equal(t, p.want, got, p.at)
+			}
+		})
+	}
+}
+
+func TestReady_VestingBalance(t *testing.T) {
+	node := testutil.NewFakeNode()
+	key := testutil.NewKey(t.Name())
+	locked := sdk.NewCoins(sdk.NewInt64Coin(testDenom, 1000))
+	end := testutil.FakeGenesisTime.Add(10 * time.Hour)
+	node.PutAccount(
+		vestingtypes.NewDelayedVestingAccount(authtypes.NewBaseAccountWithAddress(key.Address), locked, end.Unix()),
+		sdk.NewInt64Coin(testDenom, 1500),
+	)
+
+	cfg := testConfig(node, key)
+	cfg.MinBalance = sdk.NewCoins(sdk.NewInt64Coin(testDenom, 1000))
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// The bank balance is enough, but most of it is still locked.
+	require.ErrorIs(t, b.Ready(context.Background()), broadcaster.ErrBalanceTooLow)
+
+	node.NextBlockAt(end)
+	require.NoError(t, b.Ready(context.Background()))
+}
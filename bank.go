@@ -0,0 +1,244 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// ErrInsufficientFunds is returned when account's balance isn't enough.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// InsufficientFundsError is returned when account's balance isn't enough. It matches ErrInsufficientFunds.
+type InsufficientFundsError struct {
+	// Shortfall is the missing amount. It is empty when the node rejected tx and only fees are missing
+	// or the balance can't be fetched.
+	Shortfall sdk.Coins
+	// Err is the error of tx rejected by the node. It is nil when the balance is checked before broadcasting.
+	Err error
+}
+
+func (e *InsufficientFundsError) Error() string {
+	msg := ErrInsufficientFunds.Error()
+	if !e.Shortfall.Empty() {
+		msg = fmt.Sprintf("%s: %s is missing", msg, e.Shortfall)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Err)
+	}
+
+	return msg
+}
+
+// Is makes InsufficientFundsError match ErrInsufficientFunds.
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+func (e *InsufficientFundsError) Unwrap() error {
+	return e.Err
+}
+
+// SendCoins sends coins to the address.
+// The spendable balance is checked before broadcasting, so fees are not a part of the shortfall.
+func (b *broadcaster) SendCoins(ctx context.Context, to sdk.AccAddress, amount sdk.Coins, memo string) (*BroadcastResult, error) {
+	if !amount.IsValid() || amount.IsZero() {
+		return nil, fmt.Errorf("invalid amount: %s", amount)
+	}
+
+	balance, err := b.GetSpendableBalance(ctx, b.From())
+	if err != nil {
+		return nil, err
+	}
+
+	if shortfall := shortfall(balance, amount); !shortfall.Empty() {
+		return nil, &InsufficientFundsError{Shortfall: shortfall}
+	}
+
+	res, err := b.BroadcastContext(ctx, []sdk.Msg{banktypes.NewMsgSend(b.From(), to, amount)}, memo, BroadcastOptions{})
+	if err != nil && isInsufficientFunds(res) {
+		return res, b.rejectedFunds(ctx, err, amount)
+	}
+
+	return res, err
+}
+
+// SendCoinsString sends coins to the address. Amount could be written in any unit of the denom metadata,
+// e.g. "10.5dec", it's converted to the base denom.
+func (b *broadcaster) SendCoinsString(ctx context.Context, to, amount, memo string) (*BroadcastResult, error) {
+	addr, err := sdk.AccAddressFromBech32(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	coins, err := b.ParseCoinsHuman(ctx, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.SendCoins(ctx, addr, coins, memo)
+}
+
+// balance returns all balances of the address.
+func (b *broadcaster) balance(ctx context.Context, address sdk.AccAddress) (sdk.Coins, error) {
+	res, err := banktypes.NewQueryClient(b.ctx).AllBalances(ctx, &banktypes.QueryAllBalancesRequest{
+		Address: address.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balance: %w", err)
+	}
+
+	return res.Balances, nil
+}
+
+// shortfall returns amount which is missing in balance.
+func shortfall(balance, amount sdk.Coins) sdk.Coins {
+	out := sdk.NewCoins()
+	for _, v := range amount {
+		if have := balance.AmountOf(v.Denom); have.LT(v.Amount) {
+			out = out.Add(sdk.NewCoin(v.Denom, v.Amount.Sub(have)))
+		}
+	}
+
+	return out
+}
+
+// isInsufficientFunds returns true if the node rejected tx because of insufficient funds.
+func isInsufficientFunds(res *BroadcastResult) bool {
+	return res != nil && res.Response != nil &&
+		res.Response.Codespace == sdkerrors.RootCodespace &&
+		res.Response.Code == sdkerrors.ErrInsufficientFunds.ABCICode()
+}
+
+// rejectedFunds returns InsufficientFundsError wrapping err of tx spending amount which was rejected by the node.
+// The balance is fetched again, since it has changed after it was checked.
+func (b *broadcaster) rejectedFunds(ctx context.Context, err error, amount sdk.Coins) error {
+	out := &InsufficientFundsError{Err: err}
+	if balance, berr := b.GetSpendableBalance(ctx, b.From()); berr == nil {
+		out.Shortfall = shortfall(balance, amount)
+	}
+
+	return out
+}
+
+// DefaultMaxOutputsPerTx is the default number of MultiSend outputs in a single tx.
+const DefaultMaxOutputsPerTx = 500
+
+// ErrDuplicateRecipient is returned by MultiSend when outputs contain the same address twice.
+var ErrDuplicateRecipient = errors.New("duplicate recipient")
+
+// Output is a recipient of MultiSend.
+type Output struct {
+	Address sdk.AccAddress
+	Coins   sdk.Coins
+}
+
+// MultiSendOptions configures MultiSend.
+type MultiSendOptions struct {
+	// MaxOutputsPerTx limits number of outputs in a single tx. DefaultMaxOutputsPerTx is used by default.
+	MaxOutputsPerTx int
+	// MergeDuplicates merges outputs with the same address instead of returning ErrDuplicateRecipient.
+	MergeDuplicates bool
+}
+
+// MultiSendResult contains the outcome of MultiSend.
+type MultiSendResult struct {
+	// Results contains results of broadcast txs in order of outputs.
+	Results []*BroadcastResult
+	// TxHashes maps recipients' addresses to hashes of txs which paid them.
+	TxHashes map[string]string
+}
+
+// MultiSend sends coins to several recipients with MsgMultiSend. Outputs are split into txs of
+// MaxOutputsPerTx outputs, every tx has a single input from the broadcaster's account.
+// Broadcasting stops on the first error, the result of already broadcast txs is returned alongside the error.
+func (b *broadcaster) MultiSend(ctx context.Context, outputs []Output, memo string, opts MultiSendOptions) (*MultiSendResult, error) {
+	outputs, err := mergeOutputs(outputs, opts.MergeDuplicates)
+	if err != nil {
+		return nil, err
+	}
+
+	size := opts.MaxOutputsPerTx
+	if size <= 0 {
+		size = DefaultMaxOutputsPerTx
+	}
+
+	var msgs []*banktypes.MsgMultiSend
+	for i := 0; i < len(outputs); i += size {
+		end := i + size
+		if end > len(outputs) {
+			end = len(outputs)
+		}
+
+		msg := newMsgMultiSend(b.From(), outputs[i:end])
+		if err := msg.ValidateBasic(); err != nil {
+			return nil, fmt.Errorf("invalid outputs: %w", err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	out := &MultiSendResult{
+		TxHashes: make(map[string]string, len(outputs)),
+	}
+	for _, msg := range msgs {
+		res, err := b.BroadcastContext(ctx, []sdk.Msg{msg}, memo, BroadcastOptions{})
+		if err != nil {
+			if isInsufficientFunds(res) {
+				err = b.rejectedFunds(ctx, err, msg.Inputs[0].Coins)
+			}
+			return out, err
+		}
+
+		out.Results = append(out.Results, res)
+		for _, v := range msg.Outputs {
+			out.TxHashes[v.Address] = res.TxHash
+		}
+	}
+
+	return out, nil
+}
+
+// newMsgMultiSend returns MsgMultiSend with a single input equal to the sum of outputs.
+func newMsgMultiSend(from sdk.AccAddress, outputs []Output) *banktypes.MsgMultiSend {
+	total := sdk.NewCoins()
+	list := make([]banktypes.Output, len(outputs))
+	for i, v := range outputs {
+		total = total.Add(v.Coins...)
+		list[i] = banktypes.NewOutput(v.Address, v.Coins)
+	}
+
+	return banktypes.NewMsgMultiSend([]banktypes.Input{banktypes.NewInput(from, total)}, list)
+}
+
+// mergeOutputs checks outputs and merges ones with the same address if merge is set.
+func mergeOutputs(outputs []Output, merge bool) ([]Output, error) {
+	if len(outputs) == 0 {
+		return nil, errors.New("outputs are empty")
+	}
+
+	index := make(map[string]int, len(outputs))
+	out := make([]Output, 0, len(outputs))
+	for _, v := range outputs {
+		if !v.Coins.IsValid() || v.Coins.IsZero() {
+			return nil, fmt.Errorf("invalid amount for %s: %s", v.Address, v.Coins)
+		}
+
+		i, ok := index[v.Address.String()]
+		if !ok {
+			index[v.Address.String()] = len(out)
+			out = append(out, v)
+			continue
+		}
+
+		if !merge {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateRecipient, v.Address)
+		}
+		out[i].Coins = out[i].Coins.Add(v.Coins...)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,268 @@
+package broadcaster_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// payouts returns n outputs to distinct recipients with amounts 1..n.
+func payouts(n int) []broadcaster.Output {
+	out := make([]broadcaster.Output, n)
+	for i := range out {
+		out[i] = broadcaster.Output{
+			Address: testutil.NewKey(fmt.Sprint("payee", i)).Address,
+			Coins:   sdk.NewCoins(sdk.NewInt64Coin(testDenom, int64(i+1))),
+		}
+	}
+	return out
+}
+
+func TestMultiSend_Chunks(t *testing.T) {
+	const size = 3
+
+	tt := []struct {
+		name   string
+		n      int
+		chunks []int
+	}{
+		{name: "single output", n: 1, chunks: []int{1}},
+		{name: "one below the limit", n: size - 1, chunks: []int{2}},
+		{name: "the limit", n: size, chunks: []int{3}},
+		{name: "one above the limit", n: size + 1, chunks: []int{3, 1}},
+		{name: "twice the limit", n: 2 * size, chunks: []int{3, 3}},
+		{name: "twice the limit and one", n: 2*size + 1, chunks: []int{3, 3, 1}},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			b, err := broadcaster.New(testConfig(node, key))
+			require.NoError(t, err)
+			defer b.Close()
+
+			outputs := payouts(tc.n)
+			res, err := b.MultiSend(context.Background(), outputs, "payout", broadcaster.MultiSendOptions{MaxOutputsPerTx: size})
+			require.NoError(t, err)
+			require.Len(t, res.Results, len(tc.chunks))
+			require.Len(t, res.TxHashes, tc.n)
+
+			decode := b.EncodingConfig().TxConfig.TxDecoder()
+			mempool := node.Mempool()
+			require.Len(t, mempool, len(tc.chunks))
+
+			var next int
+			for i, v := range mempool {
+				require.Equal(t, res.Results[i].TxHash, v.Hash)
+
+				tx, err := decode(v.Bytes)
+				require.NoError(t, err)
+				require.Len(t, tx.GetMsgs(), 1)
+
+				msg := tx.GetMsgs()[0].(*banktypes.MsgMultiSend)
+				require.Len(t, msg.Outputs, tc.chunks[i])
+
+				// The single input of every tx pays exactly the sum of its outputs.
+				require.Len(t, msg.Inputs, 1)
+				require.Equal(t, key.Address.String(), msg.Inputs[0].Address)
+				total := sdk.NewCoins()
+				for _, o := range msg.Outputs {
+					require.Equal(t, outputs[next].Address.String(), o.Address)
+					require.Equal(t, v.Hash, res.TxHashes[o.Address])
+					total = total.Add(o.Coins...)
+					next++
+				}
+				require.Equal(t, total, msg.Inputs[0].Coins)
+			}
+			require.Equal(t, tc.n, next)
+
+			for _, v := range res.Results {
+				requireCommitted(t, node, v.TxHash)
+			}
+			for _, v := range outputs {
+				require.Equal(t, v.Coins, node.Balance(v.Address))
+			}
+		})
+	}
+}
+
+func TestMultiSend_Duplicates(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	outputs := append(payouts(2), payouts(1)...)
+
+	_, err = b.MultiSend(context.Background(), outputs, "", broadcaster.MultiSendOptions{})
+	require.ErrorIs(t, err, broadcaster.ErrDuplicateRecipient)
+	require.Empty(t, node.Mempool())
+
+	res, err := b.MultiSend(context.Background(), outputs, "", broadcaster.MultiSendOptions{MergeDuplicates: true})
+	require.NoError(t, err)
+	require.Len(t, res.TxHashes, 2)
+	requireCommitted(t, node, res.Results[0].TxHash)
+
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(testDenom, 2)), node.Balance(outputs[0].Address))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(testDenom, 2)), node.Balance(outputs[1].Address))
+}
+
+func TestMultiSend_InvalidOutputs(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	zero := payouts(2)
+	zero[1].Coins = sdk.Coins{}
+
+	for name, outputs := range map[string][]broadcaster.Output{
+		"empty":       nil,
+		"zero amount": zero,
+	} {
+		_, err := b.MultiSend(context.Background(), outputs, "", broadcaster.MultiSendOptions{})
+		require.Error(t, err, name)
+	}
+	require.Empty(t, node.Mempool())
+}
+
+func TestSendCoins(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	to := testutil.NewKey("payee").Address
+	amount := sdk.NewCoins(sdk.NewInt64Coin(testDenom, 10))
+
+	res, err := b.SendCoins(context.Background(), to, amount, "payout")
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+	require.Equal(t, amount, node.Balance(to))
+}
+
+func TestSendCoins_Shortfall(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetBalance(key.Address, sdk.NewInt64Coin(testDenom, 4))
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	amount := sdk.NewCoins(sdk.NewInt64Coin(testDenom, 10), sdk.NewInt64Coin("uother", 3))
+	_, err = b.SendCoins(context.Background(), testutil.NewKey("payee").Address, amount, "")
+	require.ErrorIs(t, err, broadcaster.ErrInsufficientFunds)
+
+	// The balance is checked before broadcasting, so nothing is sent and there is no node's error.
+	var fundsErr *broadcaster.InsufficientFundsError
+	require.ErrorAs(t, err, &fundsErr)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(testDenom, 6), sdk.NewInt64Coin("uother", 3)), fundsErr.Shortfall)
+	require.NoError(t, fundsErr.Unwrap())
+	require.Empty(t, node.Mempool())
+}
+
+// spendingNode spends the balance of the account right before tx reaches the node,
+// like a concurrent tx does after the balance was checked.
+type spendingNode struct {
+	*testutil.FakeNode
+
+	addr    sdk.AccAddress
+	balance sdk.Coin
+}
+
+func (n *spendingNode) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	n.SetBalance(n.addr, n.balance)
+
+	return n.FakeNode.BroadcastTxSync(ctx, tx)
+}
+
+func TestSendCoins_Rejected(t *testing.T) {
+	fake, key := newFakeChain(t)
+	// CheckTx doesn't execute messages, so the rejection of the node is emulated.
+	fake.OnCheckTx(func(testutil.FakeTx) error {
+		return sdkerrors.Wrap(sdkerrors.ErrInsufficientFunds, "spent by another tx")
+	})
+
+	cfg := testConfig(fake, key)
+	cfg.RPCClient = &spendingNode{FakeNode: fake, addr: key.Address, balance: sdk.NewInt64Coin(testDenom, 4)}
+	// The retry would fail at simulation, the rejection of the first attempt is tested.
+	cfg.DisableAutoRetry = true
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	amount := sdk.NewCoins(sdk.NewInt64Coin(testDenom, 10))
+	res, err := b.SendCoins(context.Background(), testutil.NewKey("payee").Address, amount, "")
+	require.ErrorIs(t, err, broadcaster.ErrInsufficientFunds)
+	require.NotNil(t, res)
+
+	// The node's error is kept and the shortfall is computed from the balance after the rejection.
+	var fundsErr *broadcaster.InsufficientFundsError
+	require.ErrorAs(t, err, &fundsErr)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(testDenom, 6)), fundsErr.Shortfall)
+
+	var txErr *broadcaster.TxError
+	require.ErrorAs(t, err, &txErr)
+	require.Equal(t, sdkerrors.ErrInsufficientFunds.ABCICode(), txErr.Response.Code)
+	require.Empty(t, fake.Mempool())
+}
+
+func TestSendCoins_InvalidAmount(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	for name, amount := range map[string]sdk.Coins{
+		"empty":    nil,
+		"zero":     {sdk.NewInt64Coin(testDenom, 0)},
+		"negative": {sdk.Coin{Denom: testDenom, Amount: sdk.NewInt(-1)}},
+		"unsorted": {sdk.NewInt64Coin("uother", 1), sdk.NewInt64Coin(testDenom, 1)},
+	} {
+		_, err := b.SendCoins(context.Background(), testutil.NewKey("payee").Address, amount, "")
+		require.ErrorContains(t, err, "invalid amount", name)
+	}
+	require.Empty(t, node.Mempool())
+}
+
+func TestSendCoinsString(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	to := testutil.NewKey("payee").Address
+
+	// The amount is converted to the base denom.
+	res, err := b.SendCoinsString(context.Background(), to.String(), "1.5dec", "")
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(testDenom, 1500000)), node.Balance(to))
+
+	_, err = b.SendCoinsString(context.Background(), "invalid", "1dec", "")
+	require.ErrorContains(t, err, "invalid recipient")
+
+	for _, amount := range []string{"", "1.5", "0.0000001dec", "1unknown"} {
+		_, err = b.SendCoinsString(context.Background(), to.String(), amount, "")
+		require.Error(t, err, amount)
+	}
+	require.Empty(t, node.Mempool())
+}
@@ -0,0 +1,137 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// BatchSimulation is the result of SimulateBatch.
+type BatchSimulation struct {
+	// Gas is gas used by the whole batch without the adjustment.
+	Gas uint64
+	// MsgGas is gas attributed to msgs in order. It's empty unless Config.SimulateBatchPrefixes is set.
+	MsgGas []MsgGas
+}
+
+// MsgGas is gas attributed to a msg of the batch.
+type MsgGas struct {
+	TypeURL string
+	// Gas is the difference of gas used by prefixes of the batch with and without the msg.
+	// Gas of the first msg includes gas used by the tx itself, e.g. signature verification.
+	Gas uint64
+	// Exact is false when the gas is an average over several msgs simulated together
+	// because of Config.SimulateBatchPrefixes limit.
+	Exact bool
+}
+
+// GasPerMsgType returns the highest exact gas of every msg type, so it could be used for Config.GasPerMsgType.
+// Gas of the first msg is skipped since it includes gas used by the tx itself.
+func (s *BatchSimulation) GasPerMsgType() map[string]uint64 {
+	out := make(map[string]uint64)
+	for i, v := range s.MsgGas {
+		if i == 0 || !v.Exact {
+			continue
+		}
+
+		if v.Gas > out[v.TypeURL] {
+			out[v.TypeURL] = v.Gas
+		}
+	}
+
+	return out
+}
+
+// SimulateBatch simulates tx with msgs and returns gas it uses. When Config.SimulateBatchPrefixes is set,
+// gas is attributed to msgs by simulating prefixes of the batch and taking differences of their gas.
+// The attribution is best effort, gas used by a msg could depend on preceding ones.
+func (b *broadcaster) SimulateBatch(ctx context.Context, msgs []sdk.Msg, memo string) (*BatchSimulation, error) {
+	if err := b.checkMsgTypes(msgs); err != nil {
+		return nil, err
+	}
+
+	opts, err := b.resolveOptions(ctx, msgs, BroadcastOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	txf, ext := b.txFactory(b.TxFactory(), memo, opts).WithGas(0).WithGasAdjustment(1), opts.extensions()
+
+	total, err := b.simulateGasUsed(ctx, txf, msgs, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &BatchSimulation{Gas: total}
+	if b.cfg.SimulateBatchPrefixes <= 0 {
+		return out, nil
+	}
+
+	var prev uint64
+	start := 0
+	for _, end := range append(prefixCuts(len(msgs), b.cfg.SimulateBatchPrefixes), len(msgs)) {
+		gas := total
+		if end < len(msgs) {
+			if gas, err = b.simulateGasUsed(ctx, txf, msgs[:end], ext); err != nil {
+				return out, fmt.Errorf("failed to simulate %d msgs: %w", end, err)
+			}
+		}
+
+		var diff uint64
+		if gas > prev {
+			diff = gas - prev
+		}
+
+		for _, msg := range msgs[start:end] {
+			out.MsgGas = append(out.MsgGas, MsgGas{
+				TypeURL: sdk.MsgTypeURL(msg),
+				Gas:     diff / uint64(end-start),
+				Exact:   end-start == 1,
+			})
+		}
+
+		prev, start = gas, end
+	}
+
+	return out, nil
+}
+
+// prefixCuts returns lengths of at most max prefixes of n msgs, which split them evenly.
+func prefixCuts(n, max int) []int {
+	if max > n-1 {
+		max = n - 1
+	}
+
+	out := make([]int, 0, max)
+	for i := 1; i <= max; i++ {
+		out = append(out, i*n/(max+1))
+	}
+
+	return out
+}
+
+// simulateGasUsed returns gas used by tx as reported by the node, it's multiplied by the gas adjustment of txf.
+func (b *broadcaster) simulateGasUsed(ctx context.Context, txf tx.Factory, msgs []sdk.Msg, ext extensionOptions) (uint64, error) {
+	if b.cfg.RPCTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.cfg.RPCTimeout)
+		defer cancel()
+	}
+
+	var (
+		gas uint64
+		err error
+	)
+	if b.txService != nil {
+		gas, err = b.simulateTxService(ctx, txf, msgs, ext)
+	} else {
+		gas, err = b.simulateABCI(ctx, txf, msgs, ext)
+	}
+	if err != nil {
+		return 0, &simulationError{err: err}
+	}
+
+	return gas, nil
+}
@@ -0,0 +1,115 @@
+package broadcaster_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestSimulateBatch(t *testing.T) {
+	const (
+		sendGas      = 20_000
+		multiSendGas = 35_000
+		txGas        = testutil.DefaultFakeTxGas
+	)
+	sendURL, multiSendURL := sdk.MsgTypeURL(&banktypes.MsgSend{}), sdk.MsgTypeURL(&banktypes.MsgMultiSend{})
+
+	tt := []struct {
+		name        string
+		prefixes    int
+		msgGas      []broadcaster.MsgGas
+		perType     map[string]uint64
+		simulations int32
+	}{
+		{
+			name:        "total only",
+			perType:     map[string]uint64{},
+			simulations: 1,
+		},
+		{
+			name:     "every prefix",
+			prefixes: 10,
+			msgGas: []broadcaster.MsgGas{
+				{TypeURL: sendURL, Gas: txGas + sendGas, Exact: true},
+				{TypeURL: multiSendURL, Gas: multiSendGas, Exact: true},
+				{TypeURL: sendURL, Gas: sendGas, Exact: true},
+				{TypeURL: multiSendURL, Gas: multiSendGas, Exact: true},
+				{TypeURL: multiSendURL, Gas: multiSendGas, Exact: true},
+			},
+			perType:     map[string]uint64{sendURL: sendGas, multiSendURL: multiSendGas},
+			simulations: 5,
+		},
+		{
+			// Prefixes of 1 and 3 msgs split the batch into groups of 1, 2 and 2 msgs.
+			name:     "capped prefixes",
+			prefixes: 2,
+			msgGas: []broadcaster.MsgGas{
+				{TypeURL: sendURL, Gas: txGas + sendGas, Exact: true},
+				{TypeURL: multiSendURL, Gas: (multiSendGas + sendGas) / 2},
+				{TypeURL: sendURL, Gas: (multiSendGas + sendGas) / 2},
+				{TypeURL: multiSendURL, Gas: multiSendGas},
+				{TypeURL: multiSendURL, Gas: multiSendGas},
+			},
+			perType:     map[string]uint64{},
+			simulations: 3,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			node.SetMsgGas(sendURL, sendGas)
+			node.SetMsgGas(multiSendURL, multiSendGas)
+			counting := &countingSimulation{FakeNode: node}
+
+			cfg := testConfig(node, key)
+			cfg.RPCClient = counting
+			cfg.SimulateBatchPrefixes = tc.prefixes
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			msgs := []sdk.Msg{
+				sendMsg(key.Address, 1), multiSendMsg(key.Address, 2), sendMsg(key.Address, 3),
+				multiSendMsg(key.Address, 4), multiSendMsg(key.Address, 5),
+			}
+
+			res, err := b.SimulateBatch(context.Background(), msgs, "")
+			require.NoError(t, err)
+			require.EqualValues(t, txGas+2*sendGas+3*multiSendGas, res.Gas)
+			require.Equal(t, tc.msgGas, res.MsgGas)
+			require.Equal(t, tc.perType, res.GasPerMsgType())
+			require.Equal(t, tc.simulations, atomic.LoadInt32(&counting.simulations))
+
+			// Simulation doesn't broadcast.
+			require.Zero(t, node.Calls("broadcast_tx_sync"))
+		})
+	}
+}
+
+func TestSimulateBatch_SingleMsg(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.SimulateBatchPrefixes = 10
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.SimulateBatch(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "")
+	require.NoError(t, err)
+	require.EqualValues(t, testutil.DefaultFakeTxGas+testutil.DefaultFakeMsgGas, res.Gas)
+	require.Equal(t, []broadcaster.MsgGas{
+		{TypeURL: sdk.MsgTypeURL(&banktypes.MsgSend{}), Gas: res.Gas, Exact: true},
+	}, res.MsgGas)
+}
@@ -5,19 +5,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
 	"github.com/cosmos/cosmos-sdk/client/tx"
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 	"github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/tendermint/spm/cosmoscmd"
 
 	"github.com/Decentr-net/decentr/app"
@@ -29,8 +38,33 @@ func init() {
 }
 
 // ErrTxInMempoolCache is returned when tx is already broadcast and exists in mempool cache.
+// The returned error is MempoolCacheError which matches ErrTxInMempoolCache.
 var ErrTxInMempoolCache = errors.New("tx is already in mempool cache")
 
+// MempoolCacheError is returned when tx is already broadcast and exists in mempool cache.
+// It contains the hash of tx, so the pending tx could be tracked.
+type MempoolCacheError struct {
+	TxHash string
+}
+
+func (e *MempoolCacheError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrTxInMempoolCache, e.TxHash)
+}
+
+// Is makes MempoolCacheError match ErrTxInMempoolCache.
+func (e *MempoolCacheError) Is(target error) bool {
+	return target == ErrTxInMempoolCache
+}
+
+// ErrUnregisteredMsgType is returned when msg's type url is not registered in the interface registry.
+var ErrUnregisteredMsgType = errors.New("msg type is not registered")
+
+// ErrNoMessages is returned when tx has no messages.
+var ErrNoMessages = errors.New("no messages")
+
+// ErrNilMessage is returned when some of messages is nil.
+var ErrNilMessage = errors.New("nil message")
+
 //go:generate mockgen -destination=./mock/broadcaster.go -package=mock -source=blockchain.go
 
 // Broadcaster provides functionality to broadcast messages to cosmos based blockchain node.
@@ -48,55 +82,116 @@ type Broadcaster interface {
 	PingContext(ctx context.Context) error
 }
 
-var accountSequenceMismatchErrorRegExp = regexp.MustCompile(`.+account sequence mismatch, expected (\d+), got \d+:.+`)
+var accountSequenceMismatchErrorRegExp = regexp.MustCompile(`account sequence mismatch, expected (\d+), got \d+`)
 
 type broadcaster struct {
-	ctx client.Context
-	txf tx.Factory
+	cfg Config
 
-	mu sync.Mutex
-}
+	ctx    client.Context
+	txf    tx.Factory // txf is immutable, account number and sequence are kept in acc.
+	acc    accountState
+	key    atomic.Value // key contains signerKey. It's changed by SwitchKey, so ctx's from fields shouldn't be used.
+	enc    cosmoscmd.EncodingConfig
+	client *SharedClient
+
+	extraClients        []*SharedClient
+	hedgeStats          hedgeStats
+	propagationFailures uint64
+
+	metadataMu sync.Mutex
+	metadata   []banktypes.Metadata
+
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	blockCache  blockCache
+	nodeVersion NodeVersion
+	txService   *grpc.ClientConn // txService is the connection to the gRPC tx service, it's nil if it isn't used.
+
+	accountMissing int32 // accountMissing is 1 if the account wasn't found on the last lookup.
+
+	slots    chan struct{} // slots limits concurrent broadcasts, it's nil if they're unlimited.
+	inFlight int64
+	// resultStoreFailures is the number of results Config.ResultStore failed to save.
+	resultStoreFailures uint64
+	lastActive          int64 // lastActive is unix nanoseconds of the last finished broadcast.
+	jitter              *jitterSource
 
-// Config ...
-type Config struct {
-	KeyringRootDir     string
-	KeyringBackend     string
-	KeyringPromptInput string
+	memoNonce uint64 // memoNonce is the last nonce appended to the memo by Config.UniquifyMemo.
 
-	NodeURI       string
-	BroadcastMode string
+	recentErrors *errorRing
 
-	From    string
-	ChainID string
+	mu           sync.Mutex // mu serializes signing and broadcasting.
+	pipeline     *pipeline
+	shadowSeq    uint64 // shadowSeq is the sequence used in dry-run mode. It is guarded by mu.
+	halt         *haltWatcher
+	tracker      *tracker
+	balanceWatch *balanceWatcher
+	gasEstimator *gasEstimator
+	mismatches   *mismatchWindow
+	interceptors interceptors
+	coalescer    coalescer
+	reconciler   *reconciler
 
-	Fees      sdk.Coins
-	Gas       uint64
-	GasAdjust float64
+	metricsMsgTypes map[string]bool // metricsMsgTypes is a set of Config.MetricsMsgTypes.
 }
 
 // New returns new instance of broadcaster
 func New(cfg Config) (*broadcaster, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	kr, err := keyring.New(
 		config.AppName,
 		cfg.KeyringBackend,
 		cfg.KeyringRootDir,
-		strings.NewReader(cfg.KeyringPromptInput),
+		cfg.keyringInput(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create keyring: %w", err)
 	}
 
+	if cfg.KeyringBackend == keyring.BackendMemory {
+		if err := cfg.seedKeyring(kr); err != nil {
+			return nil, fmt.Errorf("failed to create keys: %w", err)
+		}
+	}
+
+	if cfg.ArmoredKey != "" {
+		if err := importArmoredKey(kr, cfg.From, cfg.ArmoredKey, cfg.ArmorPassphrase); err != nil {
+			return nil, err
+		}
+	}
+
 	acc, err := kr.Key(cfg.From)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get account: %w", err)
+		return nil, fmt.Errorf("failed to get account: %w", keyNotFoundError(kr, cfg, err))
 	}
 
-	c, err := client.NewClientFromNode(cfg.NodeURI)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create client: %w", err)
+	if expected, ok := cfg.expectedAddress(cfg.From); ok {
+		if err := verifyAddress(cfg.From, acc.GetAddress(), expected); err != nil {
+			return nil, err
+		}
+	}
+
+	c := cfg.Client
+	if cfg.RPCClient != nil {
+		c = NewSharedClientFrom(cfg.RPCClient)
+	} else if c == nil {
+		if c, err = NewSharedClient(cfg.NodeURI, cfg.clientOptions()...); err != nil {
+			return nil, err
+		}
+	} else if err := c.acquire(); err != nil {
+		return nil, fmt.Errorf("failed to acquire client: %w", err)
 	}
 
 	encodingConfig := cosmoscmd.MakeEncodingConfig(app.ModuleBasics)
+	for _, register := range cfg.RegisterInterfaces {
+		register(encodingConfig.InterfaceRegistry)
+	}
+	RegisterMsgResponses(encodingConfig.InterfaceRegistry)
+
 	ctx := client.Context{}.
 		WithCodec(encodingConfig.Marshaler).
 		WithChainID(cfg.ChainID).
@@ -104,52 +199,169 @@ func New(cfg Config) (*broadcaster, error) {
 		WithTxConfig(encodingConfig.TxConfig).
 		WithLegacyAmino(encodingConfig.Amino).
 		WithAccountRetriever(types.AccountRetriever{}).
-		WithBroadcastMode(cfg.BroadcastMode).
+		WithBroadcastMode(cfg.broadcastMode().nodeMode()).
 		WithHomeDir(cfg.KeyringRootDir).
 		WithKeyring(kr).
 		WithFrom(acc.GetName()).
 		WithFromName(acc.GetName()).
-		WithFromAddress(acc.GetAddress()).
-		WithNodeURI(cfg.NodeURI).
-		WithClient(c)
+		WithFromAddress(acc.GetAddress())
+	if cfg.NodeURI != "" {
+		ctx = ctx.WithNodeURI(cfg.NodeURI)
+	}
+	ctx = ctx.WithClient(c.Client())
+
+	fees, gasPrices, err := cfg.fees()
+	if err != nil {
+		return nil, err
+	}
 
 	factory := tx.NewFactoryCLI(ctx, &pflag.FlagSet{}).
-		WithFees(cfg.Fees.String()).
+		WithFees(fees.String()).
+		WithGasPrices(gasPrices.String()).
 		WithGas(cfg.Gas).
 		WithGasAdjustment(cfg.GasAdjust)
 
 	b := &broadcaster{
-		ctx: ctx,
-		txf: factory,
+		cfg: cfg,
+
+		ctx:    ctx,
+		txf:    factory,
+		enc:    encodingConfig,
+		client: c,
+
+		closing: make(chan struct{}),
+		jitter:  newJitterSource(cfg.clock().Now().UnixNano()),
+
+		memoNonce: uint64(cfg.clock().Now().UnixNano()),
+
+		recentErrors: newErrorRing(cfg.RecentErrors),
 
 		mu: sync.Mutex{},
 	}
 
-	if err := b.refreshSequence(); err != nil {
+	b.key.Store(signerKey{name: acc.GetName(), address: acc.GetAddress()})
+
+	if err := b.initAccount(); err != nil {
+		_ = c.Release()
 		return nil, fmt.Errorf("failed to refresh sequence: %w", err)
 	}
 
+	if err := b.checkNodeVersion(context.Background()); err != nil {
+		_ = c.Release()
+		return nil, fmt.Errorf("failed to check node version: %w", err)
+	}
+
+	if b.txService, err = b.dialTxService(context.Background()); err != nil {
+		_ = c.Release()
+		return nil, fmt.Errorf("failed to connect to tx service: %w", err)
+	}
+
+	for _, uri := range cfg.ExtraNodeURIs {
+		extra, err := NewSharedClient(uri, cfg.clientOptions()...)
+		if err != nil {
+			_ = b.Close()
+			return nil, fmt.Errorf("failed to create client for %s: %w", uri, err)
+		}
+		b.extraClients = append(b.extraClients, extra)
+	}
+
+	if cfg.SequenceFile != "" {
+		if seq, ok := b.loadSequence(); ok && seq > b.acc.sequence() {
+			b.acc.setSequence(seq)
+		}
+	}
+
+	if cfg.SequenceStore != nil {
+		if err := cfg.SequenceStore.Init(context.Background(), b.acc.sequence()); err != nil {
+			_ = b.Close()
+			return nil, fmt.Errorf("failed to init sequence store: %w", err)
+		}
+	}
+
+	b.shadowSeq = b.acc.sequence()
+
+	if cfg.MaxConcurrentBroadcasts > 0 {
+		b.slots = make(chan struct{}, cfg.MaxConcurrentBroadcasts)
+	}
+
+	if cfg.Pipelined {
+		b.pipeline = newPipeline(b.acc.sequence())
+	}
+
+	if cfg.HaltDetection.Interval > 0 {
+		b.halt = newHaltWatcher(cfg.HaltDetection, cfg.clock(), b.jitter, b.GetHeightFresh)
+	}
+
+	if cfg.BalanceWatch.Interval > 0 {
+		b.balanceWatch = newBalanceWatcher(cfg.BalanceWatch, cfg.clock(), b.jitter, func(ctx context.Context) (sdk.Coins, error) {
+			return b.GetSpendableBalance(ctx, b.From())
+		})
+	}
+
+	if len(cfg.MetricsMsgTypes) > 0 {
+		b.metricsMsgTypes = make(map[string]bool, len(cfg.MetricsMsgTypes))
+		for _, v := range cfg.MetricsMsgTypes {
+			b.metricsMsgTypes[v] = true
+		}
+	}
+
+	if cfg.SequenceReconcile.Interval > 0 {
+		b.touch()
+		b.reconciler = newReconciler(cfg.SequenceReconcile.Interval, cfg.clock(), b.jitter, b.reconcileSequence)
+	}
+
+	if cfg.SequenceContention.Window > 0 {
+		b.mismatches = &mismatchWindow{window: cfg.SequenceContention.Window}
+	}
+
+	if cfg.AdaptiveGas.Enabled {
+		if b.gasEstimator, err = newGasEstimator(context.Background(), cfg.AdaptiveGas); err != nil {
+			_ = b.Close()
+			return nil, err
+		}
+	}
+
+	if cfg.Tracking.Interval > 0 {
+		tracking := cfg.Tracking
+		if b.gasEstimator != nil || cfg.Metrics != nil || cfg.ResultStore != nil {
+			// Txs broadcast without waiting for commit are observed and stored once the tracker resolves them.
+			onResolve := tracking.OnResolve
+			tracking.OnResolve = func(tx TrackedTx) {
+				b.observeCommit(WithRequestID(context.Background(), tx.RequestID), tx.Msgs, tx.Response)
+				b.storeResolved(tx)
+				if onResolve != nil {
+					onResolve(tx)
+				}
+			}
+		}
+		b.tracker = newTracker(tracking, cfg.clock(), b.trackerNodes, b.trackedSequence)
+	}
+
 	return b, nil
 }
 
 // From returns address of broadcaster.
 func (b *broadcaster) From() sdk.AccAddress {
-	return b.ctx.FromAddress
+	return b.signer().address
 }
 
-// GetHeight returns current height.
-func (b *broadcaster) GetHeight(ctx context.Context) (uint64, error) {
-	c, err := b.ctx.GetNode()
-	if err != nil {
-		return 0, fmt.Errorf("failed get node: %w", err)
-	}
+// ClientContext returns client context used by broadcaster.
+// It could be used to run queries over the same connection.
+func (b *broadcaster) ClientContext() client.Context {
+	key := b.signer()
 
-	i, err := c.ABCIInfo(ctx)
-	if err != nil {
-		return 0, fmt.Errorf("failed to fetch ABCIInfo: %w", err)
-	}
+	return b.ctx.WithFrom(key.name).WithFromName(key.name).WithFromAddress(key.address)
+}
 
-	return uint64(i.Response.LastBlockHeight), nil
+// TxFactory returns a snapshot of tx factory used by broadcaster.
+// Changes made to the returned factory don't affect the broadcaster, use Config instead.
+func (b *broadcaster) TxFactory() tx.Factory {
+	return b.factory()
+}
+
+// EncodingConfig returns encoding config used by broadcaster.
+func (b *broadcaster) EncodingConfig() cosmoscmd.EncodingConfig {
+	return b.enc
 }
 
 // BroadcastMsg broadcasts alone message.
@@ -159,15 +371,437 @@ func (b *broadcaster) BroadcastMsg(msg sdk.Msg, memo string) (*sdk.TxResponse, e
 
 // Broadcast broadcasts messages.
 func (b *broadcaster) Broadcast(msgs []sdk.Msg, memo string) (*sdk.TxResponse, error) {
-	out, err := b.broadcast(msgs, memo, false)
+	res, err := b.BroadcastContext(context.Background(), msgs, memo, BroadcastOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Response, nil
+}
+
+// BroadcastContext broadcasts messages with options.
+// When tx was signed the result is returned alongside the error, so the locally computed tx hash is
+// available even if the node wasn't reached.
+// Panics are recovered and returned as PanicError.
+func (b *broadcaster) BroadcastContext(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions,
+) (res *BroadcastResult, err error) {
+	ctx, timings := withTimings(ctx)
+	start := b.cfg.clock().Now()
+	defer func() {
+		if res == nil {
+			return
+		}
+
+		timings.Total = b.since(start)
+		res.Timings = *timings
+
+		var attemptsErr *AttemptsError
+		if errors.As(err, &attemptsErr) {
+			res.History = attemptsErr.Attempts()
+		}
+	}()
+	defer func() {
+		if err != nil {
+			b.recordError(ctx, res, err)
+		}
+	}()
+	defer recoverPanic(&err)
+
+	if msgs, err = b.intercept(ctx, msgs); err != nil {
+		return nil, err
+	}
+
+	if err := b.checkMsgTypes(msgs); err != nil {
+		return nil, err
+	}
+
+	mode := b.broadcastMode(opts)
+	if err := mode.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := validateIdempotencyKey(opts.IdempotencyKey); err != nil {
+		return nil, err
+	}
+
+	if err := b.checkHalt(); err != nil {
+		return nil, err
+	}
+
+	if err := b.checkBalance(); err != nil {
+		return nil, err
+	}
+
+	// The account could be funded again since it was found missing.
+	if b.isAccountMissing() {
+		if err := b.RefreshSequence(); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts, err = b.resolveOptions(ctx, msgs, opts); err != nil {
+		return nil, err
+	}
 
+	if b.cfg.UniquifyMemo {
+		opts.nonce = b.nextMemoNonce()
+		if memo := b.txFactory(b.txf, memo, opts).Memo(); len(memo) > MaxMemoCharacters {
+			return nil, fmt.Errorf("%w: %d characters with the nonce", ErrMemoTooLong, len(memo))
+		}
+	}
+
+	timings.Validate = b.since(start)
+
+	release, err := b.acquireSlot(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to broadcast: %w", err)
+		return nil, err
+	}
+	res, err = b.broadcastWithDeadline(ctx, msgs, memo, opts)
+	release()
+	if res != nil {
+		res.RequestID = RequestIDFromContext(ctx)
+		res.MemoNonce = opts.nonce
+	}
+	if err != nil {
+		// Only txs rejected by the node are known to fail, unanswered ones could still be committed.
+		if !b.cfg.DryRun && res != nil && res.Response != nil && res.Response.Code != 0 {
+			b.storeOutcome(ctx, *res, err)
+		}
+		return res, fmt.Errorf("failed to broadcast: %w", err)
+	}
+
+	if b.cfg.DryRun {
+		return res, nil
+	}
+
+	b.track(ctx, res, msgs)
+
+	if !mode.waitsForCommit() {
+		b.verifyPropagation(ctx, res.TxHash)
+	}
+
+	if mode.waitsForCommit() {
+		commitStart := b.cfg.clock().Now()
+		resp, err := b.waitForCommit(ctx, res.TxHash)
+		timings.WaitForCommit = b.since(commitStart)
+		if err != nil {
+			return res, fmt.Errorf("failed to wait for commit: %w", err)
+		}
+		res.Response = resp
+		res.Events = nil
+		if events, err := ParseTxEvents(resp); err == nil && resp.Code == 0 {
+			res.Events = events
+		}
+
+		if b.tracker != nil {
+			b.resolveTracked(resp)
+		} else {
+			b.observeCommit(ctx, msgs, resp)
+			if resp.Code != 0 {
+				b.storeOutcome(ctx, *res, newTxError(resp))
+			} else {
+				b.storeOutcome(ctx, *res, nil)
+			}
+		}
+
+		if resp.Code != 0 {
+			return res, newTxError(resp)
+		}
+	}
+
+	return res, nil
+}
+
+// BuildAndSign builds and signs tx without broadcasting it. It returns encoded tx and its hash.
+// The local sequence is consumed like a broadcast would do unless opts.Speculative is set.
+func (b *broadcaster) BuildAndSign(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions,
+) (txBytes []byte, hash string, err error) {
+	defer recoverPanic(&err)
+
+	if msgs, err = b.intercept(ctx, msgs); err != nil {
+		return nil, "", err
+	}
+
+	if err := b.checkMsgTypes(msgs); err != nil {
+		return nil, "", err
+	}
+
+	if err := validateIdempotencyKey(opts.IdempotencyKey); err != nil {
+		return nil, "", err
+	}
+
+	if opts, err = b.resolveOptions(ctx, msgs, opts); err != nil {
+		return nil, "", err
+	}
+
+	// Simulation doesn't need the sequence exclusively, so it is done with a snapshot without holding the lock.
+	snapshot := b.TxFactory()
+	gas, simErr := b.simulateGas(ctx, b.txFactory(snapshot, memo, opts), msgs, opts, false)
+	if simErr != nil && (b.cfg.DryRun || Classify(simErr) != ClassSequenceMismatch) {
+		return nil, "", fmt.Errorf("failed to build and sign tx: %w", simErr)
+	}
+
+	switch {
+	case b.cfg.DryRun:
+		txBytes, err = b.signDryRun(ctx, msgs, memo, opts, gas)
+	case b.pipeline != nil && simErr == nil && !opts.Speculative:
+		txBytes, err = b.signPipelined(ctx, msgs, memo, opts, gas)
+	default:
+		txBytes, err = b.signLocked(ctx, msgs, memo, opts, gas, simErr)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build and sign tx: %w", err)
+	}
+
+	return txBytes, TxHash(txBytes), nil
+}
+
+// signLocked signs tx with the account's sequence and consumes it unless opts.Speculative is set.
+// The sequence mismatch of simulation is corrected and the simulation is repeated with the actual sequence.
+func (b *broadcaster) signLocked(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions, gas uint64, simErr error,
+) (_ []byte, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !opts.Speculative {
+		release, err := b.acquireSequence(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if rerr := release(); rerr != nil && err == nil {
+				err = rerr
+			}
+		}()
+	}
+
+	if simErr != nil {
+		if seq := getNextSequence(simErr.Error()); seq != 0 {
+			b.acc.setSequence(seq)
+		}
+		if gas, err = b.simulateGas(ctx, b.txFactory(b.factory(), memo, opts), msgs, opts, false); err != nil {
+			return nil, err
+		}
+	}
+
+	txBytes, err := b.signTx(ctx, b.txFactory(b.factory(), memo, opts).WithGas(gas), msgs, opts.extensions())
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.Speculative {
+		b.acc.incSequence()
+		// Tx is already signed, so failed persisting shouldn't fail the call.
+		_ = b.persistSequence()
+	}
+
+	return txBytes, nil
+}
+
+// signPipelined signs tx with the reserved sequence like broadcastPipelined does and consumes the sequence
+// on its turn. Tx is re-signed if the txs reserved before have failed and the sequence has changed.
+func (b *broadcaster) signPipelined(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions, gas uint64,
+) ([]byte, error) {
+	s := b.pipeline.reserve()
+
+	txBytes, err := b.signTx(ctx, b.txFactory(b.TxFactory(), memo, opts).WithGas(gas).WithSequence(s.seq), msgs, opts.extensions())
+	if err != nil {
+		b.pipeline.abandon(s)
+		return nil, err
+	}
+
+	select {
+	case <-s.prev.settled:
+	case <-ctx.Done():
+		b.pipeline.abandon(s)
+		return nil, ctx.Err()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer func() {
+		b.pipeline.release(s, b.acc.sequence())
+	}()
+
+	if b.acc.sequence() != s.seq {
+		if txBytes, err = b.signTx(ctx, b.txFactory(b.factory(), memo, opts).WithGas(gas), msgs, opts.extensions()); err != nil {
+			return nil, err
+		}
+	}
+
+	b.acc.incSequence()
+	// Tx is already signed, so failed persisting shouldn't fail the call.
+	_ = b.persistSequence()
+
+	return txBytes, nil
+}
+
+// GenerateUnsignedTx builds unsigned tx and returns it in the same JSON form as "--generate-only" CLI flag does.
+// The signer info contains the current sequence, so the tx could be signed offline.
+// Account number is not a part of tx and has to be provided to the signer separately.
+func (b *broadcaster) GenerateUnsignedTx(msgs []sdk.Msg, memo string, opts BroadcastOptions) (_ []byte, err error) {
+	defer recoverPanic(&err)
+
+	if msgs, err = b.intercept(context.Background(), msgs); err != nil {
+		return nil, err
+	}
+
+	if err := b.checkMsgTypes(msgs); err != nil {
+		return nil, err
+	}
+
+	if err := validateIdempotencyKey(opts.IdempotencyKey); err != nil {
+		return nil, err
+	}
+
+	if opts, err = b.resolveOptions(context.Background(), msgs, opts); err != nil {
+		return nil, err
+	}
+
+	txf := b.txFactory(b.TxFactory(), memo, opts)
+
+	gas, err := b.simulateGas(context.Background(), txf, msgs, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	txf = txf.WithGas(gas)
+
+	unsignedTx, err := tx.BuildUnsignedTx(txf, msgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tx: %w", err)
+	}
+	if err := opts.extensions().apply(unsignedTx); err != nil {
+		return nil, err
+	}
+
+	key, err := b.ctx.Keyring.Key(b.signer().name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key: %w", err)
+	}
+
+	signMode := txf.SignMode()
+	if signMode == signing.SignMode_SIGN_MODE_UNSPECIFIED {
+		signMode = b.ctx.TxConfig.SignModeHandler().DefaultMode()
+	}
+
+	if err := unsignedTx.SetSignatures(signing.SignatureV2{
+		PubKey:   key.GetPubKey(),
+		Data:     &signing.SingleSignatureData{SignMode: signMode},
+		Sequence: txf.Sequence(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set signer info: %w", err)
+	}
+
+	out, err := b.ctx.TxConfig.TxJSONEncoder()(unsignedTx.GetTx())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tx: %w", err)
 	}
 
 	return out, nil
 }
 
+// BroadcastRaw broadcasts already signed tx bytes.
+// Since the tx can't be re-signed, sequence mismatch is not retried. Transient failures are retried
+// with the same bytes and, if the node is unavailable, the bytes are sent to the extra nodes in order.
+func (b *broadcaster) BroadcastRaw(ctx context.Context, txBytes []byte) (*sdk.TxResponse, error) {
+	if _, err := b.ctx.TxConfig.TxDecoder()(txBytes); err != nil {
+		return nil, fmt.Errorf("failed to decode tx: %w", err)
+	}
+
+	if b.cfg.DryRun {
+		return &sdk.TxResponse{TxHash: TxHash(txBytes)}, nil
+	}
+
+	resp, err := b.sendRaw(ctx, txBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if sdkerrors.ErrTxInMempoolCache.ABCICode() == resp.Code {
+		if !b.cfg.MempoolCacheAsSuccess {
+			return nil, &MempoolCacheError{TxHash: TxHash(txBytes)}
+		}
+		resp = &sdk.TxResponse{TxHash: TxHash(txBytes)}
+	}
+
+	if b.cfg.broadcastMode().waitsForCommit() {
+		if resp, err = b.waitForCommit(ctx, resp.TxHash); err != nil {
+			return nil, fmt.Errorf("failed to wait for commit: %w", err)
+		}
+
+		if resp.Code != 0 {
+			return nil, newTxError(resp)
+		}
+	}
+
+	return resp, nil
+}
+
+// sendRaw sends tx bytes until the node accepts them or the retry policy allows.
+// The accepted or already cached response is returned, other ones are returned as errors.
+func (b *broadcaster) sendRaw(ctx context.Context, txBytes []byte) (*sdk.TxResponse, error) {
+	maxAttempts := b.cfg.RetryPolicy.maxAttempts()
+	if b.cfg.DisableAutoRetry {
+		maxAttempts = 1
+	}
+
+	var (
+		clientCtx = b.ctx
+		nodeURI   = b.cfg.NodeURI
+		send      = b.sendTx
+		extra     = 0
+		history   []AttemptError
+	)
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, newAttemptsError(history, nodeURI, err)
+		}
+
+		var class ErrorClass
+		resp, err := send(ctx, clientCtx, txBytes)
+		switch {
+		case err != nil:
+			err = fmt.Errorf("failed to broadcast tx: %w", err)
+			class = Classify(err)
+		case resp.Code == 0 || resp.Code == sdkerrors.ErrTxInMempoolCache.ABCICode():
+			return resp, nil
+		default:
+			err = newTxError(resp)
+			class = responseClass(resp)
+		}
+
+		action := retryFail
+		switch {
+		case class == ClassNodeUnavailable && extra < len(b.extraClients):
+			action = retryFailover
+		case class == ClassNodeUnavailable, class == ClassTransient:
+			action = retryResend
+		}
+
+		if action == retryFail || attempt >= maxAttempts {
+			return nil, newAttemptsError(history, nodeURI, err)
+		}
+		history = append(history, AttemptError{Attempt: attempt, NodeURI: nodeURI, Action: action.String(), Err: err})
+
+		if action == retryFailover {
+			// Only the primary node is reachable through the gRPC tx service.
+			nodeURI = b.cfg.ExtraNodeURIs[extra]
+			clientCtx = b.ctx.WithClient(b.extraClients[extra].Client()).WithNodeURI(nodeURI)
+			send = broadcastTx
+			extra++
+			continue
+		}
+
+		if err := b.waitBackoff(ctx, attempt+1); err != nil {
+			return nil, newAttemptsError(history, nodeURI, err)
+		}
+	}
+}
+
 // PingContext pings node.
 func (b *broadcaster) PingContext(ctx context.Context) error {
 	c, err := b.ctx.GetNode()
@@ -181,40 +815,582 @@ func (b *broadcaster) PingContext(ctx context.Context) error {
 	return nil
 }
 
-func (b *broadcaster) broadcast(msgs []sdk.Msg, memo string, isRetry bool) (*sdk.TxResponse, error) {
-	if !isRetry {
-		b.mu.Lock()
-		defer b.mu.Unlock()
+// Close stops background routines and releases the rpc client. The broadcaster shouldn't be used after closing.
+func (b *broadcaster) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.closing)
+	})
+
+	if b.halt != nil {
+		b.halt.close()
+	}
+
+	if b.tracker != nil {
+		b.tracker.close()
 	}
 
-	txf := b.txf.WithMemo(memo)
+	if b.balanceWatch != nil {
+		b.balanceWatch.close()
+	}
 
-	if txf.GasAdjustment() == 0 {
-		txf = txf.WithGasAdjustment(1)
+	if b.reconciler != nil {
+		b.reconciler.close()
 	}
 
-	if txf.Gas() == 0 {
-		_, gas, err := tx.CalculateGas(b.ctx, txf, msgs...)
+	for _, c := range b.extraClients {
+		_ = c.Release()
+	}
+
+	if b.txService != nil {
+		_ = b.txService.Close()
+	}
+
+	return b.client.Release()
+}
+
+// RefreshSequence fetches account number and sequence from the chain.
+// It waits for the ongoing broadcast to finish.
+func (b *broadcaster) RefreshSequence() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.refreshSequence()
+}
+
+// broadcastMode returns broadcast mode for a single call.
+func (b *broadcaster) broadcastMode(opts BroadcastOptions) BroadcastMode {
+	if opts.Mode != "" {
+		return opts.Mode
+	}
+
+	return b.cfg.broadcastMode()
+}
+
+// checkMsgTypes ensures that there are messages and every msg could be encoded with the interface registry.
+func (b *broadcaster) checkMsgTypes(msgs []sdk.Msg) error {
+	if len(msgs) == 0 {
+		return ErrNoMessages
+	}
+
+	for i, msg := range msgs {
+		if isNilMsg(msg) {
+			return fmt.Errorf("%w at index %d", ErrNilMessage, i)
+		}
+
+		url := sdk.MsgTypeURL(msg)
+		if _, err := b.enc.InterfaceRegistry.Resolve(url); err != nil {
+			return fmt.Errorf("%w: %s", ErrUnregisteredMsgType, url)
+		}
+	}
+
+	return nil
+}
+
+// isNilMsg returns true if msg is nil. Typed nil pointers are not equal to nil interface, but they are nil messages too.
+func isNilMsg(msg sdk.Msg) bool {
+	return msg == nil || (reflect.ValueOf(msg).Kind() == reflect.Ptr && reflect.ValueOf(msg).IsNil())
+}
+
+// broadcastWithDeadline broadcasts messages within Config.MaxBroadcastDuration.
+func (b *broadcaster) broadcastWithDeadline(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions,
+) (*BroadcastResult, error) {
+	if b.cfg.MaxBroadcastDuration == 0 {
+		return b.broadcast(ctx, msgs, memo, opts)
+	}
+
+	deadlineCtx, cancel := withClockTimeout(ctx, b.cfg.clock(), b.cfg.MaxBroadcastDuration)
+	defer cancel()
+
+	res, err := b.broadcast(deadlineCtx, msgs, memo, opts)
+	if err != nil && ctx.Err() == nil && errors.Is(deadlineCtx.Err(), context.DeadlineExceeded) {
+		var attempts int
+		if res != nil {
+			attempts = res.Attempts
+		}
+
+		return res, &DeadlineError{Attempts: attempts, Err: err}
+	}
+
+	return res, err
+}
+
+func (b *broadcaster) broadcast(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions,
+) (res *BroadcastResult, err error) {
+	// Simulation doesn't need the sequence exclusively, so it is done with a snapshot without holding the lock.
+	snapshot := b.TxFactory()
+	gas, simErr := b.simulateGas(ctx, b.txFactory(snapshot, memo, opts), msgs, opts, false)
+
+	if b.cfg.DryRun {
+		if simErr != nil {
+			return nil, simErr
+		}
+
+		return b.broadcastDryRun(ctx, msgs, memo, opts, gas)
+	}
+
+	if b.pipeline != nil {
+		return b.broadcastPipelined(ctx, msgs, memo, opts, gas, simErr, snapshot.Sequence())
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	release, err := b.acquireSequence(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rerr := release(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	var history []AttemptError
+	if simErr != nil {
+		if gas, history, err = b.resimulateLocked(ctx, msgs, memo, opts, simErr, snapshot.Sequence()); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.broadcastLocked(ctx, msgs, memo, opts, gas, history, nil, false)
+}
+
+// resimulateLocked simulates tx again with the actual sequence after the simulation with simSeq failed.
+// With the default retry policy any failed simulation is repeated once, a configured policy repeats
+// only the ones failed with a sequence mismatch and other errors are returned as they are.
+// The failed simulation is returned as the first attempt. b.mu should be held by caller.
+func (b *broadcaster) resimulateLocked(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions, simErr error, simSeq uint64,
+) (uint64, []AttemptError, error) {
+	seq := getNextSequence(simErr.Error())
+	mismatch := seq != 0 || Classify(simErr) == ClassSequenceMismatch
+	if !mismatch && !b.cfg.RetryPolicy.isDefault() {
+		return 0, nil, simErr
+	}
+
+	// The snapshot's sequence could be outdated, so simulation is repeated with the actual one.
+	// The sequence expected by the node is stale if the account's one was changed since the simulation.
+	if seq != 0 && b.acc.sequence() == simSeq {
+		b.acc.setSequence(seq)
+	}
+	if !b.autoRetry(opts) {
+		return 0, nil, simErr
+	}
+
+	action := retryResimulate
+	if mismatch {
+		action = retryFixSequence
+	}
+
+	history := []AttemptError{{Attempt: 1, NodeURI: b.cfg.NodeURI, Action: action.String(), Err: simErr}}
+	if mismatch {
+		if err := b.noteSequenceMismatch(ctx); err != nil {
+			return 0, nil, newAttemptsError(history, b.cfg.NodeURI, err)
+		}
+	}
+
+	gas, err := b.simulateGas(ctx, b.txFactory(b.factory(), memo, opts), msgs, opts, false)
+	if err != nil {
+		return 0, nil, newAttemptsError(history, b.cfg.NodeURI, err)
+	}
+
+	return gas, history, nil
+}
+
+// broadcastPipelined signs tx with the reserved sequence concurrently with other calls
+// and hands it to the transport on its turn. The next call is let to submit its tx right after that,
+// so the rpcs overlap, and the outcomes are settled in sequence order. b.mu guards only the account state
+// and it isn't held during the rpc.
+func (b *broadcaster) broadcastPipelined(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions, gas uint64, simErr error, simSeq uint64,
+) (*BroadcastResult, error) {
+	s := b.pipeline.reserve()
+
+	// Tx which failed simulation is signed on its settling turn after the simulation is repeated.
+	var presigned *signedTx
+	if simErr == nil {
+		txBytes, err := b.signTx(ctx, b.txFactory(b.TxFactory(), memo, opts).WithGas(gas).WithSequence(s.seq), msgs, opts.extensions())
 		if err != nil {
-			if !isRetry {
-				if seq := getNextSequence(err.Error()); seq != 0 {
-					b.txf = b.txf.WithSequence(seq)
-				}
+			b.pipeline.abandon(s)
+			return nil, err
+		}
+		presigned = &signedTx{bytes: txBytes, seq: s.seq}
+	}
+
+	select {
+	case <-s.prev.sent:
+	case <-ctx.Done():
+		b.pipeline.abandon(s)
+		return nil, ctx.Err()
+	}
+
+	if presigned != nil {
+		b.submitPipelined(ctx, s, presigned, opts)
+	}
+
+	// The outcome depends on the txs submitted before, so it is settled after theirs.
+	// The tx could be already submitted, so the turn is awaited even if ctx is done.
+	<-s.prev.settled
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer func() {
+		b.pipeline.release(s, b.acc.sequence())
+	}()
 
-				return b.broadcast(msgs, memo, true)
+	// A sequence lower than the tx's one is expected by the node if the preceding tx has failed
+	// or it hasn't reached the node yet. Such a response is dropped and the tx is submitted again
+	// with the settled sequence.
+	if presigned != nil && presigned.err == nil && responseClass(presigned.resp) == ClassSequenceMismatch &&
+		getNextSequence(presigned.resp.RawLog) < presigned.seq {
+		presigned.sent = false
+	}
+
+	var history []AttemptError
+	if simErr != nil {
+		var err error
+		if gas, history, err = b.resimulateLocked(ctx, msgs, memo, opts, simErr, simSeq); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.broadcastLocked(ctx, msgs, memo, opts, gas, history, presigned, true)
+}
+
+// submitPipelined hands the presigned tx to the transport and passes the turn to the next slot.
+// The response is kept in presigned to be settled later.
+func (b *broadcaster) submitPipelined(ctx context.Context, s *slot, presigned *signedTx, opts BroadcastOptions) {
+	b.mu.Lock()
+	clientCtx := b.ctx.WithBroadcastMode(b.broadcastMode(opts).nodeMode())
+	b.mu.Unlock()
+
+	start := b.cfg.clock().Now()
+	b.pipeline.handOff(s)
+	if opts.Hedge {
+		presigned.resp, presigned.err = b.broadcastHedged(ctx, clientCtx, presigned.bytes)
+	} else {
+		presigned.resp, presigned.err = b.sendTx(ctx, clientCtx, presigned.bytes)
+	}
+	presigned.latency = b.since(start)
+	presigned.sent = true
+	timingsFromContext(ctx).BroadcastRPC += presigned.latency
+}
+
+// signedTx is tx signed in advance. If it's already submitted by the pipeline, sent is set
+// and the outcome of the rpc is kept.
+type signedTx struct {
+	bytes []byte
+	seq   uint64
+
+	sent    bool
+	resp    *sdk.TxResponse
+	err     error
+	latency time.Duration
+}
+
+// broadcastLocked signs and broadcasts tx with the given gas. b.mu should be held by caller.
+// Failed attempts are retried with corrective actions until the retry policy allows.
+// The presigned tx is used if it was signed with the actual sequence, the outcome of the already sent one
+// is used as the first attempt. b.mu is released during the rpc if unlockRPC is set, it's used when the order is kept by the pipeline.
+func (b *broadcaster) broadcastLocked(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions, gas uint64,
+	history []AttemptError, presigned *signedTx, unlockRPC bool,
+) (*BroadcastResult, error) {
+	maxAttempts := b.cfg.RetryPolicy.maxAttempts()
+	if !b.autoRetry(opts) {
+		maxAttempts = 1
+	}
+	// Only a sequence mismatch during simulation leads to the history passed by caller.
+	mismatches := len(history)
+
+	var res *BroadcastResult
+	for attempt := len(history) + 1; ; attempt++ {
+		sent := presigned != nil && presigned.sent
+		if err := ctx.Err(); err != nil && !sent {
+			return res, newAttemptsError(history, b.cfg.NodeURI, err)
+		}
+
+		var txBytes []byte
+		seq := b.acc.sequence()
+		switch {
+		case sent:
+			txBytes, seq = presigned.bytes, presigned.seq
+		case presigned != nil && presigned.seq == seq:
+			txBytes = presigned.bytes
+		default:
+			var err error
+			if txBytes, err = b.signTx(ctx, b.txFactory(b.factory(), memo, opts).WithGas(gas), msgs, opts.extensions()); err != nil {
+				return nil, newAttemptsError(history, b.cfg.NodeURI, err)
 			}
+		}
 
-			return nil, fmt.Errorf("failed to calculate gas: %w", err)
+		res = &BroadcastResult{
+			Signer:   b.From(),
+			TxHash:   TxHash(txBytes),
+			Sequence: seq,
+			Attempts: attempt,
+			History:  history,
 		}
-		txf = txf.WithGas(gas)
+
+		// broadcast to a Tendermint node
+		clientCtx := b.ctx.WithBroadcastMode(b.broadcastMode(opts).nodeMode())
+
+		var (
+			resp    *sdk.TxResponse
+			err     error
+			latency time.Duration
+		)
+		if sent {
+			resp, err, latency = presigned.resp, presigned.err, presigned.latency
+		} else {
+			start := b.cfg.clock().Now()
+			if unlockRPC {
+				b.mu.Unlock()
+			}
+			if opts.Hedge {
+				resp, err = b.broadcastHedged(ctx, clientCtx, txBytes)
+			} else {
+				resp, err = b.sendTx(ctx, clientCtx, txBytes)
+			}
+			if unlockRPC {
+				b.mu.Lock()
+			}
+			latency = b.since(start)
+			timingsFromContext(ctx).BroadcastRPC += latency
+		}
+		presigned = nil
+		b.observeAttempt(msgs, resp, err, latency)
+		if err != nil {
+			return res, newAttemptsError(history, b.cfg.NodeURI, fmt.Errorf("failed to broadcast tx: %w", err))
+		}
+		res.Response = resp
+
+		if sdkerrors.ErrTxInMempoolCache.ABCICode() == resp.Code {
+			if !b.cfg.MempoolCacheAsSuccess {
+				return res, newAttemptsError(history, b.cfg.NodeURI, &MempoolCacheError{TxHash: res.TxHash})
+			}
+			// The same tx was already accepted, so it is reported as successfully broadcast.
+			resp = &sdk.TxResponse{TxHash: res.TxHash}
+			res.Response = resp
+		}
+
+		if resp.Code == 0 {
+			// The tx sent by the pipeline could be accepted with a sequence other than the account's one.
+			b.acc.setSequence(seq + 1)
+			// Tx is already broadcast, so failed persisting shouldn't fail the call.
+			_ = b.persistSequence()
+
+			if events, err := ParseTxEvents(resp); err == nil {
+				res.Events = events
+			}
+
+			return res, nil
+		}
+
+		action := classifyResponse(resp)
+		if action == retryBumpGas && opts.tableGas {
+			b.reportStaleGas(ctx, msgs, gas)
+			// The entries are reported once, retries of the tx don't make them more stale.
+			opts.tableGas = false
+		}
+		// The sequence is corrected even if tx isn't retried, so the next broadcast uses the expected one.
+		if action == retryFixSequence {
+			b.acc.setSequence(getNextSequence(resp.RawLog))
+		}
+		if responseClass(resp) == ClassSequenceMismatch {
+			mismatches++
+			if err := b.noteSequenceMismatch(ctx); err != nil {
+				return res, newAttemptsError(history, b.cfg.NodeURI, fmt.Errorf("%w: %s", err, newTxError(resp)))
+			}
+
+			if b.cfg.MaxSequenceMismatchRetries > 0 && mismatches > b.cfg.MaxSequenceMismatchRetries {
+				action = retryFail
+			}
+		}
+
+		if action == retryFail || attempt >= maxAttempts {
+			return res, newAttemptsError(history, b.cfg.NodeURI, newTxError(resp))
+		}
+		history = append(history, AttemptError{
+			Attempt: attempt, NodeURI: b.cfg.NodeURI, Action: action.String(), Err: newTxError(resp),
+		})
+
+		if action == retryRefreshAccount {
+			if err := b.refreshAccount(ctx); err != nil {
+				return res, newAttemptsError(history, b.cfg.NodeURI, err)
+			}
+		}
+
+		if err := b.waitBackoff(ctx, attempt+1); err != nil {
+			return res, newAttemptsError(history, b.cfg.NodeURI, err)
+		}
+
+		if gas, err = b.simulateGas(ctx, b.txFactory(b.factory(), memo, opts), msgs, opts, true); err != nil {
+			return res, newAttemptsError(history, b.cfg.NodeURI, err)
+		}
+
+		if action == retryBumpGas && b.cfg.RetryPolicy.OutOfGasMultiplier > 0 {
+			gas = uint64(float64(gas) * b.cfg.RetryPolicy.OutOfGasMultiplier)
+		}
+	}
+}
+
+// isSimulationUnavailable returns true if the node doesn't support simulation,
+// e.g. the query is disabled or the endpoint is not found.
+func isSimulationUnavailable(err error) bool {
+	if status.Code(err) == codes.Unimplemented {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, v := range []string{"unimplemented", "not supported", "unknown query path", "404"} {
+		if strings.Contains(msg, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// simulationError is returned when gas simulation fails.
+type simulationError struct {
+	err error
+}
+
+func (e *simulationError) Error() string {
+	return fmt.Sprintf("failed to calculate gas: %s", e.err)
+}
+
+func (e *simulationError) Unwrap() error {
+	return e.err
+}
+
+// txFactory returns tx factory for a single call based on txf.
+func (b *broadcaster) txFactory(txf tx.Factory, memo string, opts BroadcastOptions) tx.Factory {
+	txf = txf.WithMemo(nonceMemo(IdempotentMemo(opts.MemoPrefix+memo, opts.IdempotencyKey), opts.nonce))
+
+	if opts.Fees != nil {
+		txf = txf.WithFees(opts.Fees.String()).WithGasPrices("")
+	}
+	if opts.GasPrices != nil {
+		txf = txf.WithGasPrices(opts.GasPrices.String()).WithFees("")
+	}
+	if opts.Gas != 0 {
+		txf = txf.WithGas(opts.Gas)
+	}
+	if opts.GasAdjust != 0 {
+		txf = txf.WithGasAdjustment(opts.GasAdjust)
+	}
+	if opts.TimeoutHeight != 0 {
+		txf = txf.WithTimeoutHeight(opts.TimeoutHeight)
+	}
+
+	if txf.GasAdjustment() == 0 {
+		txf = txf.WithGasAdjustment(DefaultGasAdjustment)
+	}
+
+	return txf
+}
+
+// simulateGas returns gas required for tx like simulate. With Config.AlwaysSimulate tx is simulated even if its gas
+// is fixed: the estimate is discarded, but simulation errors are returned, so invalid txs fail before broadcasting.
+// retry is true for attempts after the first one, they aren't simulated when Config.SkipSimulationOnRetry is set.
+func (b *broadcaster) simulateGas(ctx context.Context, txf tx.Factory, msgs []sdk.Msg, opts BroadcastOptions, retry bool) (uint64, error) {
+	validate := b.cfg.AlwaysSimulate || opts.AlwaysSimulate
+	if txf.Gas() != 0 && validate && !(retry && b.cfg.SkipSimulationOnRetry) {
+		if _, err := b.simulate(ctx, txf.WithGas(0), msgs, opts.extensions()); err != nil {
+			return 0, err
+		}
+	}
+
+	return b.simulate(ctx, txf, msgs, opts.extensions())
+}
+
+// simulate returns gas required for tx. Simulation is skipped when txf has gas set.
+func (b *broadcaster) simulate(ctx context.Context, txf tx.Factory, msgs []sdk.Msg, ext extensionOptions) (uint64, error) {
+	if txf.Gas() != 0 {
+		return txf.Gas(), nil
+	}
+
+	t, start := timingsFromContext(ctx), b.cfg.clock().Now()
+	defer func() {
+		t.Simulate += b.since(start)
+	}()
+
+	if txf.GasAdjustment() == 1 {
+		b.warnf(ctx, "gas adjustment is 1, simulated gas has no headroom")
 	}
 
+	var (
+		gas uint64
+		err error
+	)
+	if b.cfg.RPCTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.cfg.RPCTimeout)
+		defer cancel()
+	}
+
+	if b.txService != nil {
+		gas, err = b.simulateTxService(ctx, txf, msgs, ext)
+	} else {
+		gas, err = b.simulateABCI(ctx, txf, msgs, ext)
+	}
+	if err != nil {
+		if b.cfg.FallbackGas > 0 && isSimulationUnavailable(err) {
+			b.warnf(ctx, "simulation is unavailable, fallback gas %d is used: %s", b.cfg.FallbackGas, err)
+			return b.cfg.FallbackGas, nil
+		}
+
+		// The node wraps errors of simulation into unknown request, so the message is matched too.
+		if errors.Is(err, sdkerrors.ErrUnknownAddress) || isAccountNotFound(err) {
+			b.setAccountMissing(true)
+			return 0, &simulationError{err: fmt.Errorf("%w: %s", ErrAccountNotFound, err)}
+		}
+
+		return 0, &simulationError{err: err}
+	}
+
+	return gas, nil
+}
+
+// signTx builds and signs tx using txf. The tx is recorded to Config.RecordDir if it's set.
+// Panics are recovered, so callers could release the reserved sequence.
+func (b *broadcaster) signTx(ctx context.Context, txf tx.Factory, msgs []sdk.Msg, ext extensionOptions) (txBytes []byte, err error) {
+	defer recoverPanic(&err)
+
+	t, start := timingsFromContext(ctx), b.cfg.clock().Now()
+	defer func() {
+		t.Sign += b.since(start)
+	}()
+
+	txBytes, err = b.buildTx(txf, msgs, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.cfg.RecordDir != "" {
+		if err := b.record(txf, msgs, ext, txBytes); err != nil {
+			return nil, fmt.Errorf("failed to record tx: %w", err)
+		}
+	}
+
+	return txBytes, nil
+}
+
+// buildTx builds and signs tx using txf.
+func (b *broadcaster) buildTx(txf tx.Factory, msgs []sdk.Msg, ext extensionOptions) ([]byte, error) {
 	unsignedTx, err := tx.BuildUnsignedTx(txf, msgs...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build tx: %w", err)
 	}
+	if err := ext.apply(unsignedTx); err != nil {
+		return nil, fmt.Errorf("failed to build tx: %w", err)
+	}
 
-	if err := tx.Sign(txf, b.ctx.GetFromName(), unsignedTx, true); err != nil {
+	if err := tx.Sign(txf, b.signer().name, unsignedTx, true); err != nil {
 		return nil, fmt.Errorf("failed to sign tx: %w", err)
 	}
 
@@ -223,35 +1399,85 @@ func (b *broadcaster) broadcast(msgs []sdk.Msg, memo string, isRetry bool) (*sdk
 		return nil, fmt.Errorf("failed to encode tx: %w", err)
 	}
 
-	// broadcast to a Tendermint node
-	resp, err := b.ctx.BroadcastTx(txBytes)
+	return txBytes, nil
+}
+
+// buildSimTx builds tx for simulation. Unlike tx.BuildSimTx it sets extension options
+// and uses the signer's public key instead of the first key of the keyring.
+func (b *broadcaster) buildSimTx(txf tx.Factory, msgs []sdk.Msg, ext extensionOptions) ([]byte, error) {
+	txb, err := tx.BuildUnsignedTx(txf, msgs...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to broadcast tx: %w", err)
+		return nil, fmt.Errorf("failed to build tx: %w", err)
+	}
+	if err := ext.apply(txb); err != nil {
+		return nil, fmt.Errorf("failed to build tx: %w", err)
 	}
 
-	if resp.Code != 0 {
-		if sdkerrors.ErrTxInMempoolCache.ABCICode() == resp.Code {
-			return nil, ErrTxInMempoolCache
-		}
+	info, err := txf.Keybase().Key(b.signer().name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key: %w", err)
+	}
 
-		if !isRetry {
-			if seq := getNextSequence(resp.RawLog); seq != 0 {
-				b.txf = b.txf.WithSequence(seq)
-			}
+	// The signature is empty, the node doesn't verify it in simulation.
+	sig := signing.SignatureV2{
+		PubKey:   info.GetPubKey(),
+		Data:     &signing.SingleSignatureData{SignMode: txf.SignMode()},
+		Sequence: txf.Sequence(),
+	}
+	if err := txb.SetSignatures(sig); err != nil {
+		return nil, fmt.Errorf("failed to set signature: %w", err)
+	}
 
-			return b.broadcast(msgs, memo, true)
-		}
+	return b.ctx.TxConfig.TxEncoder()(txb.GetTx())
+}
 
-		return nil, fmt.Errorf("failed to broadcast tx: %s", resp.String())
+// broadcastTx broadcasts tx bytes using client context's broadcast mode.
+// It works like client.Context.BroadcastTx but respects ctx.
+func broadcastTx(ctx context.Context, clientCtx client.Context, txBytes []byte) (*sdk.TxResponse, error) {
+	node, err := clientCtx.GetNode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
 	}
 
-	b.txf = b.txf.WithSequence(b.txf.Sequence() + 1)
-
-	return resp, nil
+	switch clientCtx.BroadcastMode {
+	case flags.BroadcastSync:
+		res, err := node.BroadcastTxSync(ctx, txBytes)
+		if errRes := client.CheckTendermintError(err, txBytes); errRes != nil {
+			return errRes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return sdk.NewResponseFormatBroadcastTx(res), nil
+	case flags.BroadcastAsync:
+		res, err := node.BroadcastTxAsync(ctx, txBytes)
+		if errRes := client.CheckTendermintError(err, txBytes); errRes != nil {
+			return errRes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return sdk.NewResponseFormatBroadcastTx(res), nil
+	case flags.BroadcastBlock:
+		res, err := node.BroadcastTxCommit(ctx, txBytes)
+		if errRes := client.CheckTendermintError(err, txBytes); errRes != nil {
+			return errRes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return sdk.NewResponseFormatBroadcastTxCommit(res), nil
+	default:
+		return nil, fmt.Errorf("unsupported broadcast mode %s", clientCtx.BroadcastMode)
+	}
 }
 
 func (b *broadcaster) refreshSequence() error {
 	if err := b.txf.AccountRetriever().EnsureExists(b.ctx, b.From()); err != nil {
+		if isAccountNotFound(err) {
+			b.setAccountMissing(true)
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, b.From())
+		}
 		return fmt.Errorf("failed to EnsureExists: %w", err)
 	}
 
@@ -260,11 +1486,27 @@ func (b *broadcaster) refreshSequence() error {
 		return fmt.Errorf("failed to get GetAccountNumberSequence: %w", err)
 	}
 
-	b.txf = b.txf.WithAccountNumber(num).WithSequence(seq)
+	b.acc.set(num, seq)
+	b.setAccountMissing(false)
 
 	return nil
 }
 
+// refreshAccount checks that the node belongs to the configured chain and fetches account number and sequence.
+// It's used when signature verification fails, e.g. after the account number has changed by chain export and import.
+func (b *broadcaster) refreshAccount(ctx context.Context) error {
+	block, err := b.fetchLatestBlock(ctx)
+	if err != nil {
+		return err
+	}
+
+	if block.ChainID != b.cfg.ChainID {
+		return fmt.Errorf("%w: node's chain id is %s", ErrChainIDMismatch, block.ChainID)
+	}
+
+	return b.refreshSequence()
+}
+
 func getNextSequence(m string) uint64 {
 	s := accountSequenceMismatchErrorRegExp.FindStringSubmatch(m)
 
@@ -0,0 +1,975 @@
+package broadcaster_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/testutil/testdata"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestNew_RegisterInterfaces(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.RegisterInterfaces = []func(codectypes.InterfaceRegistry){testdata.RegisterInterfaces}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{testdata.NewTestMsg(key.Address)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	mempool := node.Mempool()
+	require.Len(t, mempool, 1)
+	require.Equal(t, res.TxHash, mempool[0].Hash)
+	require.Equal(t, []string{"/testdata.TestMsg"}, mempool[0].MsgTypes())
+}
+
+func TestBroadcast_UnregisteredMsgType(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	queries := node.Calls("abci_query")
+	msgs := []sdk.Msg{sendMsg(key.Address, 1), testdata.NewTestMsg(key.Address)}
+	_, err = b.Broadcast(msgs, "")
+	require.ErrorIs(t, err, broadcaster.ErrUnregisteredMsgType)
+	require.Contains(t, err.Error(), "/testdata.TestMsg")
+
+	// The msg is rejected before simulation and signing.
+	require.Equal(t, queries, node.Calls("abci_query"))
+	require.Zero(t, node.Calls("broadcast_tx_sync"))
+	require.Empty(t, node.Mempool())
+}
+
+func TestBroadcast_InvalidMsgs(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	calls := map[string]func(msgs []sdk.Msg) error{
+		"broadcast": func(msgs []sdk.Msg) error {
+			_, err := b.BroadcastContext(context.Background(), msgs, "", broadcaster.BroadcastOptions{})
+			return err
+		},
+		"build and sign": func(msgs []sdk.Msg) error {
+			_, _, err := b.BuildAndSign(context.Background(), msgs, "", broadcaster.BroadcastOptions{})
+			return err
+		},
+		"generate unsigned": func(msgs []sdk.Msg) error {
+			_, err := b.GenerateUnsignedTx(msgs, "", broadcaster.BroadcastOptions{})
+			return err
+		},
+		"simulate batch": func(msgs []sdk.Msg) error {
+			_, err := b.SimulateBatch(context.Background(), msgs, "")
+			return err
+		},
+	}
+
+	tt := []struct {
+		name    string
+		msgs    []sdk.Msg
+		wantErr error
+		index   string
+	}{
+		{name: "nil slice", msgs: nil, wantErr: broadcaster.ErrNoMessages},
+		{name: "empty slice", msgs: []sdk.Msg{}, wantErr: broadcaster.ErrNoMessages},
+		{name: "nil msg", msgs: []sdk.Msg{sendMsg(key.Address, 1), nil}, wantErr: broadcaster.ErrNilMessage, index: "at index 1"},
+		{
+			name:    "typed nil msg",
+			msgs:    []sdk.Msg{(*banktypes.MsgSend)(nil), sendMsg(key.Address, 1)},
+			wantErr: broadcaster.ErrNilMessage,
+			index:   "at index 0",
+		},
+	}
+
+	for _, tc := range tt {
+		for name, call := range calls {
+			queries := node.Calls("abci_query")
+
+			err := call(tc.msgs)
+			require.ErrorIs(t, err, tc.wantErr, "%s: %s", tc.name, name)
+			require.Contains(t, err.Error(), tc.index, "%s: %s", tc.name, name)
+
+			// The msgs are rejected before simulation and signing.
+			require.Equal(t, queries, node.Calls("abci_query"), "%s: %s", tc.name, name)
+		}
+	}
+
+	_, err = b.BroadcastShared(context.Background(), nil, "")
+	require.ErrorIs(t, err, broadcaster.ErrNilMessage)
+
+	require.Zero(t, node.Calls("broadcast_tx_sync"))
+	require.Empty(t, node.Mempool())
+}
+
+func TestBroadcaster_ClientContext(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := banktypes.NewQueryClient(b.ClientContext()).Balance(context.Background(), &banktypes.QueryBalanceRequest{
+		Address: key.Address.String(),
+		Denom:   testDenom,
+	})
+	require.NoError(t, err)
+	require.Equal(t, node.Balance(key.Address).AmountOf(testDenom), res.Balance.Amount)
+
+	require.Equal(t, key.Address, b.ClientContext().GetFromAddress())
+	require.NotNil(t, b.EncodingConfig().TxConfig)
+}
+
+func TestBroadcaster_TxFactorySnapshot(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetSequence(key.Address, 5)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	txf := b.TxFactory()
+	require.Equal(t, uint64(5), txf.Sequence())
+	_ = txf.WithSequence(100).WithGas(1)
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), res.Sequence)
+	require.Equal(t, uint64(6), b.TxFactory().Sequence())
+}
+
+func TestBroadcastRaw(t *testing.T) {
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	tt := []struct {
+		name     string
+		prepare  func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config
+		signed   func(node *testutil.FakeNode, key testutil.Key)
+		wantErr  error
+		wantSent int
+	}{
+		{
+			name: "success",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				return testConfig(node, key)
+			},
+			wantSent: 1,
+		},
+		{
+			name: "transient failure is resent",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				node.FailNext("broadcast_tx_sync", netErr)
+				return testConfig(node, key)
+			},
+			wantSent: 2,
+		},
+		{
+			name: "retry disabled",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				node.FailNext("broadcast_tx_sync", netErr)
+				cfg := testConfig(node, key)
+				cfg.DisableAutoRetry = true
+				return cfg
+			},
+			wantErr:  netErr,
+			wantSent: 1,
+		},
+		{
+			name: "sequence mismatch isn't retried",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				return testConfig(node, key)
+			},
+			signed: func(node *testutil.FakeNode, key testutil.Key) {
+				node.SetSequence(key.Address, 5)
+			},
+			wantErr:  sdkerrors.ErrWrongSequence,
+			wantSent: 1,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			b, err := broadcaster.New(tc.prepare(t, node, key))
+			require.NoError(t, err)
+			defer b.Close()
+
+			txBytes, hash, err := b.BuildAndSign(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+			if tc.signed != nil {
+				tc.signed(node, key)
+			}
+
+			res, err := b.BroadcastRaw(context.Background(), txBytes)
+			require.Equal(t, tc.wantSent, node.Calls("broadcast_tx_sync"))
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, hash, res.TxHash)
+			requireCommitted(t, node, hash)
+		})
+	}
+}
+
+func TestBroadcastRaw_Failover(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	extra, _ := newFakeChain(t)
+	srv := extra.Serve()
+	defer srv.Close()
+
+	cfg := testConfig(node, key)
+	cfg.ExtraNodeURIs = []string{srv.URL}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	txBytes, hash, err := b.BuildAndSign(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	node.SetDown(true)
+
+	res, err := b.BroadcastRaw(context.Background(), txBytes)
+	require.NoError(t, err)
+	require.Equal(t, hash, res.TxHash)
+	require.Equal(t, 1, node.Calls("broadcast_tx_sync"))
+	require.Empty(t, node.Mempool())
+	requireCommitted(t, extra, hash)
+}
+
+func TestBuildAndSign(t *testing.T) {
+	tt := []struct {
+		name    string
+		cfg     func(cfg *broadcaster.Config)
+		opts    broadcaster.BroadcastOptions
+		wantSeq []uint64
+		nextSeq uint64
+	}{
+		{
+			name:    "consumes sequence",
+			opts:    broadcaster.BroadcastOptions{Gas: 200000},
+			wantSeq: []uint64{0, 1, 2},
+			nextSeq: 3,
+		},
+		{
+			name:    "speculative",
+			opts:    broadcaster.BroadcastOptions{Speculative: true},
+			wantSeq: []uint64{0, 0, 0},
+			nextSeq: 0,
+		},
+		{
+			name:    "pipelined",
+			cfg:     func(cfg *broadcaster.Config) { cfg.Pipelined = true },
+			opts:    broadcaster.BroadcastOptions{Gas: 200000},
+			wantSeq: []uint64{0, 1, 2},
+			nextSeq: 3,
+		},
+		{
+			name:    "dry run doesn't consume real sequence",
+			cfg:     func(cfg *broadcaster.Config) { cfg.DryRun = true },
+			wantSeq: []uint64{0, 1, 2},
+			nextSeq: 0,
+		},
+		{
+			name:    "speculative dry run",
+			cfg:     func(cfg *broadcaster.Config) { cfg.DryRun = true },
+			opts:    broadcaster.BroadcastOptions{Speculative: true},
+			wantSeq: []uint64{0, 0, 0},
+			nextSeq: 0,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			cfg := testConfig(node, key)
+			if tc.cfg != nil {
+				tc.cfg(&cfg)
+			}
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			for i, want := range tc.wantSeq {
+				txBytes, hash, err := b.BuildAndSign(context.Background(), []sdk.Msg{sendMsg(key.Address, int64(i+1))}, "", tc.opts)
+				require.NoError(t, err)
+				require.Equal(t, broadcaster.TxHash(txBytes), hash)
+
+				tx, err := testutil.DecodeFakeTx(txBytes)
+				require.NoError(t, err)
+				require.Equal(t, want, tx.Sequence)
+			}
+
+			require.Equal(t, tc.nextSeq, b.TxFactory().Sequence())
+			require.Zero(t, node.Calls("broadcast_tx_sync"))
+		})
+	}
+}
+
+func TestBuildAndSign_RoundTrip(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	// The node can't simulate txs ahead of its sequence, so txs signed in advance have the gas set.
+	var txs [][]byte
+	for i := 0; i < 3; i++ {
+		txBytes, hash, err := b.BuildAndSign(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{Gas: 200000})
+		require.NoError(t, err)
+		require.Equal(t, hash, broadcaster.TxHash(txBytes))
+		txs = append(txs, txBytes)
+	}
+
+	for _, txBytes := range txs {
+		res, err := b.BroadcastRaw(context.Background(), txBytes)
+		require.NoError(t, err)
+		require.Equal(t, broadcaster.TxHash(txBytes), res.TxHash)
+	}
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.Sequence)
+
+	node.NextBlock()
+	for _, hash := range []string{broadcaster.TxHash(txs[0]), broadcaster.TxHash(txs[1]), broadcaster.TxHash(txs[2]), res.TxHash} {
+		tx, ok := node.CommittedTx(hash)
+		require.True(t, ok, "tx %s is not committed", hash)
+		require.Zero(t, tx.TxResult.Code, tx.TxResult.Log)
+	}
+}
+
+func TestBuildAndSign_SimulationError(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	queries := node.Calls("abci_query")
+	_, _, err = b.BuildAndSign(context.Background(), []sdk.Msg{sendMsg(key.Address, 2_000_000_000)}, "", broadcaster.BroadcastOptions{})
+	require.ErrorContains(t, err, "insufficient funds")
+
+	// Only a sequence mismatch is worth simulating again.
+	require.Equal(t, queries+1, node.Calls("abci_query"))
+	require.Equal(t, uint64(0), b.TxFactory().Sequence())
+}
+
+func TestGenerateUnsignedTx(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetSequence(key.Address, 7)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	msg := sendMsg(key.Address, 10)
+	fees := sdk.NewCoins(sdk.NewInt64Coin(testDenom, 500))
+	out, err := b.GenerateUnsignedTx([]sdk.Msg{msg}, "memo", broadcaster.BroadcastOptions{Fees: fees})
+	require.NoError(t, err)
+
+	txConfig := b.EncodingConfig().TxConfig
+	decoded, err := txConfig.TxJSONDecoder()(out)
+	require.NoError(t, err)
+
+	tx, ok := decoded.(authsigning.Tx)
+	require.True(t, ok)
+	require.Len(t, tx.GetMsgs(), 1)
+	require.Equal(t, msg, tx.GetMsgs()[0])
+	require.Equal(t, "memo", tx.GetMemo())
+	require.Equal(t, fees, tx.GetFee())
+	require.NotZero(t, tx.GetGas())
+
+	sigs, err := tx.GetSignaturesV2()
+	require.NoError(t, err)
+	require.Len(t, sigs, 1)
+	require.Equal(t, uint64(7), sigs[0].Sequence)
+	require.Equal(t, key.PrivKey.PubKey(), sigs[0].PubKey)
+	require.Empty(t, sigs[0].Data.(*signing.SingleSignatureData).Signature)
+
+	// The same JSON is produced after the round-trip, so the tx is not altered by decoding.
+	again, err := txConfig.TxJSONEncoder()(decoded)
+	require.NoError(t, err)
+	require.JSONEq(t, string(out), string(again))
+
+	// Generating doesn't consume the sequence.
+	require.Equal(t, uint64(7), b.TxFactory().Sequence())
+}
+
+func TestBroadcast_ModeOverride(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = broadcaster.ModeAsync
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	modes := []broadcaster.BroadcastMode{"", broadcaster.ModeSync, "", broadcaster.ModeBlock, broadcaster.ModeSync, ""}
+	for i, mode := range modes {
+		_, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, int64(i+1))}, "", broadcaster.BroadcastOptions{Mode: mode})
+		require.NoError(t, err)
+	}
+
+	// Block mode is emulated with sync one.
+	require.Equal(t, 3, node.Calls("broadcast_tx_async"))
+	require.Equal(t, 3, node.Calls("broadcast_tx_sync"))
+	require.Zero(t, node.Calls("broadcast_tx_commit"))
+
+	// The override doesn't change the shared context.
+	require.Equal(t, string(broadcaster.ModeAsync), b.ClientContext().BroadcastMode)
+
+	_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{Mode: "syncc"})
+	require.ErrorContains(t, err, "unknown broadcast mode")
+	require.Equal(t, 3, node.Calls("broadcast_tx_async"))
+	require.Equal(t, 3, node.Calls("broadcast_tx_sync"))
+}
+
+// slowSimulation makes the node simulate every tx for the delay with the fixed gas used.
+// The hook is called by every simulation before the delay.
+func slowSimulation(node *testutil.FakeNode, delay time.Duration, hook func()) {
+	node.HandleQuery(testutil.SimulateQueryPath, func([]byte) ([]byte, error) {
+		if hook != nil {
+			hook()
+		}
+		time.Sleep(delay)
+
+		return (&txtypes.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 100000}}).Marshal()
+	})
+}
+
+func TestBroadcast_ConcurrentSimulation(t *testing.T) {
+	const callers = 8
+
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Every simulation waits for the others, so it passes only if callers simulate concurrently.
+	var arrived sync.WaitGroup
+	arrived.Add(callers)
+	all := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(all)
+	}()
+	slowSimulation(node, 0, func() {
+		arrived.Done()
+		select {
+		case <-all:
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		seqs   []uint64
+		hashes []string
+	)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, int64(i+1))}, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+
+			mu.Lock()
+			seqs = append(seqs, res.Sequence)
+			hashes = append(hashes, res.TxHash)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	select {
+	case <-all:
+	default:
+		t.Fatal("simulations are serialized")
+	}
+
+	// Sequences are assigned under the lock, so they are consecutive.
+	require.ElementsMatch(t, []uint64{0, 1, 2, 3, 4, 5, 6, 7}, seqs)
+	node.NextBlock()
+	for _, hash := range hashes {
+		tx, ok := node.CommittedTx(hash)
+		require.True(t, ok)
+		require.Zero(t, tx.TxResult.Code, tx.TxResult.Log)
+	}
+}
+
+func TestBroadcast_SequenceMismatchRetry(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Another process has used the account since the broadcaster fetched the sequence.
+	node.SetSequence(key.Address, 4)
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), res.Sequence)
+	require.Equal(t, 2, res.Attempts)
+	require.Len(t, res.History, 1)
+	require.Equal(t, "fix sequence", res.History[0].Action)
+	requireCommitted(t, node, res.TxHash)
+}
+
+func TestBroadcast_MempoolCache(t *testing.T) {
+	for _, asSuccess := range []bool{false, true} {
+		asSuccess := asSuccess
+		t.Run(fmt.Sprintf("as success %t", asSuccess), func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			cfg := testConfig(node, key)
+			cfg.MempoolCacheAsSuccess = asSuccess
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			// The gas is fixed and the sequence is taken back, so the same tx is signed again.
+			opts := broadcaster.BroadcastOptions{Gas: 200000}
+			msgs := []sdk.Msg{sendMsg(key.Address, 1)}
+			first, err := b.BroadcastContext(context.Background(), msgs, "", opts)
+			require.NoError(t, err)
+			require.NoError(t, b.RefreshSequence())
+
+			res, err := b.BroadcastContext(context.Background(), msgs, "", opts)
+			require.Equal(t, 2, node.Calls("broadcast_tx_sync"), "mempool cache isn't retried")
+			if !asSuccess {
+				require.ErrorIs(t, err, broadcaster.ErrTxInMempoolCache)
+
+				var cacheErr *broadcaster.MempoolCacheError
+				require.ErrorAs(t, err, &cacheErr)
+				require.Equal(t, first.TxHash, cacheErr.TxHash)
+				require.Equal(t, broadcaster.ClassDuplicate, broadcaster.Classify(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, first.TxHash, res.TxHash)
+			require.Equal(t, uint64(1), b.TxFactory().Sequence())
+
+			// Raw bytes already in mempool are handled the same way.
+			raw, err := b.BroadcastRaw(context.Background(), node.Mempool()[0].Bytes)
+			require.NoError(t, err)
+			require.Equal(t, first.TxHash, raw.TxHash)
+		})
+	}
+}
+
+func TestBroadcast_MaxBroadcastDuration(t *testing.T) {
+	tt := []struct {
+		name          string
+		maxDuration   time.Duration
+		callerTimeout time.Duration
+		wantDeadline  bool
+	}{
+		{
+			name:          "deadline cuts backoff",
+			maxDuration:   time.Minute,
+			callerTimeout: time.Minute,
+			wantDeadline:  true,
+		},
+		{
+			name:          "caller's deadline is sooner",
+			maxDuration:   time.Minute,
+			callerTimeout: 100 * time.Millisecond,
+		},
+	}
+
+	node, key := newFakeChain(t)
+	node.OnCheckTx(func(testutil.FakeTx) error {
+		return sdkerrors.ErrMempoolIsFull
+	})
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// The deadline and the backoff follow the fake clock, so the deadline expires only when it's advanced.
+			clock := testutil.NewFakeClock(time.Now())
+			cfg := testConfig(node, key)
+			cfg.Clock = clock
+			cfg.MaxBroadcastDuration = tc.maxDuration
+			cfg.RetryPolicy = broadcaster.RetryPolicy{MaxAttempts: 5, Backoff: time.Hour, Jitter: broadcaster.JitterNone}
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), tc.callerTimeout)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := b.BroadcastContext(ctx, []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+				done <- err
+			}()
+
+			if tc.wantDeadline {
+				// The first attempt has failed and the deadline and the backoff are pending.
+				clock.BlockUntil(2)
+				clock.Advance(tc.maxDuration)
+			}
+
+			select {
+			case err = <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("broadcast isn't stopped")
+			}
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+
+			var deadlineErr *broadcaster.DeadlineError
+			require.Equal(t, tc.wantDeadline, errors.As(err, &deadlineErr), err)
+			if tc.wantDeadline {
+				require.ErrorIs(t, err, broadcaster.ErrBroadcastDeadlineExceeded)
+				require.Equal(t, 1, deadlineErr.Attempts, "the second attempt waits for the backoff")
+			}
+		})
+	}
+}
+
+func TestBroadcast_RetryBackoff(t *testing.T) {
+	backoffs := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+
+	tt := []struct {
+		name   string
+		jitter broadcaster.Jitter
+		// min returns the lower bound of the jittered backoff.
+		min func(d time.Duration) time.Duration
+	}{
+		{name: "none", jitter: broadcaster.JitterNone, min: func(d time.Duration) time.Duration { return d }},
+		{name: "equal", jitter: broadcaster.JitterEqual, min: func(d time.Duration) time.Duration { return d / 2 }},
+		{name: "full", jitter: broadcaster.JitterFull, min: func(time.Duration) time.Duration { return 0 }},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			var checks int32
+			node.OnCheckTx(func(testutil.FakeTx) error {
+				if atomic.AddInt32(&checks, 1) <= int32(len(backoffs)) {
+					return sdkerrors.ErrMempoolIsFull
+				}
+				return nil
+			})
+
+			clock := testutil.NewFakeClock(time.Now())
+			cfg := testConfig(node, key)
+			cfg.Clock = clock
+			cfg.RetryPolicy = broadcaster.RetryPolicy{
+				MaxAttempts: 5,
+				Backoff:     time.Second,
+				MaxBackoff:  3 * time.Second,
+				Jitter:      tc.jitter,
+			}
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			type result struct {
+				res *broadcaster.BroadcastResult
+				err error
+			}
+			done := make(chan result, 1)
+			go func() {
+				res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+				done <- result{res, err}
+			}()
+
+			// Every backoff is within its jitter bounds: the timer isn't fired before the lower bound
+			// and is fired by the backoff.
+			for _, backoff := range backoffs {
+				clock.BlockUntil(1)
+
+				var advanced time.Duration
+				if min := tc.min(backoff); min > 0 {
+					advanced = min - time.Millisecond
+					clock.Advance(advanced)
+					require.Equal(t, 1, clock.Waiters(), "backoff of %s is fired before %s", backoff, min)
+				}
+				clock.Advance(backoff - advanced)
+				require.Zero(t, clock.Waiters(), "backoff of %s isn't fired", backoff)
+			}
+
+			select {
+			case r := <-done:
+				require.NoError(t, r.err)
+				require.Equal(t, len(backoffs)+1, r.res.Attempts)
+				requireCommitted(t, node, r.res.TxHash)
+			case <-time.After(5 * time.Second):
+				t.Fatal("broadcast isn't finished")
+			}
+		})
+	}
+}
+
+func BenchmarkBroadcast_Concurrent(b *testing.B) {
+	for _, callers := range []int{1, 4, 16} {
+		callers := callers
+		b.Run(fmt.Sprintf("callers=%d", callers), func(b *testing.B) {
+			node := testutil.NewFakeNode()
+			key := testutil.NewKey(b.Name())
+			node.AddAccount(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+			slowSimulation(node, 20*time.Millisecond, nil)
+
+			br, err := broadcaster.New(testConfig(node, key))
+			require.NoError(b, err)
+			defer br.Close()
+
+			b.ResetTimer()
+
+			var (
+				wg   sync.WaitGroup
+				next = make(chan struct{})
+			)
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range next {
+						if _, err := br.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{}); err != nil {
+							b.Error(err)
+						}
+					}
+				}()
+			}
+			for i := 0; i < b.N; i++ {
+				next <- struct{}{}
+			}
+			close(next)
+			wg.Wait()
+		})
+	}
+}
+
+// simulatePath is the abci query path of the tx service's Simulate.
+const simulatePath = "/cosmos.tx.v1beta1.Service/Simulate"
+
+// noSimulationService is gRPC tx service with disabled simulation. Txs are broadcast to the fake node.
+type noSimulationService struct {
+	txtypes.UnimplementedServiceServer
+	node *testutil.FakeNode
+}
+
+func (s *noSimulationService) BroadcastTx(ctx context.Context, req *txtypes.BroadcastTxRequest) (*txtypes.BroadcastTxResponse, error) {
+	res, err := s.node.BroadcastTxSync(ctx, req.TxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{
+		TxHash: res.Hash.String(), Code: res.Code, Codespace: res.Codespace, RawLog: res.Log,
+	}}, nil
+}
+
+func TestBroadcast_FallbackGas(t *testing.T) {
+	const fallbackGas = 150000
+
+	tt := []struct {
+		name    string
+		prepare func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config
+		gas     uint64
+		wantErr bool
+	}{
+		{
+			name: "grpc unimplemented",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				lis, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+
+				srv := grpc.NewServer()
+				txtypes.RegisterServiceServer(srv, &noSimulationService{node: node})
+				go func() { _ = srv.Serve(lis) }()
+				t.Cleanup(srv.Stop)
+
+				cfg := testConfig(node, key)
+				cfg.GRPCAddr = lis.Addr().String()
+				cfg.TxService = broadcaster.TxServiceGRPC
+				return cfg
+			},
+			gas: fallbackGas,
+		},
+		{
+			name: "unknown query path",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				node.HandleQuery(simulatePath, func([]byte) ([]byte, error) {
+					return nil, sdkerrors.Wrapf(sdkerrors.ErrUnknownRequest, "unknown query path: %s", simulatePath)
+				})
+				return testConfig(node, key)
+			},
+			gas: fallbackGas,
+		},
+		{
+			name: "http 404",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				h := node.Handler()
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, _ := io.ReadAll(r.Body)
+					if bytes.Contains(body, []byte(simulatePath)) {
+						http.NotFound(w, r)
+						return
+					}
+					r.Body = io.NopCloser(bytes.NewReader(body))
+					h.ServeHTTP(w, r)
+				}))
+				t.Cleanup(srv.Close)
+
+				return uriConfig(node, key, srv.URL)
+			},
+			gas: fallbackGas,
+		},
+		{
+			name: "other simulation error",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				node.HandleQuery(simulatePath, func([]byte) ([]byte, error) {
+					return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, "invalid tx")
+				})
+				return testConfig(node, key)
+			},
+			wantErr: true,
+		},
+		{
+			name: "fallback is disabled",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				node.HandleQuery(simulatePath, func([]byte) ([]byte, error) {
+					return nil, sdkerrors.Wrapf(sdkerrors.ErrUnknownRequest, "unknown query path: %s", simulatePath)
+				})
+				cfg := testConfig(node, key)
+				cfg.FallbackGas = 0
+				return cfg
+			},
+			wantErr: true,
+		},
+		{
+			name: "out of gas is bumped",
+			prepare: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				node.SetTxGas(fallbackGas + 50000)
+				node.HandleQuery(simulatePath, func([]byte) ([]byte, error) {
+					return nil, sdkerrors.Wrapf(sdkerrors.ErrUnknownRequest, "unknown query path: %s", simulatePath)
+				})
+				return testConfig(node, key)
+			},
+			gas: 2 * fallbackGas,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			cfg := tc.prepare(t, node, key)
+			if tc.name != "fallback is disabled" {
+				cfg.FallbackGas = fallbackGas
+			}
+			cfg.RetryPolicy.OutOfGasMultiplier = 2
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Empty(t, node.Mempool())
+				return
+			}
+			require.NoError(t, err)
+			requireCommitted(t, node, res.TxHash)
+
+			committed, _ := node.CommittedTx(res.TxHash)
+			require.Equal(t, int64(tc.gas), committed.TxResult.GasWanted)
+		})
+	}
+}
+
+func TestBroadcast_GasAdjustment(t *testing.T) {
+	// gasUsed is the gas the fake node simulates for a single bank transfer.
+	const gasUsed = testutil.DefaultFakeTxGas + 20000
+
+	tt := []struct {
+		name   string
+		cfg    float64
+		opts   float64
+		gas    int64
+		warned bool
+	}{
+		{name: "default", gas: gasUsed * broadcaster.DefaultGasAdjustment},
+		{name: "config", cfg: 1.5, gas: gasUsed * 1.5},
+		{name: "call overrides config", cfg: 1.5, opts: 2, gas: gasUsed * 2},
+		{name: "no headroom", cfg: 1, gas: gasUsed, warned: true},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			logger := &warnings{}
+
+			cfg := testConfig(node, key)
+			cfg.GasAdjust = tc.cfg
+			cfg.Logger = logger
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+				broadcaster.BroadcastOptions{GasAdjust: tc.opts})
+			require.NoError(t, err)
+			requireCommitted(t, node, res.TxHash)
+
+			committed, _ := node.CommittedTx(res.TxHash)
+			require.Equal(t, tc.gas, committed.TxResult.GasWanted)
+			require.Equal(t, tc.warned, len(logger.get()) > 0, logger.get())
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// nodeClient lists methods of rpc client used by the broadcaster. Config.RPCClient should implement them
+// properly, the other methods of rpcclient.Client aren't called.
+type nodeClient interface {
+	rpcclient.ABCIClient // ABCIQueryWithOptions is used by queries and simulation, the broadcast methods by broadcasts.
+	Status(ctx context.Context) (*ctypes.ResultStatus, error)
+	Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error)
+	TxSearch(
+		ctx context.Context, query string, prove bool, page, perPage *int, orderBy string,
+	) (*ctypes.ResultTxSearch, error)
+	UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error)
+	Commit(ctx context.Context, height *int64) (*ctypes.ResultCommit, error)
+	Validators(ctx context.Context, height *int64, page, perPage *int) (*ctypes.ResultValidators, error)
+}
+
+var _ nodeClient = rpcclient.Client(nil)
+
+// DefaultMaxSubscriptions is the default limit of concurrent websocket subscriptions of a client.
+// It matches the default max_subscriptions_per_client of the node.
+const DefaultMaxSubscriptions = 5
+
+// subscriber is the name of websocket subscriber. Nodes replace it with the client's address anyway.
+const subscriber = "go-broadcaster"
+
+// unsubscribeTimeout limits unsubscribing, which is done after the caller's context may be cancelled.
+const unsubscribeTimeout = 5 * time.Second
+
+// SharedClient is a rpc client which could be shared by several broadcasters connected to the same node.
+// The underlying connection is closed when the last user releases the client.
+type SharedClient struct {
+	client   rpcclient.Client
+	external bool // external client isn't stopped on release.
+	conns    connStats
+	dns      *dnsWatcher // dns re-resolves the node's host, it's nil if it's disabled.
+
+	maxSubscriptions int
+
+	mu            sync.Mutex
+	refs          int
+	closed        bool
+	subscriptions map[string]bool // subscriptions are active queries, the node delivers events by query.
+}
+
+// NewSharedClient returns new instance of shared client. The caller holds a reference which should be released.
+// The node's version is probed to pick the client compatible with Tendermint 0.34 or CometBFT 0.37+.
+func NewSharedClient(nodeURI string, opts ...ClientOption) (*SharedClient, error) {
+	o := clientOptions{clock: RealClock}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	maxSubscriptions := o.maxSubscriptions
+	if maxSubscriptions <= 0 {
+		maxSubscriptions = DefaultMaxSubscriptions
+	}
+
+	out := &SharedClient{
+		maxSubscriptions: maxSubscriptions,
+		refs:             1,
+		subscriptions:    make(map[string]bool),
+	}
+
+	httpClient, err := newHTTPClient(nodeURI, o, &out.conns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http client: %w", err)
+	}
+
+	if out.client, err = newNodeClient(nodeURI, httpClient); err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Websocket connections are dialed like the http ones, so subscriptions go through the proxy too.
+	if !strings.HasPrefix(nodeURI, "unix") {
+		dial, err := wsDialer(o)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create websocket dialer: %w", err)
+		}
+		if out.client, err = withWSEvents(out.client, nodeURI, dial, o.clock); err != nil {
+			return nil, fmt.Errorf("failed to create client: %w", err)
+		}
+	}
+
+	if o.transport.DNSRefreshInterval > 0 && !strings.HasPrefix(nodeURI, "unix") {
+		t := httpClient.Transport.(*tracingTransport)
+		if out.dns = newDNSWatcher(nodeURI, o, t.base.(*http.Transport)); out.dns != nil {
+			t.onError = out.dns.refresh
+		}
+	}
+
+	return out, nil
+}
+
+// NewSharedClientFrom returns shared client wrapping the pre-built rpc client. The rpc client isn't stopped
+// when the last reference is released. The caller holds a reference which should be released.
+func NewSharedClientFrom(c rpcclient.Client) *SharedClient {
+	return &SharedClient{
+		client:           c,
+		external:         true,
+		maxSubscriptions: DefaultMaxSubscriptions,
+		refs:             1,
+		subscriptions:    make(map[string]bool),
+	}
+}
+
+// Client returns the underlying rpc client.
+func (c *SharedClient) Client() rpcclient.Client {
+	return c.client
+}
+
+// ConnStats returns stats of connections to the node. They're empty for pre-built clients.
+func (c *SharedClient) ConnStats() ConnStats {
+	return c.conns.get()
+}
+
+// Release releases the reference. The underlying client is stopped when there are no references left.
+func (c *SharedClient) Release() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.refs == 0 {
+		return errors.New("shared client is already released")
+	}
+
+	c.refs--
+	if c.refs > 0 || c.closed {
+		return nil
+	}
+
+	c.closed = true
+	if c.dns != nil {
+		c.dns.close()
+	}
+	if !c.external && c.client.IsRunning() {
+		if err := c.client.Stop(); err != nil {
+			return fmt.Errorf("failed to stop client: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// subscribe subscribes to events matching the query over websocket and returns a function which unsubscribes.
+// The client is started on the first subscription, pre-built clients should be started by the caller.
+// It fails if the limit of subscriptions is reached or the query is already subscribed, since the node
+// delivers events of the same query to a single subscription.
+func (c *SharedClient) subscribe(ctx context.Context, query string) (<-chan ctypes.ResultEvent, func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, nil, errors.New("shared client is closed")
+	}
+	if len(c.subscriptions) >= c.maxSubscriptions {
+		return nil, nil, fmt.Errorf("all %d subscriptions are used", c.maxSubscriptions)
+	}
+	if c.subscriptions[query] {
+		return nil, nil, errors.New("query is already subscribed")
+	}
+
+	if !c.client.IsRunning() {
+		if c.external {
+			return nil, nil, errors.New("client isn't started")
+		}
+		if err := c.client.Start(); err != nil {
+			return nil, nil, fmt.Errorf("failed to start client: %w", err)
+		}
+	}
+
+	ch, err := c.client.Subscribe(ctx, subscriber, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+	c.subscriptions[query] = true
+
+	unsubscribe := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+		defer cancel()
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		delete(c.subscriptions, query)
+		if c.client.IsRunning() {
+			_ = c.client.Unsubscribe(ctx, subscriber, query)
+		}
+	}
+
+	return ch, unsubscribe, nil
+}
+
+// acquire adds a reference to the client.
+func (c *SharedClient) acquire() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errors.New("shared client is closed")
+	}
+
+	c.refs++
+
+	return nil
+}
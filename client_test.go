@@ -0,0 +1,140 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// countingServer serves the node over http and counts connections accepted by the server.
+func countingServer(t *testing.T, node *testutil.FakeNode) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var conns int32
+	srv := httptest.NewUnstartedServer(node.Handler())
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	return srv, &conns
+}
+
+// uriConfig returns config of broadcaster connecting to the node by uri.
+func uriConfig(node *testutil.FakeNode, key testutil.Key, uri string) broadcaster.Config {
+	cfg := testConfig(node, key)
+	cfg.RPCClient = nil
+	cfg.NodeURI = uri
+
+	return cfg
+}
+
+func TestSharedClient(t *testing.T) {
+	node, key := newFakeChain(t)
+	other := testutil.NewKey("other")
+	node.AddAccount(other.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+	srv, conns := countingServer(t, node)
+
+	c, err := broadcaster.NewSharedClient(srv.URL)
+	require.NoError(t, err)
+
+	var bs []interface {
+		Broadcast(msgs []sdk.Msg, memo string) (*sdk.TxResponse, error)
+		Close() error
+	}
+	for _, k := range []testutil.Key{key, other} {
+		cfg := uriConfig(node, k, "")
+		cfg.Client = c
+
+		b, err := broadcaster.New(cfg)
+		require.NoError(t, err)
+		bs = append(bs, b)
+	}
+	require.NoError(t, c.Release())
+
+	for i, b := range bs {
+		_, err := b.Broadcast([]sdk.Msg{sendMsg([]testutil.Key{key, other}[i].Address, 1)}, "")
+		require.NoError(t, err)
+	}
+
+	require.EqualValues(t, 1, atomic.LoadInt32(conns))
+	require.EqualValues(t, 1, c.ConnStats().Created)
+
+	// The client is stopped only when the last broadcaster is closed.
+	require.NoError(t, bs[0].Close())
+	_, err = bs[1].Broadcast([]sdk.Msg{sendMsg(other.Address, 1)}, "")
+	require.NoError(t, err)
+
+	require.NoError(t, bs[1].Close())
+	require.Error(t, c.Release())
+}
+
+func TestNewPool_SharesClient(t *testing.T) {
+	node := testutil.NewFakeNode()
+	srv, conns := countingServer(t, node)
+
+	var members []broadcaster.Config
+	for _, name := range []string{"first", "second", "third"} {
+		key := testutil.NewKey(t.Name() + name)
+		node.AddAccount(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+		members = append(members, uriConfig(node, key, srv.URL))
+	}
+
+	p, err := broadcaster.NewPool(broadcaster.PoolConfig{Members: members})
+	require.NoError(t, err)
+
+	_, err = p.Broadcast([]sdk.Msg{sendMsg(p.From(), 1)}, "")
+	require.NoError(t, err)
+
+	require.EqualValues(t, 1, atomic.LoadInt32(conns))
+	require.NoError(t, p.Close())
+}
+
+func TestRPCClient(t *testing.T) {
+	node, key := newFakeChain(t)
+	rec := testutil.NewRecordingClient(node)
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = rec
+	require.Empty(t, cfg.NodeURI)
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	rec.ResetCalls()
+	node.FailNext("broadcast_tx_sync", errors.New("connection reset"))
+
+	_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.ErrorContains(t, err, "connection reset")
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+
+	// Every request goes through the injected client, the failed ones are recorded with their errors.
+	var broadcasts []testutil.Call
+	for _, c := range rec.Calls() {
+		if c.Method == "broadcast_tx_sync" {
+			broadcasts = append(broadcasts, c)
+		}
+	}
+	require.Len(t, broadcasts, 2)
+	require.ErrorContains(t, broadcasts[0].Err, "connection reset")
+	require.NoError(t, broadcasts[1].Err)
+	require.Equal(t, node.Calls("broadcast_tx_sync"), len(broadcasts))
+}
@@ -0,0 +1,109 @@
+package broadcaster
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is a source of time used by every time-dependent part of the broadcaster.
+// It can be replaced to control time in tests, see testutil.FakeClock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	Sleep(d time.Duration)
+}
+
+// Timer is a timer created by Clock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// RealClock is Clock backed by the time package.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+// clock returns configured clock or RealClock.
+func (c Config) clock() Clock {
+	if c.Clock == nil {
+		return RealClock
+	}
+
+	return c.Clock
+}
+
+// timeoutContext is done when its parent is done or the clock's timer fires, like one of context.WithTimeout.
+// The parent's deadline is reported, since the clock's time could differ from the real one.
+type timeoutContext struct {
+	context.Context
+
+	done chan struct{}
+	stop chan struct{}
+	once sync.Once
+
+	mu  sync.Mutex
+	err error
+}
+
+// withClockTimeout returns the context which is cancelled with context.DeadlineExceeded after d of the clock.
+func withClockTimeout(parent context.Context, clock Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	ctx := &timeoutContext{
+		Context: parent,
+		done:    make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	timer := clock.NewTimer(d)
+
+	go func() {
+		defer timer.Stop()
+
+		select {
+		case <-parent.Done():
+			ctx.finish(parent.Err())
+		case <-timer.C():
+			ctx.finish(context.DeadlineExceeded)
+		case <-ctx.stop:
+			ctx.finish(context.Canceled)
+		}
+	}()
+
+	return ctx, func() {
+		ctx.once.Do(func() {
+			close(ctx.stop)
+		})
+	}
+}
+
+func (c *timeoutContext) finish(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+
+	close(c.done)
+}
+
+func (c *timeoutContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *timeoutContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
@@ -0,0 +1,213 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Defaults of BroadcastShared.
+const (
+	DefaultCoalesceWindow  = 200 * time.Millisecond
+	DefaultCoalesceMaxMsgs = 100
+)
+
+// ErrSharedTxFailed is returned by BroadcastShared to callers whose msg didn't fail the shared tx,
+// when another msg of the tx did.
+var ErrSharedTxFailed = errors.New("shared tx is failed by another msg")
+
+// coalescer gathers msgs of concurrent BroadcastShared calls into shared txs, a batch per memo.
+type coalescer struct {
+	mu      sync.Mutex
+	batches map[string]*sharedBatch
+}
+
+// sharedBatch is a tx shared by several callers.
+type sharedBatch struct {
+	// calls, pending and assembled are guarded by coalescer.mu.
+	calls     []*sharedCall
+	pending   int // pending is the number of calls which aren't removed.
+	assembled bool
+	full      chan struct{} // full is closed when pending calls reach Config.CoalesceMaxMsgs.
+
+	done chan struct{} // done is closed when res and err are set.
+	res  *BroadcastResult
+	err  error
+}
+
+// sharedCall is a msg of a caller. index is its index in the assembled tx.
+type sharedCall struct {
+	msg       sdk.Msg
+	requestID string
+	removed   bool
+	index     int
+}
+
+// BroadcastShared broadcasts msg in a tx shared with concurrent callers. The call waits up to
+// Config.CoalesceWindow for msgs of other callers with the same memo, then they're broadcast together.
+// Every caller gets the shared result with MsgIndex of its msg. When the failure of the tx is attributed
+// to a msg, its caller gets the error and others get ErrSharedTxFailed.
+// Msg of the caller whose ctx is done before the tx is assembled is removed from it; after assembly
+// the caller still waits for the shared outcome.
+func (b *broadcaster) BroadcastShared(ctx context.Context, msg sdk.Msg, memo string) (*BroadcastResult, error) {
+	if err := b.checkMsgTypes([]sdk.Msg{msg}); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	batch, call := b.joinBatch(msg, memo, RequestIDFromContext(ctx))
+
+	select {
+	case <-batch.done:
+	case <-ctx.Done():
+		b.coalescer.mu.Lock()
+		assembled := batch.assembled
+		if !assembled {
+			call.removed = true
+			batch.pending--
+		}
+		b.coalescer.mu.Unlock()
+
+		if !assembled {
+			return nil, ctx.Err()
+		}
+		<-batch.done
+	}
+
+	return sharedOutcome(batch, call)
+}
+
+// joinBatch adds msg of the request to the pending batch of the memo. The batch is started if there isn't one.
+func (b *broadcaster) joinBatch(msg sdk.Msg, memo, requestID string) (*sharedBatch, *sharedCall) {
+	maxMsgs := b.cfg.CoalesceMaxMsgs
+	if maxMsgs <= 0 {
+		maxMsgs = DefaultCoalesceMaxMsgs
+	}
+
+	b.coalescer.mu.Lock()
+	defer b.coalescer.mu.Unlock()
+
+	if b.coalescer.batches == nil {
+		b.coalescer.batches = make(map[string]*sharedBatch)
+	}
+
+	batch, ok := b.coalescer.batches[memo]
+	if !ok {
+		batch = &sharedBatch{
+			full: make(chan struct{}),
+			done: make(chan struct{}),
+		}
+		b.coalescer.batches[memo] = batch
+
+		go b.runBatch(batch, memo)
+	}
+
+	call := &sharedCall{msg: msg, requestID: requestID}
+	batch.calls = append(batch.calls, call)
+	batch.pending++
+
+	// Removed calls don't count, their msgs aren't assembled.
+	if batch.pending == maxMsgs {
+		// The next caller starts a new batch.
+		delete(b.coalescer.batches, memo)
+		close(batch.full)
+	}
+
+	return batch, call
+}
+
+// runBatch waits for the window or the batch to be full, assembles the tx and broadcasts it.
+func (b *broadcaster) runBatch(batch *sharedBatch, memo string) {
+	defer close(batch.done)
+
+	window := b.cfg.CoalesceWindow
+	if window <= 0 {
+		window = DefaultCoalesceWindow
+	}
+
+	timer := b.cfg.clock().NewTimer(window)
+	select {
+	case <-timer.C():
+	case <-batch.full:
+		timer.Stop()
+	case <-b.closing:
+		timer.Stop()
+	}
+
+	b.coalescer.mu.Lock()
+	if b.coalescer.batches[memo] == batch {
+		delete(b.coalescer.batches, memo)
+	}
+	batch.assembled = true
+
+	var (
+		msgs      []sdk.Msg
+		requestID string
+	)
+	for _, v := range batch.calls {
+		if v.removed {
+			continue
+		}
+		if len(msgs) == 0 {
+			requestID = v.requestID
+		}
+		v.index = len(msgs)
+		msgs = append(msgs, v.msg)
+	}
+	b.coalescer.mu.Unlock()
+
+	if len(msgs) == 0 {
+		return
+	}
+
+	// Callers' contexts can't cancel the tx shared with others, it's canceled only by closing the broadcaster.
+	// The tx is attributed to the first caller's request.
+	ctx, cancel := context.WithCancel(WithRequestID(context.Background(), requestID))
+	defer cancel()
+	go func() {
+		select {
+		case <-b.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	batch.res, batch.err = b.BroadcastContext(ctx, msgs, memo, BroadcastOptions{})
+}
+
+// sharedOutcome returns the result of the shared tx for the call.
+func sharedOutcome(batch *sharedBatch, call *sharedCall) (*BroadcastResult, error) {
+	var res *BroadcastResult
+	if batch.res != nil {
+		v := *batch.res
+		v.MsgIndex = call.index
+		res = &v
+	}
+
+	if batch.err == nil {
+		return res, nil
+	}
+
+	if index := failedMsgIndex(batch.err); index != nil && *index != call.index {
+		return res, fmt.Errorf("%w: msg %d: %s", ErrSharedTxFailed, *index, batch.err)
+	}
+
+	return res, batch.err
+}
+
+// failedMsgIndex returns the index of the msg which failed tx if the error attributes it.
+func failedMsgIndex(err error) *int {
+	var txErr *TxError
+	if errors.As(err, &txErr) && txErr.FailedMsgIndex != nil {
+		return txErr.FailedMsgIndex
+	}
+
+	return parseFailedMsgIndex(err.Error())
+}
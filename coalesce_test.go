@@ -0,0 +1,311 @@
+package broadcaster_test
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/bytes"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// gatedSimulation is the fake node whose simulation waits until it's released.
+type gatedSimulation struct {
+	*testutil.FakeNode
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (n *gatedSimulation) ABCIQueryWithOptions(
+	ctx context.Context, path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions,
+) (*ctypes.ResultABCIQuery, error) {
+	if path == testutil.SimulateQueryPath {
+		n.entered <- struct{}{}
+		<-n.release
+	}
+
+	return n.FakeNode.ABCIQueryWithOptions(ctx, path, data, opts)
+}
+
+// sharedOutcome is the outcome of BroadcastShared call.
+type sharedOutcome struct {
+	res *broadcaster.BroadcastResult
+	err error
+}
+
+// sharedBroadcaster is the part of the broadcaster coalescing msgs.
+type sharedBroadcaster interface {
+	BroadcastShared(ctx context.Context, msg sdk.Msg, memo string) (*broadcaster.BroadcastResult, error)
+}
+
+// broadcastShared calls BroadcastShared in a goroutine and returns the channel of its outcome.
+func broadcastShared(ctx context.Context, b sharedBroadcaster, msg sdk.Msg) <-chan sharedOutcome {
+	out := make(chan sharedOutcome, 1)
+	go func() {
+		res, err := b.BroadcastShared(ctx, msg, "")
+		out <- sharedOutcome{res: res, err: err}
+	}()
+
+	return out
+}
+
+func TestBroadcastShared(t *testing.T) {
+	const window = time.Second
+
+	node, key := newFakeChain(t)
+	recipient := testutil.NewKey("recipient").Address
+	clock := testutil.NewFakeClock(time.Now())
+
+	cfg := testConfig(node, key)
+	cfg.Clock = clock
+	cfg.CoalesceWindow = window
+	cfg.CoalesceMaxMsgs = 3
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// The full batch is broadcast right away.
+	var outcomes []<-chan sharedOutcome
+	for i := 1; i <= 3; i++ {
+		outcomes = append(outcomes, broadcastShared(context.Background(), b, sendMsg(key.Address, int64(i))))
+		// The batch is started by the first caller.
+		clock.BlockUntil(1)
+	}
+
+	var hash string
+	indexes := map[int]bool{}
+	for _, out := range outcomes {
+		v := <-out
+		require.NoError(t, v.err)
+		if hash == "" {
+			hash = v.res.TxHash
+		}
+		require.Equal(t, hash, v.res.TxHash)
+		indexes[v.res.MsgIndex] = true
+	}
+	require.Equal(t, map[int]bool{0: true, 1: true, 2: true}, indexes)
+	requireCommitted(t, node, hash)
+	require.EqualValues(t, 1+2+3, node.Balance(recipient).AmountOf(testDenom).Int64())
+
+	// The batch which isn't full is broadcast after the window.
+	out := broadcastShared(context.Background(), b, sendMsg(key.Address, 4))
+	clock.BlockUntil(1)
+	require.Empty(t, out)
+	clock.Advance(window)
+
+	v := <-out
+	require.NoError(t, v.err)
+	require.Zero(t, v.res.MsgIndex)
+	requireCommitted(t, node, v.res.TxHash)
+	require.Equal(t, 2, node.Calls("broadcast_tx_sync"))
+}
+
+func TestBroadcastShared_CancelledBeforeAssembly(t *testing.T) {
+	const window = time.Second
+
+	node, key := newFakeChain(t)
+	recipient := testutil.NewKey("recipient").Address
+	clock := testutil.NewFakeClock(time.Now())
+
+	cfg := testConfig(node, key)
+	cfg.Clock = clock
+	cfg.CoalesceWindow = window
+	cfg.CoalesceMaxMsgs = 2
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := broadcastShared(ctx, b, sendMsg(key.Address, 100))
+	clock.BlockUntil(1)
+
+	// The msg is removed, it doesn't count towards the limit.
+	cancel()
+	v := <-cancelled
+	require.ErrorIs(t, v.err, context.Canceled)
+	require.Nil(t, v.res)
+
+	first := broadcastShared(broadcaster.WithRequestID(context.Background(), "first"), b, sendMsg(key.Address, 1))
+	second := broadcastShared(broadcaster.WithRequestID(context.Background(), "second"), b, sendMsg(key.Address, 2))
+
+	v1, v2 := <-first, <-second
+	require.NoError(t, v1.err)
+	require.NoError(t, v2.err)
+	require.Equal(t, v1.res.TxHash, v2.res.TxHash)
+	// The tx is attributed to the first caller which isn't removed.
+	require.Equal(t, v1.res.RequestID, v2.res.RequestID)
+	require.Contains(t, []string{"first", "second"}, v1.res.RequestID)
+	require.ElementsMatch(t, []int{0, 1}, []int{v1.res.MsgIndex, v2.res.MsgIndex})
+	requireCommitted(t, node, v1.res.TxHash)
+	require.EqualValues(t, 1+2, node.Balance(recipient).AmountOf(testDenom).Int64())
+
+	// The batch whose callers are all gone isn't broadcast.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancelled = broadcastShared(ctx, b, sendMsg(key.Address, 100))
+	clock.BlockUntil(1)
+	cancel()
+	require.ErrorIs(t, (<-cancelled).err, context.Canceled)
+
+	clock.Advance(window)
+	clock.BlockUntil(0)
+	require.Equal(t, 1, node.Calls("broadcast_tx_sync"))
+}
+
+func TestBroadcastShared_CancelledAfterAssembly(t *testing.T) {
+	node, key := newFakeChain(t)
+	gated := &gatedSimulation{FakeNode: node, entered: make(chan struct{}, 1), release: make(chan struct{})}
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = gated
+	cfg.CoalesceMaxMsgs = 1
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := broadcastShared(ctx, b, sendMsg(key.Address, 1))
+
+	// The tx is assembled and being simulated, the caller still waits for it.
+	<-gated.entered
+	cancel()
+	require.Never(t, func() bool { return len(out) > 0 }, 50*time.Millisecond, time.Millisecond)
+
+	close(gated.release)
+	v := <-out
+	require.NoError(t, v.err)
+	requireCommitted(t, node, v.res.TxHash)
+}
+
+func TestBroadcastShared_Closed(t *testing.T) {
+	node, key := newFakeChain(t)
+	gated := &gatedSimulation{FakeNode: node, entered: make(chan struct{}, 1), release: make(chan struct{})}
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = gated
+	cfg.CoalesceMaxMsgs = 1
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	out := broadcastShared(context.Background(), b, sendMsg(key.Address, 1))
+
+	// The tx is assembled and being simulated, closing the broadcaster cancels it.
+	<-gated.entered
+	node.SetLatency(time.Hour)
+	close(gated.release)
+	require.NoError(t, b.Close())
+
+	select {
+	case v := <-out:
+		require.ErrorIs(t, v.err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("shared tx isn't canceled")
+	}
+	require.Zero(t, node.Calls("broadcast_tx_sync"))
+}
+
+func TestBroadcastShared_AttributedFailure(t *testing.T) {
+	node, key := newFakeChain(t)
+	clock := testutil.NewFakeClock(time.Now())
+
+	cfg := testConfig(node, key)
+	cfg.Clock = clock
+	cfg.CoalesceMaxMsgs = 2
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	valid := broadcastShared(context.Background(), b, sendMsg(key.Address, 1))
+	clock.BlockUntil(1)
+	invalid := broadcastShared(context.Background(), b, sendMsg(key.Address, 2_000_000_000))
+
+	// The caller of the failed msg gets the error, the other one learns the tx failed because of another msg.
+	v := <-invalid
+	require.ErrorContains(t, v.err, "insufficient funds")
+	require.NotErrorIs(t, v.err, broadcaster.ErrSharedTxFailed)
+
+	v = <-valid
+	require.ErrorIs(t, v.err, broadcaster.ErrSharedTxFailed)
+	require.ErrorContains(t, v.err, "msg 1")
+	require.Equal(t, broadcaster.ClassTransient, broadcaster.Classify(v.err))
+	require.Zero(t, node.Calls("broadcast_tx_sync"))
+}
+
+func TestBroadcastShared_Concurrent(t *testing.T) {
+	const (
+		callers = 50
+		maxMsgs = 5
+	)
+
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+	recipient := testutil.NewKey("recipient").Address
+
+	cfg := testConfig(node, key)
+	cfg.CoalesceWindow = 10 * time.Millisecond
+	cfg.CoalesceMaxMsgs = maxMsgs
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	var (
+		wg       sync.WaitGroup
+		outcomes = make(chan sharedOutcome, callers)
+	)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// Some callers give up at random moments, before or after their tx is assembled.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if i%3 == 0 {
+				time.AfterFunc(time.Duration(rand.Intn(20))*time.Millisecond, cancel)
+			}
+
+			res, err := b.BroadcastShared(ctx, sendMsg(key.Address, 1), "")
+			outcomes <- sharedOutcome{res: res, err: err}
+		}(i)
+	}
+	wg.Wait()
+	close(outcomes)
+
+	var sent int64
+	txs := map[string]map[int]bool{}
+	for v := range outcomes {
+		if v.err != nil {
+			require.ErrorIs(t, v.err, context.Canceled)
+			continue
+		}
+
+		if txs[v.res.TxHash] == nil {
+			txs[v.res.TxHash] = map[int]bool{}
+		}
+		require.False(t, txs[v.res.TxHash][v.res.MsgIndex], "msg index is shared")
+		txs[v.res.TxHash][v.res.MsgIndex] = true
+		sent++
+	}
+
+	// Every msg which wasn't removed is sent once, txs don't exceed the limit.
+	for _, indexes := range txs {
+		require.LessOrEqual(t, len(indexes), maxMsgs)
+	}
+	require.Eventually(t, func() bool {
+		return node.Balance(recipient).AmountOf(testDenom).Int64() == sent
+	}, 5*time.Second, time.Millisecond)
+}
@@ -0,0 +1,184 @@
+package broadcaster
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	jsonrpcclient "github.com/tendermint/tendermint/rpc/jsonrpc/client"
+	"github.com/tendermint/tendermint/types"
+)
+
+// cometVersion is the first CometBFT version which encodes event attributes as plain strings.
+const cometVersion = "0.37.0"
+
+// versionProbeTimeout limits the request of node's version on connect.
+const versionProbeTimeout = 5 * time.Second
+
+// newNodeClient returns rpc client compatible with the node. Nodes running CometBFT 0.37+ are served
+// by cometClient, the others by the Tendermint 0.34 client. The legacy client is used if the node doesn't respond.
+func newNodeClient(nodeURI string, httpClient *http.Client) (rpcclient.Client, error) {
+	c, err := rpchttp.NewWithClient(nodeURI, "/websocket", httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), versionProbeTimeout)
+	defer cancel()
+
+	status, err := c.Status(ctx)
+	if err != nil {
+		return c, nil
+	}
+
+	v, err := parseVersion(status.NodeInfo.Version)
+	if err != nil {
+		return c, nil
+	}
+	if min, _ := parseVersion(cometVersion); v.compare(min) < 0 {
+		return c, nil
+	}
+
+	return newCometClient(nodeURI, c, httpClient)
+}
+
+// cometClient is rpc client of CometBFT 0.37+ nodes. Responses containing events are normalized
+// to the Tendermint 0.34 form, where attributes are base64 encoded, before they're decoded.
+type cometClient struct {
+	*rpchttp.HTTP
+	rpc *jsonrpcclient.Client
+}
+
+func newCometClient(nodeURI string, c *rpchttp.HTTP, httpClient *http.Client) (*cometClient, error) {
+	rpc, err := jsonrpcclient.NewWithHTTPClient(nodeURI, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create json rpc client: %w", err)
+	}
+
+	return &cometClient{HTTP: c, rpc: rpc}, nil
+}
+
+// Tx implements rpcclient.Client.
+func (c *cometClient) Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error) {
+	result := new(ctypes.ResultTx)
+	if err := c.call(ctx, "tx", map[string]interface{}{"hash": hash, "prove": prove}, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TxSearch implements rpcclient.Client.
+func (c *cometClient) TxSearch(
+	ctx context.Context, query string, prove bool, page, perPage *int, orderBy string,
+) (*ctypes.ResultTxSearch, error) {
+	params := map[string]interface{}{
+		"query":    query,
+		"prove":    prove,
+		"order_by": orderBy,
+	}
+	if page != nil {
+		params["page"] = page
+	}
+	if perPage != nil {
+		params["per_page"] = perPage
+	}
+
+	result := new(ctypes.ResultTxSearch)
+	if err := c.call(ctx, "tx_search", params, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// BlockResults implements rpcclient.Client.
+func (c *cometClient) BlockResults(ctx context.Context, height *int64) (*ctypes.ResultBlockResults, error) {
+	params := make(map[string]interface{})
+	if height != nil {
+		params["height"] = height
+	}
+
+	result := new(ctypes.ResultBlockResults)
+	if err := c.call(ctx, "block_results", params, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// BroadcastTxCommit implements rpcclient.Client.
+func (c *cometClient) BroadcastTxCommit(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTxCommit, error) {
+	result := new(ctypes.ResultBroadcastTxCommit)
+	if err := c.call(ctx, "broadcast_tx_commit", map[string]interface{}{"tx": tx}, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// call calls the method and decodes normalized result.
+func (c *cometClient) call(ctx context.Context, method string, params map[string]interface{}, result interface{}) error {
+	var raw json.RawMessage
+	if _, err := c.rpc.Call(ctx, method, params, &raw); err != nil {
+		return err
+	}
+
+	normalized, err := normalizeEventAttributes(raw)
+	if err != nil {
+		return fmt.Errorf("failed to normalize %s result: %w", method, err)
+	}
+
+	return tmjson.Unmarshal(normalized, result)
+}
+
+// normalizeEventAttributes encodes keys and values of events' attributes in base64.
+func normalizeEventAttributes(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	walkEvents(v)
+
+	return json.Marshal(v)
+}
+
+// walkEvents finds attributes of events in the decoded json and encodes them in base64.
+func walkEvents(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if attrs, ok := v["attributes"].([]interface{}); ok {
+			for _, a := range attrs {
+				attr, ok := a.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for _, k := range []string{"key", "value"} {
+					if s, ok := attr[k].(string); ok {
+						attr[k] = base64.StdEncoding.EncodeToString([]byte(s))
+					}
+				}
+			}
+		}
+
+		for _, child := range v {
+			walkEvents(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walkEvents(child)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package broadcaster_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// fixtureTxHash is the hash of tx in testdata/rpc fixtures.
+const fixtureTxHash = "FB739AF9AE11630880BD7B3A18B4815AB5B4FE33DE6F19471BF446884AE49068"
+
+// fixtureServer answers json rpc requests with results from testdata/rpc/<method>_<format>.json.
+func fixtureServer(t *testing.T, format string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result, err := os.ReadFile(fmt.Sprintf("testdata/rpc/%s_%s.json", req.Method, format))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestSharedClient_WireFormats(t *testing.T) {
+	hash, err := hex.DecodeString(fixtureTxHash)
+	require.NoError(t, err)
+
+	results := map[string]*ctypes.ResultTx{}
+	for _, format := range []string{"tendermint_0.34", "cometbft_0.37"} {
+		c, err := broadcaster.NewSharedClient(fixtureServer(t, format).URL)
+		require.NoError(t, err, format)
+		defer c.Release()
+
+		res, err := c.Client().Tx(context.Background(), hash, false)
+		require.NoError(t, err, format)
+		results[format] = res
+	}
+
+	// Both formats are decoded to the same result with readable attributes.
+	require.Equal(t, results["tendermint_0.34"], results["cometbft_0.37"])
+
+	attrs := map[string]string{}
+	for _, e := range results["cometbft_0.37"].TxResult.Events {
+		for _, a := range e.Attributes {
+			attrs[e.Type+"."+string(a.Key)] = string(a.Value)
+		}
+	}
+	require.Equal(t, "/cosmos.bank.v1beta1.MsgSend", attrs["message.action"])
+	require.Equal(t, "decentr1l6lmju373whsfmzapjw0rmhj7v6apv0mp24laa", attrs["transfer.recipient"])
+}
+
+func TestBroadcast_WireFormats(t *testing.T) {
+	for name, serve := range map[string]func(*testutil.FakeNode) *httptest.Server{
+		"tendermint": (*testutil.FakeNode).Serve,
+		"cometbft":   (*testutil.FakeNode).ServeComet,
+	} {
+		serve := serve
+		t.Run(name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			node.SetValidators(4)
+			node.NextBlock()
+
+			srv := serve(node)
+			defer srv.Close()
+
+			cfg := uriConfig(node, key, srv.URL)
+			cfg.BroadcastMode = broadcaster.ModeBlock
+			cfg.CommitPollInterval = 10 * time.Millisecond
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			go func() {
+				for len(node.Mempool()) == 0 {
+					time.Sleep(time.Millisecond)
+				}
+				node.NextBlock()
+			}()
+
+			// The commit is awaited through the tx endpoint.
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+				broadcaster.BroadcastOptions{IdempotencyKey: "wire"})
+			require.NoError(t, err)
+
+			committed, ok := node.CommittedTx(res.TxHash)
+			require.True(t, ok)
+			want := sdk.NewResponseResultTx(committed, nil, "")
+			require.Equal(t, want, res.Response)
+			require.NotEmpty(t, res.Events)
+
+			// Txs are searched through the tx_search endpoint.
+			found, err := b.FindTxByIdempotencyKey(context.Background(), "wire")
+			require.NoError(t, err)
+			require.Equal(t, want, found.Response)
+
+			// Proofs are requested through the tx endpoint too.
+			node.NextBlock()
+			verified, err := b.GetTxWithProof(context.Background(), res.TxHash, broadcaster.NodeHeaders(node, testutil.FakeChainID))
+			require.NoError(t, err)
+			require.Equal(t, want, verified.Response)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package broadcaster
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// CommitTimeoutError is returned when tx wasn't committed in time.
+// It wraps the context's error when waiting is interrupted by the caller.
+type CommitTimeoutError struct {
+	TxHash string
+	Err    error
+}
+
+// Error implements error interface.
+func (e *CommitTimeoutError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("tx %s is not committed: %s", e.TxHash, e.Err)
+	}
+
+	return fmt.Sprintf("tx %s is not committed in time", e.TxHash)
+}
+
+func (e *CommitTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// waitForCommit waits until tx with the hash is committed. The tracker resolves tx when tracking is enabled,
+// otherwise the node is polled with Config.CommitPollInterval, since nothing else follows tx then.
+// The response has the same form as one returned by the node in block mode.
+func (b *broadcaster) waitForCommit(ctx context.Context, txHash string) (*sdk.TxResponse, error) {
+	hash, err := hex.DecodeString(txHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx hash: %w", err)
+	}
+
+	if b.tracker != nil {
+		return b.waitTracked(ctx, txHash)
+	}
+
+	node, err := b.ctx.GetNode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	timeout := b.cfg.clock().NewTimer(b.cfg.commitTimeout())
+	defer timeout.Stop()
+
+	for {
+		// The node returns error until tx is committed.
+		if res, err := node.Tx(ctx, hash, false); err == nil {
+			return sdk.NewResponseResultTx(res, nil, ""), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, &CommitTimeoutError{TxHash: txHash, Err: ctx.Err()}
+		case <-timeout.C():
+			return nil, &CommitTimeoutError{TxHash: txHash}
+		case <-b.cfg.clock().After(b.cfg.commitPollInterval()):
+		}
+	}
+}
+
+// waitTracked waits for the tracker to resolve tx. Failed tx is returned with its response like
+// the committed one. Expiration of tx is reported as CommitTimeoutError.
+func (b *broadcaster) waitTracked(ctx context.Context, txHash string) (*sdk.TxResponse, error) {
+	type outcome struct {
+		resp *sdk.TxResponse
+		err  error
+	}
+
+	resolved := make(chan outcome, 1)
+	if err := b.OnCommit(txHash, func(resp *sdk.TxResponse, err error) {
+		resolved <- outcome{resp: resp, err: err}
+	}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, &CommitTimeoutError{TxHash: txHash, Err: ctx.Err()}
+	case <-b.closing:
+		// The tracker is stopped, so tx won't be resolved.
+		return nil, &CommitTimeoutError{TxHash: txHash, Err: errors.New("broadcaster is closed")}
+	case v := <-resolved:
+		var txErr *TxError
+		switch {
+		case v.err == nil, errors.As(v.err, &txErr):
+			return v.resp, nil
+		case errors.Is(v.err, ErrTxExpired):
+			return nil, &CommitTimeoutError{TxHash: txHash}
+		default:
+			return nil, v.err
+		}
+	}
+}
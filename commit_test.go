@@ -0,0 +1,158 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+func TestBroadcast_BlockModeEmulation(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = broadcaster.ModeBlock
+	cfg.CommitPollInterval = 10 * time.Millisecond
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	go func() {
+		for len(node.Mempool()) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		node.NextBlock()
+	}()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	// The tx is sent in sync mode and the response has the same form as block mode's one.
+	require.Equal(t, 1, node.Calls("broadcast_tx_sync"))
+	require.Zero(t, node.Calls("broadcast_tx_commit"))
+
+	committed, ok := node.CommittedTx(res.TxHash)
+	require.True(t, ok)
+	require.Equal(t, sdk.NewResponseResultTx(committed, nil, ""), res.Response)
+	require.Equal(t, node.Height(), res.Response.Height)
+	require.NotEmpty(t, res.Response.Logs)
+}
+
+func TestBroadcast_CommitTimeout(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = broadcaster.ModeCommit
+	cfg.CommitTimeout = 50 * time.Millisecond
+	cfg.CommitPollInterval = 10 * time.Millisecond
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+
+	var timeoutErr *broadcaster.CommitTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	require.Equal(t, res.TxHash, timeoutErr.TxHash)
+	require.NoError(t, timeoutErr.Unwrap())
+	require.Equal(t, broadcaster.ClassTransient, broadcaster.Classify(err))
+}
+
+func TestBroadcast_CommitWaitCancelled(t *testing.T) {
+	for _, cause := range []error{context.Canceled, context.DeadlineExceeded} {
+		cause := cause
+		t.Run(cause.Error(), func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			cfg := testConfig(node, key)
+			cfg.BroadcastMode = broadcaster.ModeBlock
+			cfg.CommitPollInterval = 10 * time.Millisecond
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if errors.Is(cause, context.DeadlineExceeded) {
+				ctx, cancel = context.WithTimeout(context.Background(), 300*time.Millisecond)
+				defer cancel()
+			} else {
+				go func() {
+					for len(node.Mempool()) == 0 {
+						time.Sleep(time.Millisecond)
+					}
+					cancel()
+				}()
+			}
+
+			res, err := b.BroadcastContext(ctx, []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+			require.ErrorIs(t, err, cause)
+
+			// The hash is still known, so the caller could look for the tx later.
+			var timeoutErr *broadcaster.CommitTimeoutError
+			require.ErrorAs(t, err, &timeoutErr)
+			require.Equal(t, res.TxHash, timeoutErr.TxHash)
+			require.Equal(t, node.Mempool()[0].Hash, res.TxHash)
+		})
+	}
+}
+
+func TestBroadcast_CommitTracked(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = broadcaster.ModeBlock
+	// The commit is awaited through the tracker, the private polling would never find it in time.
+	cfg.CommitPollInterval = time.Hour
+	cfg.Tracking = broadcaster.TxTracking{Interval: trackingInterval}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	go func() {
+		for len(node.Mempool()) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		node.NextBlock()
+	}()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	committed, ok := node.CommittedTx(res.TxHash)
+	require.True(t, ok)
+	require.Equal(t, sdk.NewResponseResultTx(committed, nil, ""), res.Response)
+	requireStatus(t, b, res.TxHash, broadcaster.TxCommitted)
+}
+
+func TestBroadcast_CommitTrackedTimeout(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = broadcaster.ModeCommit
+	cfg.CommitTimeout = 50 * time.Millisecond
+	cfg.CommitPollInterval = time.Hour
+	cfg.Tracking = broadcaster.TxTracking{Interval: trackingInterval}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+
+	// The expiration of tx is reported like the timeout of polling.
+	var timeoutErr *broadcaster.CommitTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	require.Equal(t, res.TxHash, timeoutErr.TxHash)
+	require.NoError(t, timeoutErr.Unwrap())
+	requireStatus(t, b, res.TxHash, broadcaster.TxExpired)
+}
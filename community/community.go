@@ -0,0 +1,101 @@
+// Package community contains helpers to broadcast messages of decentr's community module.
+package community
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/gofrs/uuid"
+
+	communitytypes "github.com/Decentr-net/decentr/x/community/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+// ErrInvalidArgument is returned when msg built from arguments doesn't pass validation.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+func init() {
+	broadcaster.RegisterErrorClass(ErrInvalidArgument, broadcaster.ClassInvalidRequest)
+}
+
+// Broadcaster is used by Client to broadcast messages.
+type Broadcaster interface {
+	From() sdk.AccAddress
+	BroadcastContext(ctx context.Context, msgs []sdk.Msg, memo string, opts broadcaster.BroadcastOptions) (*broadcaster.BroadcastResult, error)
+}
+
+// CreatePostResult contains the outcome of post creation.
+type CreatePostResult struct {
+	*broadcaster.BroadcastResult
+
+	// PostUUID is the uuid of created post.
+	PostUUID uuid.UUID
+}
+
+// Client broadcasts community module's messages on behalf of broadcaster's account.
+type Client struct {
+	b Broadcaster
+}
+
+// New returns new instance of Client.
+func New(b Broadcaster) *Client {
+	return &Client{b: b}
+}
+
+// CreatePost creates a post.
+func (c *Client) CreatePost(
+	ctx context.Context, title string, category communitytypes.Category, previewImage, text string,
+) (*CreatePostResult, error) {
+	msg := communitytypes.NewMsgCreatePost(title, category, previewImage, text, c.b.From())
+
+	res, err := c.broadcast(ctx, &msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreatePostResult{
+		BroadcastResult: res,
+		PostUUID:        uuid.FromStringOrNil(msg.Post.Uuid),
+	}, nil
+}
+
+// DeletePost deletes the post.
+func (c *Client) DeletePost(ctx context.Context, postOwner sdk.AccAddress, postUUID uuid.UUID) (*broadcaster.BroadcastResult, error) {
+	msg := communitytypes.NewMsgDeletePost(c.b.From(), postOwner, postUUID)
+
+	return c.broadcast(ctx, &msg)
+}
+
+// SetLike sets like's weight of the post.
+func (c *Client) SetLike(
+	ctx context.Context, postOwner sdk.AccAddress, postUUID uuid.UUID, weight communitytypes.LikeWeight,
+) (*broadcaster.BroadcastResult, error) {
+	msg := communitytypes.NewMsgSetLike(postOwner, postUUID, c.b.From(), weight)
+
+	return c.broadcast(ctx, &msg)
+}
+
+// Follow follows the account.
+func (c *Client) Follow(ctx context.Context, whom sdk.AccAddress) (*broadcaster.BroadcastResult, error) {
+	msg := communitytypes.NewMsgFollow(c.b.From(), whom)
+
+	return c.broadcast(ctx, &msg)
+}
+
+// Unfollow unfollows the account.
+func (c *Client) Unfollow(ctx context.Context, whom sdk.AccAddress) (*broadcaster.BroadcastResult, error) {
+	msg := communitytypes.NewMsgUnfollow(c.b.From(), whom)
+
+	return c.broadcast(ctx, &msg)
+}
+
+func (c *Client) broadcast(ctx context.Context, msg sdk.Msg) (*broadcaster.BroadcastResult, error) {
+	if err := msg.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArgument, err)
+	}
+
+	return c.b.BroadcastContext(ctx, []sdk.Msg{msg}, "", broadcaster.BroadcastOptions{})
+}
@@ -0,0 +1,212 @@
+package community_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+
+	communitytypes "github.com/Decentr-net/decentr/x/community/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/community"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+var update = flag.Bool("update", false, "rewrite golden msgs in testdata")
+
+// stubBroadcaster records broadcast msgs.
+type stubBroadcaster struct {
+	from sdk.AccAddress
+	msgs []sdk.Msg
+	err  error
+}
+
+func (b *stubBroadcaster) From() sdk.AccAddress {
+	return b.from
+}
+
+func (b *stubBroadcaster) BroadcastContext(
+	_ context.Context, msgs []sdk.Msg, _ string, _ broadcaster.BroadcastOptions,
+) (*broadcaster.BroadcastResult, error) {
+	b.msgs = append(b.msgs, msgs...)
+	if b.err != nil {
+		return nil, b.err
+	}
+	return &broadcaster.BroadcastResult{TxHash: "HASH"}, nil
+}
+
+var (
+	owner     = testutil.NewKey("owner").Address
+	postOwner = testutil.NewKey("post owner").Address
+	postUUID  = uuid.Must(uuid.FromString("3f1a2b3c-4d5e-11ed-8f6a-0242ac120002"))
+)
+
+func TestClient(t *testing.T) {
+	tt := []struct {
+		name   string
+		golden string
+		call   func(c *community.Client) (*broadcaster.BroadcastResult, error)
+	}{
+		{
+			name:   "create post",
+			golden: "create_post",
+			call: func(c *community.Client) (*broadcaster.BroadcastResult, error) {
+				res, err := c.CreatePost(context.Background(), "title", communitytypes.Category_CATEGORY_WORLD_NEWS,
+					"https://decentr.net/preview.png", "some text which is long enough to be a post")
+				if err != nil {
+					return nil, err
+				}
+				return res.BroadcastResult, nil
+			},
+		},
+		{
+			name:   "delete post",
+			golden: "delete_post",
+			call: func(c *community.Client) (*broadcaster.BroadcastResult, error) {
+				return c.DeletePost(context.Background(), postOwner, postUUID)
+			},
+		},
+		{
+			name:   "like",
+			golden: "set_like_up",
+			call: func(c *community.Client) (*broadcaster.BroadcastResult, error) {
+				return c.SetLike(context.Background(), postOwner, postUUID, communitytypes.LikeWeight_LIKE_WEIGHT_UP)
+			},
+		},
+		{
+			name:   "dislike",
+			golden: "set_like_down",
+			call: func(c *community.Client) (*broadcaster.BroadcastResult, error) {
+				return c.SetLike(context.Background(), postOwner, postUUID, communitytypes.LikeWeight_LIKE_WEIGHT_DOWN)
+			},
+		},
+		{
+			name:   "follow",
+			golden: "follow",
+			call: func(c *community.Client) (*broadcaster.BroadcastResult, error) {
+				return c.Follow(context.Background(), postOwner)
+			},
+		},
+		{
+			name:   "unfollow",
+			golden: "unfollow",
+			call: func(c *community.Client) (*broadcaster.BroadcastResult, error) {
+				return c.Unfollow(context.Background(), postOwner)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			b := &stubBroadcaster{from: owner}
+
+			res, err := tc.call(community.New(b))
+			require.NoError(t, err)
+			require.Equal(t, "HASH", res.TxHash)
+			require.Len(t, b.msgs, 1)
+
+			// Post uuid is generated, so it's replaced before comparison.
+			if msg, ok := b.msgs[0].(*communitytypes.MsgCreatePost); ok {
+				require.NotEqual(t, uuid.Nil, uuid.FromStringOrNil(msg.Post.Uuid))
+				msg.Post.Uuid = postUUID.String()
+			}
+
+			requireGolden(t, tc.golden, b.msgs[0])
+		})
+	}
+}
+
+func requireGolden(t *testing.T, name string, msg sdk.Msg) {
+	t.Helper()
+
+	got, err := codec.ProtoMarshalJSON(msg, nil)
+	require.NoError(t, err)
+
+	path := filepath.Join("testdata", name+".json")
+	if *update {
+		require.NoError(t, os.WriteFile(path, append(got, '\n'), 0o600))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.JSONEq(t, string(want), string(got))
+}
+
+func TestClient_CreatePostUUID(t *testing.T) {
+	b := &stubBroadcaster{from: owner}
+
+	res, err := community.New(b).CreatePost(context.Background(), "title", communitytypes.Category_CATEGORY_WORLD_NEWS,
+		"", "some text which is long enough to be a post")
+	require.NoError(t, err)
+	require.Equal(t, b.msgs[0].(*communitytypes.MsgCreatePost).Post.Uuid, res.PostUUID.String())
+}
+
+func TestClient_InvalidArgument(t *testing.T) {
+	tt := []struct {
+		name string
+		call func(c *community.Client) error
+	}{
+		{
+			name: "empty title",
+			call: func(c *community.Client) error {
+				_, err := c.CreatePost(context.Background(), "", communitytypes.Category_CATEGORY_WORLD_NEWS, "", "text")
+				return err
+			},
+		},
+		{
+			name: "empty post owner",
+			call: func(c *community.Client) error {
+				_, err := c.DeletePost(context.Background(), nil, postUUID)
+				return err
+			},
+		},
+		{
+			name: "invalid like weight",
+			call: func(c *community.Client) error {
+				_, err := c.SetLike(context.Background(), postOwner, postUUID, communitytypes.LikeWeight(5))
+				return err
+			},
+		},
+		{
+			name: "follow empty address",
+			call: func(c *community.Client) error {
+				_, err := c.Follow(context.Background(), nil)
+				return err
+			},
+		},
+		{
+			name: "unfollow empty address",
+			call: func(c *community.Client) error {
+				_, err := c.Unfollow(context.Background(), nil)
+				return err
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			b := &stubBroadcaster{from: owner}
+
+			require.ErrorIs(t, tc.call(community.New(b)), community.ErrInvalidArgument)
+			require.Empty(t, b.msgs, "invalid msg isn't broadcast")
+		})
+	}
+}
+
+func TestClient_BroadcastError(t *testing.T) {
+	b := &stubBroadcaster{from: owner, err: errors.New("node is down")}
+
+	_, err := community.New(b).Follow(context.Background(), postOwner)
+	require.ErrorIs(t, err, b.err)
+	require.NotErrorIs(t, err, community.ErrInvalidArgument)
+}
@@ -0,0 +1,540 @@
+package broadcaster
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+)
+
+// BroadcastMode defines how long broadcasting waits for the node.
+type BroadcastMode string
+
+const (
+	// ModeSync waits for CheckTx execution.
+	ModeSync BroadcastMode = flags.BroadcastSync
+	// ModeAsync returns right after tx is sent.
+	ModeAsync BroadcastMode = flags.BroadcastAsync
+	// ModeBlock waits for tx to be committed.
+	// It is emulated by broadcasting in sync mode and polling the node for the tx.
+	ModeBlock BroadcastMode = flags.BroadcastBlock
+	// ModeCommit is an alias of ModeBlock.
+	ModeCommit BroadcastMode = "commit"
+)
+
+// Default values of Config.
+const (
+	DefaultGasAdjustment = 1.2
+
+	DefaultCommitTimeout      = 30 * time.Second
+	DefaultCommitPollInterval = time.Second
+)
+
+// Validate checks if mode is supported.
+func (m BroadcastMode) Validate() error {
+	switch m {
+	case ModeSync, ModeAsync, ModeBlock, ModeCommit:
+		return nil
+	default:
+		return fmt.Errorf("unknown broadcast mode %q: supported modes are %s, %s, %s, %s",
+			m, ModeSync, ModeAsync, ModeBlock, ModeCommit)
+	}
+}
+
+// waitsForCommit returns true if broadcasting should wait for tx to be committed.
+func (m BroadcastMode) waitsForCommit() bool {
+	return m == ModeBlock || m == ModeCommit
+}
+
+// nodeMode returns mode used to send tx to the node.
+func (m BroadcastMode) nodeMode() string {
+	if m.waitsForCommit() {
+		return flags.BroadcastSync
+	}
+
+	return string(m)
+}
+
+// Config ...
+type Config struct {
+	KeyringRootDir     string
+	KeyringBackend     string
+	KeyringPromptInput string
+	// PassphraseFunc returns the keyring's passphrase. It's called whenever the keyring prompts for it,
+	// so the secret could be fetched at unlock time. KeyringPromptInput is used if it's nil.
+	PassphraseFunc func() (string, error)
+
+	// Mnemonic or PrivKeyHex create From key in "memory" keyring backend. Exactly one of them is required
+	// for the backend and they can't be used with the others.
+	Mnemonic string
+	// PrivKeyHex is hex encoded secp256k1 private key.
+	PrivKeyHex string
+	// Keys are additional keys created in "memory" keyring backend, e.g. for SwitchKey.
+	Keys []KeySeed
+
+	// ArmoredKey is the key exported with "decentrd keys export". It's imported as From key into the keyring
+	// unless the keyring already has it. ArmorPassphrase decrypts it.
+	ArmoredKey      string
+	ArmorPassphrase string
+	// ExpectedAddress is the bech32 address From key should resolve to, New fails with ErrAddressMismatch otherwise.
+	// It protects from signing with a stale key of the same name. It isn't checked when empty.
+	ExpectedAddress string
+	// ExpectedAddresses are bech32 addresses of the other keys checked by SwitchKey.
+	ExpectedAddresses map[string]string
+
+	// AllowKeyExport allows ExportArmored to export the private key.
+	AllowKeyExport bool
+
+	NodeURI       string
+	BroadcastMode BroadcastMode
+	// ExtraNodeURIs are additional nodes used by hedged broadcasts.
+	ExtraNodeURIs []string
+	// VerifyPropagation enables background check that broadcast tx has reached the first of ExtraNodeURIs.
+	VerifyPropagation bool
+	// PropagationGracePeriod is the time given to tx to reach the second node.
+	// DefaultPropagationGracePeriod is used by default.
+	PropagationGracePeriod time.Duration
+	// HedgeDelay is a delay before hedged tx is sent to the secondary node. DefaultHedgeDelay is used by default.
+	HedgeDelay time.Duration
+	// Client is used instead of creating a new connection to NodeURI when set.
+	// The broadcaster holds a reference to the client until Close is called.
+	Client *SharedClient
+	// RPCClient is a pre-built rpc client used instead of connecting to NodeURI, which is optional then.
+	// The client isn't stopped by the broadcaster. Methods used by the broadcaster are listed by nodeClient.
+	RPCClient rpcclient.Client
+
+	From    string
+	ChainID string
+
+	Fees sdk.Coins
+	// FeesStr is human readable fees, e.g. "0.05dec". It's parsed with DenomMetadata and can't be used with Fees.
+	FeesStr string
+	// GasPrices are used to calculate fees from gas. They can't be used with fees.
+	GasPrices sdk.DecCoins
+	// GasPricesStr is human readable gas prices, e.g. "0.025udec". It can't be used with GasPrices.
+	GasPricesStr string
+
+	Gas uint64
+	// GasAdjust multiplies simulated gas. It should be at least 1, DefaultGasAdjustment is used by default.
+	GasAdjust float64
+	// FallbackGas is used when the node doesn't support simulation. Broadcast fails in this case by default.
+	FallbackGas uint64
+	// AlwaysSimulate makes txs with fixed gas simulated too, so invalid ones fail before broadcasting.
+	// The simulated gas is discarded.
+	AlwaysSimulate bool
+	// SkipSimulationOnRetry skips the simulation of AlwaysSimulate on retries.
+	SkipSimulationOnRetry bool
+	// GasPerMsgType is gas of messages by their type urls. Simulation is skipped when every message
+	// of a tx has an entry, the tx gets the sum of entries and GasTxOverhead. It takes precedence over Gas.
+	GasPerMsgType map[string]uint64
+	// GasTxOverhead is gas added to the sum of GasPerMsgType entries for the tx itself.
+	GasTxOverhead uint64
+	// OnStaleGas is called when tx with gas from GasPerMsgType runs out of gas, so the entries of its types
+	// are likely stale.
+	OnStaleGas func(msgTypes []string, gas uint64)
+	// AdaptiveGas configures estimation of gas from gas used by committed txs. It's disabled by default.
+	// GasPerMsgType takes precedence over it.
+	AdaptiveGas AdaptiveGas
+
+	// ExtensionOptions are set to tx body of every tx. BroadcastOptions.ExtensionOptions override them.
+	// Their types should be registered in the interface registry.
+	ExtensionOptions []*codectypes.Any
+	// NonCriticalExtensionOptions are set to tx body of every tx. Nodes ignore the ones they don't know.
+	NonCriticalExtensionOptions []*codectypes.Any
+
+	// UniquifyMemo appends a unique nonce field to the memo of every broadcast, e.g. "nonce=kx3b9", so deliberate
+	// re-submissions of the same msgs don't collide in the node's mempool cache. The nonce is reported
+	// in BroadcastResult.MemoNonce. It's disabled by default.
+	UniquifyMemo bool
+
+	// SimulateBatchPrefixes limits extra simulations SimulateBatch does to attribute gas to msgs.
+	// Gas isn't attributed by default.
+	SimulateBatchPrefixes int
+	// RPCTimeout limits every simulation request. It isn't limited by default, but the caller's context is respected.
+	RPCTimeout time.Duration
+
+	// ProxyURL is the proxy used to connect to nodes. Schemes http, https and socks5 are supported.
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used by default.
+	// Websocket subscriptions use the proxy too, http and https proxies are passed through with CONNECT.
+	ProxyURL string
+	// Transport tunes connections to nodes.
+	Transport TransportConfig
+	// MaxSubscriptions limits concurrent websocket subscriptions of WaitForEvent, the excess ones poll the node.
+	// DefaultMaxSubscriptions is used by default.
+	MaxSubscriptions int
+
+	// GRPCAddr is the address of the node's gRPC server, e.g. localhost:9090.
+	GRPCAddr string
+	// TxService defines whether txs are simulated and broadcast through the gRPC tx service.
+	// TxServiceAuto is used by default.
+	TxService TxServiceMode
+
+	// MinNodeVersion and MaxNodeVersion limit the node's app version, e.g. v1.6.0.
+	// Unsupported version is reported to the logger unless StrictVersionCheck is set. Empty bounds aren't checked.
+	MinNodeVersion string
+	MaxNodeVersion string
+	// StrictVersionCheck makes New fail with ErrUnsupportedNodeVersion when the node's version is unsupported.
+	StrictVersionCheck bool
+
+	// HeightCacheTTL makes GetHeight serve the last fetched height for the duration. It isn't cached by default.
+	HeightCacheTTL time.Duration
+
+	// RecentErrors is the number of broadcast errors kept by RecentErrors. DefaultRecentErrors is used by default.
+	RecentErrors int
+
+	// HaltDetection configures detection of chain halts. It's disabled by default.
+	HaltDetection HaltDetection
+
+	// Tracking configures tracking of broadcast txs until they're resolved. It's disabled by default.
+	Tracking TxTracking
+
+	// SequenceContention configures detection of frequent sequence mismatches. It's disabled by default.
+	SequenceContention SequenceContention
+	// MaxSequenceMismatchRetries limits retries of a broadcast caused by sequence mismatches.
+	// Only RetryPolicy.MaxAttempts limits them by default.
+	MaxSequenceMismatchRetries int
+
+	// ResultStore saves terminal outcomes of broadcasts. Nothing is saved by default.
+	ResultStore ResultStore
+	// ResultStoreAttempts limits attempts to save a result. DefaultResultStoreAttempts is used by default.
+	ResultStoreAttempts int
+
+	// BalanceWatch configures watching of the account's spendable balance. It's disabled by default.
+	BalanceWatch BalanceWatch
+
+	// MinBalance is the minimal spendable balance required by Ready. It isn't checked when empty.
+	MinBalance sdk.Coins
+
+	// DenomMetadata is used to parse human readable amounts. The chain's metadata is used by default.
+	DenomMetadata []banktypes.Metadata
+
+	// OnAccountMissing is called when the account disappears from chain or appears again.
+	// Broadcasts fail with ErrAccountNotFound meanwhile, the account is looked up again on every broadcast.
+	OnAccountMissing func(missing bool)
+
+	// StartupRetryTimeout limits retries of the account lookup in New. It isn't retried by default.
+	StartupRetryTimeout time.Duration
+	// AllowUnfundedAccount allows New to succeed when the account doesn't exist on chain yet.
+	AllowUnfundedAccount bool
+
+	// CommitTimeout limits waiting for tx to be committed in block mode. DefaultCommitTimeout is used by default.
+	CommitTimeout time.Duration
+	// CommitPollInterval is an interval of polling the node for tx in block mode.
+	// DefaultCommitPollInterval is used by default.
+	CommitPollInterval time.Duration
+
+	// Metrics receives observations of broadcast attempts and committed txs.
+	Metrics Metrics
+	// MetricsMsgTypes is an allowlist of msg type urls reported to Metrics as is.
+	// Other types are reported as MsgTypeOther. All types are reported by default.
+	MetricsMsgTypes []string
+
+	// RetryPolicy defines how failed broadcasts are retried.
+	RetryPolicy RetryPolicy
+	// DisableAutoRetry makes every broadcast a single attempt: sequence mismatches, out of gas and other
+	// failures are returned without signing and sending tx again. The sequence is still corrected,
+	// so the next call uses the expected one. RetryPolicy and MaxSequenceMismatchRetries are ignored.
+	DisableAutoRetry bool
+	// MaxBroadcastDuration limits the total time of all attempts of a single broadcast.
+	// Waiting for commit isn't limited by it. The caller's context deadline wins if it is sooner.
+	MaxBroadcastDuration time.Duration
+
+	// MaxConcurrentBroadcasts limits how many broadcasts may simulate and talk to the node at once.
+	// Waiting for commit isn't limited by it. It's unlimited by default.
+	MaxConcurrentBroadcasts int
+	// RejectWhenBusy makes broadcasts fail with ErrTooManyInflight instead of waiting for a free slot.
+	RejectWhenBusy bool
+
+	// CoalesceWindow is the time BroadcastShared waits for msgs of other callers. DefaultCoalesceWindow is used by default.
+	CoalesceWindow time.Duration
+	// CoalesceMaxMsgs limits msgs of a tx shared by BroadcastShared. DefaultCoalesceMaxMsgs is used by default.
+	CoalesceMaxMsgs int
+
+	// MempoolCacheAsSuccess makes broadcast of tx which is already in mempool cache successful
+	// instead of returning MempoolCacheError. The response contains only the tx hash then.
+	MempoolCacheAsSuccess bool
+
+	// DryRun makes broadcaster to validate, simulate and sign txs without broadcasting them.
+	// Txs are signed with a shadow sequence, so the account's sequence isn't consumed.
+	// Synthetic successful responses are returned instead of node's ones.
+	DryRun bool
+	// DryRunWriter receives JSON line with every tx built in dry-run mode.
+	DryRunWriter io.Writer
+
+	// RecordDir is a directory where every signed tx is recorded with its inputs as TxRecord.
+	// Records could be replayed with ReplayRecord to detect changes in tx construction.
+	RecordDir string
+
+	// Pipelined enables concurrent signing of txs. Txs are signed with consecutive sequences in parallel
+	// and submitted in order, so several txs of the account could get into the same block.
+	Pipelined bool
+
+	// SequenceStore keeps account's sequence when set, so several broadcasters could use the same account.
+	// It can't be used together with Pipelined.
+	SequenceStore SequenceStore
+	// SequenceReconcile configures background reconciliation of the sequence with the chain. It's disabled by default
+	// and can't be used together with Pipelined and SequenceStore.
+	SequenceReconcile SequenceReconcile
+
+	// SequenceFile is a path to the file where the last used sequence is persisted.
+	// On start the greater of chain's and persisted sequences is used, so txs remaining in mempool
+	// after restart don't cause sequence mismatch.
+	SequenceFile string
+
+	// Logger receives warnings about events which don't fail the call. They are discarded by default.
+	Logger Logger
+	// Clock is used for every timeout, backoff and polling interval. RealClock is used by default.
+	Clock Clock
+
+	// RegisterInterfaces are invoked on the interface registry to register msg types
+	// which are not a part of decentr's modules.
+	RegisterInterfaces []func(codectypes.InterfaceRegistry)
+}
+
+// SetBroadcastMode sets broadcast mode from string. It is kept for backward compatibility.
+func (c *Config) SetBroadcastMode(mode string) {
+	c.BroadcastMode = BroadcastMode(mode)
+}
+
+// Validate checks config for errors.
+func (c Config) Validate() error {
+	if c.NodeURI == "" && c.Client == nil && c.RPCClient == nil {
+		return errors.New("node uri is required")
+	}
+
+	if err := c.validateKeySeeds(); err != nil {
+		return err
+	}
+
+	if c.ExpectedAddress != "" {
+		if _, _, err := bech32.DecodeAndConvert(c.ExpectedAddress); err != nil {
+			return fmt.Errorf("invalid expected address: %w", err)
+		}
+	}
+	for name, v := range c.ExpectedAddresses {
+		if _, _, err := bech32.DecodeAndConvert(v); err != nil {
+			return fmt.Errorf("invalid expected address of key %s: %w", name, err)
+		}
+	}
+
+	if c.Client != nil && c.RPCClient != nil {
+		return errors.New("client and rpc client can't be set together")
+	}
+
+	if c.From == "" {
+		return errors.New("from is required")
+	}
+
+	if c.ChainID == "" {
+		return errors.New("chain id is required")
+	}
+
+	if err := c.broadcastMode().Validate(); err != nil {
+		return err
+	}
+
+	if c.Pipelined && c.SequenceStore != nil {
+		return errors.New("pipelined mode can't be used with sequence store")
+	}
+
+	if err := c.SequenceReconcile.Validate(); err != nil {
+		return err
+	}
+	if c.SequenceReconcile.Interval > 0 && (c.Pipelined || c.SequenceStore != nil) {
+		return errors.New("sequence reconcile can't be used with pipelined mode and sequence store")
+	}
+
+	if c.CommitTimeout < 0 || c.CommitPollInterval < 0 {
+		return errors.New("commit timeout and poll interval should be positive")
+	}
+
+	if _, _, err := c.fees(); err != nil {
+		return err
+	}
+
+	if c.GasAdjust != 0 && c.GasAdjust < 1 {
+		return errors.New("gas adjustment should be at least 1")
+	}
+
+	if err := validateGasPerMsgType(c.GasPerMsgType); err != nil {
+		return err
+	}
+
+	if c.StartupRetryTimeout < 0 {
+		return errors.New("startup retry timeout should be positive")
+	}
+
+	if c.HedgeDelay < 0 {
+		return errors.New("hedge delay should be positive")
+	}
+
+	if c.CoalesceWindow < 0 || c.CoalesceMaxMsgs < 0 {
+		return errors.New("coalesce window and max msgs should be positive")
+	}
+
+	if c.SimulateBatchPrefixes < 0 {
+		return errors.New("simulate batch prefixes should be positive")
+	}
+
+	if c.RPCTimeout < 0 {
+		return errors.New("rpc timeout should be positive")
+	}
+
+	if c.ProxyURL != "" {
+		if _, err := parseProxyURL(c.ProxyURL); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Transport.Validate(); err != nil {
+		return err
+	}
+
+	if c.MaxSequenceMismatchRetries < 0 {
+		return errors.New("max sequence mismatch retries should be positive")
+	}
+
+	if err := c.SequenceContention.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.AdaptiveGas.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.BalanceWatch.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Tracking.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.TxService.Validate(); err != nil {
+		return err
+	}
+
+	if c.TxService == TxServiceGRPC && c.GRPCAddr == "" {
+		return errors.New("grpc address is required by grpc tx service")
+	}
+
+	for _, v := range []string{c.MinNodeVersion, c.MaxNodeVersion} {
+		if v == "" {
+			continue
+		}
+		if _, err := parseVersion(v); err != nil {
+			return fmt.Errorf("failed to parse node version bound: %w", err)
+		}
+	}
+
+	if c.HeightCacheTTL < 0 {
+		return errors.New("height cache ttl should be positive")
+	}
+
+	if c.HaltDetection.Interval < 0 {
+		return errors.New("halt detection interval should be positive")
+	}
+
+	if c.MaxBroadcastDuration < 0 {
+		return errors.New("max broadcast duration should be positive")
+	}
+
+	if c.RetryPolicy.Backoff < 0 || c.RetryPolicy.MaxBackoff < 0 {
+		return errors.New("retry backoff should be positive")
+	}
+
+	if err := c.RetryPolicy.Jitter.Validate(); err != nil {
+		return err
+	}
+
+	if c.MaxConcurrentBroadcasts < 0 {
+		return errors.New("max concurrent broadcasts should be positive")
+	}
+
+	return nil
+}
+
+// broadcastMode returns configured broadcast mode. Sync mode is used by default.
+func (c Config) broadcastMode() BroadcastMode {
+	if c.BroadcastMode == "" {
+		return ModeSync
+	}
+
+	return c.BroadcastMode
+}
+
+// fees returns fees and gas prices parsed from the strings if they are set.
+func (c Config) fees() (sdk.Coins, sdk.DecCoins, error) {
+	fees, gasPrices := c.Fees, c.GasPrices
+
+	if c.FeesStr != "" {
+		if !fees.Empty() {
+			return nil, nil, errors.New("fees and fees string can't be set together")
+		}
+
+		var err error
+		if fees, err = ParseCoinsHuman(c.FeesStr, c.DenomMetadata...); err != nil {
+			return nil, nil, fmt.Errorf("invalid fees: %w", err)
+		}
+	}
+
+	if c.GasPricesStr != "" {
+		if !gasPrices.Empty() {
+			return nil, nil, errors.New("gas prices and gas prices string can't be set together")
+		}
+
+		var err error
+		if gasPrices, err = ParseDecCoinsHuman(c.GasPricesStr, c.DenomMetadata...); err != nil {
+			return nil, nil, fmt.Errorf("invalid gas prices: %w", err)
+		}
+	}
+
+	if !fees.Empty() && !gasPrices.Empty() {
+		return nil, nil, errors.New("fees and gas prices can't be set together")
+	}
+
+	return fees, gasPrices, nil
+}
+
+// propagationGracePeriod returns configured propagation grace period or the default one.
+func (c Config) propagationGracePeriod() time.Duration {
+	if c.PropagationGracePeriod <= 0 {
+		return DefaultPropagationGracePeriod
+	}
+
+	return c.PropagationGracePeriod
+}
+
+// hedgeDelay returns configured hedge delay or the default one.
+func (c Config) hedgeDelay() time.Duration {
+	if c.HedgeDelay == 0 {
+		return DefaultHedgeDelay
+	}
+
+	return c.HedgeDelay
+}
+
+// commitTimeout returns configured commit timeout or the default one.
+func (c Config) commitTimeout() time.Duration {
+	if c.CommitTimeout == 0 {
+		return DefaultCommitTimeout
+	}
+
+	return c.CommitTimeout
+}
+
+// commitPollInterval returns configured commit poll interval or the default one.
+func (c Config) commitPollInterval() time.Duration {
+	if c.CommitPollInterval == 0 {
+		return DefaultCommitPollInterval
+	}
+
+	return c.CommitPollInterval
+}
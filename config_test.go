@@ -0,0 +1,88 @@
+package broadcaster_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+// validConfig returns minimal valid config, the cases change it to check validation of a single field.
+func validConfig() broadcaster.Config {
+	return broadcaster.Config{
+		NodeURI: "http://localhost:26657",
+		From:    "test",
+		ChainID: "fake-chain",
+	}
+}
+
+func TestConfig_Validate_BroadcastMode(t *testing.T) {
+	tt := []struct {
+		mode    broadcaster.BroadcastMode
+		wantErr bool
+	}{
+		{mode: ""},
+		{mode: broadcaster.ModeSync},
+		{mode: broadcaster.ModeAsync},
+		{mode: broadcaster.ModeBlock},
+		{mode: broadcaster.ModeCommit},
+		{mode: "syncc", wantErr: true},
+		{mode: "SYNC", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(string(tc.mode), func(t *testing.T) {
+			cfg := validConfig()
+			cfg.BroadcastMode = tc.mode
+
+			err := cfg.Validate()
+			if tc.wantErr {
+				require.ErrorContains(t, err, "unknown broadcast mode")
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestConfig_SetBroadcastMode(t *testing.T) {
+	cfg := validConfig()
+	cfg.SetBroadcastMode("async")
+	require.Equal(t, broadcaster.ModeAsync, cfg.BroadcastMode)
+
+	cfg.SetBroadcastMode("syncc")
+	require.Error(t, cfg.Validate())
+}
+
+func TestNew_InvalidBroadcastMode(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = "syncc"
+
+	_, err := broadcaster.New(cfg)
+	require.ErrorContains(t, err, `unknown broadcast mode "syncc"`)
+	require.Zero(t, node.Calls("status"))
+}
+
+func TestConfig_Validate_GasAdjust(t *testing.T) {
+	tt := []struct {
+		adjust  float64
+		wantErr bool
+	}{
+		{adjust: 0},
+		{adjust: 1},
+		{adjust: 1.5},
+		{adjust: 0.99, wantErr: true},
+		{adjust: -1, wantErr: true},
+	}
+
+	for _, tc := range tt {
+		cfg := validConfig()
+		cfg.GasAdjust = tc.adjust
+
+		require.Equal(t, tc.wantErr, cfg.Validate() != nil, tc.adjust)
+	}
+}
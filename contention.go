@@ -0,0 +1,118 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrSequenceContention is returned when sequence mismatches are too frequent, which is likely caused
+// by another process broadcasting from the same account.
+var ErrSequenceContention = errors.New("sequence contention")
+
+// SequenceContention configures detection of sequence mismatches caused by another process broadcasting
+// from the same account. Mismatches are counted over a rolling window.
+type SequenceContention struct {
+	// Window is a rolling window mismatches are counted in. Detection is disabled when it's zero.
+	Window time.Duration
+	// Threshold is a number of mismatches within the window above which the account is considered contended.
+	Threshold int
+	// FailFast makes broadcasts fail with ErrSequenceContention on mismatch while the account is contended.
+	// The sequence is still corrected, so next broadcasts use the expected one.
+	FailFast bool
+	// OnContention is called when the number of mismatches within the window exceeds the threshold.
+	OnContention func(mismatches int)
+}
+
+// Validate validates the sequence contention config.
+func (c SequenceContention) Validate() error {
+	if c.Window < 0 || c.Threshold < 0 {
+		return errors.New("sequence contention window and threshold should be positive")
+	}
+
+	return nil
+}
+
+// mismatchWindow counts sequence mismatches over a rolling window.
+type mismatchWindow struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	times []time.Time
+}
+
+// add records the mismatch and returns the number of mismatches within the window.
+func (w *mismatchWindow) add(now time.Time) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.times = append(w.prune(now), now)
+
+	return len(w.times)
+}
+
+// count returns the number of mismatches within the window.
+func (w *mismatchWindow) count(now time.Time) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.times = w.prune(now)
+
+	return len(w.times)
+}
+
+// reset forgets the recorded mismatches.
+func (w *mismatchWindow) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.times = nil
+}
+
+// prune returns mismatches within the window. w.mu should be held by caller.
+func (w *mismatchWindow) prune(now time.Time) []time.Time {
+	i := 0
+	for i < len(w.times) && now.Sub(w.times[i]) >= w.window {
+		i++
+	}
+
+	return w.times[i:]
+}
+
+// noteSequenceMismatch records the mismatch. It returns ErrSequenceContention if the account is contended
+// and SequenceContention.FailFast is set.
+func (b *broadcaster) noteSequenceMismatch(ctx context.Context) error {
+	if b.mismatches == nil {
+		return nil
+	}
+
+	cfg := b.cfg.SequenceContention
+	n := b.mismatches.add(b.cfg.clock().Now())
+	if n <= cfg.Threshold {
+		return nil
+	}
+
+	if n == cfg.Threshold+1 {
+		b.warnf(ctx, "%d sequence mismatches within %s, another process could broadcast from %s", n, cfg.Window, b.From())
+
+		if cfg.OnContention != nil {
+			cfg.OnContention(n)
+		}
+	}
+
+	if cfg.FailFast {
+		return ErrSequenceContention
+	}
+
+	return nil
+}
+
+// sequenceMismatches returns the number of mismatches within SequenceContention.Window.
+func (b *broadcaster) sequenceMismatches() int {
+	if b.mismatches == nil {
+		return 0
+	}
+
+	return b.mismatches.count(b.cfg.clock().Now())
+}
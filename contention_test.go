@@ -0,0 +1,161 @@
+package broadcaster_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// competingWriter is the fake node where another process broadcasting from the account gets its tx
+// into the mempool right before every tx of the broadcaster while it's enabled.
+type competingWriter struct {
+	*testutil.FakeNode
+	address sdk.AccAddress
+	enabled int32
+}
+
+var _ rpcclient.Client = (*competingWriter)(nil)
+
+func (n *competingWriter) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	if atomic.LoadInt32(&n.enabled) == 1 {
+		n.write()
+	}
+
+	return n.FakeNode.BroadcastTxSync(ctx, tx)
+}
+
+// write takes the next sequence of the account. The account shouldn't have pending txs.
+func (n *competingWriter) write() {
+	acc, _ := n.Account(n.address)
+	n.SetSequence(n.address, acc.GetSequence()+1)
+}
+
+func TestSequenceContention(t *testing.T) {
+	node, key := newFakeChain(t)
+	writer := &competingWriter{FakeNode: node, address: key.Address}
+
+	contentions := make(chan int, 10)
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = writer
+	cfg.SequenceContention = broadcaster.SequenceContention{
+		Window:    time.Hour,
+		Threshold: 2,
+		FailFast:  true,
+		OnContention: func(mismatches int) {
+			contentions <- mismatches
+		},
+	}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	broadcast := func() (*broadcaster.BroadcastResult, error) {
+		return b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	}
+
+	// Every broadcast is preceded by a tx of the other process, so it mismatches once and is retried.
+	for i := 1; i <= 2; i++ {
+		writer.write()
+
+		res, err := broadcast()
+		require.NoError(t, err)
+		require.Equal(t, 2, res.Attempts)
+		require.Equal(t, i, b.Stats().SequenceMismatches)
+		requireCommitted(t, node, res.TxHash)
+	}
+	require.Empty(t, contentions)
+
+	// The mismatch above the threshold is reported and fails the broadcast.
+	writer.write()
+	_, err = broadcast()
+	require.ErrorIs(t, err, broadcaster.ErrSequenceContention)
+	require.Equal(t, broadcaster.ClassSequenceMismatch, broadcaster.Classify(err))
+	require.Equal(t, 3, <-contentions)
+	require.Equal(t, 3, b.Stats().SequenceMismatches)
+
+	// The sequence is corrected anyway, so the next broadcast succeeds.
+	res, err := broadcast()
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Attempts)
+	requireCommitted(t, node, res.TxHash)
+	require.Empty(t, contentions)
+}
+
+func TestSequenceContention_OtherSimulationErrors(t *testing.T) {
+	tt := []struct {
+		name        string
+		policy      broadcaster.RetryPolicy
+		simulations int32
+	}{
+		// The failed simulation is repeated once by default.
+		{name: "default policy", simulations: 2},
+		// The configured policy repeats only simulations failed with a sequence mismatch.
+		{name: "configured policy", policy: broadcaster.RetryPolicy{MaxAttempts: 3}, simulations: 1},
+	}
+
+	node, key := newFakeChain(t)
+	node.SetBalance(key.Address)
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			counting := &countingSimulation{FakeNode: node}
+
+			cfg := testConfig(node, key)
+			cfg.RPCClient = counting
+			cfg.RetryPolicy = tc.policy
+			cfg.SequenceContention = broadcaster.SequenceContention{Window: time.Hour, FailFast: true}
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			// Failed simulation which isn't a sequence mismatch isn't counted.
+			_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+			require.ErrorContains(t, err, "insufficient funds")
+			require.NotErrorIs(t, err, broadcaster.ErrSequenceContention)
+			require.Equal(t, tc.simulations, atomic.LoadInt32(&counting.simulations))
+			require.Zero(t, b.Stats().SequenceMismatches)
+			require.Zero(t, node.Calls("broadcast_tx_sync"))
+		})
+	}
+}
+
+func TestMaxSequenceMismatchRetries(t *testing.T) {
+	node, key := newFakeChain(t)
+	writer := &competingWriter{FakeNode: node, address: key.Address, enabled: 1}
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = writer
+	cfg.RetryPolicy.MaxAttempts = 10
+	cfg.MaxSequenceMismatchRetries = 2
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// The other process takes the sequence before every attempt, so they all mismatch.
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.Equal(t, broadcaster.ClassSequenceMismatch, broadcaster.Classify(err))
+	require.Equal(t, 3, res.Attempts)
+	require.Equal(t, 3, node.Calls("broadcast_tx_sync"))
+
+	// The next broadcast uses the sequence expected by the node.
+	atomic.StoreInt32(&writer.enabled, 0)
+	res, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Attempts)
+	requireCommitted(t, node, res.TxHash)
+}
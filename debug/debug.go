@@ -0,0 +1,102 @@
+// Package debug exposes broadcaster's internals over http and expvar.
+// Everything is read through broadcaster's thread-safe accessors.
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+// nodeTimeout limits the request of the node's status.
+const nodeTimeout = 5 * time.Second
+
+// Source is a broadcaster which internals are exposed.
+type Source interface {
+	Stats() broadcaster.Stats
+	LatestBlock(ctx context.Context) (broadcaster.BlockInfo, error)
+	PendingTxs() []broadcaster.TrackedTx
+	RecentErrors() []broadcaster.RecentError
+}
+
+// Snapshot is broadcaster's state rendered by Handler.
+type Snapshot struct {
+	Stats broadcaster.Stats `json:"stats"`
+	Node  NodeStatus        `json:"node"`
+	// PendingTxs are tracked txs which aren't resolved yet. It's empty when tracking is disabled.
+	PendingTxs   []PendingTx               `json:"pending_txs"`
+	RecentErrors []broadcaster.RecentError `json:"recent_errors"`
+}
+
+// NodeStatus is the node's latest block or the error of its request.
+type NodeStatus struct {
+	Height uint64 `json:"height,omitempty"`
+	// Time is a pointer, so it's omitted with the other fields when the request fails.
+	Time    *time.Time `json:"time,omitempty"`
+	ChainID string     `json:"chain_id,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// PendingTx is tracked tx which isn't resolved yet.
+type PendingTx struct {
+	TxHash   string    `json:"tx_hash"`
+	Sequence uint64    `json:"sequence"`
+	Deadline time.Time `json:"deadline"`
+	MsgTypes []string  `json:"msg_types"`
+}
+
+// Handler renders Snapshot of the broadcaster as JSON, e.g. to be mounted as /debug/broadcaster.
+func Handler(src Source) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), nodeTimeout)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(TakeSnapshot(ctx, src))
+	})
+}
+
+// TakeSnapshot returns the broadcaster's state.
+func TakeSnapshot(ctx context.Context, src Source) Snapshot {
+	s := Snapshot{
+		Stats:        src.Stats(),
+		RecentErrors: src.RecentErrors(),
+	}
+
+	if block, err := src.LatestBlock(ctx); err != nil {
+		s.Node.Error = err.Error()
+	} else {
+		s.Node = NodeStatus{Height: block.Height, Time: &block.Time, ChainID: block.ChainID}
+	}
+
+	for _, v := range src.PendingTxs() {
+		tx := PendingTx{
+			TxHash:   v.TxHash,
+			Sequence: v.Sequence,
+			Deadline: v.Deadline,
+			MsgTypes: make([]string, len(v.Msgs)),
+		}
+		for i, msg := range v.Msgs {
+			tx.MsgTypes[i] = sdk.MsgTypeURL(msg)
+		}
+		s.PendingTxs = append(s.PendingTxs, tx)
+	}
+
+	return s
+}
+
+// Publish publishes the broadcaster's Stats as expvar variable with the name.
+// Like expvar.Publish it panics if the name is already used.
+func Publish(name string, src Source) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return src.Stats()
+	}))
+}
@@ -0,0 +1,115 @@
+package debug_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/debug"
+)
+
+// source is the fake broadcaster. LatestBlock waits for ctx if block is nil.
+type source struct {
+	block   *broadcaster.BlockInfo
+	pending []broadcaster.TrackedTx
+	errors  []broadcaster.RecentError
+}
+
+func (s *source) Stats() broadcaster.Stats {
+	return broadcaster.Stats{Height: 7, HedgesFired: 2}
+}
+
+func (s *source) LatestBlock(ctx context.Context) (broadcaster.BlockInfo, error) {
+	if s.block == nil {
+		<-ctx.Done()
+		return broadcaster.BlockInfo{}, ctx.Err()
+	}
+
+	return *s.block, nil
+}
+
+func (s *source) PendingTxs() []broadcaster.TrackedTx {
+	return s.pending
+}
+
+func (s *source) RecentErrors() []broadcaster.RecentError {
+	return s.errors
+}
+
+// serve requests the handler with ctx and returns the decoded body.
+func serve(t *testing.T, ctx context.Context, src debug.Source) map[string]json.RawMessage {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	debug.Handler(src).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/broadcaster", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var out map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+
+	return out
+}
+
+func TestHandler(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	msg := banktypes.NewMsgSend(sdk.AccAddress("from"), sdk.AccAddress("to"), sdk.NewCoins(sdk.NewInt64Coin("udec", 1)))
+
+	src := &source{
+		block: &broadcaster.BlockInfo{Height: 10, Time: now, ChainID: "testnet"},
+		pending: []broadcaster.TrackedTx{
+			{TxHash: "AB", Sequence: 3, Deadline: now.Add(time.Minute), Msgs: []sdk.Msg{msg}},
+		},
+		errors: []broadcaster.RecentError{
+			{Time: now, RequestID: "req", TxHash: "CD", Class: "invalid_request", Error: "rejected"},
+		},
+	}
+
+	out := serve(t, context.Background(), src)
+	require.Len(t, out, 4)
+
+	var stats broadcaster.Stats
+	require.NoError(t, json.Unmarshal(out["stats"], &stats))
+	require.Equal(t, uint64(7), stats.Height)
+	require.Equal(t, uint64(2), stats.HedgesFired)
+
+	require.JSONEq(t, `{"height": 10, "time": "2022-10-01T12:00:00Z", "chain_id": "testnet"}`, string(out["node"]))
+	require.JSONEq(t, `[{
+		"tx_hash": "AB",
+		"sequence": 3,
+		"deadline": "2022-10-01T12:01:00Z",
+		"msg_types": ["/cosmos.bank.v1beta1.MsgSend"]
+	}]`, string(out["pending_txs"]))
+	require.JSONEq(t, `[{
+		"time": "2022-10-01T12:00:00Z",
+		"request_id": "req",
+		"tx_hash": "CD",
+		"class": "invalid_request",
+		"error": "rejected"
+	}]`, string(out["recent_errors"]))
+}
+
+func TestHandler_NodeTimeout(t *testing.T) {
+	src := &source{}
+
+	// The rest of the snapshot is rendered when the node doesn't respond in time.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	out := serve(t, ctx, src)
+	require.JSONEq(t, `{"error": "context deadline exceeded"}`, string(out["node"]))
+	require.JSONEq(t, `null`, string(out["pending_txs"]))
+
+	var stats broadcaster.Stats
+	require.NoError(t, json.Unmarshal(out["stats"], &stats))
+	require.Equal(t, uint64(7), stats.Height)
+	require.Equal(t, uint64(2), stats.HedgesFired)
+}
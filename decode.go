@@ -0,0 +1,198 @@
+package broadcaster
+
+import (
+	"fmt"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+)
+
+// DecodedTx contains decoded transaction's data.
+type DecodedTx struct {
+	Msgs          []sdk.Msg
+	Memo          string
+	Fee           sdk.Coins
+	GasLimit      uint64
+	TimeoutHeight uint64
+	Signers       []sdk.AccAddress
+
+	ExtensionOptions            []*codectypes.Any
+	NonCriticalExtensionOptions []*codectypes.Any
+}
+
+// DecodeOption configures tx decoding.
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	allowUnknownMsgs bool
+}
+
+// WithUnknownMsgs makes decoder to represent messages of unregistered types as UnknownMsg instead of failing.
+func WithUnknownMsgs() DecodeOption {
+	return func(o *decodeOptions) {
+		o.allowUnknownMsgs = true
+	}
+}
+
+// UnknownMsg is a placeholder for msg which type is not registered in the interface registry.
+type UnknownMsg struct {
+	TypeURL string
+	Value   []byte
+}
+
+var _ sdk.Msg = &UnknownMsg{}
+
+// Reset implements proto.Message.
+func (m *UnknownMsg) Reset() { *m = UnknownMsg{} }
+
+// String implements proto.Message.
+func (m *UnknownMsg) String() string { return fmt.Sprintf("unknown msg %s", m.TypeURL) }
+
+// ProtoMessage implements proto.Message.
+func (*UnknownMsg) ProtoMessage() {}
+
+// ValidateBasic implements sdk.Msg.
+func (m *UnknownMsg) ValidateBasic() error {
+	return fmt.Errorf("%w: %s", ErrUnregisteredMsgType, m.TypeURL)
+}
+
+// GetSigners implements sdk.Msg. Signers of unknown msg can't be determined.
+func (*UnknownMsg) GetSigners() []sdk.AccAddress { return nil }
+
+// DecodeTx decodes proto encoded tx.
+func (b *broadcaster) DecodeTx(txBytes []byte, opts ...DecodeOption) (DecodedTx, error) {
+	var o decodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var raw txtypes.TxRaw
+	if err := raw.Unmarshal(txBytes); err != nil {
+		return DecodedTx{}, fmt.Errorf("failed to unmarshal tx: %w", err)
+	}
+
+	var body txtypes.TxBody
+	if err := body.Unmarshal(raw.BodyBytes); err != nil {
+		return DecodedTx{}, fmt.Errorf("failed to unmarshal tx body: %w", err)
+	}
+
+	var authInfo txtypes.AuthInfo
+	if err := authInfo.Unmarshal(raw.AuthInfoBytes); err != nil {
+		return DecodedTx{}, fmt.Errorf("failed to unmarshal auth info: %w", err)
+	}
+
+	out := DecodedTx{
+		Msgs:          make([]sdk.Msg, len(body.Messages)),
+		Memo:          body.Memo,
+		TimeoutHeight: body.TimeoutHeight,
+
+		ExtensionOptions:            body.ExtensionOptions,
+		NonCriticalExtensionOptions: body.NonCriticalExtensionOptions,
+	}
+
+	for i, v := range body.Messages {
+		var msg sdk.Msg
+		if err := b.enc.InterfaceRegistry.UnpackAny(v, &msg); err != nil {
+			if !o.allowUnknownMsgs {
+				return DecodedTx{}, fmt.Errorf("failed to unpack msg %d: %w", i, err)
+			}
+			msg = &UnknownMsg{TypeURL: v.TypeUrl, Value: v.Value}
+		}
+		out.Msgs[i] = msg
+	}
+
+	if authInfo.Fee != nil {
+		out.Fee = authInfo.Fee.Amount
+		out.GasLimit = authInfo.Fee.GasLimit
+	}
+
+	signers, err := b.signerInfosAddresses(authInfo.SignerInfos)
+	if err != nil {
+		return DecodedTx{}, err
+	}
+	if signers == nil {
+		signers = msgsSigners(out.Msgs)
+	}
+	out.Signers = signers
+
+	return out, nil
+}
+
+// DecodeTxJSON decodes proto-json encoded tx. Unknown msgs are not supported by json decoder.
+func (b *broadcaster) DecodeTxJSON(txJSON []byte) (DecodedTx, error) {
+	tx, err := b.ctx.TxConfig.TxJSONDecoder()(txJSON)
+	if err != nil {
+		return DecodedTx{}, fmt.Errorf("failed to decode tx: %w", err)
+	}
+
+	signingTx, ok := tx.(authsigning.Tx)
+	if !ok {
+		return DecodedTx{}, fmt.Errorf("unexpected tx type %T", tx)
+	}
+
+	out := DecodedTx{
+		Msgs:          signingTx.GetMsgs(),
+		Memo:          signingTx.GetMemo(),
+		Fee:           signingTx.GetFee(),
+		GasLimit:      signingTx.GetGas(),
+		TimeoutHeight: signingTx.GetTimeoutHeight(),
+		Signers:       signingTx.GetSigners(),
+	}
+
+	if extTx, ok := tx.(extensionOptionsTx); ok {
+		out.ExtensionOptions = extTx.GetExtensionOptions()
+		out.NonCriticalExtensionOptions = extTx.GetNonCriticalExtensionOptions()
+	}
+
+	return out, nil
+}
+
+// extensionOptionsTx is tx carrying extension options of its body.
+type extensionOptionsTx interface {
+	GetExtensionOptions() []*codectypes.Any
+	GetNonCriticalExtensionOptions() []*codectypes.Any
+}
+
+// signerInfosAddresses returns addresses of signers' public keys.
+// It returns nil if some of signer infos doesn't contain public key.
+func (b *broadcaster) signerInfosAddresses(infos []*txtypes.SignerInfo) ([]sdk.AccAddress, error) {
+	if len(infos) == 0 {
+		return nil, nil
+	}
+
+	out := make([]sdk.AccAddress, len(infos))
+	for i, v := range infos {
+		if v.PublicKey == nil {
+			return nil, nil
+		}
+
+		var pk cryptotypes.PubKey
+		if err := b.enc.InterfaceRegistry.UnpackAny(v.PublicKey, &pk); err != nil {
+			return nil, fmt.Errorf("failed to unpack public key %d: %w", i, err)
+		}
+		out[i] = sdk.AccAddress(pk.Address())
+	}
+
+	return out, nil
+}
+
+// msgsSigners returns unique signers of messages in order of their appearance.
+func msgsSigners(msgs []sdk.Msg) []sdk.AccAddress {
+	var out []sdk.AccAddress
+	seen := make(map[string]struct{})
+
+	for _, msg := range msgs {
+		for _, v := range msg.GetSigners() {
+			if _, ok := seen[v.String()]; ok {
+				continue
+			}
+			seen[v.String()] = struct{}{}
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
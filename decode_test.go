@@ -0,0 +1,169 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/testutil/testdata"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+func TestDecodeTx(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	msgs := []sdk.Msg{sendMsg(key.Address, 1), sendMsg(key.Address, 2)}
+	fees := sdk.NewCoins(sdk.NewInt64Coin(testDenom, 300))
+	txBytes, _, err := b.BuildAndSign(context.Background(), msgs, "memo", broadcaster.BroadcastOptions{Fees: fees, Gas: 150000})
+	require.NoError(t, err)
+
+	decoded, err := b.DecodeTx(txBytes)
+	require.NoError(t, err)
+	require.Equal(t, broadcaster.DecodedTx{
+		Msgs:     msgs,
+		Memo:     "memo",
+		Fee:      fees,
+		GasLimit: 150000,
+		Signers:  []sdk.AccAddress{key.Address},
+	}, decoded)
+
+	_, err = b.DecodeTx([]byte("garbage"))
+	require.Error(t, err)
+}
+
+func TestDecodeTx_TimeoutHeight(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	txb := b.EncodingConfig().TxConfig.NewTxBuilder()
+	require.NoError(t, txb.SetMsgs(sendMsg(key.Address, 1)))
+	txb.SetTimeoutHeight(42)
+
+	txBytes, err := b.EncodingConfig().TxConfig.TxEncoder()(txb.GetTx())
+	require.NoError(t, err)
+
+	decoded, err := b.DecodeTx(txBytes)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), decoded.TimeoutHeight)
+	// Unsigned tx has no signer infos, so signers are taken from messages.
+	require.Equal(t, []sdk.AccAddress{key.Address}, decoded.Signers)
+}
+
+func TestDecodeTx_UnknownMsgs(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.RegisterInterfaces = []func(codectypes.InterfaceRegistry){testdata.RegisterInterfaces}
+	signer, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer signer.Close()
+
+	txBytes, _, err := signer.BuildAndSign(context.Background(), []sdk.Msg{
+		sendMsg(key.Address, 1), testdata.NewTestMsg(key.Address),
+	}, "", broadcaster.BroadcastOptions{Gas: 100000})
+	require.NoError(t, err)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	_, err = b.DecodeTx(txBytes)
+	require.Error(t, err)
+
+	decoded, err := b.DecodeTx(txBytes, broadcaster.WithUnknownMsgs())
+	require.NoError(t, err)
+	require.Len(t, decoded.Msgs, 2)
+	require.Equal(t, sendMsg(key.Address, 1), decoded.Msgs[0])
+	require.IsType(t, &broadcaster.UnknownMsg{}, decoded.Msgs[1])
+	require.Equal(t, "/testdata.TestMsg", decoded.Msgs[1].(*broadcaster.UnknownMsg).TypeURL)
+	require.ErrorIs(t, decoded.Msgs[1].ValidateBasic(), broadcaster.ErrUnregisteredMsgType)
+	require.Equal(t, []sdk.AccAddress{key.Address}, decoded.Signers)
+}
+
+func TestDecodeTxJSON(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	msg := sendMsg(key.Address, 5)
+	txJSON, err := b.GenerateUnsignedTx([]sdk.Msg{msg}, "memo", broadcaster.BroadcastOptions{Gas: 120000})
+	require.NoError(t, err)
+
+	decoded, err := b.DecodeTxJSON(txJSON)
+	require.NoError(t, err)
+	require.Equal(t, []sdk.Msg{msg}, decoded.Msgs)
+	require.Equal(t, "memo", decoded.Memo)
+	require.Equal(t, uint64(120000), decoded.GasLimit)
+	require.Equal(t, []sdk.AccAddress{key.Address}, decoded.Signers)
+
+	_, err = b.DecodeTxJSON([]byte(`{"body":`))
+	require.Error(t, err)
+}
+
+func TestDecodeTx_ExtensionOptions(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	dog, err := codectypes.NewAnyWithValue(&testdata.Dog{Name: "rex"})
+	require.NoError(t, err)
+	cat, err := codectypes.NewAnyWithValue(&testdata.Cat{Moniker: "tom"})
+	require.NoError(t, err)
+
+	cfg := testConfig(node, key)
+	cfg.RegisterInterfaces = []func(codectypes.InterfaceRegistry){testdata.RegisterInterfaces}
+	cfg.NonCriticalExtensionOptions = []*codectypes.Any{cat}
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	requireOptions := func(t *testing.T, want, got []*codectypes.Any) {
+		t.Helper()
+
+		require.Len(t, got, len(want))
+		for i := range want {
+			require.Equal(t, want[i].TypeUrl, got[i].TypeUrl)
+			require.Equal(t, want[i].Value, got[i].Value)
+		}
+	}
+
+	opts := broadcaster.BroadcastOptions{Gas: 100000, ExtensionOptions: []*codectypes.Any{dog}}
+	msgs := []sdk.Msg{sendMsg(key.Address, 1)}
+
+	// Options are set along with the config defaults and survive the round trip.
+	txBytes, _, err := b.BuildAndSign(context.Background(), msgs, "", opts)
+	require.NoError(t, err)
+
+	decoded, err := b.DecodeTx(txBytes)
+	require.NoError(t, err)
+	require.Equal(t, msgs, decoded.Msgs)
+	requireOptions(t, []*codectypes.Any{dog}, decoded.ExtensionOptions)
+	requireOptions(t, []*codectypes.Any{cat}, decoded.NonCriticalExtensionOptions)
+
+	txJSON, err := b.GenerateUnsignedTx(msgs, "", opts)
+	require.NoError(t, err)
+
+	decoded, err = b.DecodeTxJSON(txJSON)
+	require.NoError(t, err)
+	requireOptions(t, []*codectypes.Any{dog}, decoded.ExtensionOptions)
+	requireOptions(t, []*codectypes.Any{cat}, decoded.NonCriticalExtensionOptions)
+
+	// Options of unregistered types are rejected before encoding.
+	unregistered, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer unregistered.Close()
+
+	_, _, err = unregistered.BuildAndSign(context.Background(), msgs, "", opts)
+	require.ErrorIs(t, err, broadcaster.ErrUnregisteredExtensionOption)
+	require.Contains(t, err.Error(), "/testdata.Dog")
+}
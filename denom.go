@@ -0,0 +1,198 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+
+	"github.com/Decentr-net/decentr/config"
+)
+
+// DefaultDenomMetadata describes decentr's coin. It is used when the chain doesn't provide denom metadata.
+var DefaultDenomMetadata = []banktypes.Metadata{
+	{
+		Base:    config.DefaultBondDenom,
+		Display: "dec",
+		DenomUnits: []*banktypes.DenomUnit{
+			{Denom: config.DefaultBondDenom, Exponent: 0},
+			{Denom: "dec", Exponent: 6},
+		},
+	},
+}
+
+// ParseCoinsHuman parses coins written in any unit of the metadata, e.g. "0.05dec" or "50000udec",
+// and converts them to the base denoms. Coins of unknown denoms are kept as is.
+// DefaultDenomMetadata is used when metadata is not provided.
+func ParseCoinsHuman(s string, metadata ...banktypes.Metadata) (sdk.Coins, error) {
+	decCoins, err := ParseDecCoinsHuman(s, metadata...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := sdk.NewCoins()
+	for _, v := range decCoins {
+		if !v.Amount.IsInteger() {
+			return nil, fmt.Errorf("amount %s is too precise", v)
+		}
+		out = out.Add(sdk.NewCoin(v.Denom, v.Amount.TruncateInt()))
+	}
+
+	return out, nil
+}
+
+// ParseDecCoinsHuman works like ParseCoinsHuman but allows fractional amounts of the base denoms.
+// It's used to parse gas prices.
+func ParseDecCoinsHuman(s string, metadata ...banktypes.Metadata) (sdk.DecCoins, error) {
+	if len(metadata) == 0 {
+		metadata = DefaultDenomMetadata
+	}
+
+	decCoins, err := sdk.ParseDecCoins(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coins: %w", err)
+	}
+
+	out := sdk.NewDecCoins()
+	for _, v := range decCoins {
+		denom, exponent := v.Denom, uint32(0)
+		if m, u, ok := findDenomUnit(metadata, v.Denom); ok {
+			denom, exponent = m.Base, u.Exponent
+		}
+
+		out = out.Add(sdk.NewDecCoinFromDec(denom, v.Amount.Mul(pow10(exponent))))
+	}
+
+	return out, nil
+}
+
+// FormatCoins formats coins in display units of the metadata, e.g. "50000udec" is formatted as "0.05dec".
+// The result could be parsed back with ParseCoinsHuman. DefaultDenomMetadata is used when metadata is not provided.
+func FormatCoins(coins sdk.Coins, metadata ...banktypes.Metadata) string {
+	if len(metadata) == 0 {
+		metadata = DefaultDenomMetadata
+	}
+
+	out := make([]string, len(coins))
+	for i, v := range coins {
+		out[i] = v.String()
+
+		m, ok := findMetadata(metadata, v.Denom)
+		if !ok {
+			continue
+		}
+
+		_, u, ok := findDenomUnit([]banktypes.Metadata{m}, m.Display)
+		if !ok || u.Exponent == 0 {
+			continue
+		}
+
+		amount := sdk.NewDecFromInt(v.Amount).Quo(pow10(u.Exponent))
+		out[i] = trimDec(amount) + u.Denom
+	}
+
+	return strings.Join(out, ",")
+}
+
+// ParseCoinsHuman parses human readable coins using the chain's denom metadata.
+// Metadata is taken from Config.DenomMetadata if it's set, otherwise it's queried once and cached.
+// DefaultDenomMetadata is used as a fallback.
+func (b *broadcaster) ParseCoinsHuman(ctx context.Context, s string) (sdk.Coins, error) {
+	if err := checkCoinsSyntax(s); err != nil {
+		return nil, err
+	}
+
+	return ParseCoinsHuman(s, b.denomMetadata(ctx)...)
+}
+
+// parseDecCoinsHuman parses human readable dec coins using the chain's denom metadata like ParseCoinsHuman.
+func (b *broadcaster) parseDecCoinsHuman(ctx context.Context, s string) (sdk.DecCoins, error) {
+	if err := checkCoinsSyntax(s); err != nil {
+		return nil, err
+	}
+
+	return ParseDecCoinsHuman(s, b.denomMetadata(ctx)...)
+}
+
+// checkCoinsSyntax checks coins before they are parsed with the chain's metadata,
+// so malformed input doesn't cause metadata query.
+func checkCoinsSyntax(s string) error {
+	if _, err := sdk.ParseDecCoins(s); err != nil {
+		return fmt.Errorf("failed to parse coins: %w", err)
+	}
+
+	return nil
+}
+
+// denomMetadata returns denom metadata used to parse human readable amounts.
+func (b *broadcaster) denomMetadata(ctx context.Context) []banktypes.Metadata {
+	if len(b.cfg.DenomMetadata) > 0 {
+		return b.cfg.DenomMetadata
+	}
+
+	b.metadataMu.Lock()
+	defer b.metadataMu.Unlock()
+
+	if b.metadata == nil {
+		res, err := banktypes.NewQueryClient(b.ctx).DenomsMetadata(ctx, &banktypes.QueryDenomsMetadataRequest{})
+		if err != nil {
+			// Failed query isn't cached, so it's repeated next time.
+			return DefaultDenomMetadata
+		}
+
+		b.metadata = append(res.Metadatas, DefaultDenomMetadata...)
+	}
+
+	return b.metadata
+}
+
+// findMetadata returns metadata with the base denom.
+func findMetadata(metadata []banktypes.Metadata, base string) (banktypes.Metadata, bool) {
+	for _, m := range metadata {
+		if m.Base == base {
+			return m, true
+		}
+	}
+
+	return banktypes.Metadata{}, false
+}
+
+// findDenomUnit returns metadata and its unit which has the denom or alias.
+func findDenomUnit(metadata []banktypes.Metadata, denom string) (banktypes.Metadata, banktypes.DenomUnit, bool) {
+	for _, m := range metadata {
+		for _, u := range m.DenomUnits {
+			if strings.EqualFold(u.Denom, denom) || containsFold(u.Aliases, denom) {
+				return m, *u, true
+			}
+		}
+	}
+
+	return banktypes.Metadata{}, banktypes.DenomUnit{}, false
+}
+
+// pow10 returns 10^exp.
+func pow10(exp uint32) sdk.Dec {
+	return sdk.NewDecFromInt(sdk.NewIntWithDecimal(1, int(exp)))
+}
+
+// trimDec formats dec without trailing zeros.
+func trimDec(d sdk.Dec) string {
+	s := d.String()
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+
+	return s
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
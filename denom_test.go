@@ -0,0 +1,192 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+// ethMetadata has units with exponents 0, 9 and 18.
+var ethMetadata = banktypes.Metadata{
+	Base:    "wei",
+	Display: "eth",
+	DenomUnits: []*banktypes.DenomUnit{
+		{Denom: "wei", Exponent: 0},
+		{Denom: "gwei", Exponent: 9, Aliases: []string{"nanoether"}},
+		{Denom: "eth", Exponent: 18},
+	},
+}
+
+func coins(s string) sdk.Coins {
+	out, err := sdk.ParseCoinsNormalized(s)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func TestParseCoinsHuman(t *testing.T) {
+	tt := []struct {
+		in       string
+		metadata []banktypes.Metadata
+		want     sdk.Coins
+		wantErr  bool
+	}{
+		{in: "0.05dec", want: coins("50000udec")},
+		{in: "50000udec", want: coins("50000udec")},
+		{in: "1dec", want: coins("1000000udec")},
+		{in: "1DEC", want: coins("1000000udec")},
+		{in: "0.000001dec", want: coins("1udec")},
+		{in: "0.0000001dec", wantErr: true},
+		{in: "0.5udec", wantErr: true},
+		{in: "1dec,5udec", want: coins("1000005udec")},
+		{in: "0dec", want: sdk.NewCoins()},
+		{in: "", want: sdk.NewCoins()},
+		{in: "10uother", want: coins("10uother")},
+		{in: "10.5uother", wantErr: true},
+		{in: "1dec,10uother", want: coins("1000000udec,10uother")},
+		{in: "dec", wantErr: true},
+		{in: "-1dec", wantErr: true},
+		{in: "1 dec dec", wantErr: true},
+		{in: "1eth", metadata: []banktypes.Metadata{ethMetadata}, want: coins("1000000000000000000wei")},
+		{in: "0.000000000000000001eth", metadata: []banktypes.Metadata{ethMetadata}, want: coins("1wei")},
+		{in: "1.5gwei", metadata: []banktypes.Metadata{ethMetadata}, want: coins("1500000000wei")},
+		{in: "2nanoether", metadata: []banktypes.Metadata{ethMetadata}, want: coins("2000000000wei")},
+		{in: "1dec", metadata: []banktypes.Metadata{ethMetadata}, want: coins("1dec")},
+	}
+
+	for _, tc := range tt {
+		got, err := broadcaster.ParseCoinsHuman(tc.in, tc.metadata...)
+		if tc.wantErr {
+			require.Error(t, err, tc.in)
+			continue
+		}
+		require.NoError(t, err, tc.in)
+		require.True(t, tc.want.IsEqual(got), "%s: want %s, got %s", tc.in, tc.want, got)
+	}
+}
+
+func TestParseDecCoinsHuman(t *testing.T) {
+	tt := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "0.025udec", want: "0.025udec"},
+		{in: "0.000000025dec", want: "0.025udec"},
+		{in: "0.0000000000000000001dec", wantErr: true},
+		{in: "0.5uother", want: "0.5uother"},
+	}
+
+	for _, tc := range tt {
+		got, err := broadcaster.ParseDecCoinsHuman(tc.in)
+		if tc.wantErr {
+			require.Error(t, err, tc.in)
+			continue
+		}
+		require.NoError(t, err, tc.in)
+
+		want, err := sdk.ParseDecCoins(tc.want)
+		require.NoError(t, err)
+		require.True(t, want.IsEqual(got), "%s: want %s, got %s", tc.in, want, got)
+	}
+}
+
+func TestFormatCoins(t *testing.T) {
+	tt := []struct {
+		coins    sdk.Coins
+		metadata []banktypes.Metadata
+		want     string
+	}{
+		{coins: coins("50000udec"), want: "0.05dec"},
+		{coins: coins("1000000udec"), want: "1dec"},
+		{coins: coins("1udec"), want: "0.000001dec"},
+		{coins: coins("123456789udec"), want: "123.456789dec"},
+		{coins: coins("10uother"), want: "10uother"},
+		{coins: coins("1udec,10uother"), want: "0.000001dec,10uother"},
+		{coins: coins("1wei"), metadata: []banktypes.Metadata{ethMetadata}, want: "0.000000000000000001eth"},
+		{coins: coins("1500000000000000000wei"), metadata: []banktypes.Metadata{ethMetadata}, want: "1.5eth"},
+		{coins: sdk.NewCoins(), want: ""},
+	}
+
+	for _, tc := range tt {
+		got := broadcaster.FormatCoins(tc.coins, tc.metadata...)
+		require.Equal(t, tc.want, got)
+
+		// Formatted coins are parsed back.
+		parsed, err := broadcaster.ParseCoinsHuman(got, tc.metadata...)
+		require.NoError(t, err, got)
+		require.True(t, tc.coins.IsEqual(parsed), "%s: want %s, got %s", got, tc.coins, parsed)
+	}
+}
+
+func TestBroadcaster_ParseCoinsHuman(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetDenomMetadata(ethMetadata)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	// The chain's metadata is used, the default one is kept as a fallback.
+	got, err := b.ParseCoinsHuman(context.Background(), "1.5gwei,1dec")
+	require.NoError(t, err)
+	require.Equal(t, coins("1500000000wei,1000000udec"), got)
+
+	_, err = b.ParseCoinsHuman(context.Background(), "1.5wei")
+	require.Error(t, err)
+}
+
+func TestConfig_Validate_FeesStr(t *testing.T) {
+	tt := []struct {
+		name    string
+		change  func(cfg *broadcaster.Config)
+		wantErr bool
+	}{
+		{name: "fees", change: func(cfg *broadcaster.Config) { cfg.FeesStr = "0.05dec" }},
+		{name: "gas prices", change: func(cfg *broadcaster.Config) { cfg.GasPricesStr = "0.025udec" }},
+		{
+			name:    "invalid fees",
+			change:  func(cfg *broadcaster.Config) { cfg.FeesStr = "0.0000001dec" },
+			wantErr: true,
+		},
+		{
+			name:    "invalid gas prices",
+			change:  func(cfg *broadcaster.Config) { cfg.GasPricesStr = "dec" },
+			wantErr: true,
+		},
+		{
+			name: "fees and fees string",
+			change: func(cfg *broadcaster.Config) {
+				cfg.Fees, cfg.FeesStr = coins("1udec"), "1dec"
+			},
+			wantErr: true,
+		},
+		{
+			name: "gas prices and gas prices string",
+			change: func(cfg *broadcaster.Config) {
+				cfg.GasPrices, cfg.GasPricesStr = sdk.NewDecCoins(sdk.NewInt64DecCoin("udec", 1)), "1udec"
+			},
+			wantErr: true,
+		},
+		{
+			name: "fees string and gas prices string",
+			change: func(cfg *broadcaster.Config) {
+				cfg.FeesStr, cfg.GasPricesStr = "1dec", "1udec"
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		cfg := validConfig()
+		tc.change(&cfg)
+
+		require.Equal(t, tc.wantErr, cfg.Validate() != nil, tc.name)
+	}
+}
@@ -0,0 +1,165 @@
+package broadcaster
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dnsLookupTimeout limits a single lookup of the node's host.
+const dnsLookupTimeout = 5 * time.Second
+
+// Resolver resolves host names. net.Resolver implements it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// dnsWatcher re-resolves the node's host periodically and after failed requests. When the address set
+// changes, idle connections are closed, so the next requests dial the new addresses. In-flight requests
+// aren't interrupted.
+type dnsWatcher struct {
+	host      string
+	resolver  Resolver
+	transport *http.Transport
+	clock     Clock
+	interval  time.Duration
+
+	addrs []string // addrs is accessed only by run.
+
+	trigger  chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newDNSWatcher starts watching the host of the node uri. It returns nil if the host is an ip address.
+func newDNSWatcher(nodeURI string, o clientOptions, transport *http.Transport) *dnsWatcher {
+	u, err := url.Parse(nodeURI)
+	if err != nil || u.Hostname() == "" || net.ParseIP(u.Hostname()) != nil {
+		return nil
+	}
+
+	w := &dnsWatcher{
+		host:      u.Hostname(),
+		resolver:  o.transport.resolver(),
+		transport: transport,
+		clock:     o.clock,
+		interval:  o.transport.DNSRefreshInterval,
+
+		trigger: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	w.addrs, _ = w.lookup()
+
+	go w.run()
+
+	return w
+}
+
+// refresh requests re-resolution without waiting for it.
+func (w *dnsWatcher) refresh() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (w *dnsWatcher) close() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+func (w *dnsWatcher) run() {
+	defer close(w.done)
+
+	for {
+		timer := w.clock.NewTimer(w.interval)
+
+		select {
+		case <-w.stop:
+			timer.Stop()
+			return
+		case <-w.trigger:
+			timer.Stop()
+		case <-timer.C():
+		}
+
+		addrs, err := w.lookup()
+		if err != nil || equalStrings(addrs, w.addrs) {
+			continue
+		}
+
+		w.addrs = addrs
+		w.transport.CloseIdleConnections()
+	}
+}
+
+func (w *dnsWatcher) lookup() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+
+	addrs, err := w.resolver.LookupHost(ctx, w.host)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(addrs)
+
+	return addrs, nil
+}
+
+// resolver returns configured resolver or the default one.
+func (t TransportConfig) resolver() Resolver {
+	if t.Resolver == nil {
+		return net.DefaultResolver
+	}
+
+	return t.Resolver
+}
+
+// dialContext dials the address resolving its host with the resolver. Addresses are tried in order.
+func dialContext(dialer *net.Dialer, resolver Resolver) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := resolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var conn net.Conn
+		for _, ip := range ips {
+			if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+				return conn, nil
+			}
+		}
+		if err == nil {
+			err = &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+
+		return nil, err
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,150 @@
+package broadcaster_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// stubResolver resolves any host to the addresses set by the test.
+type stubResolver struct {
+	mu      sync.Mutex
+	addrs   []string
+	lookups int
+}
+
+func (r *stubResolver) LookupHost(context.Context, string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lookups++
+	return append([]string(nil), r.addrs...), nil
+}
+
+func (r *stubResolver) set(addrs ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.addrs = addrs
+}
+
+func (r *stubResolver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lookups
+}
+
+// replica serves the node at the ip and counts requests.
+type replica struct {
+	*httptest.Server
+	requests int32
+}
+
+// nodeReplicas serves the node at 127.0.0.1 and 127.0.0.2 on the same port, like a host whose addresses rotate.
+func nodeReplicas(t *testing.T, node *testutil.FakeNode) (*replica, *replica, string) {
+	t.Helper()
+
+	first, second := &replica{}, &replica{}
+	for _, r := range []*replica{first, second} {
+		r := r
+		r.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&r.requests, 1)
+			node.Handler().ServeHTTP(w, req)
+		}))
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := lis.Addr().(*net.TCPAddr).Port
+	first.Listener = lis
+
+	lis, err = net.Listen("tcp", fmt.Sprintf("127.0.0.2:%d", port))
+	require.NoError(t, err)
+	second.Listener = lis
+
+	for _, r := range []*replica{first, second} {
+		r.Start()
+		t.Cleanup(r.Close)
+	}
+
+	return first, second, fmt.Sprintf("http://node.test:%d", port)
+}
+
+func TestDNSRefresh(t *testing.T) {
+	tt := []struct {
+		name     string
+		interval time.Duration
+		rotate   func(t *testing.T, first *replica, resolver *stubResolver)
+	}{
+		{
+			name:     "periodic",
+			interval: 20 * time.Millisecond,
+			rotate: func(t *testing.T, _ *replica, resolver *stubResolver) {
+				// The second lookup after the change starts when the first one is handled.
+				lookups := resolver.count()
+				require.Eventually(t, func() bool { return resolver.count() >= lookups+2 }, 5*time.Second, time.Millisecond)
+			},
+		},
+		{
+			// The old address goes down, the failed request is retried on a new connection.
+			name:     "failure",
+			interval: time.Hour,
+			rotate: func(t *testing.T, first *replica, resolver *stubResolver) {
+				first.CloseClientConnections()
+				first.Close()
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			first, second, uri := nodeReplicas(t, node)
+
+			resolver := &stubResolver{}
+			resolver.set("127.0.0.1")
+
+			cfg := uriConfig(node, key, uri)
+			cfg.Transport = broadcaster.TransportConfig{DNSRefreshInterval: tc.interval, Resolver: resolver}
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			broadcast := func() error {
+				_, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+				return err
+			}
+
+			require.NoError(t, broadcast())
+			require.NotZero(t, atomic.LoadInt32(&first.requests))
+			require.Zero(t, atomic.LoadInt32(&second.requests))
+
+			resolver.set("127.0.0.2")
+			lookups := resolver.count()
+			tc.rotate(t, first, resolver)
+
+			// Connections to the old address aren't used anymore.
+			served := atomic.LoadInt32(&first.requests)
+			require.NoError(t, broadcast())
+			require.NoError(t, broadcast())
+			require.Greater(t, resolver.count(), lookups)
+			require.Equal(t, served, atomic.LoadInt32(&first.requests), "old address is used")
+			require.NotZero(t, atomic.LoadInt32(&second.requests))
+		})
+	}
+}
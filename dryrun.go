@@ -0,0 +1,98 @@
+package broadcaster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// dryRunRecord is written to Config.DryRunWriter for every tx built in dry-run mode.
+type dryRunRecord struct {
+	TxHash   string          `json:"tx_hash"`
+	Sequence uint64          `json:"sequence"`
+	Tx       json.RawMessage `json:"tx"`
+}
+
+// broadcastDryRun signs tx with the shadow sequence and returns synthetic response instead of broadcasting.
+func (b *broadcaster) broadcastDryRun(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions, gas uint64,
+) (*BroadcastResult, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	txBytes, err := b.signTx(ctx, b.txFactory(b.factory(), memo, opts).WithGas(gas).WithSequence(b.shadowSeq), msgs, opts.extensions())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BroadcastResult{
+		Signer:   b.From(),
+		TxHash:   TxHash(txBytes),
+		Sequence: b.shadowSeq,
+		Attempts: 1,
+		Response: &sdk.TxResponse{
+			TxHash:    TxHash(txBytes),
+			GasWanted: int64(gas),
+		},
+	}
+
+	if err := b.recordDryRun(txBytes, b.shadowSeq); err != nil {
+		return res, err
+	}
+	b.shadowSeq++
+
+	return res, nil
+}
+
+// signDryRun signs tx with the dry-run sequence and consumes it unless opts.Speculative is set.
+func (b *broadcaster) signDryRun(
+	ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions, gas uint64,
+) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	txBytes, err := b.signTx(ctx, b.txFactory(b.factory(), memo, opts).WithGas(gas).WithSequence(b.shadowSeq), msgs, opts.extensions())
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.Speculative {
+		b.shadowSeq++
+	}
+
+	return txBytes, nil
+}
+
+// recordDryRun writes tx to Config.DryRunWriter.
+func (b *broadcaster) recordDryRun(txBytes []byte, seq uint64) error {
+	if b.cfg.DryRunWriter == nil {
+		return nil
+	}
+
+	tx, err := b.ctx.TxConfig.TxDecoder()(txBytes)
+	if err != nil {
+		return fmt.Errorf("failed to decode tx: %w", err)
+	}
+
+	txJSON, err := b.ctx.TxConfig.TxJSONEncoder()(tx)
+	if err != nil {
+		return fmt.Errorf("failed to encode tx: %w", err)
+	}
+
+	data, err := json.Marshal(dryRunRecord{
+		TxHash:   TxHash(txBytes),
+		Sequence: seq,
+		Tx:       txJSON,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	if _, err := b.cfg.DryRunWriter.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package broadcaster_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+func TestDryRun(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetSequence(key.Address, 3)
+
+	var records bytes.Buffer
+	cfg := testConfig(node, key)
+	cfg.DryRun = true
+	cfg.DryRunWriter = &records
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	var hashes []string
+	for i := 0; i < 3; i++ {
+		res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+		require.NoError(t, err)
+		require.Equal(t, uint64(3+i), res.Sequence, "shadow sequence keeps the batch consistent")
+		require.Zero(t, res.Response.Code)
+		require.Equal(t, res.TxHash, res.Response.TxHash)
+		require.Positive(t, res.Response.GasWanted)
+
+		hashes = append(hashes, res.TxHash)
+	}
+
+	for _, method := range []string{"broadcast_tx_sync", "broadcast_tx_async", "broadcast_tx_commit"} {
+		require.Zero(t, node.Calls(method), method)
+	}
+	require.Empty(t, node.Mempool())
+	require.Equal(t, uint64(3), node.Sequence(key.Address))
+	require.Equal(t, uint64(3), b.TxFactory().Sequence(), "real sequence isn't consumed")
+
+	// Every would-be tx is recorded.
+	require.Equal(t, 3, bytes.Count(records.Bytes(), []byte("\n")))
+	scanner := bufio.NewScanner(&records)
+	for i := 0; scanner.Scan(); i++ {
+		var record struct {
+			TxHash   string          `json:"tx_hash"`
+			Sequence uint64          `json:"sequence"`
+			Tx       json.RawMessage `json:"tx"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		require.Equal(t, hashes[i], record.TxHash)
+		require.Equal(t, uint64(3+i), record.Sequence)
+
+		tx, err := b.EncodingConfig().TxConfig.TxJSONDecoder()(record.Tx)
+		require.NoError(t, err)
+		require.Len(t, tx.GetMsgs(), 1)
+	}
+}
@@ -0,0 +1,285 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TxError is returned when the node rejects tx or tx fails in a block.
+// It unwraps to the registered sdk error of the response's code, so errors.Is(err, sdkerrors.ErrOutOfGas) works.
+type TxError struct {
+	Response *sdk.TxResponse
+	// FailedMsgIndex is the index of the message which failed tx. It's nil when the chain doesn't report it.
+	FailedMsgIndex *int
+}
+
+// newTxError returns TxError of the response with the failed message attributed when the raw log allows it.
+func newTxError(resp *sdk.TxResponse) *TxError {
+	return &TxError{Response: resp, FailedMsgIndex: parseFailedMsgIndex(resp.RawLog)}
+}
+
+// failedMsgIndexPatterns match the failed message's index in raw logs of different sdk versions:
+// "failed to execute message; message index: N: ..." of v0.40+ and json logs with "msg_index" of earlier ones.
+var failedMsgIndexPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`message index: (\d+)`),
+	regexp.MustCompile(`"msg_index":\s*"?(\d+)`),
+}
+
+// parseFailedMsgIndex returns the index of the failed message found in the raw log.
+func parseFailedMsgIndex(rawLog string) *int {
+	for _, re := range failedMsgIndexPatterns {
+		m := re.FindStringSubmatch(rawLog)
+		if m == nil {
+			continue
+		}
+
+		if idx, err := strconv.Atoi(m[1]); err == nil {
+			return &idx
+		}
+	}
+
+	return nil
+}
+
+func (e *TxError) Error() string {
+	return fmt.Sprintf("failed to broadcast tx: %s", e.Response.String())
+}
+
+func (e *TxError) Unwrap() error {
+	return sdkerrors.ABCIError(e.Response.Codespace, e.Response.Code, e.Response.RawLog)
+}
+
+// ErrorClass is a class of broadcast error used to decide whether it's worth retrying.
+type ErrorClass int
+
+const (
+	// ClassUnknown is an error which can't be classified.
+	ClassUnknown ErrorClass = iota
+	// ClassTransient is a temporary failure which is likely to pass on retry.
+	ClassTransient
+	// ClassSequenceMismatch is a failure caused by the account's sequence used by someone else.
+	ClassSequenceMismatch
+	// ClassInsufficientFunds is a failure caused by the lack of funds on the account.
+	ClassInsufficientFunds
+	// ClassOutOfGas is a failure caused by too low gas limit.
+	ClassOutOfGas
+	// ClassInvalidRequest is a failure caused by the request or configuration which won't pass on retry.
+	ClassInvalidRequest
+	// ClassNodeUnavailable is a failure to reach the node or trust its response.
+	ClassNodeUnavailable
+	// ClassDuplicate is a failure caused by tx which is already known to the node.
+	ClassDuplicate
+	// ClassAccountMismatch is a failure of signature verification caused by stale account number or wrong chain id.
+	ClassAccountMismatch
+	// ClassAccountNotFound is a failure caused by the account which doesn't exist on chain yet.
+	ClassAccountNotFound
+	// ClassNotFound is a failure to find the requested tx or other object.
+	ClassNotFound
+)
+
+// String implements fmt.Stringer.
+func (c ErrorClass) String() string {
+	switch c {
+	case ClassTransient:
+		return "transient"
+	case ClassSequenceMismatch:
+		return "sequence_mismatch"
+	case ClassInsufficientFunds:
+		return "insufficient_funds"
+	case ClassOutOfGas:
+		return "out_of_gas"
+	case ClassInvalidRequest:
+		return "invalid_request"
+	case ClassNodeUnavailable:
+		return "node_unavailable"
+	case ClassDuplicate:
+		return "duplicate"
+	case ClassAccountMismatch:
+		return "account_mismatch"
+	case ClassAccountNotFound:
+		return "account_not_found"
+	case ClassNotFound:
+		return "not_found"
+	default:
+		return "unknown"
+	}
+}
+
+// Retryable returns true if errors of the class are worth retrying.
+func (c ErrorClass) Retryable() bool {
+	switch c {
+	case ClassTransient, ClassSequenceMismatch, ClassOutOfGas, ClassNodeUnavailable, ClassAccountMismatch:
+		return true
+	default:
+		return false
+	}
+}
+
+// errorClass is the class of errors matching err.
+type errorClass struct {
+	err   error
+	class ErrorClass
+}
+
+// errorClasses maps errors to their classes. The first match wins, so package errors go before sdk ones.
+var errorClasses = []errorClass{
+	{ErrTxInMempoolCache, ClassDuplicate},
+	{ErrUnregisteredMsgType, ClassInvalidRequest},
+	{ErrNoMessages, ClassInvalidRequest},
+	{ErrNilMessage, ClassInvalidRequest},
+	{ErrMsgRejected, ClassInvalidRequest},
+	{ErrUnregisteredExtensionOption, ClassInvalidRequest},
+	{ErrWrongPassphrase, ClassInvalidRequest},
+	{ErrKeyExportDisabled, ClassInvalidRequest},
+	{ErrAddressMismatch, ClassInvalidRequest},
+	{ErrInvalidSignedTx, ClassInvalidRequest},
+	{ErrInvalidIdempotencyKey, ClassInvalidRequest},
+	{ErrMemoTooLong, ClassInvalidRequest},
+	{ErrDuplicateRecipient, ClassInvalidRequest},
+	{ErrUnexpectedMsgResponse, ClassInvalidRequest},
+	{ErrInvalidOffchainSignature, ClassInvalidRequest},
+	{ErrChainIDMismatch, ClassInvalidRequest},
+	{ErrTrackingDisabled, ClassInvalidRequest},
+	{ErrRegistryClosed, ClassInvalidRequest},
+	{ErrTxNotFound, ClassNotFound},
+	{ErrBroadcasterNotFound, ClassNotFound},
+	{ErrBroadcastPanic, ClassUnknown},
+	{ErrInsufficientFunds, ClassInsufficientFunds},
+	{ErrBalanceTooLow, ClassInsufficientFunds},
+	{ErrAccountNotFound, ClassAccountNotFound},
+	{ErrBroadcastDeadlineExceeded, ClassTransient},
+	{ErrChainHalted, ClassTransient},
+	{ErrTooManyInflight, ClassTransient},
+	{ErrTxExpired, ClassTransient},
+	{ErrTxLost, ClassTransient},
+	{ErrSharedTxFailed, ClassTransient},
+	{ErrSequenceContention, ClassSequenceMismatch},
+	{ErrNodeUnavailable, ClassNodeUnavailable},
+	{ErrNodeCatchingUp, ClassNodeUnavailable},
+	{ErrProofInvalid, ClassNodeUnavailable},
+	{ErrUnsupportedNodeVersion, ClassNodeUnavailable},
+
+	{sdkerrors.ErrTxInMempoolCache, ClassDuplicate},
+	{sdkerrors.ErrWrongSequence, ClassSequenceMismatch},
+	{sdkerrors.ErrOutOfGas, ClassOutOfGas},
+	{sdkerrors.ErrInsufficientFunds, ClassInsufficientFunds},
+	{sdkerrors.ErrUnknownAddress, ClassAccountNotFound},
+	{sdkerrors.ErrMempoolIsFull, ClassTransient},
+	{sdkerrors.ErrInsufficientFee, ClassInvalidRequest},
+	{sdkerrors.ErrUnauthorized, ClassInvalidRequest},
+	{sdkerrors.ErrInvalidAddress, ClassInvalidRequest},
+	{sdkerrors.ErrInvalidCoins, ClassInvalidRequest},
+	{sdkerrors.ErrInvalidRequest, ClassInvalidRequest},
+	{sdkerrors.ErrUnknownRequest, ClassInvalidRequest},
+	{sdkerrors.ErrTxDecode, ClassInvalidRequest},
+	{sdkerrors.ErrTxTooLarge, ClassInvalidRequest},
+	{sdkerrors.ErrMemoTooLarge, ClassInvalidRequest},
+	{sdkerrors.ErrInvalidChainID, ClassInvalidRequest},
+	{sdkerrors.ErrInvalidPubKey, ClassInvalidRequest},
+	{sdkerrors.ErrNoSignatures, ClassInvalidRequest},
+	{sdkerrors.ErrTooManySignatures, ClassInvalidRequest},
+	{sdkerrors.ErrTxTimeoutHeight, ClassInvalidRequest},
+
+	{context.DeadlineExceeded, ClassTransient},
+}
+
+// registeredClasses are classes of errors of other packages added by RegisterErrorClass.
+var registeredClasses struct {
+	sync.RWMutex
+	list []errorClass
+}
+
+// RegisterErrorClass makes Classify return the class for errors matching err. It's used by packages built
+// on top of the broadcaster to classify their errors and should be called from init.
+func RegisterErrorClass(err error, class ErrorClass) {
+	registeredClasses.Lock()
+	defer registeredClasses.Unlock()
+
+	registeredClasses.list = append(registeredClasses.list, errorClass{err: err, class: class})
+}
+
+// registeredClass returns the class registered for the error.
+func registeredClass(err error) (ErrorClass, bool) {
+	registeredClasses.RLock()
+	defer registeredClasses.RUnlock()
+
+	for _, v := range registeredClasses.list {
+		if errors.Is(err, v.err) {
+			return v.class, true
+		}
+	}
+
+	return ClassUnknown, false
+}
+
+// Classify returns the class of the error returned by the broadcaster.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ClassUnknown
+	}
+
+	// Only the terminal error matters, the previous attempts are already handled.
+	var attemptsErr *AttemptsError
+	if errors.As(err, &attemptsErr) {
+		err = attemptsErr.Unwrap()
+	}
+
+	// Sequence mismatch is often reported only in the log, e.g. by the ante handler during simulation.
+	if getNextSequence(err.Error()) != 0 || strings.Contains(err.Error(), "account sequence mismatch") {
+		return ClassSequenceMismatch
+	}
+
+	// Signature verification fails with the generic unauthorized code.
+	if errors.Is(err, sdkerrors.ErrUnauthorized) && strings.Contains(err.Error(), "signature verification failed") {
+		return ClassAccountMismatch
+	}
+
+	for _, v := range errorClasses {
+		if errors.Is(err, v.err) {
+			return v.class
+		}
+	}
+
+	if class, ok := registeredClass(err); ok {
+		return class
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ClassNodeUnavailable
+	}
+
+	// status.Code doesn't unwrap the error.
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) && grpcErr.GRPCStatus().Code() == codes.Unavailable {
+		return ClassNodeUnavailable
+	}
+
+	var commitErr *CommitTimeoutError
+	if errors.As(err, &commitErr) {
+		return ClassTransient
+	}
+
+	return ClassUnknown
+}
+
+// IsRetryable returns true if the error is worth retrying.
+func IsRetryable(err error) bool {
+	return Classify(err).Retryable()
+}
+
+// responseClass returns the class of failed response.
+func responseClass(resp *sdk.TxResponse) ErrorClass {
+	return Classify(newTxError(resp))
+}
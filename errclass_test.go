@@ -0,0 +1,243 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/community"
+	"github.com/Decentr-net/go-broadcaster/operations"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// txError returns error of tx rejected with the sdk error.
+func txError(err *sdkerrors.Error, rawLog string) error {
+	return &broadcaster.TxError{Response: &sdk.TxResponse{Codespace: err.Codespace(), Code: err.ABCICode(), RawLog: rawLog}}
+}
+
+func TestClassify(t *testing.T) {
+	tt := []struct {
+		name  string
+		err   error
+		class broadcaster.ErrorClass
+	}{
+		{name: "nil", err: nil, class: broadcaster.ClassUnknown},
+		{name: "unknown", err: errors.New("boom"), class: broadcaster.ClassUnknown},
+		{
+			name:  "mempool full",
+			err:   fmt.Errorf("failed to broadcast: %w", txError(sdkerrors.ErrMempoolIsFull, "mempool is full")),
+			class: broadcaster.ClassTransient,
+		},
+		{
+			name:  "deadline",
+			err:   fmt.Errorf("failed to simulate: %w", context.DeadlineExceeded),
+			class: broadcaster.ClassTransient,
+		},
+		{
+			name:  "commit timeout",
+			err:   fmt.Errorf("failed to wait for commit: %w", &broadcaster.CommitTimeoutError{TxHash: "AB"}),
+			class: broadcaster.ClassTransient,
+		},
+		{
+			name:  "sequence mismatch response",
+			err:   txError(sdkerrors.ErrWrongSequence, "account sequence mismatch, expected 5, got 3: incorrect account sequence"),
+			class: broadcaster.ClassSequenceMismatch,
+		},
+		{
+			name:  "sequence mismatch of simulation",
+			err:   fmt.Errorf("failed to simulate: %w", errors.New("rpc error: code = Unknown desc = account sequence mismatch, expected 7, got 6")),
+			class: broadcaster.ClassSequenceMismatch,
+		},
+		{
+			name:  "insufficient funds response",
+			err:   txError(sdkerrors.ErrInsufficientFunds, "1udec is smaller than 2udec: insufficient funds"),
+			class: broadcaster.ClassInsufficientFunds,
+		},
+		{
+			name:  "out of gas",
+			err:   fmt.Errorf("failed to broadcast: %w", txError(sdkerrors.ErrOutOfGas, "out of gas in location: WriteFlat")),
+			class: broadcaster.ClassOutOfGas,
+		},
+		{
+			name:  "insufficient fee",
+			err:   txError(sdkerrors.ErrInsufficientFee, "insufficient fees; got: 1udec required: 2udec"),
+			class: broadcaster.ClassInvalidRequest,
+		},
+		{
+			name:  "unauthorized",
+			err:   txError(sdkerrors.ErrUnauthorized, "unauthorized"),
+			class: broadcaster.ClassInvalidRequest,
+		},
+		{
+			name:  "signature verification",
+			err:   txError(sdkerrors.ErrUnauthorized, "signature verification failed; please verify account number (1) and chain-id (test)"),
+			class: broadcaster.ClassAccountMismatch,
+		},
+		{
+			name:  "network",
+			err:   fmt.Errorf("post failed: %w", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}),
+			class: broadcaster.ClassNodeUnavailable,
+		},
+		{
+			name:  "grpc unavailable",
+			err:   fmt.Errorf("failed to simulate: %w", status.Error(codes.Unavailable, "connection closed")),
+			class: broadcaster.ClassNodeUnavailable,
+		},
+		{
+			name:  "duplicate",
+			err:   txError(sdkerrors.ErrTxInMempoolCache, "tx already exists in cache"),
+			class: broadcaster.ClassDuplicate,
+		},
+		{
+			name:  "unknown address",
+			err:   fmt.Errorf("failed to simulate: %w", sdkerrors.Wrap(sdkerrors.ErrUnknownAddress, "account decentr1x does not exist")),
+			class: broadcaster.ClassAccountNotFound,
+		},
+		{
+			name:  "community invalid argument",
+			err:   fmt.Errorf("%w: empty title", community.ErrInvalidArgument),
+			class: broadcaster.ClassInvalidRequest,
+		},
+		{
+			name:  "operations unauthorized",
+			err:   fmt.Errorf("failed to distribute rewards: %w", operations.ErrUnauthorized),
+			class: broadcaster.ClassInvalidRequest,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.class, broadcaster.Classify(tc.err))
+			require.Equal(t, tc.class.Retryable(), broadcaster.IsRetryable(tc.err))
+		})
+	}
+}
+
+// TestClassify_TypedErrors checks the class of every typed error of the package and the packages on top of it.
+func TestClassify_TypedErrors(t *testing.T) {
+	tt := []struct {
+		err   error
+		class broadcaster.ErrorClass
+	}{
+		{broadcaster.ErrTxInMempoolCache, broadcaster.ClassDuplicate},
+		{broadcaster.ErrUnregisteredMsgType, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrNoMessages, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrNilMessage, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrMsgRejected, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrUnregisteredExtensionOption, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrWrongPassphrase, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrKeyExportDisabled, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrAddressMismatch, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrInvalidSignedTx, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrInvalidIdempotencyKey, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrMemoTooLong, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrDuplicateRecipient, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrUnexpectedMsgResponse, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrInvalidOffchainSignature, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrChainIDMismatch, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrTrackingDisabled, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrRegistryClosed, broadcaster.ClassInvalidRequest},
+		{broadcaster.ErrTxNotFound, broadcaster.ClassNotFound},
+		{broadcaster.ErrBroadcasterNotFound, broadcaster.ClassNotFound},
+		{broadcaster.ErrBroadcastPanic, broadcaster.ClassUnknown},
+		{broadcaster.ErrInsufficientFunds, broadcaster.ClassInsufficientFunds},
+		{broadcaster.ErrBalanceTooLow, broadcaster.ClassInsufficientFunds},
+		{broadcaster.ErrAccountNotFound, broadcaster.ClassAccountNotFound},
+		{broadcaster.ErrBroadcastDeadlineExceeded, broadcaster.ClassTransient},
+		{broadcaster.ErrChainHalted, broadcaster.ClassTransient},
+		{broadcaster.ErrTooManyInflight, broadcaster.ClassTransient},
+		{broadcaster.ErrTxExpired, broadcaster.ClassTransient},
+		{broadcaster.ErrTxLost, broadcaster.ClassTransient},
+		{broadcaster.ErrSharedTxFailed, broadcaster.ClassTransient},
+		{broadcaster.ErrSequenceContention, broadcaster.ClassSequenceMismatch},
+		{broadcaster.ErrNodeUnavailable, broadcaster.ClassNodeUnavailable},
+		{broadcaster.ErrNodeCatchingUp, broadcaster.ClassNodeUnavailable},
+		{broadcaster.ErrProofInvalid, broadcaster.ClassNodeUnavailable},
+		{broadcaster.ErrUnsupportedNodeVersion, broadcaster.ClassNodeUnavailable},
+
+		{community.ErrInvalidArgument, broadcaster.ClassInvalidRequest},
+		{operations.ErrInvalidArgument, broadcaster.ClassInvalidRequest},
+		{operations.ErrUnauthorized, broadcaster.ClassInvalidRequest},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.class, broadcaster.Classify(fmt.Errorf("wrapped: %w", tc.err)), tc.err)
+	}
+}
+
+func TestTxError_FailedMsgIndex(t *testing.T) {
+	tt := []struct {
+		fixture string
+		want    *int
+	}{
+		{fixture: "failed_sdk_0.39.json", want: intPtr(1)},
+		{fixture: "failed_sdk_0.45.txt", want: intPtr(1)},
+		{fixture: "failed_sdk_0.47.txt", want: intPtr(1)},
+		{fixture: "failed_sdk_0.50.txt", want: intPtr(12)},
+		{fixture: "out_of_gas_sdk_0.45.txt"},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.fixture, func(t *testing.T) {
+			rawLog, err := os.ReadFile(filepath.Join("testdata", "rawlog", tc.fixture))
+			require.NoError(t, err)
+
+			node, key := newFakeChain(t)
+			node.OnCheckTx(func(testutil.FakeTx) error {
+				return sdkerrors.Wrap(sdkerrors.ErrInsufficientFunds, string(rawLog))
+			})
+
+			b, err := broadcaster.New(testConfig(node, key))
+			require.NoError(t, err)
+			defer b.Close()
+
+			_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+				broadcaster.BroadcastOptions{DisableAutoRetry: true})
+			var txErr *broadcaster.TxError
+			require.ErrorAs(t, err, &txErr)
+			require.Equal(t, tc.want, txErr.FailedMsgIndex)
+		})
+	}
+
+	// The message failed on delivery is attributed too.
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = broadcaster.ModeCommit
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	msgs := []sdk.Msg{sendMsg(key.Address, 1), sendMsg(key.Address, 2_000_000_000), sendMsg(key.Address, 1)}
+	_, err = b.BroadcastContext(context.Background(), msgs, "",
+		broadcaster.BroadcastOptions{Gas: 1_000_000, DisableAutoRetry: true})
+	var txErr *broadcaster.TxError
+	require.ErrorAs(t, err, &txErr)
+	require.ErrorIs(t, err, sdkerrors.ErrInsufficientFunds)
+	require.Equal(t, intPtr(1), txErr.FailedMsgIndex)
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestErrorClass_String(t *testing.T) {
+	seen := map[string]bool{}
+	for c := broadcaster.ClassUnknown; c <= broadcaster.ClassNotFound; c++ {
+		require.False(t, seen[c.String()], "class %d has duplicate name %s", c, c)
+		seen[c.String()] = true
+	}
+	require.Equal(t, "unknown", broadcaster.ErrorClass(-1).String())
+}
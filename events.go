@@ -0,0 +1,127 @@
+package broadcaster
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Attribute is an event's key/value pair.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Event is an event emitted by a message.
+type Event struct {
+	Type       string
+	Attributes []Attribute
+}
+
+// MsgEvents contains events emitted by a single message of tx.
+type MsgEvents struct {
+	MsgIndex int
+	// Log is the message's log. It's empty on chains which don't fill it.
+	Log    string
+	Events []Event
+}
+
+// ParseTxEvents returns events of tx grouped by messages.
+// Attributes are decoded from base64 when node returns them encoded.
+func ParseTxEvents(resp *sdk.TxResponse) ([]MsgEvents, error) {
+	if resp == nil {
+		return nil, errors.New("response is nil")
+	}
+
+	logs := resp.Logs
+	if len(logs) == 0 && resp.RawLog != "" {
+		var err error
+		if logs, err = sdk.ParseABCILogs(resp.RawLog); err != nil {
+			return nil, fmt.Errorf("failed to parse raw log: %w", err)
+		}
+	}
+
+	out := make([]MsgEvents, len(logs))
+	for i, log := range logs {
+		events := make([]Event, len(log.Events))
+		for j, event := range log.Events {
+			events[j] = decodeEvent(event)
+		}
+
+		out[i] = MsgEvents{
+			MsgIndex: int(log.MsgIndex),
+			Log:      log.Log,
+			Events:   events,
+		}
+	}
+
+	return out, nil
+}
+
+// FindAttribute returns value of the first attribute with the key in events with the type.
+func FindAttribute(events []Event, eventType, key string) (string, bool) {
+	for _, event := range events {
+		if event.Type != eventType {
+			continue
+		}
+
+		for _, attr := range event.Attributes {
+			if attr.Key == key {
+				return attr.Value, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// decodeEvent converts sdk event to Event. Some nodes return attributes base64 encoded,
+// so they are decoded when every key of the event is a base64 encoded printable string.
+func decodeEvent(event sdk.StringEvent) Event {
+	attrs := make([]Attribute, len(event.Attributes))
+	for i, attr := range event.Attributes {
+		attrs[i] = Attribute{Key: attr.Key, Value: attr.Value}
+	}
+
+	if len(attrs) == 0 {
+		return Event{Type: event.Type, Attributes: attrs}
+	}
+
+	decoded := make([]Attribute, len(attrs))
+	for i, attr := range attrs {
+		key, ok := decodeBase64Printable(attr.Key)
+		if !ok {
+			return Event{Type: event.Type, Attributes: attrs}
+		}
+
+		value, err := base64.StdEncoding.DecodeString(attr.Value)
+		if err != nil {
+			return Event{Type: event.Type, Attributes: attrs}
+		}
+
+		decoded[i] = Attribute{Key: key, Value: string(value)}
+	}
+
+	return Event{Type: event.Type, Attributes: decoded}
+}
+
+func decodeBase64Printable(s string) (string, bool) {
+	if s == "" {
+		return "", false
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return "", false
+	}
+
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			return "", false
+		}
+	}
+
+	return string(b), true
+}
@@ -0,0 +1,150 @@
+package broadcaster_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+func TestParseTxEvents(t *testing.T) {
+	tt := []struct {
+		name    string
+		resp    *sdk.TxResponse
+		want    []broadcaster.MsgEvents
+		wantErr bool
+	}{
+		{
+			name:    "nil response",
+			wantErr: true,
+		},
+		{
+			name: "empty",
+			resp: &sdk.TxResponse{},
+			want: []broadcaster.MsgEvents{},
+		},
+		{
+			name: "plain attributes in logs",
+			resp: &sdk.TxResponse{Logs: sdk.ABCIMessageLogs{{
+				MsgIndex: 0,
+				Events: sdk.StringEvents{{
+					Type:       "message",
+					Attributes: []sdk.Attribute{{Key: "action", Value: "/cosmos.bank.v1beta1.MsgSend"}},
+				}},
+			}}},
+			want: []broadcaster.MsgEvents{{
+				MsgIndex: 0,
+				Events: []broadcaster.Event{{
+					Type:       "message",
+					Attributes: []broadcaster.Attribute{{Key: "action", Value: "/cosmos.bank.v1beta1.MsgSend"}},
+				}},
+			}},
+		},
+		{
+			name: "base64 attributes in raw log",
+			resp: &sdk.TxResponse{
+				RawLog: `[{"msg_index":1,"log":"ok","events":[{"type":"post","attributes":[{"key":"dXVpZA==","value":"MTIz"}]}]}]`,
+			},
+			want: []broadcaster.MsgEvents{{
+				MsgIndex: 1,
+				Log:      "ok",
+				Events: []broadcaster.Event{{
+					Type:       "post",
+					Attributes: []broadcaster.Attribute{{Key: "uuid", Value: "123"}},
+				}},
+			}},
+		},
+		{
+			name: "base64-like values of plain keys are kept",
+			resp: &sdk.TxResponse{
+				RawLog: `[{"msg_index":0,"events":[{"type":"transfer","attributes":[{"key":"amount","value":"MTIz"}]}]}]`,
+			},
+			want: []broadcaster.MsgEvents{{
+				Events: []broadcaster.Event{{
+					Type:       "transfer",
+					Attributes: []broadcaster.Attribute{{Key: "amount", Value: "MTIz"}},
+				}},
+			}},
+		},
+		{
+			name:    "invalid raw log",
+			resp:    &sdk.TxResponse{RawLog: "out of gas"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := broadcaster.ParseTxEvents(tc.resp)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseTxEvents_MsgLogs(t *testing.T) {
+	rawLog, err := os.ReadFile(filepath.Join("testdata", "rawlog", "success_with_logs.json"))
+	require.NoError(t, err)
+
+	got, err := broadcaster.ParseTxEvents(&sdk.TxResponse{RawLog: string(rawLog)})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	for i, v := range got {
+		require.Equal(t, i, v.MsgIndex)
+		require.Equal(t, fmt.Sprintf("sent %dudec", i+1), v.Log)
+	}
+}
+
+func TestFindAttribute(t *testing.T) {
+	events := []broadcaster.Event{
+		{Type: "transfer", Attributes: []broadcaster.Attribute{{Key: "amount", Value: "1udec"}}},
+		{Type: "message", Attributes: []broadcaster.Attribute{{Key: "sender", Value: "a"}, {Key: "action", Value: "send"}}},
+		{Type: "message", Attributes: []broadcaster.Attribute{{Key: "action", Value: "other"}}},
+	}
+
+	v, ok := broadcaster.FindAttribute(events, "message", "action")
+	require.True(t, ok)
+	require.Equal(t, "send", v)
+
+	_, ok = broadcaster.FindAttribute(events, "transfer", "action")
+	require.False(t, ok)
+}
+
+func TestBroadcastResult_Events(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = broadcaster.ModeBlock
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 7), sendMsg(key.Address, 8)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Len(t, res.Events, 2)
+
+	for i, amount := range []string{"7udec", "8udec"} {
+		require.Equal(t, i, res.Events[i].MsgIndex)
+
+		action, ok := broadcaster.FindAttribute(res.Events[i].Events, "message", "action")
+		require.True(t, ok)
+		require.Equal(t, "/cosmos.bank.v1beta1.MsgSend", action)
+
+		v, ok := broadcaster.FindAttribute(res.Events[i].Events, "transfer", "amount")
+		require.True(t, ok)
+		require.Equal(t, amount, v)
+	}
+}
@@ -0,0 +1,74 @@
+package broadcaster
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtx "github.com/cosmos/cosmos-sdk/x/auth/tx"
+)
+
+// ErrUnregisteredExtensionOption is returned when extension option's type url is not registered in the interface registry.
+var ErrUnregisteredExtensionOption = errors.New("extension option type is not registered")
+
+// extensionOptions are settings of tx which tx.Factory doesn't carry: extension options of tx body
+// and the fee granter.
+type extensionOptions struct {
+	critical    []*codectypes.Any
+	nonCritical []*codectypes.Any
+	feeGranter  sdk.AccAddress
+}
+
+// extensions returns extension options of opts.
+func (o BroadcastOptions) extensions() extensionOptions {
+	return extensionOptions{
+		critical:    o.ExtensionOptions,
+		nonCritical: o.NonCriticalExtensionOptions,
+		feeGranter:  o.FeeGranter,
+	}
+}
+
+// empty returns true if there are no extension options.
+func (e extensionOptions) empty() bool {
+	return len(e.critical) == 0 && len(e.nonCritical) == 0
+}
+
+// apply sets extension options and the fee granter to the tx builder.
+func (e extensionOptions) apply(txb client.TxBuilder) error {
+	if !e.feeGranter.Empty() {
+		txb.SetFeeGranter(e.feeGranter)
+	}
+
+	if e.empty() {
+		return nil
+	}
+
+	extTxb, ok := txb.(authtx.ExtensionOptionsTxBuilder)
+	if !ok {
+		return errors.New("tx builder doesn't support extension options")
+	}
+
+	extTxb.SetExtensionOptions(e.critical...)
+	extTxb.SetNonCriticalExtensionOptions(e.nonCritical...)
+
+	return nil
+}
+
+// checkExtensionOptions ensures that every extension option could be decoded with the interface registry.
+func (b *broadcaster) checkExtensionOptions(e extensionOptions) error {
+	for _, list := range [][]*codectypes.Any{e.critical, e.nonCritical} {
+		for i, v := range list {
+			if v == nil {
+				return fmt.Errorf("nil extension option at index %d", i)
+			}
+
+			if _, err := b.enc.InterfaceRegistry.Resolve(v.TypeUrl); err != nil {
+				return fmt.Errorf("%w: %s", ErrUnregisteredExtensionOption, v.TypeUrl)
+			}
+		}
+	}
+
+	return nil
+}
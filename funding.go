@@ -0,0 +1,67 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// FundingReport contains the outcome of EnsureFunded.
+type FundingReport struct {
+	// Sent contains amounts sent to accounts which balances were below the minimum.
+	Sent []Output
+	// Result is the result of MultiSend. It is nil when nothing was sent.
+	Result *MultiSendResult
+}
+
+// EnsureFunded tops up targets which balance of the denom is below minBalance up to topUpTo.
+// Coins are sent from the broadcaster's account with MultiSend. It does nothing when every target has enough coins,
+// so it's safe to run periodically.
+func (b *broadcaster) EnsureFunded(
+	ctx context.Context, targets []sdk.AccAddress, minBalance, topUpTo sdk.Coin,
+) (*FundingReport, error) {
+	if minBalance.Denom != topUpTo.Denom {
+		return nil, fmt.Errorf("min balance and top up amount should have the same denom")
+	}
+
+	if topUpTo.IsLT(minBalance) {
+		return nil, fmt.Errorf("top up amount should be greater than min balance")
+	}
+
+	report := &FundingReport{}
+	seen := make(map[string]bool, len(targets))
+	for _, v := range targets {
+		if seen[v.String()] {
+			continue
+		}
+		seen[v.String()] = true
+
+		balance, err := b.balance(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get balance of %s: %w", v, err)
+		}
+
+		have := balance.AmountOf(topUpTo.Denom)
+		if have.GTE(minBalance.Amount) {
+			continue
+		}
+
+		report.Sent = append(report.Sent, Output{
+			Address: v,
+			Coins:   sdk.NewCoins(sdk.NewCoin(topUpTo.Denom, topUpTo.Amount.Sub(have))),
+		})
+	}
+
+	if len(report.Sent) == 0 {
+		return report, nil
+	}
+
+	res, err := b.MultiSend(ctx, report.Sent, "", MultiSendOptions{})
+	report.Result = res
+	if err != nil {
+		return report, fmt.Errorf("failed to send coins: %w", err)
+	}
+
+	return report, nil
+}
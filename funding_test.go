@@ -0,0 +1,74 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestEnsureFunded(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	var (
+		empty   = testutil.NewKey("empty").Address
+		low     = testutil.NewKey("low").Address
+		atMin   = testutil.NewKey("at min").Address
+		rich    = testutil.NewKey("rich").Address
+		foreign = testutil.NewKey("foreign").Address
+	)
+	node.AddAccount(low, sdk.NewInt64Coin(testDenom, 30))
+	node.AddAccount(atMin, sdk.NewInt64Coin(testDenom, 50))
+	node.AddAccount(rich, sdk.NewInt64Coin(testDenom, 500))
+	node.AddAccount(foreign, sdk.NewInt64Coin("uother", 1000))
+
+	minBalance, topUpTo := sdk.NewInt64Coin(testDenom, 50), sdk.NewInt64Coin(testDenom, 100)
+	targets := []sdk.AccAddress{empty, low, atMin, rich, foreign, low}
+
+	report, err := b.EnsureFunded(context.Background(), targets, minBalance, topUpTo)
+	require.NoError(t, err)
+	require.Equal(t, []broadcaster.Output{
+		{Address: empty, Coins: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 100))},
+		{Address: low, Coins: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 70))},
+		{Address: foreign, Coins: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 100))},
+	}, report.Sent, "only the missing part of the denom is sent, duplicates are topped up once")
+	require.Len(t, report.Result.Results, 1)
+	requireCommitted(t, node, report.Result.Results[0].TxHash)
+
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(testDenom, 100)), node.Balance(empty))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(testDenom, 100)), node.Balance(low))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(testDenom, 50)), node.Balance(atMin))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(testDenom, 500)), node.Balance(rich))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uother", 1000), sdk.NewInt64Coin(testDenom, 100)), node.Balance(foreign))
+
+	// Everyone is funded now, so the next run is a no-op.
+	report, err = b.EnsureFunded(context.Background(), targets, minBalance, topUpTo)
+	require.NoError(t, err)
+	require.Empty(t, report.Sent)
+	require.Nil(t, report.Result)
+	require.Empty(t, node.Mempool())
+}
+
+func TestEnsureFunded_InvalidAmounts(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	target := []sdk.AccAddress{testutil.NewKey("target").Address}
+
+	_, err = b.EnsureFunded(context.Background(), target, sdk.NewInt64Coin(testDenom, 50), sdk.NewInt64Coin("uother", 100))
+	require.ErrorContains(t, err, "same denom")
+
+	_, err = b.EnsureFunded(context.Background(), target, sdk.NewInt64Coin(testDenom, 100), sdk.NewInt64Coin(testDenom, 50))
+	require.ErrorContains(t, err, "greater than min balance")
+}
@@ -0,0 +1,64 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// validateGasPerMsgType ensures that every entry of the gas table has a type url and gas.
+func validateGasPerMsgType(table map[string]uint64) error {
+	for k, v := range table {
+		if k == "" || v == 0 {
+			return errors.New("gas per msg type should have type urls and positive gas")
+		}
+	}
+
+	return nil
+}
+
+// tableGas returns the sum of gas of msgs from Config.GasPerMsgType and the override plus Config.GasTxOverhead.
+// It returns false unless every msg has an entry.
+func (b *broadcaster) tableGas(msgs []sdk.Msg, override map[string]uint64) (uint64, bool) {
+	if len(b.cfg.GasPerMsgType) == 0 && len(override) == 0 {
+		return 0, false
+	}
+
+	gas := b.cfg.GasTxOverhead
+	for _, msg := range msgs {
+		url := sdk.MsgTypeURL(msg)
+
+		v, ok := override[url]
+		if !ok {
+			v, ok = b.cfg.GasPerMsgType[url]
+		}
+		if !ok {
+			return 0, false
+		}
+
+		gas += v
+	}
+
+	return gas, true
+}
+
+// reportStaleGas notifies Config.OnStaleGas that tx with gas from the table ran out of gas.
+func (b *broadcaster) reportStaleGas(ctx context.Context, msgs []sdk.Msg, gas uint64) {
+	seen := make(map[string]bool, len(msgs))
+	var types []string
+	for _, msg := range msgs {
+		if url := sdk.MsgTypeURL(msg); !seen[url] {
+			seen[url] = true
+			types = append(types, url)
+		}
+	}
+	sort.Strings(types)
+
+	b.warnf(ctx, "tx ran out of %d gas from gas per msg type, entries of %v could be stale", gas, types)
+
+	if b.cfg.OnStaleGas != nil {
+		b.cfg.OnStaleGas(types, gas)
+	}
+}
@@ -0,0 +1,243 @@
+package broadcaster_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/bytes"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// countingSimulation is the fake node which counts simulations.
+type countingSimulation struct {
+	*testutil.FakeNode
+	simulations int32
+}
+
+func (n *countingSimulation) ABCIQueryWithOptions(
+	ctx context.Context, path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions,
+) (*ctypes.ResultABCIQuery, error) {
+	if path == testutil.SimulateQueryPath {
+		atomic.AddInt32(&n.simulations, 1)
+	}
+
+	return n.FakeNode.ABCIQueryWithOptions(ctx, path, data, opts)
+}
+
+// multiSendMsg returns bank multi-send of the amount from the key to a new address.
+func multiSendMsg(from sdk.AccAddress, amount int64) *banktypes.MsgMultiSend {
+	coins := sdk.NewCoins(sdk.NewInt64Coin(testDenom, amount))
+	return banktypes.NewMsgMultiSend(
+		[]banktypes.Input{banktypes.NewInput(from, coins)},
+		[]banktypes.Output{banktypes.NewOutput(testutil.NewKey("recipient").Address, coins)},
+	)
+}
+
+func TestBroadcast_GasPerMsgType(t *testing.T) {
+	node, key := newFakeChain(t)
+	counting := &countingSimulation{FakeNode: node}
+
+	sendURL, multiSendURL := sdk.MsgTypeURL(&banktypes.MsgSend{}), sdk.MsgTypeURL(&banktypes.MsgMultiSend{})
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = counting
+	cfg.GasPerMsgType = map[string]uint64{sendURL: 30_000}
+	cfg.GasTxOverhead = 60_000
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	tt := []struct {
+		name     string
+		msgs     []sdk.Msg
+		opts     broadcaster.BroadcastOptions
+		gas      uint64
+		simulate bool
+	}{
+		{
+			name: "single msg",
+			msgs: []sdk.Msg{sendMsg(key.Address, 1)},
+			gas:  90_000,
+		},
+		{
+			name: "batch",
+			msgs: []sdk.Msg{sendMsg(key.Address, 1), sendMsg(key.Address, 2)},
+			gas:  120_000,
+		},
+		{
+			name:     "mixed batch",
+			msgs:     []sdk.Msg{sendMsg(key.Address, 1), multiSendMsg(key.Address, 2)},
+			simulate: true,
+		},
+		{
+			name: "added entry",
+			msgs: []sdk.Msg{sendMsg(key.Address, 1), multiSendMsg(key.Address, 2)},
+			opts: broadcaster.BroadcastOptions{GasPerMsgType: map[string]uint64{multiSendURL: 40_000}},
+			gas:  130_000,
+		},
+		{
+			name: "overridden entry",
+			msgs: []sdk.Msg{sendMsg(key.Address, 1)},
+			opts: broadcaster.BroadcastOptions{GasPerMsgType: map[string]uint64{sendURL: 25_000}},
+			gas:  85_000,
+		},
+		{
+			name: "explicit gas",
+			msgs: []sdk.Msg{sendMsg(key.Address, 1)},
+			opts: broadcaster.BroadcastOptions{Gas: 200_000, GasPerMsgType: map[string]uint64{sendURL: 25_000}},
+			gas:  200_000,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			simulations := atomic.LoadInt32(&counting.simulations)
+
+			res, err := b.BroadcastContext(context.Background(), tc.msgs, "", tc.opts)
+			require.NoError(t, err)
+			requireCommitted(t, node, res.TxHash)
+
+			committed, _ := node.CommittedTx(res.TxHash)
+			if tc.simulate {
+				require.Equal(t, simulations+1, atomic.LoadInt32(&counting.simulations))
+				require.NotContains(t, []int64{120_000, 130_000}, committed.TxResult.GasWanted)
+				return
+			}
+			require.Equal(t, simulations, atomic.LoadInt32(&counting.simulations))
+			require.EqualValues(t, tc.gas, committed.TxResult.GasWanted)
+		})
+	}
+}
+
+func TestBroadcast_GasPerMsgType_Stale(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	type stale struct {
+		types []string
+		gas   uint64
+	}
+	reports := make(chan stale, 10)
+
+	cfg := testConfig(node, key)
+	// The entry doesn't cover gas of the tx itself, so it's out of gas.
+	cfg.GasPerMsgType = map[string]uint64{sdk.MsgTypeURL(&banktypes.MsgSend{}): 1000}
+	cfg.OnStaleGas = func(types []string, gas uint64) {
+		reports <- stale{types: types, gas: gas}
+	}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	_, _ = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1), sendMsg(key.Address, 2)}, "", broadcaster.BroadcastOptions{})
+	require.Equal(t, stale{types: []string{sdk.MsgTypeURL(&banktypes.MsgSend{})}, gas: 2000}, <-reports)
+
+	// Txs priced without the table aren't reported.
+	_, _ = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{Gas: 1000})
+	require.Empty(t, reports)
+}
+
+func TestConfig_Validate_GasPerMsgType(t *testing.T) {
+	for _, table := range []map[string]uint64{{"": 1000}, {"/cosmos.bank.v1beta1.MsgSend": 0}} {
+		cfg := validConfig()
+		cfg.GasPerMsgType = table
+		require.ErrorContains(t, cfg.Validate(), "gas per msg type")
+	}
+}
+
+// memoryGasStore is broadcaster.GasStore keeping estimates in memory.
+type memoryGasStore struct {
+	mu        sync.Mutex
+	estimates map[string]broadcaster.GasEstimate
+}
+
+func (s *memoryGasStore) Load(context.Context) (map[string]broadcaster.GasEstimate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]broadcaster.GasEstimate, len(s.estimates))
+	for k, v := range s.estimates {
+		out[k] = v
+	}
+
+	return out, nil
+}
+
+func (s *memoryGasStore) Save(_ context.Context, signature string, estimate broadcaster.GasEstimate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.estimates[signature] = estimate
+	return nil
+}
+
+func TestBroadcast_AdaptiveGas(t *testing.T) {
+	const minSamples = 3
+
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+	counting := &countingSimulation{FakeNode: node}
+	store := &memoryGasStore{estimates: map[string]broadcaster.GasEstimate{}}
+	signature := sdk.MsgTypeURL(&banktypes.MsgSend{})
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = counting
+	cfg.BroadcastMode = broadcaster.ModeCommit
+	cfg.AdaptiveGas = broadcaster.AdaptiveGas{Enabled: true, MinSamples: minSamples, Store: store}
+
+	broadcast := func(b contextBroadcaster) int64 {
+		t.Helper()
+
+		res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+		require.NoError(t, err)
+		committed, ok := node.CommittedTx(res.TxHash)
+		require.True(t, ok)
+		require.Zero(t, committed.TxResult.Code, committed.TxResult.Log)
+
+		return committed.TxResult.GasWanted
+	}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Txs are simulated until the signature has enough samples.
+	for i := 0; i < minSamples; i++ {
+		broadcast(b)
+	}
+	require.EqualValues(t, minSamples, atomic.LoadInt32(&counting.simulations))
+
+	estimate := b.Stats().GasEstimates[signature]
+	require.Equal(t, minSamples, estimate.Samples)
+	require.EqualValues(t, testutil.DefaultFakeTxGas+testutil.DefaultFakeMsgGas, estimate.Mean)
+	require.Zero(t, estimate.Variance)
+	require.Equal(t, estimate, store.estimates[signature])
+
+	require.EqualValues(t, estimate.Mean, broadcast(b))
+	require.EqualValues(t, minSamples, atomic.LoadInt32(&counting.simulations))
+
+	// The restarted broadcaster starts from the stored estimates.
+	restarted, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer restarted.Close()
+
+	require.Equal(t, minSamples+1, restarted.Stats().GasEstimates[signature].Samples)
+	require.EqualValues(t, estimate.Mean, broadcast(restarted))
+	require.EqualValues(t, minSamples, atomic.LoadInt32(&counting.simulations))
+
+	// Other signatures are still simulated.
+	_, err = restarted.BroadcastContext(context.Background(), []sdk.Msg{multiSendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.EqualValues(t, minSamples+1, atomic.LoadInt32(&counting.simulations))
+}
@@ -0,0 +1,214 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// DefaultHaltIntervals is the default number of intervals without new blocks after which the chain is considered halted.
+const DefaultHaltIntervals = 3
+
+// ErrChainHalted is returned when the chain is halted.
+var ErrChainHalted = errors.New("chain is halted")
+
+// HaltDetection configures detection of chain halts. The height is sampled every Interval
+// and the chain is considered halted when the height doesn't advance for Intervals samples in a row.
+type HaltDetection struct {
+	// Interval is an interval of sampling the height. Detection is disabled when it's zero.
+	Interval time.Duration
+	// Intervals is a number of samples without new blocks after which the chain is considered halted.
+	// DefaultHaltIntervals is used by default.
+	Intervals int
+	// RejectBroadcasts makes broadcasts fail with ErrChainHalted while the chain is halted.
+	RejectBroadcasts bool
+	// OnChange is called when the chain halts or resumes.
+	OnChange func(halted bool, height uint64)
+}
+
+// Stats contains broadcaster's state.
+type Stats struct {
+	// Height is the last height sampled by halt detection.
+	Height uint64
+	// Halted is true when the chain is considered halted.
+	Halted bool
+
+	// HedgesFired is the number of hedged broadcasts which sent tx to the secondary node.
+	HedgesFired uint64
+	// HedgesSecondaryWon is the number of hedged broadcasts where the secondary node responded first.
+	HedgesSecondaryWon uint64
+
+	// PropagationFailures is the number of txs which haven't reached the second node in time.
+	PropagationFailures uint64
+
+	// NodeVersion is the node's version detected at start.
+	NodeVersion NodeVersion
+
+	// Conns contains stats of connections to the primary node.
+	Conns ConnStats
+
+	// AccountMissing is true when the account wasn't found on chain on the last lookup.
+	AccountMissing bool
+	// SequenceMismatches is the number of sequence mismatches within SequenceContention.Window.
+	SequenceMismatches int
+
+	// Balance is the last spendable balance sampled by the balance watch.
+	Balance sdk.Coins
+	// BalanceState is the state of the balance.
+	BalanceState BalanceState
+
+	// InFlight is the number of broadcasts which are simulating or talking to the node now.
+	InFlight int
+	// ResultStoreFailures is the number of results Config.ResultStore failed to save.
+	ResultStoreFailures uint64
+
+	// GasEstimates are gas estimates learned by adaptive gas estimation by signatures.
+	GasEstimates map[string]GasEstimate
+}
+
+// haltWatcher samples the height in background and detects halts.
+type haltWatcher struct {
+	cfg       HaltDetection
+	clock     Clock
+	jitter    *jitterSource
+	getHeight func(ctx context.Context) (uint64, error)
+
+	mu     sync.Mutex
+	height uint64
+	stale  int
+	halted bool
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newHaltWatcher(cfg HaltDetection, clock Clock, jitter *jitterSource, getHeight func(ctx context.Context) (uint64, error)) *haltWatcher {
+	if cfg.Intervals <= 0 {
+		cfg.Intervals = DefaultHaltIntervals
+	}
+
+	w := &haltWatcher{
+		cfg:       cfg,
+		clock:     clock,
+		jitter:    jitter,
+		getHeight: getHeight,
+
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w
+}
+
+func (w *haltWatcher) run() {
+	defer close(w.done)
+
+	for {
+		// Up to a tenth of the interval is added, so replicas don't sample in lockstep.
+		timer := w.clock.NewTimer(w.cfg.Interval + w.jitter.apply(JitterFull, w.cfg.Interval/10))
+
+		select {
+		case <-w.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+			w.sample()
+		}
+	}
+}
+
+func (w *haltWatcher) sample() {
+	ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Interval)
+	defer cancel()
+
+	// Unavailable node says nothing about the chain, so failed samples are skipped.
+	height, err := w.getHeight(ctx)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	changed := false
+	if height > w.height {
+		w.height, w.stale = height, 0
+		if w.halted {
+			w.halted, changed = false, true
+		}
+	} else {
+		w.stale++
+		if w.stale >= w.cfg.Intervals && !w.halted {
+			w.halted, changed = true, true
+		}
+	}
+	halted := w.halted
+	w.mu.Unlock()
+
+	if changed && w.cfg.OnChange != nil {
+		w.cfg.OnChange(halted, height)
+	}
+}
+
+// state returns the last sampled height and whether the chain is halted.
+func (w *haltWatcher) state() (uint64, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.height, w.halted
+}
+
+// close stops the watcher and waits for it to exit.
+func (w *haltWatcher) close() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+// Stats returns broadcaster's state.
+func (b *broadcaster) Stats() Stats {
+	s := Stats{
+		HedgesFired:        atomic.LoadUint64(&b.hedgeStats.fired),
+		HedgesSecondaryWon: atomic.LoadUint64(&b.hedgeStats.secondaryWon),
+
+		PropagationFailures: atomic.LoadUint64(&b.propagationFailures),
+
+		NodeVersion: b.nodeVersion,
+		Conns:       b.client.ConnStats(),
+
+		AccountMissing:     b.isAccountMissing(),
+		SequenceMismatches: b.sequenceMismatches(),
+
+		InFlight:            int(atomic.LoadInt64(&b.inFlight)),
+		ResultStoreFailures: atomic.LoadUint64(&b.resultStoreFailures),
+
+		GasEstimates: b.GasEstimates(),
+	}
+	if b.halt != nil {
+		s.Height, s.Halted = b.halt.state()
+	}
+	if b.balanceWatch != nil {
+		s.BalanceState, s.Balance = b.balanceWatch.get()
+	}
+
+	return s
+}
+
+// checkHalt returns ErrChainHalted if the chain is halted and broadcasts should be rejected.
+func (b *broadcaster) checkHalt() error {
+	if b.halt == nil || !b.cfg.HaltDetection.RejectBroadcasts {
+		return nil
+	}
+
+	if _, halted := b.halt.state(); halted {
+		return ErrChainHalted
+	}
+
+	return nil
+}
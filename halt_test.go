@@ -0,0 +1,92 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestHaltDetection(t *testing.T) {
+	const interval = 10 * time.Second
+
+	node, key := newFakeChain(t)
+	clock := testutil.NewFakeClock(time.Now())
+
+	type change struct {
+		halted bool
+		height uint64
+	}
+	changes := make(chan change, 10)
+
+	cfg := testConfig(node, key)
+	cfg.Clock = clock
+	cfg.HaltDetection = broadcaster.HaltDetection{
+		Interval:         interval,
+		Intervals:        3,
+		RejectBroadcasts: true,
+		OnChange: func(halted bool, height uint64) {
+			changes <- change{halted: halted, height: height}
+		},
+	}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// sample lets the watcher sample the height once. The watcher's next timer means the sample is done.
+	sample := func() {
+		clock.BlockUntil(1)
+		clock.Advance(interval + interval/10)
+		clock.BlockUntil(1)
+	}
+
+	height := uint64(node.NextBlock())
+	sample()
+	require.Equal(t, broadcaster.Stats{Height: height}, haltStats(b.Stats()))
+
+	// The height doesn't advance for two intervals, it's not a halt yet.
+	sample()
+	sample()
+	require.False(t, b.Stats().Halted)
+	require.Empty(t, changes)
+
+	sample()
+	require.Equal(t, change{halted: true, height: height}, <-changes)
+	require.Equal(t, broadcaster.Stats{Height: height, Halted: true}, haltStats(b.Stats()))
+	require.ErrorIs(t, b.Ready(context.Background()), broadcaster.ErrChainHalted)
+
+	_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.ErrorIs(t, err, broadcaster.ErrChainHalted)
+	require.Empty(t, node.Mempool())
+
+	// Failed samples say nothing about the chain.
+	node.SetDown(true)
+	sample()
+	node.SetDown(false)
+	require.True(t, b.Stats().Halted)
+	require.Empty(t, changes)
+
+	height = uint64(node.NextBlock())
+	sample()
+	require.Equal(t, change{halted: false, height: height}, <-changes)
+	require.Equal(t, broadcaster.Stats{Height: height}, haltStats(b.Stats()))
+	require.NoError(t, b.Ready(context.Background()))
+
+	_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	// The watcher stops on Close.
+	require.NoError(t, b.Close())
+	require.Zero(t, clock.Waiters())
+}
+
+// haltStats returns stats of halt detection only.
+func haltStats(s broadcaster.Stats) broadcaster.Stats {
+	return broadcaster.Stats{Height: s.Height, Halted: s.Halted}
+}
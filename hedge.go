@@ -0,0 +1,98 @@
+package broadcaster
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+// DefaultHedgeDelay is the default delay before tx is sent to the secondary node.
+const DefaultHedgeDelay = 200 * time.Millisecond
+
+// hedgeStats counts hedged broadcasts.
+type hedgeStats struct {
+	fired        uint64
+	secondaryWon uint64
+}
+
+// nodeResponse is a response of a single node.
+type nodeResponse struct {
+	resp      *sdk.TxResponse
+	err       error
+	secondary bool
+}
+
+// broadcastHedged sends tx to the primary node and, after the hedge delay or primary's failure,
+// to the secondary one. The first accepted response wins and the other request is cancelled.
+func (b *broadcaster) broadcastHedged(ctx context.Context, clientCtx client.Context, txBytes []byte) (*sdk.TxResponse, error) {
+	if len(b.extraClients) == 0 {
+		return b.sendTx(ctx, clientCtx, txBytes)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan nodeResponse, 2)
+	send := func(c client.Context, secondary bool) {
+		// Only the primary node is reachable through the gRPC tx service.
+		send := b.sendTx
+		if secondary {
+			send = broadcastTx
+		}
+		resp, err := send(ctx, c, txBytes)
+		ch <- nodeResponse{resp: resp, err: err, secondary: secondary}
+	}
+
+	go send(clientCtx, false)
+
+	timer := b.cfg.clock().NewTimer(b.cfg.hedgeDelay())
+	defer timer.Stop()
+
+	fire := func() {
+		atomic.AddUint64(&b.hedgeStats.fired, 1)
+		c := b.extraClients[0]
+		go send(clientCtx.WithClient(c.Client()).WithNodeURI(b.cfg.ExtraNodeURIs[0]), true)
+	}
+
+	var (
+		best    *nodeResponse
+		pending = 1
+		fired   = false
+	)
+	for pending > 0 {
+		select {
+		case <-timer.C():
+			if !fired {
+				fired = true
+				pending++
+				fire()
+			}
+		case r := <-ch:
+			pending--
+
+			if r.err == nil && r.resp.Code != sdkerrors.ErrTxInMempoolCache.ABCICode() {
+				if r.secondary {
+					atomic.AddUint64(&b.hedgeStats.secondaryWon, 1)
+				}
+				return r.resp, nil
+			}
+
+			// Mempool cache response means the other node has already accepted tx, so it's preferred over errors.
+			if best == nil || (best.err != nil && r.err == nil) {
+				best = &r
+			}
+
+			if !fired {
+				fired = true
+				pending++
+				fire()
+			}
+		}
+	}
+
+	return best.resp, best.err
+}
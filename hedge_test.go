@@ -0,0 +1,83 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestBroadcast_Hedge(t *testing.T) {
+	tt := []struct {
+		name         string
+		setup        func(primary, secondary *testutil.FakeNode)
+		fired        uint64
+		secondaryWon uint64
+	}{
+		{
+			name:  "fast primary",
+			setup: func(_, secondary *testutil.FakeNode) { secondary.SetLatency(time.Second) },
+		},
+		{
+			name:         "slow primary",
+			setup:        func(primary, _ *testutil.FakeNode) { primary.SetLatency(time.Second) },
+			fired:        1,
+			secondaryWon: 1,
+		},
+		{
+			name: "failed primary",
+			setup: func(primary, _ *testutil.FakeNode) {
+				primary.FailNext("broadcast_tx_sync", errors.New("connection reset"))
+			},
+			fired:        1,
+			secondaryWon: 1,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			primary, key := newFakeChain(t)
+			secondary := testutil.NewFakeNode()
+			secondary.AddAccount(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+			srv := secondary.Serve()
+			defer srv.Close()
+
+			cfg := testConfig(primary, key)
+			cfg.ExtraNodeURIs = []string{srv.URL}
+			cfg.HedgeDelay = 100 * time.Millisecond
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			// The account is loaded and the gas is fixed, so only the broadcast itself is slow.
+			require.NoError(t, b.Ready(context.Background()))
+			tc.setup(primary, secondary)
+
+			start := time.Now()
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+				broadcaster.BroadcastOptions{Gas: 100000, Hedge: true})
+			require.NoError(t, err)
+			require.Zero(t, res.Response.Code)
+			require.Less(t, time.Since(start), time.Second, "the faster response is returned")
+
+			winner := primary
+			if tc.secondaryWon > 0 {
+				winner = secondary
+			}
+			requireCommitted(t, winner, res.TxHash)
+
+			stats := b.Stats()
+			require.Equal(t, tc.fired, stats.HedgesFired)
+			require.Equal(t, tc.secondaryWon, stats.HedgesSecondaryWon)
+		})
+	}
+}
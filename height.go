@@ -0,0 +1,101 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BlockInfo describes the latest block known to the node.
+type BlockInfo struct {
+	Height  uint64
+	Time    time.Time
+	Hash    string
+	ChainID string
+}
+
+// blockCache keeps the last fetched block info for Config.HeightCacheTTL.
+type blockCache struct {
+	mu    sync.Mutex // mu is held during refresh, so concurrent callers share a single request.
+	block BlockInfo
+	at    time.Time
+}
+
+// LatestBlock returns height, time and hash of the latest block fetched by a single request.
+// It's served from cache when Config.HeightCacheTTL is set.
+func (b *broadcaster) LatestBlock(ctx context.Context) (BlockInfo, error) {
+	if b.cfg.HeightCacheTTL <= 0 {
+		return b.fetchLatestBlock(ctx)
+	}
+
+	b.blockCache.mu.Lock()
+	defer b.blockCache.mu.Unlock()
+
+	if !b.blockCache.at.IsZero() && b.cfg.clock().Now().Sub(b.blockCache.at) < b.cfg.HeightCacheTTL {
+		return b.blockCache.block, nil
+	}
+
+	block, err := b.fetchLatestBlock(ctx)
+	if err != nil {
+		return BlockInfo{}, err
+	}
+	b.blockCache.block, b.blockCache.at = block, b.cfg.clock().Now()
+
+	return block, nil
+}
+
+// GetHeight returns current height. It's served from cache when Config.HeightCacheTTL is set.
+func (b *broadcaster) GetHeight(ctx context.Context) (uint64, error) {
+	block, err := b.LatestBlock(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return block.Height, nil
+}
+
+// GetHeightFresh returns current height fetched from the node bypassing the cache.
+func (b *broadcaster) GetHeightFresh(ctx context.Context) (uint64, error) {
+	block, err := b.fetchLatestBlock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	b.observeBlock(block)
+
+	return block.Height, nil
+}
+
+// fetchLatestBlock fetches the latest block info from the node.
+func (b *broadcaster) fetchLatestBlock(ctx context.Context) (BlockInfo, error) {
+	c, err := b.ctx.GetNode()
+	if err != nil {
+		return BlockInfo{}, fmt.Errorf("failed get node: %w", err)
+	}
+
+	status, err := c.Status(ctx)
+	if err != nil {
+		return BlockInfo{}, fmt.Errorf("failed to fetch status: %w", err)
+	}
+
+	return BlockInfo{
+		Height:  uint64(status.SyncInfo.LatestBlockHeight),
+		Time:    status.SyncInfo.LatestBlockTime,
+		Hash:    status.SyncInfo.LatestBlockHash.String(),
+		ChainID: status.NodeInfo.Network,
+	}, nil
+}
+
+// observeBlock feeds the cache with the block fetched elsewhere.
+func (b *broadcaster) observeBlock(block BlockInfo) {
+	if b.cfg.HeightCacheTTL <= 0 {
+		return
+	}
+
+	b.blockCache.mu.Lock()
+	defer b.blockCache.mu.Unlock()
+
+	if block.Height >= b.blockCache.block.Height {
+		b.blockCache.block, b.blockCache.at = block, b.cfg.clock().Now()
+	}
+}
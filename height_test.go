@@ -0,0 +1,135 @@
+package broadcaster_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestGetHeight_Cache(t *testing.T) {
+	const (
+		ttl     = time.Second
+		callers = 16
+	)
+
+	node, key := newFakeChain(t)
+	clock := testutil.NewFakeClock(time.Now())
+
+	cfg := testConfig(node, key)
+	cfg.Clock = clock
+	cfg.HeightCacheTTL = ttl
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	calls := node.Calls("status")
+
+	// getHeights calls GetHeight concurrently and returns the heights.
+	getHeights := func() map[uint64]bool {
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			heights = map[uint64]bool{}
+		)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				h, err := b.GetHeight(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				mu.Lock()
+				heights[h] = true
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		return heights
+	}
+
+	first := uint64(node.NextBlock())
+	require.Equal(t, map[uint64]bool{first: true}, getHeights())
+	require.Equal(t, calls+1, node.Calls("status"), "concurrent callers share a single request")
+
+	// The height is cached within the window.
+	node.NextBlock()
+	clock.Advance(ttl - time.Millisecond)
+	require.Equal(t, map[uint64]bool{first: true}, getHeights())
+	require.Equal(t, calls+1, node.Calls("status"))
+
+	// The fresh height bypasses the cache and feeds it.
+	second, err := b.GetHeightFresh(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, first+1, second)
+	require.Equal(t, calls+2, node.Calls("status"))
+	require.Equal(t, map[uint64]bool{second: true}, getHeights())
+	require.Equal(t, calls+2, node.Calls("status"))
+
+	// The cache is refreshed past the window.
+	third := uint64(node.NextBlock())
+	clock.Advance(ttl)
+	require.Equal(t, map[uint64]bool{third: true}, getHeights())
+	require.Equal(t, calls+3, node.Calls("status"))
+}
+
+func TestGetHeight_NoCache(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	calls := node.Calls("status")
+	for i := 0; i < 3; i++ {
+		h, err := b.GetHeight(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(node.NextBlock()-1), h)
+	}
+	require.Equal(t, calls+3, node.Calls("status"))
+}
+
+func TestLatestBlock(t *testing.T) {
+	node, key := newFakeChain(t)
+	clock := testutil.NewFakeClock(time.Now())
+
+	cfg := testConfig(node, key)
+	cfg.Clock = clock
+	cfg.HeightCacheTTL = time.Second
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	height := node.NextBlockAt(time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC))
+	calls := node.Calls("status")
+
+	block, err := b.LatestBlock(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, calls+1, node.Calls("status"), "all fields come from a single request")
+
+	// The fields describe the same block.
+	res, err := node.Commit(context.Background(), &height)
+	require.NoError(t, err)
+	require.Equal(t, uint64(height), block.Height)
+	require.True(t, res.Header.Time.Equal(block.Time), "%s != %s", res.Header.Time, block.Time)
+	require.Equal(t, res.Header.Hash().String(), block.Hash)
+	require.Equal(t, testutil.FakeChainID, block.ChainID)
+
+	// GetHeight is served by the same cached block.
+	h, err := b.GetHeight(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, block.Height, h)
+	require.Equal(t, calls+1, node.Calls("status"))
+}
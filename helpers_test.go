@@ -0,0 +1,63 @@
+package broadcaster_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+const testDenom = "udec"
+
+func TestMain(m *testing.M) {
+	// Every broadcaster encrypts its key to import it into the memory keyring. Armors don't leave tests,
+	// so the cheapest bcrypt cost is used, the default one takes seconds under the race detector.
+	crypto.BcryptSecurityParameter = 4
+
+	os.Exit(m.Run())
+}
+
+// newFakeChain returns fake node with the funded account of the returned key.
+func newFakeChain(t *testing.T) (*testutil.FakeNode, testutil.Key) {
+	t.Helper()
+
+	node := testutil.NewFakeNode()
+	key := testutil.NewKey(t.Name())
+	node.AddAccount(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+	return node, key
+}
+
+// testConfig returns config of broadcaster signing with the key in memory keyring and talking to the node.
+func testConfig(node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+	return broadcaster.Config{
+		KeyringBackend: keyring.BackendMemory,
+		PrivKeyHex:     key.PrivKeyHex,
+		From:           "test",
+		ChainID:        testutil.FakeChainID,
+		RPCClient:      node,
+		BroadcastMode:  broadcaster.ModeSync,
+	}
+}
+
+// sendMsg returns bank transfer of the amount from the key to a new address.
+func sendMsg(from sdk.AccAddress, amount int64) *banktypes.MsgSend {
+	return banktypes.NewMsgSend(from, testutil.NewKey("recipient").Address, sdk.NewCoins(sdk.NewInt64Coin(testDenom, amount)))
+}
+
+// requireCommitted commits the next block and checks that tx with the hash succeeded.
+func requireCommitted(t *testing.T, node *testutil.FakeNode, hash string) {
+	t.Helper()
+
+	node.NextBlock()
+	res, ok := node.CommittedTx(hash)
+	require.True(t, ok, "tx %s is not committed", hash)
+	require.Zero(t, res.TxResult.Code, res.TxResult.Log)
+}
@@ -0,0 +1,142 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Idempotency key is embedded into the memo as MemoKeyIdempotency field, i.e. "idk=<key>". It is separated from
+// the rest of the memo with ";", e.g. "follow me back;idk=3f1a", and escaped like MemoBuilder does. So the key costs
+// len(key)+4 characters of the memo, and one more if the memo isn't empty.
+// The memo is limited by the chain's auth params (MaxMemoCharacters by default).
+const (
+	idempotencyKeyPrefix    = MemoKeyIdempotency + "="
+	idempotencyKeySeparator = ";"
+)
+
+// DefaultIdempotencySearchLimit is the number of the account's latest txs searched for idempotency key.
+const DefaultIdempotencySearchLimit = 1000
+
+// ErrTxNotFound is returned when tx is not found.
+var ErrTxNotFound = errors.New("tx is not found")
+
+// ErrInvalidIdempotencyKey is returned when idempotency key can't be embedded into the memo.
+var ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+
+// IdempotentTx is tx found by idempotency key.
+type IdempotentTx struct {
+	TxHash string
+	// Pending is true when tx is in mempool.
+	Pending bool
+	// Response is the result of committed tx. It is nil for pending tx.
+	Response *sdk.TxResponse
+}
+
+// IdempotentMemo returns memo with embedded idempotency key.
+func IdempotentMemo(memo, key string) string {
+	if key == "" {
+		return memo
+	}
+
+	if memo == "" {
+		return idempotencyKeyPrefix + escapeMemo(key)
+	}
+
+	return memo + idempotencyKeySeparator + idempotencyKeyPrefix + escapeMemo(key)
+}
+
+// ParseIdempotencyKey returns idempotency key embedded into the memo.
+func ParseIdempotencyKey(memo string) (string, bool) {
+	fields := splitMemo(memo, idempotencyKeySeparator[0])
+	for i := len(fields) - 1; i >= 0; i-- {
+		if key := strings.TrimPrefix(fields[i], idempotencyKeyPrefix); key != fields[i] && key != "" {
+			return unescapeMemo(key), true
+		}
+	}
+
+	return "", false
+}
+
+// validateIdempotencyKey checks that key fits into the memo.
+func validateIdempotencyKey(key string) error {
+	if len(IdempotentMemo("", key)) > MaxMemoCharacters {
+		return fmt.Errorf("%w: key is too long", ErrInvalidIdempotencyKey)
+	}
+
+	return nil
+}
+
+// FindTxByIdempotencyKey looks for the broadcaster's tx with the idempotency key in mempool
+// and among DefaultIdempotencySearchLimit latest committed txs. ErrTxNotFound is returned if there is no such tx.
+// It should be used after restart to decide whether the tx should be broadcast again.
+func (b *broadcaster) FindTxByIdempotencyKey(ctx context.Context, key string) (*IdempotentTx, error) {
+	if key == "" {
+		return nil, fmt.Errorf("%w: key is empty", ErrInvalidIdempotencyKey)
+	}
+
+	node, err := b.ctx.GetNode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	// Mempool is checked first, since tx could be committed between two requests.
+	limit := 100
+	unconfirmed, err := node.UnconfirmedTxs(ctx, &limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unconfirmed txs: %w", err)
+	}
+
+	for _, v := range unconfirmed.Txs {
+		if b.hasIdempotencyKey(v, key) {
+			return &IdempotentTx{TxHash: TxHash(v), Pending: true}, nil
+		}
+	}
+
+	query := fmt.Sprintf("%s.%s='%s'", sdk.EventTypeMessage, sdk.AttributeKeySender, b.From())
+	perPage := 100
+	for page := 1; (page-1)*perPage < DefaultIdempotencySearchLimit; page++ {
+		res, err := node.TxSearch(ctx, query, false, &page, &perPage, "desc")
+		if err != nil {
+			return nil, fmt.Errorf("failed to search txs: %w", err)
+		}
+
+		for _, v := range res.Txs {
+			if b.hasIdempotencyKey(v.Tx, key) {
+				return &IdempotentTx{
+					TxHash:   TxHash(v.Tx),
+					Response: sdk.NewResponseResultTx(v, nil, ""),
+				}, nil
+			}
+		}
+
+		if page*perPage >= res.TotalCount {
+			break
+		}
+	}
+
+	return nil, ErrTxNotFound
+}
+
+// hasIdempotencyKey returns true if tx is signed by the broadcaster and its memo contains the key.
+func (b *broadcaster) hasIdempotencyKey(txBytes []byte, key string) bool {
+	tx, err := b.DecodeTx(txBytes, WithUnknownMsgs())
+	if err != nil {
+		return false
+	}
+
+	if k, ok := ParseIdempotencyKey(tx.Memo); !ok || k != key {
+		return false
+	}
+
+	for _, v := range tx.Signers {
+		if v.Equals(b.From()) {
+			return true
+		}
+	}
+
+	return false
+}
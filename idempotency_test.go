@@ -0,0 +1,105 @@
+package broadcaster_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestIdempotentMemo(t *testing.T) {
+	tt := []struct {
+		name string
+		memo string
+		key  string
+		want string
+	}{
+		{name: "empty memo", key: "3f1a", want: "idk=3f1a"},
+		{name: "memo", memo: "follow me back", key: "3f1a", want: "follow me back;idk=3f1a"},
+		{name: "no key", memo: "follow me back", want: "follow me back"},
+		{name: "escaped key", key: `a;b=c\d`, want: `idk=a\;b\=c\\d`},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			memo := broadcaster.IdempotentMemo(tc.memo, tc.key)
+			require.Equal(t, tc.want, memo)
+
+			key, ok := broadcaster.ParseIdempotencyKey(memo)
+			require.Equal(t, tc.key != "", ok)
+			require.Equal(t, tc.key, key)
+		})
+	}
+}
+
+func TestFindTxByIdempotencyKey(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	broadcast := func(idk string) *broadcaster.BroadcastResult {
+		res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "memo",
+			broadcaster.BroadcastOptions{IdempotencyKey: idk})
+		require.NoError(t, err)
+		return res
+	}
+
+	committed := broadcast("committed")
+	requireCommitted(t, node, committed.TxHash)
+	pending := broadcast("pending")
+
+	// The same key used by another account isn't found.
+	other := testutil.NewKey("other")
+	node.AddAccount(other.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+	ob, err := broadcaster.New(testConfig(node, other))
+	require.NoError(t, err)
+	defer ob.Close()
+	_, err = ob.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(other.Address, 1)}, "",
+		broadcaster.BroadcastOptions{IdempotencyKey: "foreign"})
+	require.NoError(t, err)
+
+	tx, err := b.FindTxByIdempotencyKey(context.Background(), "committed")
+	require.NoError(t, err)
+	require.Equal(t, committed.TxHash, tx.TxHash)
+	require.False(t, tx.Pending)
+	require.Zero(t, tx.Response.Code)
+
+	tx, err = b.FindTxByIdempotencyKey(context.Background(), "pending")
+	require.NoError(t, err)
+	require.Equal(t, pending.TxHash, tx.TxHash)
+	require.True(t, tx.Pending)
+	require.Nil(t, tx.Response)
+
+	_, err = b.FindTxByIdempotencyKey(context.Background(), "foreign")
+	require.ErrorIs(t, err, broadcaster.ErrTxNotFound)
+
+	_, err = b.FindTxByIdempotencyKey(context.Background(), "")
+	require.ErrorIs(t, err, broadcaster.ErrInvalidIdempotencyKey)
+
+	// Pending tx is found after it's committed.
+	requireCommitted(t, node, pending.TxHash)
+	tx, err = b.FindTxByIdempotencyKey(context.Background(), "pending")
+	require.NoError(t, err)
+	require.False(t, tx.Pending)
+}
+
+func TestBroadcast_InvalidIdempotencyKey(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+		broadcaster.BroadcastOptions{IdempotencyKey: strings.Repeat("k", broadcaster.MaxMemoCharacters)})
+	require.ErrorIs(t, err, broadcaster.ErrInvalidIdempotencyKey)
+	require.Zero(t, node.Calls("broadcast_tx_sync"))
+}
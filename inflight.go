@@ -0,0 +1,47 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrTooManyInflight is returned when Config.MaxConcurrentBroadcasts is reached and
+// Config.RejectWhenBusy is set.
+var ErrTooManyInflight = errors.New("too many broadcasts in flight")
+
+// acquireSlot takes a slot limited by Config.MaxConcurrentBroadcasts. It waits for a free slot
+// until ctx is done, or fails immediately with ErrTooManyInflight if Config.RejectWhenBusy is set.
+// The returned function releases the slot.
+func (b *broadcaster) acquireSlot(ctx context.Context) (func(), error) {
+	release := func() {
+		b.touch()
+		atomic.AddInt64(&b.inFlight, -1)
+		if b.slots != nil {
+			<-b.slots
+		}
+	}
+
+	if b.slots == nil {
+		atomic.AddInt64(&b.inFlight, 1)
+		return release, nil
+	}
+
+	if b.cfg.RejectWhenBusy {
+		select {
+		case b.slots <- struct{}{}:
+			atomic.AddInt64(&b.inFlight, 1)
+			return release, nil
+		default:
+			return nil, ErrTooManyInflight
+		}
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		atomic.AddInt64(&b.inFlight, 1)
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,132 @@
+package broadcaster_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+func TestMaxConcurrentBroadcasts_Stress(t *testing.T) {
+	const (
+		limit   = 3
+		callers = 32
+	)
+
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.MaxConcurrentBroadcasts = limit
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	var (
+		current, peak int32
+		overflow      int32
+	)
+	slowSimulation(node, 0, func() {
+		n := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		if b.Stats().InFlight > limit {
+			atomic.StoreInt32(&overflow, 1)
+		}
+		time.Sleep(5 * time.Millisecond)
+	})
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, callers)
+	)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			_, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, int64(i+1))}, "", broadcaster.BroadcastOptions{})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.LessOrEqual(t, atomic.LoadInt32(&peak), int32(limit))
+	require.Greater(t, atomic.LoadInt32(&peak), int32(1), "broadcasts are serialized")
+	require.Zero(t, atomic.LoadInt32(&overflow), "in-flight count exceeds the limit")
+	require.Zero(t, b.Stats().InFlight)
+	require.Len(t, node.Mempool(), callers)
+}
+
+func TestMaxConcurrentBroadcasts_Busy(t *testing.T) {
+	tt := []struct {
+		name   string
+		reject bool
+		want   error
+	}{
+		{name: "waiting caller honors deadline", want: context.DeadlineExceeded},
+		{name: "non-blocking mode", reject: true, want: broadcaster.ErrTooManyInflight},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			cfg := testConfig(node, key)
+			cfg.MaxConcurrentBroadcasts = 1
+			cfg.RejectWhenBusy = tc.reject
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			// The first broadcast holds the only slot while it's simulated.
+			entered, unblock := make(chan struct{}), make(chan struct{})
+			var once sync.Once
+			slowSimulation(node, 0, func() {
+				once.Do(func() {
+					close(entered)
+					<-unblock
+				})
+			})
+
+			first := make(chan error, 1)
+			go func() {
+				_, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+				first <- err
+			}()
+			<-entered
+			require.Equal(t, 1, b.Stats().InFlight)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			_, err = b.BroadcastContext(ctx, []sdk.Msg{sendMsg(key.Address, 2)}, "", broadcaster.BroadcastOptions{})
+			require.ErrorIs(t, err, tc.want)
+
+			close(unblock)
+			require.NoError(t, <-first)
+			require.Zero(t, b.Stats().InFlight)
+
+			// The slot is free again.
+			_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 3)}, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ErrMsgRejected is returned when an interceptor rejects a msg.
+var ErrMsgRejected = errors.New("msg is rejected by interceptor")
+
+// Interceptor inspects msg before it's validated and signed. It returns the msg to use instead,
+// which could be msg itself, or an error to abort the broadcast.
+//
+// For example, an interceptor could require an approval carried by the context:
+//
+//	b.AddInterceptor(sdk.MsgTypeURL(&tokentypes.MsgBurn{}), func(ctx context.Context, msg sdk.Msg) (sdk.Msg, error) {
+//		if !hasApproval(ctx) {
+//			return nil, errors.New("burn isn't approved")
+//		}
+//		return msg, nil
+//	})
+type Interceptor func(ctx context.Context, msg sdk.Msg) (sdk.Msg, error)
+
+// InterceptError is returned when an interceptor rejects a msg. It matches ErrMsgRejected.
+type InterceptError struct {
+	// Index is the index of the rejected msg in the batch.
+	Index   int
+	TypeURL string
+	Err     error
+}
+
+func (e *InterceptError) Error() string {
+	return fmt.Sprintf("%s: msg %s at index %d: %s", ErrMsgRejected, e.TypeURL, e.Index, e.Err)
+}
+
+// Is makes InterceptError match ErrMsgRejected.
+func (e *InterceptError) Is(target error) bool {
+	return target == ErrMsgRejected
+}
+
+func (e *InterceptError) Unwrap() error {
+	return e.Err
+}
+
+// interceptors keeps interceptors by msg type urls in order of registration.
+type interceptors struct {
+	mu     sync.RWMutex
+	byType map[string][]Interceptor
+}
+
+// AddInterceptor registers the interceptor of msgs with the type url. Interceptors of a type
+// are run in order of registration, every next one gets the msg returned by the previous one.
+func (b *broadcaster) AddInterceptor(typeURL string, fn Interceptor) {
+	b.interceptors.mu.Lock()
+	defer b.interceptors.mu.Unlock()
+
+	if b.interceptors.byType == nil {
+		b.interceptors.byType = make(map[string][]Interceptor)
+	}
+	b.interceptors.byType[typeURL] = append(b.interceptors.byType[typeURL], fn)
+}
+
+// intercept runs interceptors of msgs and returns msgs to broadcast. The caller's slice isn't modified.
+// Nil msgs are skipped, they're rejected by validation.
+func (b *broadcaster) intercept(ctx context.Context, msgs []sdk.Msg) ([]sdk.Msg, error) {
+	b.interceptors.mu.RLock()
+	defer b.interceptors.mu.RUnlock()
+
+	if len(b.interceptors.byType) == 0 {
+		return msgs, nil
+	}
+
+	out := make([]sdk.Msg, len(msgs))
+	copy(out, msgs)
+
+	for i, msg := range out {
+		if isNilMsg(msg) {
+			continue
+		}
+
+		url := sdk.MsgTypeURL(msg)
+		for _, fn := range b.interceptors.byType[url] {
+			var err error
+			if msg, err = fn(ctx, msg); err != nil {
+				return nil, &InterceptError{Index: i, TypeURL: url, Err: err}
+			}
+		}
+		out[i] = msg
+	}
+
+	return out, nil
+}
@@ -0,0 +1,74 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestInterceptor_Reject(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	errNotApproved := errors.New("multi-send isn't approved")
+	b.AddInterceptor(sdk.MsgTypeURL(&banktypes.MsgMultiSend{}), func(context.Context, sdk.Msg) (sdk.Msg, error) {
+		return nil, errNotApproved
+	})
+
+	msgs := []sdk.Msg{sendMsg(key.Address, 1), multiSendMsg(key.Address, 1)}
+	_, err = b.BroadcastContext(context.Background(), msgs, "", broadcaster.BroadcastOptions{})
+	require.ErrorIs(t, err, broadcaster.ErrMsgRejected)
+	require.ErrorIs(t, err, errNotApproved)
+	require.Equal(t, broadcaster.ClassInvalidRequest, broadcaster.Classify(err))
+
+	var interceptErr *broadcaster.InterceptError
+	require.ErrorAs(t, err, &interceptErr)
+	require.Equal(t, 1, interceptErr.Index)
+	require.Equal(t, sdk.MsgTypeURL(&banktypes.MsgMultiSend{}), interceptErr.TypeURL)
+
+	// The whole batch is aborted.
+	require.Zero(t, node.Calls("broadcast_tx_sync"))
+
+	// Msgs of other types aren't intercepted.
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+}
+
+func TestInterceptor_Rewrite(t *testing.T) {
+	node, key := newFakeChain(t)
+	recipient := testutil.NewKey("recipient").Address
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Interceptors are run in order of registration, each gets the msg of the previous one.
+	url := sdk.MsgTypeURL(&banktypes.MsgSend{})
+	b.AddInterceptor(url, func(_ context.Context, msg sdk.Msg) (sdk.Msg, error) {
+		return sendMsg(key.Address, msg.(*banktypes.MsgSend).Amount.AmountOf(testDenom).Int64()+10), nil
+	})
+	b.AddInterceptor(url, func(_ context.Context, msg sdk.Msg) (sdk.Msg, error) {
+		return sendMsg(key.Address, msg.(*banktypes.MsgSend).Amount.AmountOf(testDenom).Int64()*2), nil
+	})
+
+	msgs := []sdk.Msg{sendMsg(key.Address, 1), multiSendMsg(key.Address, 5)}
+	res, err := b.BroadcastContext(context.Background(), msgs, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+
+	require.EqualValues(t, (1+10)*2+5, node.Balance(recipient).AmountOf(testDenom).Int64())
+
+	// The caller's msgs aren't modified.
+	require.Equal(t, sendMsg(key.Address, 1), msgs[0])
+}
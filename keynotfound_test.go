@@ -0,0 +1,44 @@
+package broadcaster
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// unlistedKeyring is the keyring which fails to list keys.
+type unlistedKeyring struct {
+	keyring.Keyring
+}
+
+func (unlistedKeyring) List() ([]keyring.Info, error) {
+	return nil, errors.New("keyring is locked")
+}
+
+func TestKeyNotFoundError(t *testing.T) {
+	cfg := Config{KeyringBackend: keyring.BackendFile, KeyringRootDir: "/keys"}
+	notFound := fmt.Errorf("missing.info: %w", sdkerrors.ErrKeyNotFound)
+
+	kr := keyring.NewInMemory()
+	_, _, err := kr.NewMnemonic("a", keyring.English, sdk.FullFundraiserPath, keyring.DefaultBIP39Passphrase, hd.Secp256k1)
+	require.NoError(t, err)
+
+	err = keyNotFoundError(kr, cfg, notFound)
+	require.ErrorIs(t, err, sdkerrors.ErrKeyNotFound)
+	require.EqualError(t, err, "missing.info: key not found (backend file, dir /keys, available keys: [a])")
+
+	// The original error is kept when the keys can't be listed.
+	err = keyNotFoundError(unlistedKeyring{Keyring: kr}, cfg, notFound)
+	require.ErrorIs(t, err, sdkerrors.ErrKeyNotFound)
+	require.EqualError(t, err, "missing.info: key not found (backend file, dir /keys)")
+
+	// Other errors are returned as they are.
+	other := errors.New("keyring is broken")
+	require.Equal(t, other, keyNotFoundError(unlistedKeyring{Keyring: kr}, cfg, other))
+}
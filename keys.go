@@ -0,0 +1,233 @@
+package broadcaster
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+// KeyInfo describes a key of the keyring.
+type KeyInfo struct {
+	Name    string
+	Address sdk.AccAddress
+	Algo    string
+}
+
+// ListKeys returns keys of the keyring.
+func (b *broadcaster) ListKeys() ([]KeyInfo, error) {
+	list, err := b.ctx.Keyring.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	out := make([]KeyInfo, len(list))
+	for i, v := range list {
+		out[i] = KeyInfo{
+			Name:    v.GetName(),
+			Address: v.GetAddress(),
+			Algo:    string(v.GetAlgo()),
+		}
+	}
+
+	return out, nil
+}
+
+// ErrAddressMismatch is returned when a key resolves to an address other than the expected one.
+var ErrAddressMismatch = errors.New("key address mismatch")
+
+// VerifyKeyAddress checks that the key of the keyring has the expected bech32 address.
+// Addresses are compared by bytes, so the expected address could have any prefix.
+func (b *broadcaster) VerifyKeyAddress(name, expected string) error {
+	info, err := b.ctx.Keyring.Key(name)
+	if err != nil {
+		return fmt.Errorf("failed to get key: %w", keyNotFoundError(b.ctx.Keyring, b.cfg, err))
+	}
+
+	return verifyAddress(name, info.GetAddress(), expected)
+}
+
+// verifyAddress returns ErrAddressMismatch if the address differs from the expected bech32 address.
+func verifyAddress(name string, address sdk.AccAddress, expected string) error {
+	_, bz, err := bech32.DecodeAndConvert(expected)
+	if err != nil {
+		return fmt.Errorf("invalid expected address %s: %w", expected, err)
+	}
+
+	if !address.Equals(sdk.AccAddress(bz)) {
+		return fmt.Errorf("%w: key %s resolves to %s, %s is expected", ErrAddressMismatch, name, address, expected)
+	}
+
+	return nil
+}
+
+// expectedAddress returns the expected address of the key configured by Config.ExpectedAddress
+// or Config.ExpectedAddresses.
+func (c Config) expectedAddress(name string) (string, bool) {
+	if name == c.From && c.ExpectedAddress != "" {
+		return c.ExpectedAddress, true
+	}
+
+	v, ok := c.ExpectedAddresses[name]
+
+	return v, ok
+}
+
+// ErrKeyExportDisabled is returned when the private key is exported without Config.AllowKeyExport.
+var ErrKeyExportDisabled = errors.New("key export is disabled")
+
+// PubKey returns public key of the signing key.
+func (b *broadcaster) PubKey() (cryptotypes.PubKey, error) {
+	info, err := b.ctx.Keyring.Key(b.signer().name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key: %w", err)
+	}
+
+	return info.GetPubKey(), nil
+}
+
+// PubKeyBech32 returns bech32 encoded public key of the signing key, e.g. "decentrpub1...".
+// The legacy encoding is used, since counterparties of the chain still accept it.
+func (b *broadcaster) PubKeyBech32() (string, error) {
+	pk, err := b.PubKey()
+	if err != nil {
+		return "", err
+	}
+
+	return legacybech32.MarshalPubKey(legacybech32.AccPK, pk)
+}
+
+// PubKeyJSON returns proto-json encoded public key of the signing key, as "decentrd keys show" prints it.
+func (b *broadcaster) PubKeyJSON() ([]byte, error) {
+	pk, err := b.PubKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.enc.Marshaler.MarshalInterfaceJSON(pk)
+}
+
+// ExportArmored returns the signing key's private key armored and encrypted with the passphrase.
+// It fails with ErrKeyExportDisabled unless Config.AllowKeyExport is set.
+func (b *broadcaster) ExportArmored(passphrase string) (string, error) {
+	if !b.cfg.AllowKeyExport {
+		return "", ErrKeyExportDisabled
+	}
+
+	if passphrase == "" {
+		return "", errors.New("passphrase is required")
+	}
+
+	name := b.signer().name
+	armor, err := b.ctx.Keyring.ExportPrivKeyArmor(name, passphrase)
+	if err != nil {
+		return "", fmt.Errorf("failed to export key %s: %w", name, err)
+	}
+
+	return armor, nil
+}
+
+// ErrWrongPassphrase is returned when armored key can't be decrypted with the passphrase.
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+// ImportKey imports the key exported with "decentrd keys export" into the keyring under the name.
+// Import is skipped if the keyring already has the same key with the name.
+func (b *broadcaster) ImportKey(name, armor, passphrase string) error {
+	return importArmoredKey(b.ctx.Keyring, name, armor, passphrase)
+}
+
+// importArmoredKey imports armored key into the keyring unless the keyring already has it.
+// ErrWrongPassphrase is returned if the key can't be decrypted.
+func importArmoredKey(kr keyring.Keyring, name, armor, passphrase string) error {
+	priv, _, err := crypto.UnarmorDecryptPrivKey(armor, passphrase)
+	if isWrongPassphrase(err) {
+		return fmt.Errorf("failed to import key %s: %w", name, ErrWrongPassphrase)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to import key %s: invalid armor: %w", name, err)
+	}
+
+	address := sdk.AccAddress(priv.PubKey().Address())
+	if info, err := kr.Key(name); err == nil {
+		if !info.GetAddress().Equals(address) {
+			return fmt.Errorf("failed to import key %s: keyring has key %s with address %s", name, name, info.GetAddress())
+		}
+		return nil
+	}
+
+	if err := kr.ImportPrivKey(name, armor, passphrase); err != nil {
+		return fmt.Errorf("failed to import key %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// isWrongPassphrase reports whether armored key decryption failed because of the passphrase.
+// The SDK maps the failure to ErrWrongPassword by the message which tendermint v0.34 spells in lowercase,
+// so the message is checked as well.
+func isWrongPassphrase(err error) bool {
+	return errors.Is(err, sdkerrors.ErrWrongPassword) ||
+		err != nil && strings.EqualFold(err.Error(), "ciphertext decryption failed")
+}
+
+// passphraseReader feeds the keyring's prompts with the passphrase. Every read returns a single line,
+// so the passphrase is requested again for every prompt.
+type passphraseReader struct {
+	fn func() (string, error)
+}
+
+func (r passphraseReader) Read(p []byte) (int, error) {
+	pass, err := r.fn()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get keyring passphrase: %w", err)
+	}
+
+	line := pass + "\n"
+	if len(p) < len(line) {
+		return 0, io.ErrShortBuffer
+	}
+
+	return copy(p, line), nil
+}
+
+// keyringInput returns the input of the keyring's prompts.
+func (c Config) keyringInput() io.Reader {
+	// Memory keyring never prompts.
+	if c.KeyringBackend == keyring.BackendMemory {
+		return nil
+	}
+
+	if c.PassphraseFunc != nil {
+		return passphraseReader{fn: c.PassphraseFunc}
+	}
+
+	return strings.NewReader(c.KeyringPromptInput)
+}
+
+// keyNotFoundError extends the error of missing key with names of available keys.
+func keyNotFoundError(kr keyring.Keyring, cfg Config, err error) error {
+	if !errors.Is(err, sdkerrors.ErrKeyNotFound) {
+		return err
+	}
+
+	list, listErr := kr.List()
+	if listErr != nil {
+		return fmt.Errorf("%w (backend %s, dir %s)", err, cfg.KeyringBackend, cfg.KeyringRootDir)
+	}
+
+	names := make([]string, len(list))
+	for i, v := range list {
+		names[i] = v.GetName()
+	}
+
+	return fmt.Errorf("%w (backend %s, dir %s, available keys: [%s])",
+		err, cfg.KeyringBackend, cfg.KeyringRootDir, strings.Join(names, ", "))
+}
@@ -0,0 +1,404 @@
+package broadcaster_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Decentr-net/decentr/config"
+	"github.com/cosmos/cosmos-sdk/crypto"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// fileKeyring creates file keyring in a temp dir with the key protected by the passphrase and returns the dir.
+func fileKeyring(t *testing.T, name string, key testutil.Key, passphrase string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	// The passphrase is entered twice when the keyring is created.
+	kr, err := keyring.New(config.AppName, keyring.BackendFile, dir, strings.NewReader(strings.Repeat(passphrase+"\n", 2)))
+	require.NoError(t, err)
+
+	armor := crypto.EncryptArmorPrivKey(key.PrivKey, "armor", string(hd.Secp256k1Type))
+	require.NoError(t, kr.ImportPrivKey(name, armor, "armor"))
+
+	return dir
+}
+
+func TestPassphraseFunc(t *testing.T) {
+	const passphrase = "vault-secret-passphrase"
+
+	node, key := newFakeChain(t)
+	dir := fileKeyring(t, "operator", key, passphrase)
+
+	fileConfig := func(fn func() (string, error)) broadcaster.Config {
+		cfg := testConfig(node, key)
+		cfg.KeyringBackend = keyring.BackendFile
+		cfg.KeyringRootDir = dir
+		cfg.PrivKeyHex = ""
+		cfg.From = "operator"
+		cfg.PassphraseFunc = fn
+		return cfg
+	}
+
+	t.Run("unlock", func(t *testing.T) {
+		b, err := broadcaster.New(fileConfig(func() (string, error) { return passphrase, nil }))
+		require.NoError(t, err)
+		defer b.Close()
+		require.Equal(t, key.Address, b.From())
+
+		for i := 0; i < 2; i++ {
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+			requireCommitted(t, node, res.TxHash)
+		}
+	})
+
+	t.Run("repeated attempts", func(t *testing.T) {
+		// The keyring asks again after the wrong passphrase.
+		var calls int32
+		b, err := broadcaster.New(fileConfig(func() (string, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return "stale-passphrase", nil
+			}
+			return passphrase, nil
+		}))
+		require.NoError(t, err)
+		defer b.Close()
+		require.Equal(t, key.Address, b.From())
+		require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		const wrong = "wrong-secret-passphrase"
+
+		_, err := broadcaster.New(fileConfig(func() (string, error) { return wrong, nil }))
+		require.Error(t, err)
+		require.NotContains(t, err.Error(), wrong)
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		var calls int32
+		_, err := broadcaster.New(fileConfig(func() (string, error) {
+			atomic.AddInt32(&calls, 1)
+			return "", errors.New("vault is sealed")
+		}))
+		require.Error(t, err)
+		require.NotZero(t, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("static input", func(t *testing.T) {
+		cfg := fileConfig(nil)
+		cfg.KeyringPromptInput = passphrase + "\n"
+
+		b, err := broadcaster.New(cfg)
+		require.NoError(t, err)
+		defer b.Close()
+		require.Equal(t, key.Address, b.From())
+	})
+}
+
+// exportedKey returns the key as "decentrd keys export" exports it from a keyring.
+func exportedKey(t *testing.T, key testutil.Key, passphrase string) string {
+	t.Helper()
+
+	kr, err := keyring.New(config.AppName, keyring.BackendTest, t.TempDir(), nil)
+	require.NoError(t, err)
+	require.NoError(t, kr.ImportPrivKey("exported", crypto.EncryptArmorPrivKey(key.PrivKey, "armor", string(hd.Secp256k1Type)), "armor"))
+
+	armor, err := kr.ExportPrivKeyArmor("exported", passphrase)
+	require.NoError(t, err)
+
+	return armor
+}
+
+func TestNew_ArmoredKey(t *testing.T) {
+	const passphrase = "export-secret-passphrase"
+
+	node, key := newFakeChain(t)
+	armor := exportedKey(t, key, passphrase)
+
+	armoredConfig := func(backend, dir string) broadcaster.Config {
+		cfg := testConfig(node, key)
+		cfg.KeyringBackend = backend
+		cfg.KeyringRootDir = dir
+		cfg.PrivKeyHex = ""
+		cfg.ArmoredKey = armor
+		cfg.ArmorPassphrase = passphrase
+		return cfg
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		b, err := broadcaster.New(armoredConfig(keyring.BackendMemory, ""))
+		require.NoError(t, err)
+		defer b.Close()
+		require.Equal(t, key.Address, b.From())
+
+		res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+		require.NoError(t, err)
+		requireCommitted(t, node, res.TxHash)
+	})
+
+	t.Run("already imported", func(t *testing.T) {
+		cfg := armoredConfig(keyring.BackendTest, t.TempDir())
+
+		for i := 0; i < 2; i++ {
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			require.Equal(t, key.Address, b.From())
+			b.Close()
+		}
+
+		// The keyring has another key under the name.
+		cfg.ArmoredKey = exportedKey(t, testutil.NewKey("other"), passphrase)
+		_, err := broadcaster.New(cfg)
+		require.ErrorContains(t, err, "keyring has key test with address "+key.Address.String())
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		const wrong = "wrong-secret-passphrase"
+
+		cfg := armoredConfig(keyring.BackendMemory, "")
+		cfg.ArmorPassphrase = wrong
+
+		_, err := broadcaster.New(cfg)
+		require.ErrorIs(t, err, broadcaster.ErrWrongPassphrase)
+		require.NotContains(t, err.Error(), wrong)
+		require.NotContains(t, err.Error(), passphrase)
+	})
+
+	t.Run("invalid armor", func(t *testing.T) {
+		cfg := armoredConfig(keyring.BackendMemory, "")
+		cfg.ArmoredKey = "not an armor"
+
+		_, err := broadcaster.New(cfg)
+		require.ErrorContains(t, err, "invalid armor")
+		require.NotErrorIs(t, err, broadcaster.ErrWrongPassphrase)
+	})
+}
+
+func TestImportKey(t *testing.T) {
+	const passphrase = "export-secret-passphrase"
+
+	node, key := newFakeChain(t)
+	other := testutil.NewKey("other")
+	node.AddAccount(other.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+	armor := exportedKey(t, other, passphrase)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	require.ErrorIs(t, b.ImportKey("other", armor, "wrong"), broadcaster.ErrWrongPassphrase)
+
+	require.NoError(t, b.ImportKey("other", armor, passphrase))
+	require.NoError(t, b.ImportKey("other", armor, passphrase))
+	require.ErrorContains(t, b.ImportKey("test", armor, passphrase), "keyring has key test")
+
+	require.NoError(t, b.SwitchKey(context.Background(), "other"))
+	require.Equal(t, other.Address, b.From())
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(other.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, other.Address, res.Signer)
+	requireCommitted(t, node, res.TxHash)
+}
+
+func TestListKeys(t *testing.T) {
+	const passphrase = "export-secret-passphrase"
+
+	node, key := newFakeChain(t)
+	other := testutil.NewKey("other")
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	keys, err := b.ListKeys()
+	require.NoError(t, err)
+	require.Equal(t, []broadcaster.KeyInfo{{Name: "test", Address: key.Address, Algo: string(hd.Secp256k1Type)}}, keys)
+
+	require.NoError(t, b.ImportKey("other", exportedKey(t, other, passphrase), passphrase))
+	keys, err = b.ListKeys()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []broadcaster.KeyInfo{
+		{Name: "test", Address: key.Address, Algo: string(hd.Secp256k1Type)},
+		{Name: "other", Address: other.Address, Algo: string(hd.Secp256k1Type)},
+	}, keys)
+
+	// The error of a missing key lists the available ones.
+	err = b.VerifyKeyAddress("missing", key.Address.String())
+	require.ErrorIs(t, err, sdkerrors.ErrKeyNotFound)
+	require.ErrorContains(t, err, "available keys: [")
+	require.ErrorContains(t, err, "other")
+}
+
+func TestPubKey(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	pk, err := b.PubKey()
+	require.NoError(t, err)
+	require.True(t, key.PrivKey.PubKey().Equals(pk))
+
+	bech, err := b.PubKeyBech32()
+	require.NoError(t, err)
+	decoded, err := legacybech32.UnmarshalPubKey(legacybech32.AccPK, bech)
+	require.NoError(t, err)
+	require.True(t, pk.Equals(decoded))
+
+	bz, err := b.PubKeyJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"@type":"/cosmos.crypto.secp256k1.PubKey","key":"`+base64.StdEncoding.EncodeToString(pk.Bytes())+`"}`, string(bz))
+}
+
+func TestExportArmored(t *testing.T) {
+	const passphrase = "export-secret-passphrase"
+
+	node, key := newFakeChain(t)
+
+	t.Run("disabled", func(t *testing.T) {
+		b, err := broadcaster.New(testConfig(node, key))
+		require.NoError(t, err)
+		defer b.Close()
+
+		armor, err := b.ExportArmored(passphrase)
+		require.ErrorIs(t, err, broadcaster.ErrKeyExportDisabled)
+		require.Empty(t, armor)
+		require.NotContains(t, err.Error(), passphrase)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		cfg := testConfig(node, key)
+		cfg.AllowKeyExport = true
+
+		b, err := broadcaster.New(cfg)
+		require.NoError(t, err)
+		defer b.Close()
+
+		_, err = b.ExportArmored("")
+		require.ErrorContains(t, err, "passphrase is required")
+
+		armor, err := b.ExportArmored(passphrase)
+		require.NoError(t, err)
+		require.NotContains(t, armor, key.PrivKeyHex)
+
+		// The export is imported into a fresh keyring.
+		imported := testConfig(node, key)
+		imported.KeyringBackend = keyring.BackendTest
+		imported.KeyringRootDir = t.TempDir()
+		imported.PrivKeyHex = ""
+		imported.ArmoredKey = armor
+		imported.ArmorPassphrase = passphrase
+
+		other, err := broadcaster.New(imported)
+		require.NoError(t, err)
+		defer other.Close()
+		require.Equal(t, key.Address, other.From())
+
+		res, err := other.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+		require.NoError(t, err)
+		requireCommitted(t, node, res.TxHash)
+
+		// The export never ends up in errors.
+		imported.ArmorPassphrase = "wrong"
+		imported.KeyringRootDir = t.TempDir()
+		_, err = broadcaster.New(imported)
+		require.ErrorIs(t, err, broadcaster.ErrWrongPassphrase)
+		require.NotContains(t, err.Error(), armor)
+	})
+}
+
+// bech32Address encodes the address with the prefix.
+func bech32Address(t *testing.T, prefix string, address sdk.AccAddress) string {
+	t.Helper()
+
+	v, err := bech32.ConvertAndEncode(prefix, address)
+	require.NoError(t, err)
+
+	return v
+}
+
+func TestNew_ExpectedAddress(t *testing.T) {
+	node, key := newFakeChain(t)
+	other := testutil.NewKey("other")
+
+	tt := []struct {
+		name     string
+		expected string
+		wantErr  string
+	}{
+		{name: "same address", expected: key.Address.String()},
+		{name: "other prefix", expected: bech32Address(t, "cosmos", key.Address)},
+		{name: "other key", expected: other.Address.String(), wantErr: "key test resolves to " + key.Address.String() + ", " + other.Address.String() + " is expected"},
+		{name: "other key and prefix", expected: bech32Address(t, "cosmos", other.Address), wantErr: "key address mismatch"},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig(node, key)
+			cfg.ExpectedAddress = tc.expected
+
+			b, err := broadcaster.New(cfg)
+			if tc.wantErr != "" {
+				require.ErrorIs(t, err, broadcaster.ErrAddressMismatch)
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer b.Close()
+
+			require.NoError(t, b.VerifyKeyAddress("test", tc.expected))
+			require.ErrorIs(t, b.VerifyKeyAddress("test", bech32Address(t, "cosmos", other.Address)), broadcaster.ErrAddressMismatch)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		cfg := testConfig(node, key)
+		cfg.ExpectedAddress = key.Address.String()[:20]
+
+		_, err := broadcaster.New(cfg)
+		require.ErrorContains(t, err, "invalid expected address")
+		require.NotErrorIs(t, err, broadcaster.ErrAddressMismatch)
+	})
+}
+
+func TestSwitchKey_ExpectedAddress(t *testing.T) {
+	node, key := newFakeChain(t)
+	other := testutil.NewKey("other")
+	node.AddAccount(other.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+	cfg := switchConfig(node, key, other)
+	cfg.ExpectedAddresses = map[string]string{"other": key.Address.String()}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	require.ErrorIs(t, b.SwitchKey(context.Background(), "other"), broadcaster.ErrAddressMismatch)
+	require.Equal(t, key.Address, b.From())
+
+	cfg.ExpectedAddresses = map[string]string{"other": bech32Address(t, "cosmos", other.Address)}
+	b, err = broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	require.NoError(t, b.SwitchKey(context.Background(), "other"))
+	require.Equal(t, other.Address, b.From())
+}
@@ -0,0 +1,22 @@
+package broadcaster
+
+// Logger is used to report events which don't fail the call.
+type Logger interface {
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+// nopLogger discards everything.
+type nopLogger struct{}
+
+func (nopLogger) Infof(string, ...interface{}) {}
+func (nopLogger) Warnf(string, ...interface{}) {}
+
+// logger returns configured logger or the one which discards everything.
+func (c Config) logger() Logger {
+	if c.Logger == nil {
+		return nopLogger{}
+	}
+
+	return c.Logger
+}
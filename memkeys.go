@@ -0,0 +1,117 @@
+package broadcaster
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// KeySeed is a key created in the memory keyring. Exactly one of Mnemonic and PrivKeyHex should be set.
+type KeySeed struct {
+	Name     string
+	Mnemonic string
+	// PrivKeyHex is hex encoded secp256k1 private key.
+	PrivKeyHex string
+}
+
+// Validate validates the key seed. Secrets aren't included into errors.
+func (s KeySeed) Validate() error {
+	if s.Name == "" {
+		return errors.New("key name is required")
+	}
+
+	if (s.Mnemonic == "") == (s.PrivKeyHex == "") {
+		return fmt.Errorf("exactly one of mnemonic and private key is required for key %s", s.Name)
+	}
+
+	if s.PrivKeyHex != "" {
+		b, err := hex.DecodeString(s.PrivKeyHex)
+		if err != nil || len(b) != secp256k1.PrivKeySize {
+			return fmt.Errorf("invalid private key of key %s", s.Name)
+		}
+	}
+
+	return nil
+}
+
+// create creates the key in the keyring.
+func (s KeySeed) create(kr keyring.Keyring) error {
+	if s.Mnemonic != "" {
+		if _, err := kr.NewAccount(s.Name, s.Mnemonic, "", sdk.GetConfig().GetFullBIP44Path(), hd.Secp256k1); err != nil {
+			return fmt.Errorf("failed to create key %s from mnemonic", s.Name)
+		}
+		return nil
+	}
+
+	b, err := hex.DecodeString(s.PrivKeyHex)
+	if err != nil {
+		return fmt.Errorf("invalid private key of key %s", s.Name)
+	}
+
+	// The keyring imports only armored keys, so the key is armored with a throwaway passphrase.
+	const passphrase = "memory"
+	armor := crypto.EncryptArmorPrivKey(&secp256k1.PrivKey{Key: b}, passphrase, string(hd.Secp256k1Type))
+	if err := kr.ImportPrivKey(s.Name, armor, passphrase); err != nil {
+		return fmt.Errorf("failed to import private key of key %s", s.Name)
+	}
+
+	return nil
+}
+
+// keySeeds returns keys which should be created in the memory keyring, the From key goes first.
+// The From key is imported from Config.ArmoredKey if it's set.
+func (c Config) keySeeds() []KeySeed {
+	if c.ArmoredKey != "" {
+		return c.Keys
+	}
+
+	return append([]KeySeed{{Name: c.From, Mnemonic: c.Mnemonic, PrivKeyHex: c.PrivKeyHex}}, c.Keys...)
+}
+
+// validateKeySeeds checks that keys are given for the memory keyring only.
+func (c Config) validateKeySeeds() error {
+	if c.KeyringBackend != keyring.BackendMemory {
+		if c.Mnemonic != "" || c.PrivKeyHex != "" || len(c.Keys) > 0 {
+			return errors.New("mnemonic, private key and keys are supported by memory keyring backend only")
+		}
+		return nil
+	}
+
+	if c.ArmoredKey != "" && (c.Mnemonic != "" || c.PrivKeyHex != "") {
+		return errors.New("armored key can't be used with mnemonic or private key")
+	}
+
+	names := make(map[string]bool)
+	if c.ArmoredKey != "" {
+		names[c.From] = true
+	}
+	for _, v := range c.keySeeds() {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+
+		if names[v.Name] {
+			return fmt.Errorf("duplicated key %s", v.Name)
+		}
+		names[v.Name] = true
+	}
+
+	return nil
+}
+
+// seedKeyring creates keys of the config in the memory keyring.
+func (c Config) seedKeyring(kr keyring.Keyring) error {
+	for _, v := range c.keySeeds() {
+		if err := v.create(kr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
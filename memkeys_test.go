@@ -0,0 +1,150 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestConfig_Validate_KeySeeds(t *testing.T) {
+	key := testutil.NewKey(t.Name())
+
+	tt := []struct {
+		name    string
+		update  func(cfg *broadcaster.Config)
+		wantErr string
+	}{
+		{
+			name:   "private key",
+			update: func(cfg *broadcaster.Config) { cfg.PrivKeyHex = key.PrivKeyHex },
+		},
+		{
+			name:   "mnemonic",
+			update: func(cfg *broadcaster.Config) { cfg.Mnemonic = testMnemonic },
+		},
+		{
+			name: "additional keys",
+			update: func(cfg *broadcaster.Config) {
+				cfg.Mnemonic = testMnemonic
+				cfg.Keys = []broadcaster.KeySeed{{Name: "other", PrivKeyHex: key.PrivKeyHex}}
+			},
+		},
+		{
+			name:    "no seed",
+			update:  func(cfg *broadcaster.Config) {},
+			wantErr: "exactly one of mnemonic and private key is required for key test",
+		},
+		{
+			name: "both seeds",
+			update: func(cfg *broadcaster.Config) {
+				cfg.Mnemonic = testMnemonic
+				cfg.PrivKeyHex = key.PrivKeyHex
+			},
+			wantErr: "exactly one of mnemonic and private key is required for key test",
+		},
+		{
+			name:    "invalid private key",
+			update:  func(cfg *broadcaster.Config) { cfg.PrivKeyHex = "zz" + key.PrivKeyHex[2:] },
+			wantErr: "invalid private key of key test",
+		},
+		{
+			name:    "short private key",
+			update:  func(cfg *broadcaster.Config) { cfg.PrivKeyHex = key.PrivKeyHex[2:] },
+			wantErr: "invalid private key of key test",
+		},
+		{
+			name: "unnamed key",
+			update: func(cfg *broadcaster.Config) {
+				cfg.Mnemonic = testMnemonic
+				cfg.Keys = []broadcaster.KeySeed{{PrivKeyHex: key.PrivKeyHex}}
+			},
+			wantErr: "key name is required",
+		},
+		{
+			name: "duplicated key",
+			update: func(cfg *broadcaster.Config) {
+				cfg.Mnemonic = testMnemonic
+				cfg.Keys = []broadcaster.KeySeed{{Name: "test", PrivKeyHex: key.PrivKeyHex}}
+			},
+			wantErr: "duplicated key test",
+		},
+		{
+			name: "other backend",
+			update: func(cfg *broadcaster.Config) {
+				cfg.KeyringBackend = keyring.BackendTest
+				cfg.PrivKeyHex = key.PrivKeyHex
+			},
+			wantErr: "supported by memory keyring backend only",
+		},
+		{
+			name: "other backend with keys",
+			update: func(cfg *broadcaster.Config) {
+				cfg.KeyringBackend = keyring.BackendTest
+				cfg.Keys = []broadcaster.KeySeed{{Name: "other", Mnemonic: testMnemonic}}
+			},
+			wantErr: "supported by memory keyring backend only",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.KeyringBackend = keyring.BackendMemory
+			tc.update(&cfg)
+
+			err := cfg.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.wantErr)
+			// Secrets never end up in errors.
+			require.NotContains(t, err.Error(), key.PrivKeyHex[2:])
+			require.NotContains(t, err.Error(), "abandon")
+		})
+	}
+}
+
+func TestNew_MemoryKeyring_Mnemonic(t *testing.T) {
+	derived, err := hd.Secp256k1.Derive()(testMnemonic, "", sdk.GetConfig().GetFullBIP44Path())
+	require.NoError(t, err)
+	address := sdk.AccAddress(hd.Secp256k1.Generate()(derived).PubKey().Address())
+
+	node := testutil.NewFakeNode()
+	node.AddAccount(address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+	cfg := testConfig(node, testutil.Key{})
+	cfg.PrivKeyHex = ""
+	cfg.Mnemonic = testMnemonic
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+	require.Equal(t, address, b.From())
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, address, res.Signer)
+	requireCommitted(t, node, res.TxHash)
+}
+
+func TestNew_MemoryKeyring_NoSeed(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.PrivKeyHex = ""
+
+	_, err := broadcaster.New(cfg)
+	require.ErrorContains(t, err, "exactly one of mnemonic and private key is required")
+	require.Zero(t, node.Calls("status"))
+}
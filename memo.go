@@ -0,0 +1,146 @@
+package broadcaster
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// MaxMemoCharacters is the default memo limit of the auth module.
+const MaxMemoCharacters = 256
+
+// MemoKeyIdempotency is the memo field which keeps the idempotency key.
+const MemoKeyIdempotency = "idk"
+
+// MemoKeyNonce is the memo field which keeps the nonce appended by Config.UniquifyMemo.
+const MemoKeyNonce = "nonce"
+
+// ErrMemoTooLong is returned when memo exceeds MaxMemoCharacters.
+var ErrMemoTooLong = errors.New("memo is too long")
+
+// MemoBuilder builds memo of key=value fields separated by ";", e.g. "req=abc123;svc=vulcan".
+// Fields are sorted by key, so the result is deterministic. "\", ";" and "=" are escaped with "\".
+type MemoBuilder struct {
+	fields map[string]string
+}
+
+// NewMemoBuilder returns new instance of MemoBuilder.
+func NewMemoBuilder() *MemoBuilder {
+	return &MemoBuilder{
+		fields: make(map[string]string),
+	}
+}
+
+// Set sets the field.
+func (m *MemoBuilder) Set(key, value string) *MemoBuilder {
+	m.fields[key] = value
+	return m
+}
+
+// SetIdempotencyKey sets the idempotency key field.
+func (m *MemoBuilder) SetIdempotencyKey(key string) *MemoBuilder {
+	return m.Set(MemoKeyIdempotency, key)
+}
+
+// String returns the memo. Use Build to check its length.
+func (m *MemoBuilder) String() string {
+	keys := make([]string, 0, len(m.fields))
+	for k := range m.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = escapeMemo(k) + "=" + escapeMemo(m.fields[k])
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Build returns the memo or ErrMemoTooLong if it exceeds MaxMemoCharacters.
+func (m *MemoBuilder) Build() (string, error) {
+	s := m.String()
+	if len(s) > MaxMemoCharacters {
+		return "", fmt.Errorf("%w: %d characters", ErrMemoTooLong, len(s))
+	}
+
+	return s, nil
+}
+
+// nonceMemo returns memo with the nonce appended as MemoKeyNonce field, e.g. "follow me back;nonce=kx3b9".
+func nonceMemo(memo, nonce string) string {
+	if nonce == "" {
+		return memo
+	}
+
+	field := MemoKeyNonce + "=" + escapeMemo(nonce)
+	if memo == "" {
+		return field
+	}
+
+	return memo + ";" + field
+}
+
+// nextMemoNonce returns a nonce which is unique among the broadcaster's txs. Nonces are monotonic and start
+// from the broadcaster's creation time, so they don't repeat after restarts.
+func (b *broadcaster) nextMemoNonce() string {
+	return strconv.FormatUint(atomic.AddUint64(&b.memoNonce, 1), 36)
+}
+
+// ParseMemo parses memo built by MemoBuilder.
+func ParseMemo(memo string) (map[string]string, error) {
+	out := make(map[string]string)
+	if memo == "" {
+		return out, nil
+	}
+
+	for _, field := range splitMemo(memo, ';') {
+		kv := splitMemo(field, '=')
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid memo field %q", field)
+		}
+
+		out[unescapeMemo(kv[0])] = unescapeMemo(kv[1])
+	}
+
+	return out, nil
+}
+
+// splitMemo splits s by unescaped sep. Parts are kept escaped.
+func splitMemo(s string, sep byte) []string {
+	var (
+		out   []string
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(out, s[start:])
+}
+
+func escapeMemo(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `;`, `\;`, `=`, `\=`).Replace(s)
+}
+
+func unescapeMemo(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,210 @@
+package broadcaster_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+func TestMemoBuilder(t *testing.T) {
+	tt := []struct {
+		name   string
+		fields map[string]string
+		want   string
+	}{
+		{name: "empty", want: ""},
+		{name: "sorted", fields: map[string]string{"svc": "vulcan", "req": "abc123"}, want: "req=abc123;svc=vulcan"},
+		{name: "escaped", fields: map[string]string{"a;b": `c=d\`}, want: `a\;b=c\=d\\`},
+		{name: "empty value", fields: map[string]string{"k": ""}, want: "k="},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m := broadcaster.NewMemoBuilder()
+			for k, v := range tc.fields {
+				m.Set(k, v)
+			}
+
+			memo, err := m.Build()
+			require.NoError(t, err)
+			require.Equal(t, tc.want, memo)
+
+			fields, err := broadcaster.ParseMemo(memo)
+			require.NoError(t, err)
+			if tc.fields == nil {
+				require.Empty(t, fields)
+			} else {
+				require.Equal(t, tc.fields, fields)
+			}
+		})
+	}
+}
+
+func TestMemoBuilder_TooLong(t *testing.T) {
+	m := broadcaster.NewMemoBuilder().Set("k", strings.Repeat("v", broadcaster.MaxMemoCharacters-2))
+	_, err := m.Build()
+	require.NoError(t, err)
+
+	// Escaping counts toward the limit.
+	m.Set("k", strings.Repeat(";", broadcaster.MaxMemoCharacters/2))
+	_, err = m.Build()
+	require.ErrorIs(t, err, broadcaster.ErrMemoTooLong)
+}
+
+func TestMemoBuilder_IdempotencyKey(t *testing.T) {
+	memo := broadcaster.NewMemoBuilder().Set("svc", "vulcan").SetIdempotencyKey("3f1a").String()
+	require.Equal(t, "idk=3f1a;svc=vulcan", memo)
+
+	key, ok := broadcaster.ParseIdempotencyKey(memo)
+	require.True(t, ok)
+	require.Equal(t, "3f1a", key)
+}
+
+func TestParseMemo_Invalid(t *testing.T) {
+	for _, memo := range []string{"free text", "a=b;c", "a=b=c", "a=b;"} {
+		_, err := broadcaster.ParseMemo(memo)
+		require.Error(t, err, memo)
+	}
+}
+
+func FuzzMemoRoundTrip(f *testing.F) {
+	f.Add("svc", "vulcan", "req", "abc123")
+	f.Add("a;b", `c=d\`, "", "")
+	f.Add(`\`, `;`, "=", `\\;=`)
+	f.Add("k", "", "idk", "x")
+
+	f.Fuzz(func(t *testing.T, k1, v1, k2, v2 string) {
+		want := map[string]string{k1: v1, k2: v2}
+
+		m := broadcaster.NewMemoBuilder()
+		for k, v := range want {
+			m.Set(k, v)
+		}
+
+		memo := m.String()
+		got, err := broadcaster.ParseMemo(memo)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", memo, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%q is parsed to %v, want %v", memo, got, want)
+		}
+		for k, v := range want {
+			if got[k] != v {
+				t.Fatalf("%q is parsed to %v, want %v", memo, got, want)
+			}
+		}
+
+		if memo != m.String() {
+			t.Fatalf("memo isn't deterministic: %q != %q", memo, m.String())
+		}
+
+		_, err = m.Build()
+		if (len(memo) > broadcaster.MaxMemoCharacters) != (err != nil) {
+			t.Fatalf("length of %q is checked wrong: %v", memo, err)
+		}
+	})
+}
+
+func FuzzParseMemo(f *testing.F) {
+	f.Add("req=abc123;svc=vulcan")
+	f.Add(`a\;b=c\=d\\`)
+	f.Add(`a=b\`)
+	f.Add("free text")
+
+	f.Fuzz(func(t *testing.T, memo string) {
+		fields, err := broadcaster.ParseMemo(memo)
+		if err != nil {
+			return
+		}
+
+		// Parsed fields are built to the memo with the same fields.
+		m := broadcaster.NewMemoBuilder()
+		for k, v := range fields {
+			m.Set(k, v)
+		}
+
+		got, err := broadcaster.ParseMemo(m.String())
+		if err != nil {
+			t.Fatalf("failed to parse rebuilt %q: %v", m.String(), err)
+		}
+		if len(got) != len(fields) {
+			t.Fatalf("rebuilt %q is parsed to %v, want %v", m.String(), got, fields)
+		}
+		for k, v := range fields {
+			if got[k] != v {
+				t.Fatalf("rebuilt %q is parsed to %v, want %v", m.String(), got, fields)
+			}
+		}
+	})
+}
+
+func TestBroadcast_UniquifyMemo(t *testing.T) {
+	for _, uniquify := range []bool{false, true} {
+		uniquify := uniquify
+		t.Run(fmt.Sprintf("uniquify=%t", uniquify), func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			cfg := testConfig(node, key)
+			cfg.UniquifyMemo = uniquify
+
+			broadcast := func() (*broadcaster.BroadcastResult, error) {
+				b, err := broadcaster.New(cfg)
+				require.NoError(t, err)
+				defer b.Close()
+
+				return b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "svc=test",
+					broadcaster.BroadcastOptions{DisableAutoRetry: true})
+			}
+
+			first, err := broadcast()
+			require.NoError(t, err)
+
+			// The tx is lost, so the re-submission is signed with the same sequence,
+			// but the node still keeps the tx in the mempool cache.
+			require.True(t, node.Evict(first.TxHash))
+			node.NextBlock()
+
+			second, err := broadcast()
+			if !uniquify {
+				require.ErrorIs(t, err, broadcaster.ErrTxInMempoolCache)
+				require.Empty(t, first.MemoNonce)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, first.Sequence, second.Sequence)
+			require.NotEqual(t, first.TxHash, second.TxHash)
+			require.NotEqual(t, first.MemoNonce, second.MemoNonce)
+
+			mempool := node.Mempool()
+			require.Len(t, mempool, 1)
+			fields, err := broadcaster.ParseMemo(mempool[0].Memo)
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"svc": "test", broadcaster.MemoKeyNonce: second.MemoNonce}, fields)
+		})
+	}
+}
+
+func TestBroadcast_UniquifyMemo_TooLong(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.UniquifyMemo = true
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// The memo fits the limit, but not with the nonce.
+	memo := strings.Repeat("a", broadcaster.MaxMemoCharacters)
+	_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, memo, broadcaster.BroadcastOptions{})
+	require.ErrorIs(t, err, broadcaster.ErrMemoTooLong)
+	require.Zero(t, node.Calls("broadcast_tx_sync"))
+}
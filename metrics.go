@@ -0,0 +1,112 @@
+package broadcaster
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Labels of msg types used by metrics.
+const (
+	// MsgTypeOther replaces msg types which aren't in Config.MetricsMsgTypes.
+	MsgTypeOther = "other"
+	// maxLabelMsgTypes limits number of msg types joined into a label, the rest is replaced with "...".
+	maxLabelMsgTypes = 3
+)
+
+// Metrics receives observations of broadcasts. It should be safe for concurrent use.
+type Metrics interface {
+	// ObserveAttempt is called after every attempt to send tx to the node.
+	ObserveAttempt(m AttemptMetrics)
+	// ObserveCommit is called when broadcast tx is found committed.
+	ObserveCommit(m CommitMetrics)
+}
+
+// AttemptMetrics describes an attempt to send tx.
+type AttemptMetrics struct {
+	// MsgTypes is a label of msg types of tx: sorted unique type urls joined with comma.
+	MsgTypes string
+	// Outcome is "ok" if tx is accepted or the class of the failure otherwise.
+	Outcome string
+	// Codespace and Code are the node's response code. They're empty if the node didn't respond.
+	Codespace string
+	Code      uint32
+	Latency   time.Duration
+}
+
+// CommitMetrics describes committed tx.
+type CommitMetrics struct {
+	// MsgTypes is a label of msg types of tx, see AttemptMetrics.MsgTypes.
+	MsgTypes  string
+	Codespace string
+	Code      uint32
+	GasUsed   int64
+}
+
+// msgTypesLabel returns the label of msg types. Types outside of Config.MetricsMsgTypes are replaced
+// with MsgTypeOther, so custom types don't blow up the cardinality.
+func (b *broadcaster) msgTypesLabel(msgs []sdk.Msg) string {
+	seen := make(map[string]bool, len(msgs))
+	var types []string
+	for _, msg := range msgs {
+		url := sdk.MsgTypeURL(msg)
+		if len(b.cfg.MetricsMsgTypes) > 0 && !b.metricsMsgTypes[url] {
+			url = MsgTypeOther
+		}
+
+		if !seen[url] {
+			seen[url] = true
+			types = append(types, url)
+		}
+	}
+	sort.Strings(types)
+
+	if len(types) > maxLabelMsgTypes {
+		types = append(types[:maxLabelMsgTypes], "...")
+	}
+
+	return strings.Join(types, ",")
+}
+
+// observeAttempt reports the attempt to Config.Metrics.
+func (b *broadcaster) observeAttempt(msgs []sdk.Msg, resp *sdk.TxResponse, err error, latency time.Duration) {
+	if b.cfg.Metrics == nil {
+		return
+	}
+
+	m := AttemptMetrics{
+		MsgTypes: b.msgTypesLabel(msgs),
+		Outcome:  "ok",
+		Latency:  latency,
+	}
+	switch {
+	case err != nil:
+		m.Outcome = Classify(err).String()
+	case resp.Code != 0:
+		m.Outcome = responseClass(resp).String()
+		m.Codespace, m.Code = resp.Codespace, resp.Code
+	}
+
+	b.cfg.Metrics.ObserveAttempt(m)
+}
+
+// observeCommit reports committed tx to Config.Metrics and the adaptive gas estimator.
+func (b *broadcaster) observeCommit(ctx context.Context, msgs []sdk.Msg, resp *sdk.TxResponse) {
+	if resp == nil {
+		return
+	}
+
+	b.observeGas(ctx, msgs, resp)
+
+	if b.cfg.Metrics != nil {
+		b.cfg.Metrics.ObserveCommit(CommitMetrics{
+			MsgTypes:  b.msgTypesLabel(msgs),
+			Codespace: resp.Codespace,
+			Code:      resp.Code,
+			GasUsed:   resp.GasUsed,
+		})
+	}
+}
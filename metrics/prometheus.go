@@ -0,0 +1,82 @@
+// Package metrics implements broadcaster.Metrics with prometheus.
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+// Prometheus is broadcaster.Metrics which exports observations as prometheus metrics.
+type Prometheus struct {
+	attempts        *prometheus.CounterVec
+	attemptDuration *prometheus.HistogramVec
+	commits         *prometheus.CounterVec
+	gasUsed         *prometheus.HistogramVec
+}
+
+// NewPrometheus returns Prometheus with metrics registered in reg under the namespace.
+func NewPrometheus(reg prometheus.Registerer, namespace string) (*Prometheus, error) {
+	p := &Prometheus{
+		attempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "broadcaster",
+			Name:      "attempts_total",
+			Help:      "Number of attempts to send tx by msg types and outcome.",
+		}, []string{"msg_types", "outcome", "code"}),
+		attemptDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "broadcaster",
+			Name:      "attempt_duration_seconds",
+			Help:      "Latency of attempts to send tx by msg types and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"msg_types", "outcome"}),
+		commits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "broadcaster",
+			Name:      "committed_txs_total",
+			Help:      "Number of committed txs by msg types and result code.",
+		}, []string{"msg_types", "code"}),
+		gasUsed: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "broadcaster",
+			Name:      "gas_used",
+			Help:      "Gas used by committed txs by msg types.",
+			Buckets:   prometheus.ExponentialBuckets(25000, 2, 10),
+		}, []string{"msg_types"}),
+	}
+
+	for _, c := range []prometheus.Collector{p.attempts, p.attemptDuration, p.commits, p.gasUsed} {
+		if err := reg.Register(c); err != nil {
+			return nil, fmt.Errorf("failed to register metrics: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// ObserveAttempt implements broadcaster.Metrics.
+func (p *Prometheus) ObserveAttempt(m broadcaster.AttemptMetrics) {
+	p.attempts.WithLabelValues(m.MsgTypes, m.Outcome, code(m.Codespace, m.Code)).Inc()
+	p.attemptDuration.WithLabelValues(m.MsgTypes, m.Outcome).Observe(m.Latency.Seconds())
+}
+
+// ObserveCommit implements broadcaster.Metrics.
+func (p *Prometheus) ObserveCommit(m broadcaster.CommitMetrics) {
+	p.commits.WithLabelValues(m.MsgTypes, code(m.Codespace, m.Code)).Inc()
+	if m.Code == 0 && m.GasUsed > 0 {
+		p.gasUsed.WithLabelValues(m.MsgTypes).Observe(float64(m.GasUsed))
+	}
+}
+
+// code returns the label of the response code.
+func code(codespace string, code uint32) string {
+	if codespace == "" {
+		return strconv.FormatUint(uint64(code), 10)
+	}
+
+	return codespace + ":" + strconv.FormatUint(uint64(code), 10)
+}
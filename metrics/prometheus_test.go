@@ -0,0 +1,82 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/metrics"
+)
+
+const sendURL = "/cosmos.bank.v1beta1.MsgSend"
+
+func TestPrometheus(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	p, err := metrics.NewPrometheus(reg, "test")
+	require.NoError(t, err)
+
+	p.ObserveAttempt(broadcaster.AttemptMetrics{MsgTypes: sendURL, Outcome: "ok", Latency: time.Second})
+	p.ObserveAttempt(broadcaster.AttemptMetrics{
+		MsgTypes: sendURL, Outcome: "insufficient_funds", Codespace: "sdk", Code: 5, Latency: time.Second,
+	})
+	p.ObserveAttempt(broadcaster.AttemptMetrics{
+		MsgTypes: sendURL, Outcome: "insufficient_funds", Codespace: "sdk", Code: 5, Latency: time.Second,
+	})
+	p.ObserveCommit(broadcaster.CommitMetrics{MsgTypes: sendURL, GasUsed: 70000})
+	p.ObserveCommit(broadcaster.CommitMetrics{MsgTypes: sendURL, Codespace: "sdk", Code: 11, GasUsed: 90000})
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(families))
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	require.ElementsMatch(t, []string{
+		"test_broadcaster_attempts_total",
+		"test_broadcaster_attempt_duration_seconds",
+		"test_broadcaster_committed_txs_total",
+		"test_broadcaster_gas_used",
+	}, names)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP test_broadcaster_attempts_total Number of attempts to send tx by msg types and outcome.
+# TYPE test_broadcaster_attempts_total counter
+test_broadcaster_attempts_total{code="0",msg_types="/cosmos.bank.v1beta1.MsgSend",outcome="ok"} 1
+test_broadcaster_attempts_total{code="sdk:5",msg_types="/cosmos.bank.v1beta1.MsgSend",outcome="insufficient_funds"} 2
+# HELP test_broadcaster_committed_txs_total Number of committed txs by msg types and result code.
+# TYPE test_broadcaster_committed_txs_total counter
+test_broadcaster_committed_txs_total{code="0",msg_types="/cosmos.bank.v1beta1.MsgSend"} 1
+test_broadcaster_committed_txs_total{code="sdk:11",msg_types="/cosmos.bank.v1beta1.MsgSend"} 1
+`), "test_broadcaster_attempts_total", "test_broadcaster_committed_txs_total"))
+
+	// Gas of failed txs isn't observed.
+	for _, f := range families {
+		if f.GetName() != "test_broadcaster_gas_used" {
+			continue
+		}
+		require.Len(t, f.GetMetric(), 1)
+		require.EqualValues(t, 1, f.GetMetric()[0].GetHistogram().GetSampleCount())
+		require.EqualValues(t, 70000, f.GetMetric()[0].GetHistogram().GetSampleSum())
+	}
+}
+
+func TestNewPrometheus_Registered(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	_, err := metrics.NewPrometheus(reg, "test")
+	require.NoError(t, err)
+
+	_, err = metrics.NewPrometheus(reg, "test")
+	require.ErrorContains(t, err, "failed to register metrics")
+
+	// Other namespace doesn't collide.
+	_, err = metrics.NewPrometheus(reg, "other")
+	require.NoError(t, err)
+}
@@ -0,0 +1,161 @@
+package broadcaster_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// recordingMetrics is broadcaster.Metrics which records observations.
+type recordingMetrics struct {
+	mu       sync.Mutex
+	attempts []broadcaster.AttemptMetrics
+	commits  []broadcaster.CommitMetrics
+}
+
+func (m *recordingMetrics) ObserveAttempt(v broadcaster.AttemptMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.attempts = append(m.attempts, v)
+}
+
+func (m *recordingMetrics) ObserveCommit(v broadcaster.CommitMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.commits = append(m.commits, v)
+}
+
+// reset returns recorded observations and forgets them.
+func (m *recordingMetrics) reset() ([]broadcaster.AttemptMetrics, []broadcaster.CommitMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempts, commits := m.attempts, m.commits
+	m.attempts, m.commits = nil, nil
+
+	return attempts, commits
+}
+
+func TestMetrics_MsgTypes(t *testing.T) {
+	var (
+		sendURL      = sdk.MsgTypeURL(&banktypes.MsgSend{})
+		multiSendURL = sdk.MsgTypeURL(&banktypes.MsgMultiSend{})
+		voteURL      = sdk.MsgTypeURL(&govtypes.MsgVote{})
+		depositURL   = sdk.MsgTypeURL(&govtypes.MsgDeposit{})
+	)
+
+	node, key := newFakeChain(t)
+	vote := govtypes.NewMsgVote(key.Address, 1, govtypes.OptionYes)
+	deposit := govtypes.NewMsgDeposit(key.Address, 1, sdk.NewCoins(sdk.NewInt64Coin(testDenom, 1)))
+
+	tt := []struct {
+		name      string
+		allowlist []string
+		msgs      []sdk.Msg
+		label     string
+	}{
+		{
+			name:  "single type",
+			msgs:  []sdk.Msg{sendMsg(key.Address, 1), sendMsg(key.Address, 2)},
+			label: sendURL,
+		},
+		{
+			name:  "sorted unique types",
+			msgs:  []sdk.Msg{sendMsg(key.Address, 1), multiSendMsg(key.Address, 1), sendMsg(key.Address, 2)},
+			label: multiSendURL + "," + sendURL,
+		},
+		{
+			name:  "too many types",
+			msgs:  []sdk.Msg{vote, sendMsg(key.Address, 1), deposit, multiSendMsg(key.Address, 1)},
+			label: multiSendURL + "," + sendURL + "," + depositURL + ",...",
+		},
+		{
+			name:      "allowlist",
+			allowlist: []string{sendURL, voteURL},
+			msgs:      []sdk.Msg{vote, sendMsg(key.Address, 1), deposit, multiSendMsg(key.Address, 1)},
+			label:     sendURL + "," + voteURL + "," + broadcaster.MsgTypeOther,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			metrics := &recordingMetrics{}
+
+			cfg := testConfig(node, key)
+			cfg.Metrics = metrics
+			cfg.MetricsMsgTypes = tc.allowlist
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			_, err = b.BroadcastContext(context.Background(), tc.msgs, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+			node.NextBlock()
+
+			attempts, _ := metrics.reset()
+			require.Len(t, attempts, 1)
+			require.Equal(t, tc.label, attempts[0].MsgTypes)
+			require.Equal(t, "ok", attempts[0].Outcome)
+			require.Zero(t, attempts[0].Code)
+			require.Positive(t, attempts[0].Latency)
+		})
+	}
+}
+
+func TestMetrics_Outcomes(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+	metrics := &recordingMetrics{}
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = broadcaster.ModeCommit
+	cfg.Metrics = metrics
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	sendURL := sdk.MsgTypeURL(&banktypes.MsgSend{})
+
+	// Committed tx is reported with its gas used.
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	attempts, commits := metrics.reset()
+	require.Len(t, attempts, 1)
+	require.Equal(t, "ok", attempts[0].Outcome)
+	require.Equal(t, []broadcaster.CommitMetrics{{MsgTypes: sendURL, GasUsed: res.Response.GasUsed}}, commits)
+	require.Positive(t, res.Response.GasUsed)
+
+	// Every attempt of rejected tx is reported with the class and the code, it isn't committed.
+	node.OnCheckTx(func(testutil.FakeTx) error { return sdkerrors.ErrInsufficientFunds })
+	_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+		broadcaster.BroadcastOptions{Gas: 100000})
+	require.Error(t, err)
+
+	attempts, commits = metrics.reset()
+	require.Len(t, attempts, broadcaster.DefaultMaxAttempts)
+	for _, v := range attempts {
+		require.Equal(t, broadcaster.AttemptMetrics{
+			MsgTypes:  sendURL,
+			Outcome:   broadcaster.ClassInsufficientFunds.String(),
+			Codespace: sdkerrors.ErrInsufficientFunds.Codespace(),
+			Code:      sdkerrors.ErrInsufficientFunds.ABCICode(),
+			Latency:   v.Latency,
+		}, v)
+	}
+	require.Empty(t, commits)
+}
@@ -0,0 +1,89 @@
+package broadcaster
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ErrInvalidOffchainSignature is returned when ADR-036 signature doesn't match the data or the address.
+var ErrInvalidOffchainSignature = errors.New("invalid off-chain signature")
+
+// SignOffchain signs arbitrary data according to ADR-036 with the broadcaster's key.
+// The data is wrapped into MsgSignData of a sign doc with empty chain-id, zero fee, account number and sequence.
+func (b *broadcaster) SignOffchain(data []byte) ([]byte, cryptotypes.PubKey, error) {
+	signBytes, err := offchainSignBytes(b.From(), data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sig, pubKey, err := b.ctx.Keyring.Sign(b.signer().name, signBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign data: %w", err)
+	}
+
+	return sig, pubKey, nil
+}
+
+// VerifyOffchain verifies ADR-036 signature of the data made by the address.
+func VerifyOffchain(addr sdk.AccAddress, data, signature []byte, pubKey cryptotypes.PubKey) error {
+	if pubKey == nil || !sdk.AccAddress(pubKey.Address()).Equals(addr) {
+		return fmt.Errorf("%w: public key doesn't belong to %s", ErrInvalidOffchainSignature, addr)
+	}
+
+	signBytes, err := offchainSignBytes(addr, data)
+	if err != nil {
+		return err
+	}
+
+	if !pubKey.VerifySignature(signBytes, signature) {
+		return ErrInvalidOffchainSignature
+	}
+
+	return nil
+}
+
+// offchainSignBytes returns amino JSON sign doc defined by ADR-036.
+func offchainSignBytes(signer sdk.AccAddress, data []byte) ([]byte, error) {
+	type msgSignData struct {
+		Data   []byte `json:"data"`
+		Signer string `json:"signer"`
+	}
+
+	type msg struct {
+		Type  string      `json:"type"`
+		Value msgSignData `json:"value"`
+	}
+
+	type fee struct {
+		Amount []sdk.Coin `json:"amount"`
+		Gas    string     `json:"gas"`
+	}
+
+	doc := struct {
+		AccountNumber string `json:"account_number"`
+		ChainID       string `json:"chain_id"`
+		Fee           fee    `json:"fee"`
+		Memo          string `json:"memo"`
+		Msgs          []msg  `json:"msgs"`
+		Sequence      string `json:"sequence"`
+	}{
+		AccountNumber: "0",
+		Fee:           fee{Amount: []sdk.Coin{}, Gas: "0"},
+		Msgs: []msg{{
+			Type:  "sign/MsgSignData",
+			Value: msgSignData{Data: data, Signer: signer.String()},
+		}},
+		Sequence: "0",
+	}
+
+	bz, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sign doc: %w", err)
+	}
+
+	return sdk.MustSortJSON(bz), nil
+}
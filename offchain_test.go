@@ -0,0 +1,94 @@
+package broadcaster_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// adr036SignDoc returns the sign doc ADR-036 tooling (e.g. Keplr's signArbitrary) signs for the data.
+func adr036SignDoc(signer string, data []byte) []byte {
+	return []byte(fmt.Sprintf(
+		`{"account_number":"0","chain_id":"","fee":{"amount":[],"gas":"0"},"memo":"",`+
+			`"msgs":[{"type":"sign/MsgSignData","value":{"data":"%s","signer":"%s"}}],"sequence":"0"}`,
+		base64.StdEncoding.EncodeToString(data), signer,
+	))
+}
+
+func TestSignOffchain(t *testing.T) {
+	const (
+		data = "login nonce 8f2c"
+		// signature is the ADR-036 signature of data by the "adr036" key.
+		signature = "a1e3de510f883da9a6a8ee5f4e27df69f3bd24d565ab9fbb6b44a0280d418a07271441de9f26189ff4a4cab7e076e40e5f234d776edcf661660bf3e210a4c974"
+	)
+
+	node := testutil.NewFakeNode()
+	key := testutil.NewKey("adr036")
+	node.AddAccount(key.Address)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	sig, pubKey, err := b.SignOffchain([]byte(data))
+	require.NoError(t, err)
+	require.Equal(t, key.PrivKey.PubKey(), pubKey)
+	require.Equal(t, signature, hex.EncodeToString(sig))
+
+	// The signature is made over the standard ADR-036 sign doc, so other tooling verifies it.
+	require.True(t, pubKey.VerifySignature(adr036SignDoc(key.Address.String(), []byte(data)), sig))
+	require.NoError(t, broadcaster.VerifyOffchain(key.Address, []byte(data), sig, pubKey))
+}
+
+func TestVerifyOffchain(t *testing.T) {
+	key, other := testutil.NewKey("adr036"), testutil.NewKey("other")
+	data := []byte("login nonce 8f2c")
+
+	// The signature is made by other ADR-036 tooling which signs the sign doc directly.
+	sig, err := key.PrivKey.Sign(adr036SignDoc(key.Address.String(), data))
+	require.NoError(t, err)
+	require.NoError(t, broadcaster.VerifyOffchain(key.Address, data, sig, key.PrivKey.PubKey()))
+
+	tampered := append([]byte(nil), sig...)
+	tampered[0] ^= 0xff
+
+	foreignSig, err := other.PrivKey.Sign(adr036SignDoc(key.Address.String(), data))
+	require.NoError(t, err)
+
+	chainSig, err := key.PrivKey.Sign([]byte(strings.Replace(
+		string(adr036SignDoc(key.Address.String(), data)), `"chain_id":""`, `"chain_id":"decentr"`, 1)))
+	require.NoError(t, err)
+
+	tt := []struct {
+		name   string
+		addr   sdk.AccAddress
+		data   []byte
+		sig    []byte
+		pubKey cryptotypes.PubKey
+	}{
+		{name: "other data", addr: key.Address, data: []byte("login nonce 8f2d"), sig: sig, pubKey: key.PrivKey.PubKey()},
+		{name: "tampered signature", addr: key.Address, data: data, sig: tampered, pubKey: key.PrivKey.PubKey()},
+		{name: "signed by other key", addr: key.Address, data: data, sig: foreignSig, pubKey: key.PrivKey.PubKey()},
+		{name: "signed with chain-id", addr: key.Address, data: data, sig: chainSig, pubKey: key.PrivKey.PubKey()},
+		{name: "other address", addr: other.Address, data: data, sig: sig, pubKey: key.PrivKey.PubKey()},
+		{name: "public key of other address", addr: key.Address, data: data, sig: sig, pubKey: other.PrivKey.PubKey()},
+		{name: "no public key", addr: key.Address, data: data, sig: sig},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := broadcaster.VerifyOffchain(tc.addr, tc.data, tc.sig, tc.pubKey)
+			require.ErrorIs(t, err, broadcaster.ErrInvalidOffchainSignature)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Package operations contains helpers to broadcast messages of decentr's operations module.
+// Messages are broadcast with broadcaster's account as the owner, so it should be a supervisor.
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+
+	operationstypes "github.com/Decentr-net/decentr/x/operations/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+// MaxRewardsPerMsg is the max number of rewards in a single MsgDistributeRewards.
+const MaxRewardsPerMsg = 1000
+
+// ErrInvalidArgument is returned when msg built from arguments doesn't pass validation.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// ErrUnauthorized is returned when broadcaster's account is not allowed to execute the operation.
+var ErrUnauthorized = errors.New("account is not a supervisor")
+
+func init() {
+	broadcaster.RegisterErrorClass(ErrInvalidArgument, broadcaster.ClassInvalidRequest)
+	broadcaster.RegisterErrorClass(ErrUnauthorized, broadcaster.ClassInvalidRequest)
+}
+
+// Broadcaster is used by Client to broadcast messages.
+type Broadcaster interface {
+	From() sdk.AccAddress
+	BroadcastContext(ctx context.Context, msgs []sdk.Msg, memo string, opts broadcaster.BroadcastOptions) (*broadcaster.BroadcastResult, error)
+}
+
+// Reward is an amount of PDV distributed to the receiver.
+type Reward struct {
+	Receiver sdk.AccAddress
+	Amount   sdk.Dec
+}
+
+// Client broadcasts operations module's messages on behalf of broadcaster's account.
+type Client struct {
+	b Broadcaster
+}
+
+// New returns new instance of Client.
+func New(b Broadcaster) *Client {
+	return &Client{b: b}
+}
+
+// ResetAccount resets the account.
+func (c *Client) ResetAccount(ctx context.Context, address sdk.AccAddress) (*broadcaster.BroadcastResult, error) {
+	msg := operationstypes.NewMsgResetAccount(c.b.From(), address)
+
+	return c.broadcast(ctx, &msg)
+}
+
+// Mint mints the coin.
+func (c *Client) Mint(ctx context.Context, coin sdk.Coin) (*broadcaster.BroadcastResult, error) {
+	msg := operationstypes.NewMsgMint(c.b.From(), coin)
+
+	return c.broadcast(ctx, &msg)
+}
+
+// Burn burns the coin.
+func (c *Client) Burn(ctx context.Context, coin sdk.Coin) (*broadcaster.BroadcastResult, error) {
+	msg := operationstypes.NewMsgBurn(c.b.From(), coin)
+
+	return c.broadcast(ctx, &msg)
+}
+
+// DistributeRewards distributes rewards. Rewards are split into chunks of MaxRewardsPerMsg,
+// every chunk is broadcast in a separate tx. Broadcasting stops on the first error,
+// results of already broadcast chunks are returned alongside the error.
+func (c *Client) DistributeRewards(ctx context.Context, rewards []Reward) ([]*broadcaster.BroadcastResult, error) {
+	if len(rewards) == 0 {
+		return nil, fmt.Errorf("%w: empty rewards list", ErrInvalidArgument)
+	}
+
+	msgs := make([]sdk.Msg, 0, (len(rewards)+MaxRewardsPerMsg-1)/MaxRewardsPerMsg)
+	for i := 0; i < len(rewards); i += MaxRewardsPerMsg {
+		chunk := rewards[i:min(i+MaxRewardsPerMsg, len(rewards))]
+
+		list := make([]operationstypes.Reward, len(chunk))
+		for j, v := range chunk {
+			list[j] = operationstypes.NewReward(v.Receiver, v.Amount)
+		}
+
+		msg := operationstypes.NewMsgDistributeRewards(c.b.From(), list)
+		if err := msg.ValidateBasic(); err != nil {
+			return nil, fmt.Errorf("%w: chunk %d: %s", ErrInvalidArgument, i/MaxRewardsPerMsg, err)
+		}
+		msgs = append(msgs, &msg)
+	}
+
+	out := make([]*broadcaster.BroadcastResult, 0, len(msgs))
+	for _, msg := range msgs {
+		res, err := c.broadcast(ctx, msg)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, res)
+	}
+
+	return out, nil
+}
+
+func (c *Client) broadcast(ctx context.Context, msg sdk.Msg) (*broadcaster.BroadcastResult, error) {
+	if err := msg.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArgument, err)
+	}
+
+	res, err := c.b.BroadcastContext(ctx, []sdk.Msg{msg}, "", broadcaster.BroadcastOptions{})
+	if err != nil && isUnauthorized(res) {
+		return res, fmt.Errorf("%w: %s", ErrUnauthorized, err)
+	}
+
+	return res, err
+}
+
+// isUnauthorized returns true if the node rejected tx because of missing permissions.
+func isUnauthorized(res *broadcaster.BroadcastResult) bool {
+	return res != nil && res.Response != nil &&
+		res.Response.Codespace == sdkerrors.RootCodespace &&
+		res.Response.Code == sdkerrors.ErrUnauthorized.ABCICode()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,201 @@
+package operations_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	operationstypes "github.com/Decentr-net/decentr/x/operations/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/operations"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+var supervisor = testutil.NewKey("supervisor").Address
+
+// stubBroadcaster records broadcast msgs. It fails the broadcast with failAt index with err and res.
+type stubBroadcaster struct {
+	msgs   []sdk.Msg
+	failAt int
+	res    *broadcaster.BroadcastResult
+	err    error
+}
+
+func newStubBroadcaster() *stubBroadcaster {
+	return &stubBroadcaster{failAt: -1}
+}
+
+func (b *stubBroadcaster) From() sdk.AccAddress {
+	return supervisor
+}
+
+func (b *stubBroadcaster) BroadcastContext(
+	_ context.Context, msgs []sdk.Msg, _ string, _ broadcaster.BroadcastOptions,
+) (*broadcaster.BroadcastResult, error) {
+	i := len(b.msgs)
+	b.msgs = append(b.msgs, msgs...)
+	if i == b.failAt {
+		return b.res, b.err
+	}
+	return &broadcaster.BroadcastResult{TxHash: fmt.Sprintf("HASH%d", i)}, nil
+}
+
+func rewards(n int) []operations.Reward {
+	out := make([]operations.Reward, n)
+	for i := range out {
+		out[i] = operations.Reward{
+			Receiver: testutil.NewKey(fmt.Sprint("receiver", i)).Address,
+			Amount:   sdk.NewDec(int64(i + 1)),
+		}
+	}
+	return out
+}
+
+func TestClient_DistributeRewards_Chunks(t *testing.T) {
+	tt := []struct {
+		name   string
+		n      int
+		chunks []int
+	}{
+		{name: "single reward", n: 1, chunks: []int{1}},
+		{name: "one below the limit", n: operations.MaxRewardsPerMsg - 1, chunks: []int{999}},
+		{name: "the limit", n: operations.MaxRewardsPerMsg, chunks: []int{1000}},
+		{name: "one above the limit", n: operations.MaxRewardsPerMsg + 1, chunks: []int{1000, 1}},
+		{name: "twice the limit", n: 2 * operations.MaxRewardsPerMsg, chunks: []int{1000, 1000}},
+		{name: "twice the limit and one", n: 2*operations.MaxRewardsPerMsg + 1, chunks: []int{1000, 1000, 1}},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			b := newStubBroadcaster()
+			in := rewards(tc.n)
+
+			res, err := operations.New(b).DistributeRewards(context.Background(), in)
+			require.NoError(t, err)
+			require.Len(t, res, len(tc.chunks))
+			require.Len(t, b.msgs, len(tc.chunks))
+
+			// Every reward is distributed once, in order.
+			var next int
+			for i, m := range b.msgs {
+				msg := m.(*operationstypes.MsgDistributeRewards)
+				require.Equal(t, supervisor.String(), msg.Owner)
+				require.Len(t, msg.Rewards, tc.chunks[i])
+				for _, v := range msg.Rewards {
+					require.Equal(t, in[next].Receiver.String(), v.Receiver)
+					require.True(t, in[next].Amount.Equal(v.Reward.Dec))
+					next++
+				}
+			}
+			require.Equal(t, tc.n, next)
+		})
+	}
+}
+
+func TestClient_DistributeRewards_PartialResults(t *testing.T) {
+	b := newStubBroadcaster()
+	b.failAt = 1
+	b.err = errors.New("node is down")
+
+	res, err := operations.New(b).DistributeRewards(context.Background(), rewards(3*operations.MaxRewardsPerMsg))
+	require.ErrorIs(t, err, b.err)
+	require.Len(t, res, 1, "results of broadcast chunks are returned")
+	require.Equal(t, "HASH0", res[0].TxHash)
+	require.Len(t, b.msgs, 2, "broadcasting stops on the first error")
+}
+
+func TestClient_DistributeRewards_InvalidArgument(t *testing.T) {
+	invalid := rewards(operations.MaxRewardsPerMsg + 1)
+	invalid[operations.MaxRewardsPerMsg].Amount = sdk.ZeroDec()
+
+	for name, in := range map[string][]operations.Reward{
+		"empty":                   nil,
+		"invalid reward in chunk": invalid,
+	} {
+		b := newStubBroadcaster()
+
+		_, err := operations.New(b).DistributeRewards(context.Background(), in)
+		require.ErrorIs(t, err, operations.ErrInvalidArgument, name)
+		require.Empty(t, b.msgs, "nothing is broadcast when any chunk is invalid: %s", name)
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	tt := []struct {
+		name         string
+		res          *broadcaster.BroadcastResult
+		unauthorized bool
+	}{
+		{
+			name: "unauthorized",
+			res: &broadcaster.BroadcastResult{Response: &sdk.TxResponse{
+				Codespace: sdkerrors.RootCodespace,
+				Code:      sdkerrors.ErrUnauthorized.ABCICode(),
+			}},
+			unauthorized: true,
+		},
+		{
+			name: "other code",
+			res: &broadcaster.BroadcastResult{Response: &sdk.TxResponse{
+				Codespace: sdkerrors.RootCodespace,
+				Code:      sdkerrors.ErrInsufficientFunds.ABCICode(),
+			}},
+		},
+		{
+			name: "other codespace",
+			res: &broadcaster.BroadcastResult{Response: &sdk.TxResponse{
+				Codespace: operationstypes.ModuleName,
+				Code:      sdkerrors.ErrUnauthorized.ABCICode(),
+			}},
+		},
+		{
+			name: "node isn't reached",
+			res:  &broadcaster.BroadcastResult{},
+		},
+		{
+			name: "no result",
+		},
+	}
+
+	calls := map[string]func(c *operations.Client) error{
+		"reset account": func(c *operations.Client) error {
+			_, err := c.ResetAccount(context.Background(), testutil.NewKey("target").Address)
+			return err
+		},
+		"mint": func(c *operations.Client) error {
+			_, err := c.Mint(context.Background(), sdk.NewInt64Coin("udec", 1))
+			return err
+		},
+		"burn": func(c *operations.Client) error {
+			_, err := c.Burn(context.Background(), sdk.NewInt64Coin("udec", 1))
+			return err
+		},
+		"distribute rewards": func(c *operations.Client) error {
+			_, err := c.DistributeRewards(context.Background(), rewards(1))
+			return err
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for name, call := range calls {
+				b := newStubBroadcaster()
+				b.failAt = 0
+				b.res = tc.res
+				b.err = errors.New("tx failed")
+
+				err := call(operations.New(b))
+				require.ErrorContains(t, err, "tx failed", name)
+				require.Equal(t, tc.unauthorized, errors.Is(err, operations.ErrUnauthorized), name)
+			}
+		})
+	}
+}
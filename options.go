@@ -0,0 +1,117 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// BroadcastOptions overrides broadcaster's defaults for a single call.
+type BroadcastOptions struct {
+	// Fees overrides Config.Fees.
+	Fees sdk.Coins
+	// FeesStr is human readable Fees, e.g. "0.1dec". It can't be used with Fees.
+	FeesStr string
+	// GasPrices overrides Config.GasPrices. It can't be used with fees.
+	GasPrices sdk.DecCoins
+	// GasPricesStr is human readable GasPrices. It can't be used with GasPrices.
+	GasPricesStr string
+	// Gas overrides Config.Gas.
+	Gas uint64
+	// GasPerMsgType adds or overrides entries of Config.GasPerMsgType. It's ignored when Gas is set.
+	GasPerMsgType map[string]uint64
+	// AlwaysSimulate makes tx simulated even if its gas is fixed like Config.AlwaysSimulate.
+	AlwaysSimulate bool
+	// GasAdjust overrides Config.GasAdjust.
+	GasAdjust float64
+	// Mode overrides Config.BroadcastMode.
+	Mode BroadcastMode
+	// FeeGranter pays fees of tx with its fee grant.
+	FeeGranter sdk.AccAddress
+	// TimeoutHeight is the height after which tx isn't included into a block. It isn't limited by default.
+	TimeoutHeight uint64
+	// Hedge sends tx to the first of Config.ExtraNodeURIs too if the primary node doesn't respond in Config.HedgeDelay.
+	// The first accepted response is returned.
+	Hedge bool
+
+	// MemoPrefix is prepended to the memo.
+	MemoPrefix string
+	// IdempotencyKey is embedded into the memo, so the tx could be found by FindTxByIdempotencyKey.
+	// See IdempotentMemo for the format.
+	IdempotencyKey string
+
+	// Speculative prevents BuildAndSign from consuming the local sequence.
+	Speculative bool
+	// DisableAutoRetry disables retries for a single call like Config.DisableAutoRetry.
+	DisableAutoRetry bool
+
+	// ExtensionOptions override Config.ExtensionOptions. They're set to tx body before signing.
+	ExtensionOptions []*codectypes.Any
+	// NonCriticalExtensionOptions override Config.NonCriticalExtensionOptions.
+	NonCriticalExtensionOptions []*codectypes.Any
+
+	// nonce is appended to the memo when Config.UniquifyMemo is set.
+	nonce string
+	// tableGas is set when Gas is taken from Config.GasPerMsgType.
+	tableGas bool
+}
+
+// resolveOptions parses string fields of opts with the denom metadata and sets gas of msgs from the gas table.
+func (b *broadcaster) resolveOptions(ctx context.Context, msgs []sdk.Msg, opts BroadcastOptions) (BroadcastOptions, error) {
+	if opts.FeesStr != "" {
+		if opts.Fees != nil {
+			return opts, errors.New("fees and fees string can't be set together")
+		}
+
+		fees, err := b.ParseCoinsHuman(ctx, opts.FeesStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid fees: %w", err)
+		}
+		opts.Fees, opts.FeesStr = fees, ""
+	}
+
+	if opts.GasPricesStr != "" {
+		if opts.GasPrices != nil {
+			return opts, errors.New("gas prices and gas prices string can't be set together")
+		}
+
+		gasPrices, err := b.parseDecCoinsHuman(ctx, opts.GasPricesStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid gas prices: %w", err)
+		}
+		opts.GasPrices, opts.GasPricesStr = gasPrices, ""
+	}
+
+	if opts.Fees != nil && opts.GasPrices != nil {
+		return opts, errors.New("fees and gas prices can't be set together")
+	}
+
+	if opts.ExtensionOptions == nil {
+		opts.ExtensionOptions = b.cfg.ExtensionOptions
+	}
+	if opts.NonCriticalExtensionOptions == nil {
+		opts.NonCriticalExtensionOptions = b.cfg.NonCriticalExtensionOptions
+	}
+	if err := b.checkExtensionOptions(opts.extensions()); err != nil {
+		return opts, err
+	}
+
+	if err := validateGasPerMsgType(opts.GasPerMsgType); err != nil {
+		return opts, err
+	}
+	if opts.Gas == 0 {
+		if gas, ok := b.tableGas(msgs, opts.GasPerMsgType); ok {
+			opts.Gas, opts.tableGas = gas, true
+		}
+	}
+	if opts.Gas == 0 && b.gasEstimator != nil {
+		if gas, ok := b.gasEstimator.estimate(gasSignature(msgs)); ok {
+			opts.Gas = gas
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,140 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+func TestBroadcast_FeeStrings(t *testing.T) {
+	const gas = 100000
+
+	tt := []struct {
+		name string
+		cfg  func(cfg *broadcaster.Config)
+		opts broadcaster.BroadcastOptions
+		fee  string
+	}{
+		{
+			name: "fees",
+			opts: broadcaster.BroadcastOptions{FeesStr: "0.003dec"},
+			fee:  "3000udec",
+		},
+		{
+			name: "gas prices",
+			opts: broadcaster.BroadcastOptions{GasPricesStr: "0.000000025dec"},
+			fee:  "2500udec",
+		},
+		{
+			name: "fees override config fees",
+			cfg:  func(cfg *broadcaster.Config) { cfg.FeesStr = "1dec" },
+			opts: broadcaster.BroadcastOptions{FeesStr: "3000udec"},
+			fee:  "3000udec",
+		},
+		{
+			name: "fees override config gas prices",
+			cfg:  func(cfg *broadcaster.Config) { cfg.GasPricesStr = "1udec" },
+			opts: broadcaster.BroadcastOptions{FeesStr: "0.003dec"},
+			fee:  "3000udec",
+		},
+		{
+			name: "gas prices override config fees",
+			cfg:  func(cfg *broadcaster.Config) { cfg.FeesStr = "1dec" },
+			opts: broadcaster.BroadcastOptions{GasPricesStr: "0.025udec"},
+			fee:  "2500udec",
+		},
+		{
+			name: "config fees",
+			cfg:  func(cfg *broadcaster.Config) { cfg.FeesStr = "0.001dec" },
+			fee:  "1000udec",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			cfg := testConfig(node, key)
+			if tc.cfg != nil {
+				tc.cfg(&cfg)
+			}
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			opts := tc.opts
+			opts.Gas = gas
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", opts)
+			require.NoError(t, err)
+
+			mempool := node.Mempool()
+			require.Len(t, mempool, 1)
+			require.Equal(t, res.TxHash, mempool[0].Hash)
+			require.Equal(t, tc.fee, mempool[0].Fee.String())
+		})
+	}
+}
+
+func TestBroadcast_InvalidFeeStrings(t *testing.T) {
+	tt := []struct {
+		name      string
+		opts      broadcaster.BroadcastOptions
+		noQueries bool
+	}{
+		{
+			name:      "malformed fees",
+			opts:      broadcaster.BroadcastOptions{FeesStr: "dec"},
+			noQueries: true,
+		},
+		{
+			name:      "malformed gas prices",
+			opts:      broadcaster.BroadcastOptions{GasPricesStr: "0.1.1dec"},
+			noQueries: true,
+		},
+		{
+			name: "too precise fees",
+			opts: broadcaster.BroadcastOptions{FeesStr: "0.0000001dec"},
+		},
+		{
+			name: "fees and fees string",
+			opts: broadcaster.BroadcastOptions{Fees: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 1)), FeesStr: "1dec"},
+		},
+		{
+			name: "gas prices and gas prices string",
+			opts: broadcaster.BroadcastOptions{
+				GasPrices:    sdk.NewDecCoins(sdk.NewInt64DecCoin(testDenom, 1)),
+				GasPricesStr: "1udec",
+			},
+		},
+		{
+			name: "fees string and gas prices string",
+			opts: broadcaster.BroadcastOptions{FeesStr: "1dec", GasPricesStr: "1udec"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			b, err := broadcaster.New(testConfig(node, key))
+			require.NoError(t, err)
+			defer b.Close()
+
+			queries := node.Calls("abci_query")
+			_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", tc.opts)
+			require.Error(t, err)
+
+			require.Zero(t, node.Calls("broadcast_tx_sync"))
+			if tc.noQueries {
+				require.Equal(t, queries, node.Calls("abci_query"), "malformed string doesn't cause metadata query")
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// ownTxsPerPage is the page size of tx search catching up missed txs.
+const ownTxsPerPage = 100
+
+// ownTxsBuffer is the capacity of the feed's channel. Txs are dropped when the consumer lets it fill up,
+// so a slow consumer doesn't stall the subscription.
+const ownTxsBuffer = 100
+
+// ownTxsFeed delivers txs of the account in order of heights. Txs seen at the last height are remembered,
+// so ones delivered by both the subscription and the catch-up aren't repeated.
+type ownTxsFeed struct {
+	out    chan *sdk.TxResponse
+	height int64
+	seen   map[string]bool
+}
+
+// send delivers tx unless it's already delivered. It returns false when tx is dropped because the channel is full.
+func (f *ownTxsFeed) send(resp *sdk.TxResponse) bool {
+	if resp.Height < f.height || f.seen[resp.TxHash] {
+		return true
+	}
+
+	if resp.Height > f.height {
+		f.height, f.seen = resp.Height, make(map[string]bool)
+	}
+	f.seen[resp.TxHash] = true
+
+	select {
+	case f.out <- resp:
+		return true
+	default:
+		return false
+	}
+}
+
+// SubscribeOwnTxs returns a feed of txs sent from the broadcaster's address, including ones signed elsewhere
+// with the same key. Txs are received over websocket. Txs committed while the subscription is down are caught up
+// with tx search from the last seen height, the search is polled when the subscription isn't available.
+// Txs are dropped with a warning when the consumer doesn't keep up. The channel is closed when ctx is done
+// or the broadcaster is closed.
+func (b *broadcaster) SubscribeOwnTxs(ctx context.Context) (<-chan *sdk.TxResponse, error) {
+	height, err := b.GetHeightFresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("%s.%s='%s'", sdk.EventTypeMessage, sdk.AttributeKeySender, b.From())
+	f := &ownTxsFeed{
+		out:    make(chan *sdk.TxResponse, ownTxsBuffer),
+		height: int64(height) + 1,
+		seen:   make(map[string]bool),
+	}
+
+	go func() {
+		defer close(f.out)
+
+		for ctx.Err() == nil {
+			b.followOwnTxs(ctx, f, query)
+
+			select {
+			case <-ctx.Done():
+			case <-b.closing:
+				return
+			case <-b.cfg.clock().After(b.cfg.commitPollInterval()):
+			}
+		}
+	}()
+
+	return f.out, nil
+}
+
+// followOwnTxs subscribes to txs matching the query and delivers them until the subscription is closed.
+// Missed txs are caught up after subscribing, so txs committed in between aren't lost.
+func (b *broadcaster) followOwnTxs(ctx context.Context, f *ownTxsFeed, query string) {
+	ch, unsubscribe, err := b.client.subscribe(ctx, fmt.Sprintf("%s='%s' AND %s", tmtypes.EventTypeKey, tmtypes.EventTx, query))
+	if err != nil {
+		b.catchUpOwnTxs(ctx, f, query)
+		return
+	}
+	defer unsubscribe()
+
+	if !b.catchUpOwnTxs(ctx, f, query) {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.closing:
+			return
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			data, ok := e.Data.(tmtypes.EventDataTx)
+			if !ok {
+				continue
+			}
+
+			resp := sdk.NewResponseResultTx(&ctypes.ResultTx{
+				Hash:     tmtypes.Tx(data.Tx).Hash(),
+				Height:   data.Height,
+				Index:    data.Index,
+				TxResult: data.Result,
+				Tx:       tmtypes.Tx(data.Tx),
+			}, nil, "")
+			if !f.send(resp) {
+				b.warnf(ctx, "own tx %s is dropped, the channel is full", resp.TxHash)
+			}
+		}
+	}
+}
+
+// catchUpOwnTxs delivers txs matching the query from the last seen height. It returns false
+// if the node is unavailable.
+func (b *broadcaster) catchUpOwnTxs(ctx context.Context, f *ownTxsFeed, query string) bool {
+	node, err := b.ctx.GetNode()
+	if err != nil {
+		return false
+	}
+
+	search := fmt.Sprintf("%s AND %s>=%d", query, tmtypes.TxHeightKey, f.height)
+	perPage := ownTxsPerPage
+
+	for page := 1; ; page++ {
+		res, err := node.TxSearch(ctx, search, false, &page, &perPage, "asc")
+		if err != nil {
+			b.warnf(ctx, "failed to catch up own txs: %s", err)
+			return false
+		}
+
+		for _, v := range res.Txs {
+			if resp := sdk.NewResponseResultTx(v, nil, ""); !f.send(resp) {
+				b.warnf(ctx, "own tx %s is dropped, the channel is full", resp.TxHash)
+			}
+		}
+
+		if len(res.Txs) < perPage || page*perPage >= res.TotalCount {
+			return true
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// gatedSubscriptions is the fake node which fails websocket subscriptions until they're opened.
+type gatedSubscriptions struct {
+	*testutil.FakeNode
+
+	mu     sync.Mutex
+	opened bool
+}
+
+func (n *gatedSubscriptions) open() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.opened = true
+}
+
+func (n *gatedSubscriptions) Subscribe(
+	ctx context.Context, subscriber, query string, outCapacity ...int,
+) (<-chan ctypes.ResultEvent, error) {
+	n.mu.Lock()
+	opened := n.opened
+	n.mu.Unlock()
+
+	if !opened {
+		return nil, errors.New("websocket is unavailable")
+	}
+
+	return n.FakeNode.Subscribe(ctx, subscriber, query, outCapacity...)
+}
+
+// ownTxsSubscriber is a broadcaster with the feed of own txs, e.g. one returned by broadcaster.New.
+type ownTxsSubscriber interface {
+	contextBroadcaster
+	SubscribeOwnTxs(ctx context.Context) (<-chan *sdk.TxResponse, error)
+	Close() error
+}
+
+// commitOwnTx broadcasts tx from the key and commits it in a new block.
+func commitOwnTx(t *testing.T, node *testutil.FakeNode, b contextBroadcaster, from sdk.AccAddress) string {
+	t.Helper()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(from, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+
+	return res.TxHash
+}
+
+// receiveOwnTx returns the next tx of the feed.
+func receiveOwnTx(t *testing.T, feed <-chan *sdk.TxResponse) *sdk.TxResponse {
+	t.Helper()
+
+	select {
+	case resp, ok := <-feed:
+		require.True(t, ok, "feed is closed")
+		return resp
+	case <-time.After(5 * time.Second):
+		t.Fatal("tx isn't received")
+		return nil
+	}
+}
+
+// requireFeedClosed drains the feed until it's closed.
+func requireFeedClosed(t *testing.T, feed <-chan *sdk.TxResponse) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-feed:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("feed isn't closed")
+		}
+	}
+}
+
+func TestSubscribeOwnTxs(t *testing.T) {
+	fake, key := newFakeChain(t)
+	node := &gatedSubscriptions{FakeNode: fake}
+
+	cfg := testConfig(fake, key)
+	cfg.RPCClient = node
+	cfg.CommitPollInterval = 10 * time.Millisecond
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Txs signed elsewhere with the same key are delivered too.
+	sender, err := broadcaster.New(testConfig(fake, key))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	// The tx committed before the call isn't delivered.
+	commitOwnTx(t, fake, sender, key.Address)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	feed, err := b.SubscribeOwnTxs(ctx)
+	require.NoError(t, err)
+
+	// The search is polled while the subscription is unavailable.
+	searched := fake.Calls("tx_search")
+	waitCalls(t, fake, "tx_search", searched)
+	first := commitOwnTx(t, fake, sender, key.Address)
+	require.Equal(t, first, receiveOwnTx(t, feed).TxHash)
+
+	// The catch-up after subscribing finds the delivered tx again, it isn't repeated.
+	node.open()
+	require.Eventually(t, func() bool { return fake.Subscriptions() == 1 }, 5*time.Second, time.Millisecond)
+	second := commitOwnTx(t, fake, sender, key.Address)
+	require.Equal(t, second, receiveOwnTx(t, feed).TxHash)
+
+	// Txs of other accounts aren't delivered.
+	other := testutil.NewKey(t.Name() + "/other")
+	fake.AddAccount(other.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+	otherSender, err := broadcaster.New(testConfig(fake, other))
+	require.NoError(t, err)
+	defer otherSender.Close()
+	commitOwnTx(t, fake, otherSender, other.Address)
+
+	// The feed resubscribes when the subscription is closed by the node.
+	subscribed := fake.Calls("subscribe")
+	require.NoError(t, fake.UnsubscribeAll(context.Background(), "go-broadcaster"))
+	waitCalls(t, fake, "subscribe", subscribed)
+	require.Eventually(t, func() bool { return fake.Subscriptions() == 1 }, 5*time.Second, time.Millisecond)
+
+	third := commitOwnTx(t, fake, sender, key.Address)
+	resp := receiveOwnTx(t, feed)
+	require.Equal(t, third, resp.TxHash)
+	require.Equal(t, fake.Height(), resp.Height)
+}
+
+func TestSubscribeOwnTxs_SlowConsumer(t *testing.T) {
+	// The txs overflow both the node's subscription and the feed's channel.
+	const count = 250
+
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	feed, err := b.SubscribeOwnTxs(ctx)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return node.Subscriptions() == 1 }, 5*time.Second, time.Millisecond)
+
+	for i := 0; i < count; i++ {
+		_, err := b.BroadcastContext(ctx, []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+		require.NoError(t, err)
+	}
+	node.NextBlock()
+
+	// The txs which don't fit are dropped instead of stalling the feed.
+	var received int
+	for done := false; !done; {
+		select {
+		case <-feed:
+			received++
+		case <-time.After(100 * time.Millisecond):
+			done = true
+		}
+	}
+	require.NotZero(t, received)
+	require.Less(t, received, count)
+
+	// The feed keeps delivering once the consumer catches up.
+	hash := commitOwnTx(t, node, b, key.Address)
+	require.Equal(t, hash, receiveOwnTx(t, feed).TxHash)
+}
+
+func TestSubscribeOwnTxs_Closed(t *testing.T) {
+	tt := []struct {
+		name  string
+		close func(cancel context.CancelFunc, b ownTxsSubscriber)
+	}{
+		{name: "context is done", close: func(cancel context.CancelFunc, _ ownTxsSubscriber) { cancel() }},
+		{name: "broadcaster is closed", close: func(_ context.CancelFunc, b ownTxsSubscriber) { _ = b.Close() }},
+	}
+
+	node, key := newFakeChain(t)
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := broadcaster.New(testConfig(node, key))
+			require.NoError(t, err)
+			defer b.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			feed, err := b.SubscribeOwnTxs(ctx)
+			require.NoError(t, err)
+			require.Eventually(t, func() bool { return node.Subscriptions() == 1 }, 5*time.Second, time.Millisecond)
+
+			tc.close(cancel, b)
+			requireFeedClosed(t, feed)
+
+			// The subscription is removed.
+			require.Eventually(t, func() bool { return node.Subscriptions() == 0 }, 5*time.Second, time.Millisecond)
+		})
+	}
+}
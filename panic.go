@@ -0,0 +1,32 @@
+package broadcaster
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrBroadcastPanic is returned when broadcast panics.
+var ErrBroadcastPanic = errors.New("panic during broadcast")
+
+// PanicError contains the value and the stack of recovered panic. It matches ErrBroadcastPanic.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrBroadcastPanic, e.Value)
+}
+
+// Is makes PanicError match ErrBroadcastPanic.
+func (e *PanicError) Is(target error) bool {
+	return target == ErrBroadcastPanic
+}
+
+// recoverPanic converts panic into PanicError assigned to err. It should be deferred.
+func recoverPanic(err *error) {
+	if v := recover(); v != nil {
+		*err = &PanicError{Value: v, Stack: debug.Stack()}
+	}
+}
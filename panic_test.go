@@ -0,0 +1,64 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+// panickingMsg is registered bank transfer which panics when it's encoded.
+type panickingMsg struct {
+	*banktypes.MsgSend
+}
+
+func (panickingMsg) XXX_MessageName() string {
+	return "cosmos.bank.v1beta1.MsgSend"
+}
+
+func (panickingMsg) XXX_Marshal([]byte, bool) ([]byte, error) {
+	panic("nil any")
+}
+
+func TestBroadcast_Panic(t *testing.T) {
+	for _, pipelined := range []bool{false, true} {
+		pipelined := pipelined
+		name := "locked"
+		if pipelined {
+			name = "pipelined"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			cfg := testConfig(node, key)
+			cfg.Pipelined = pipelined
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			// The gas is fixed, so the msg is encoded for the first time during signing with the reserved sequence.
+			opts := broadcaster.BroadcastOptions{Gas: 200000}
+			_, err = b.BroadcastContext(context.Background(), []sdk.Msg{panickingMsg{sendMsg(key.Address, 1)}}, "", opts)
+			require.ErrorIs(t, err, broadcaster.ErrBroadcastPanic)
+
+			var panicErr *broadcaster.PanicError
+			require.ErrorAs(t, err, &panicErr)
+			require.Equal(t, "nil any", panicErr.Value)
+			require.NotEmpty(t, panicErr.Stack)
+			require.Equal(t, broadcaster.ClassUnknown, broadcaster.Classify(err))
+
+			// The lock is released and the sequence isn't consumed.
+			require.Equal(t, uint64(0), b.TxFactory().Sequence())
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", opts)
+			require.NoError(t, err)
+			require.Equal(t, uint64(0), res.Sequence)
+			requireCommitted(t, node, res.TxHash)
+		})
+	}
+}
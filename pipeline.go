@@ -0,0 +1,94 @@
+package broadcaster
+
+import (
+	"sync"
+)
+
+// pipeline reserves sequences for concurrent broadcasts in pipelined mode.
+// Txs are signed concurrently with predicted sequences and handed to the transport in order of reservation,
+// since the node rejects txs with sequences gap. The turn to submit is passed as soon as tx is handed over,
+// so the rpcs overlap, but outcomes are settled in order. When a tx fails, the txs reserved after it
+// are re-signed with the corrected sequence on their settling turn.
+type pipeline struct {
+	mu sync.Mutex
+
+	confirmed uint64 // the next sequence expected by the node
+	pending   uint64 // number of reserved slots which are not settled yet
+	tail      *slot  // the last reserved slot
+}
+
+// slot is a reserved place in the submission order.
+type slot struct {
+	seq  uint64 // predicted sequence
+	prev *slot
+
+	sendOnce sync.Once
+	sent     chan struct{} // closed when tx is handed to the transport or the slot is settled
+	settled  chan struct{} // closed when the outcome of tx is known
+}
+
+func newPipeline(seq uint64) *pipeline {
+	tail := &slot{
+		sent:    make(chan struct{}),
+		settled: make(chan struct{}),
+	}
+	close(tail.sent)
+	close(tail.settled)
+
+	return &pipeline{
+		confirmed: seq,
+		tail:      tail,
+	}
+}
+
+// reserve returns a new slot with predicted sequence.
+func (p *pipeline) reserve() *slot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := &slot{
+		seq:     p.confirmed + p.pending,
+		prev:    p.tail,
+		sent:    make(chan struct{}),
+		settled: make(chan struct{}),
+	}
+
+	p.pending++
+	p.tail = s
+
+	return s
+}
+
+// handOff passes the turn to submit to the next slot. It's called right before tx is handed to the transport.
+func (p *pipeline) handOff(s *slot) {
+	s.sendOnce.Do(func() {
+		close(s.sent)
+	})
+}
+
+// release settles the slot after the previous one is settled. seq is the next sequence expected by the node.
+func (p *pipeline) release(s *slot, seq uint64) {
+	p.mu.Lock()
+	p.confirmed = seq
+	p.pending--
+	p.mu.Unlock()
+
+	p.handOff(s)
+	close(s.settled)
+}
+
+// abandon releases the slot after its turns came without changing the sequence.
+// It is used when the caller doesn't wait for the turn anymore.
+func (p *pipeline) abandon(s *slot) {
+	go func() {
+		<-s.prev.sent
+		p.handOff(s)
+
+		<-s.prev.settled
+		p.mu.Lock()
+		p.pending--
+		p.mu.Unlock()
+
+		close(s.settled)
+	}()
+}
@@ -0,0 +1,263 @@
+package broadcaster_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// pipelinedResult is a result of a single pipelined broadcast.
+type pipelinedResult struct {
+	memo string
+	res  *broadcaster.BroadcastResult
+	err  error
+}
+
+// contextBroadcaster is a broadcaster supporting options, e.g. one returned by broadcaster.New.
+type contextBroadcaster interface {
+	BroadcastContext(ctx context.Context, msgs []sdk.Msg, memo string, opts broadcaster.BroadcastOptions) (*broadcaster.BroadcastResult, error)
+}
+
+// broadcastConcurrently broadcasts a tx per memo concurrently and returns results in order of memos.
+func broadcastConcurrently(b contextBroadcaster, from sdk.AccAddress, memos []string) []pipelinedResult {
+	out := make([]pipelinedResult, len(memos))
+
+	var wg sync.WaitGroup
+	for i, memo := range memos {
+		wg.Add(1)
+		go func(i int, memo string) {
+			defer wg.Done()
+
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(from, 1)}, memo, broadcaster.BroadcastOptions{})
+			out[i] = pipelinedResult{memo: memo, res: res, err: err}
+		}(i, memo)
+	}
+	wg.Wait()
+
+	return out
+}
+
+func TestPipelined(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.Pipelined = true
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	memos := make([]string, 20)
+	for i := range memos {
+		memos[i] = fmt.Sprintf("tx %d", i)
+	}
+	results := broadcastConcurrently(b, key.Address, memos)
+
+	var seqs []uint64
+	for _, r := range results {
+		require.NoError(t, r.err, r.memo)
+		seqs = append(seqs, r.res.Sequence)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	for i, seq := range seqs {
+		require.Equal(t, uint64(i), seq)
+	}
+
+	// Txs are submitted in order of sequences even if they are signed out of order.
+	mempool := node.Mempool()
+	require.Len(t, mempool, len(memos))
+	for i, tx := range mempool {
+		require.Equal(t, uint64(i), tx.Sequence)
+	}
+
+	node.NextBlock()
+	for _, r := range results {
+		tx, ok := node.CommittedTx(r.res.TxHash)
+		require.True(t, ok)
+		require.Zero(t, tx.TxResult.Code, tx.TxResult.Log)
+	}
+	require.Equal(t, uint64(len(memos)), node.Sequence(key.Address))
+}
+
+func TestPipelined_Gap(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.OnCheckTx(func(tx testutil.FakeTx) error {
+		if tx.Memo == "fail" {
+			return sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, "rejected by test")
+		}
+		return nil
+	})
+
+	cfg := testConfig(node, key)
+	cfg.Pipelined = true
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	memos := []string{"a", "b", "fail", "c", "d", "fail", "e", "f"}
+	results := broadcastConcurrently(b, key.Address, memos)
+
+	// The txs reserved after the failed ones are re-signed to fill the gaps.
+	var seqs []uint64
+	for _, r := range results {
+		if r.memo == "fail" {
+			require.ErrorIs(t, r.err, sdkerrors.ErrInvalidRequest)
+			continue
+		}
+		require.NoError(t, r.err, r.memo)
+		seqs = append(seqs, r.res.Sequence)
+	}
+	require.ElementsMatch(t, []uint64{0, 1, 2, 3, 4, 5}, seqs)
+
+	node.NextBlock()
+	for _, r := range results {
+		if r.err != nil {
+			continue
+		}
+		tx, ok := node.CommittedTx(r.res.TxHash)
+		require.True(t, ok, r.memo)
+		require.Zero(t, tx.TxResult.Code, tx.TxResult.Log)
+	}
+	require.Equal(t, uint64(6), node.Sequence(key.Address))
+
+	// The next tx follows the filled sequence.
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), res.Sequence)
+}
+
+// blockingNode holds broadcast rpc until it's unblocked.
+type blockingNode struct {
+	*testutil.FakeNode
+
+	sent    chan struct{}
+	unblock chan struct{}
+}
+
+func (n *blockingNode) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	n.sent <- struct{}{}
+	<-n.unblock
+
+	return n.FakeNode.BroadcastTxSync(ctx, tx)
+}
+
+func TestPipelined_LockIsNotHeldDuringRPC(t *testing.T) {
+	fake, key := newFakeChain(t)
+	node := &blockingNode{FakeNode: fake, sent: make(chan struct{}, 1), unblock: make(chan struct{})}
+
+	cfg := testConfig(fake, key)
+	cfg.RPCClient = node
+	cfg.Pipelined = true
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	done := make(chan error)
+	go func() {
+		_, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+		done <- err
+	}()
+	<-node.sent
+
+	refreshed := make(chan error)
+	go func() {
+		refreshed <- b.RefreshSequence()
+	}()
+	select {
+	case err := <-refreshed:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcaster is locked during rpc")
+	}
+
+	close(node.unblock)
+	require.NoError(t, <-done)
+	require.Equal(t, uint64(1), b.TxFactory().Sequence())
+}
+
+// overlapNode delays broadcast responses by latency and counts broadcast rpcs in flight.
+// Txs reach the node in order of the calls like they do through an ordered transport.
+type overlapNode struct {
+	*testutil.FakeNode
+	latency time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (n *overlapNode) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	n.mu.Lock()
+	n.inFlight++
+	if n.inFlight > n.peak {
+		n.peak = n.inFlight
+	}
+	n.mu.Unlock()
+
+	defer func() {
+		n.mu.Lock()
+		n.inFlight--
+		n.mu.Unlock()
+	}()
+
+	res, err := n.FakeNode.BroadcastTxSync(ctx, tx)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(n.latency):
+	}
+
+	return res, err
+}
+
+func TestPipelined_SubmissionsOverlap(t *testing.T) {
+	const (
+		count   = 10
+		latency = 200 * time.Millisecond
+	)
+
+	fake, key := newFakeChain(t)
+	node := &overlapNode{FakeNode: fake, latency: latency}
+
+	cfg := testConfig(fake, key)
+	cfg.RPCClient = node
+	cfg.Pipelined = true
+	// Gas is fixed, so txs aren't simulated against the state lagging behind the submitted txs.
+	cfg.Gas = 200000
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	memos := make([]string, count)
+	for i := range memos {
+		memos[i] = fmt.Sprintf("tx %d", i)
+	}
+	start := time.Now()
+	results := broadcastConcurrently(b, key.Address, memos)
+	elapsed := time.Since(start)
+
+	for _, r := range results {
+		require.NoError(t, r.err, r.memo)
+	}
+	require.Len(t, fake.Mempool(), count)
+
+	// Serial submission takes a latency per tx, the overlapped one takes about one of them
+	// plus the resubmissions of txs which overtook the preceding ones on the way to the node.
+	require.Greater(t, node.peak, 1)
+	require.Less(t, elapsed, count/2*latency)
+}
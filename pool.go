@@ -0,0 +1,313 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Default values of PoolConfig.
+const (
+	DefaultPoolEjectAfterFailures = 3
+	DefaultPoolEjectFor           = 30 * time.Second
+)
+
+// PoolConfig ...
+type PoolConfig struct {
+	// Members are configs of pool's broadcasters. Each member should use its own account.
+	Members []Config
+
+	// EjectAfterFailures is a number of consecutive failures after which a member is ejected.
+	// DefaultPoolEjectAfterFailures is used by default.
+	EjectAfterFailures int
+	// EjectFor is a duration of member's ejection. DefaultPoolEjectFor is used by default.
+	EjectFor time.Duration
+	// Clock is used to track ejections. RealClock is used by default.
+	Clock Clock
+}
+
+// PoolMemberStats contains stats of pool's member.
+type PoolMemberStats struct {
+	Address    sdk.AccAddress
+	Healthy    bool
+	InFlight   int
+	Broadcasts uint64
+	Failures   uint64
+}
+
+// Pool distributes broadcasts across several broadcasters with different accounts.
+// Every broadcast is done by the least loaded healthy member. Members which fail several times
+// in a row are ejected for a while.
+type Pool struct {
+	members []*poolMember
+
+	ejectAfterFailures int
+	ejectFor           time.Duration
+	clock              Clock
+
+	mu sync.Mutex
+}
+
+type poolMember struct {
+	b *broadcaster
+
+	inFlight            int
+	broadcasts          uint64
+	failures            uint64
+	consecutiveFailures int
+	ejectedUntil        time.Time
+}
+
+var _ Broadcaster = &Pool{}
+
+// NewPool returns new instance of pool.
+func NewPool(cfg PoolConfig) (*Pool, error) {
+	if len(cfg.Members) == 0 {
+		return nil, errors.New("pool should have at least one member")
+	}
+
+	p := &Pool{
+		members: make([]*poolMember, len(cfg.Members)),
+
+		ejectAfterFailures: cfg.EjectAfterFailures,
+		ejectFor:           cfg.EjectFor,
+		clock:              cfg.Clock,
+	}
+
+	if p.ejectAfterFailures <= 0 {
+		p.ejectAfterFailures = DefaultPoolEjectAfterFailures
+	}
+	if p.ejectFor <= 0 {
+		p.ejectFor = DefaultPoolEjectFor
+	}
+	if p.clock == nil {
+		p.clock = RealClock
+	}
+
+	// Members connected to the same node share the rpc client.
+	clients := make(map[string]*SharedClient)
+	defer func() {
+		for _, c := range clients {
+			_ = c.Release()
+		}
+	}()
+
+	for i, v := range cfg.Members {
+		if v.Client == nil && v.NodeURI != "" {
+			if _, ok := clients[v.NodeURI]; !ok {
+				c, err := NewSharedClient(v.NodeURI, v.clientOptions()...)
+				if err != nil {
+					_ = p.closeMembers()
+					return nil, fmt.Errorf("failed to create client for member %d: %w", i, err)
+				}
+				clients[v.NodeURI] = c
+			}
+			v.Client = clients[v.NodeURI]
+		}
+
+		b, err := New(v)
+		if err != nil {
+			_ = p.closeMembers()
+			return nil, fmt.Errorf("failed to create member %d: %w", i, err)
+		}
+		p.members[i] = &poolMember{b: b}
+	}
+
+	return p, nil
+}
+
+// Close closes pool's members.
+func (p *Pool) Close() error {
+	return p.closeMembers()
+}
+
+func (p *Pool) closeMembers() error {
+	var errs []error
+	for _, m := range p.members {
+		if m == nil {
+			continue
+		}
+		if err := m.b.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to close members: %v", errs)
+	}
+
+	return nil
+}
+
+// From returns address of the first pool's member.
+// Use BroadcastResult.Signer to get address of the member which broadcast tx.
+func (p *Pool) From() sdk.AccAddress {
+	return p.members[0].b.From()
+}
+
+// GetHeight returns current height.
+func (p *Pool) GetHeight(ctx context.Context) (uint64, error) {
+	return p.pick().b.GetHeight(ctx)
+}
+
+// LatestBlock returns height, time and hash of the latest block.
+func (p *Pool) LatestBlock(ctx context.Context) (BlockInfo, error) {
+	return p.pick().b.LatestBlock(ctx)
+}
+
+// GetHeightFresh returns current height bypassing the cache.
+func (p *Pool) GetHeightFresh(ctx context.Context) (uint64, error) {
+	return p.pick().b.GetHeightFresh(ctx)
+}
+
+// BroadcastMsg broadcasts alone message.
+func (p *Pool) BroadcastMsg(msg sdk.Msg, memo string) (*sdk.TxResponse, error) {
+	return p.Broadcast([]sdk.Msg{msg}, memo)
+}
+
+// Broadcast broadcasts messages.
+func (p *Pool) Broadcast(msgs []sdk.Msg, memo string) (*sdk.TxResponse, error) {
+	res, err := p.BroadcastContext(context.Background(), msgs, memo, BroadcastOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Response, nil
+}
+
+// BroadcastContext broadcasts messages with the least loaded healthy member.
+func (p *Pool) BroadcastContext(ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions) (*BroadcastResult, error) {
+	m := p.acquire()
+	res, err := m.b.BroadcastContext(ctx, msgs, memo, opts)
+	p.release(m, err)
+
+	return res, err
+}
+
+// PingContext pings node.
+func (p *Pool) PingContext(ctx context.Context) error {
+	return p.pick().b.PingContext(ctx)
+}
+
+// Size returns number of pool's members.
+func (p *Pool) Size() int {
+	return len(p.members)
+}
+
+// Healthy returns number of members which are not ejected.
+func (p *Pool) Healthy() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var n int
+	now := p.clock.Now()
+	for _, m := range p.members {
+		if m.healthy(now) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Stats returns stats of pool's members.
+func (p *Pool) Stats() []PoolMemberStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	out := make([]PoolMemberStats, len(p.members))
+	for i, m := range p.members {
+		out[i] = PoolMemberStats{
+			Address:    m.b.From(),
+			Healthy:    m.healthy(now),
+			InFlight:   m.inFlight,
+			Broadcasts: m.broadcasts,
+			Failures:   m.failures,
+		}
+	}
+
+	return out
+}
+
+// pick returns the least loaded healthy member. If all members are ejected,
+// the one which ejection ends first is returned.
+func (p *Pool) pick() *poolMember {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.pickLocked()
+}
+
+func (p *Pool) pickLocked() *poolMember {
+	now := p.clock.Now()
+
+	var best *poolMember
+	for _, m := range p.members {
+		switch {
+		case best == nil:
+			best = m
+		case m.healthy(now) && !best.healthy(now):
+			best = m
+		case m.healthy(now) && m.inFlight < best.inFlight:
+			best = m
+		case !m.healthy(now) && !best.healthy(now) && m.ejectedUntil.Before(best.ejectedUntil):
+			best = m
+		}
+	}
+
+	return best
+}
+
+func (p *Pool) acquire() *poolMember {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m := p.pickLocked()
+	m.inFlight++
+
+	return m
+}
+
+func (p *Pool) release(m *poolMember, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m.inFlight--
+	m.broadcasts++
+
+	// Errors caused by the caller say nothing about member's health.
+	if err == nil || isCallerError(err) {
+		m.consecutiveFailures = 0
+		return
+	}
+
+	m.failures++
+	m.consecutiveFailures++
+	if m.consecutiveFailures >= p.ejectAfterFailures {
+		m.consecutiveFailures = 0
+		m.ejectedUntil = p.clock.Now().Add(p.ejectFor)
+	}
+}
+
+// isCallerError returns true if the error is caused by the request or the caller's context.
+func isCallerError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	switch Classify(err) {
+	case ClassInvalidRequest, ClassDuplicate:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *poolMember) healthy(now time.Time) bool {
+	return !now.Before(m.ejectedUntil)
+}
@@ -0,0 +1,151 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// newTestPool returns pool of two members, each of them has its own node and account.
+func newTestPool(t *testing.T, clock broadcaster.Clock) (*broadcaster.Pool, []*testutil.FakeNode, []testutil.Key) {
+	t.Helper()
+
+	var (
+		nodes   []*testutil.FakeNode
+		keys    []testutil.Key
+		members []broadcaster.Config
+	)
+	for _, name := range []string{"first", "second"} {
+		node := testutil.NewFakeNode()
+		key := testutil.NewKey(t.Name() + name)
+		node.AddAccount(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+		nodes, keys = append(nodes, node), append(keys, key)
+		members = append(members, testConfig(node, key))
+	}
+
+	p, err := broadcaster.NewPool(broadcaster.PoolConfig{
+		Members:            members,
+		EjectAfterFailures: 2,
+		EjectFor:           time.Minute,
+		Clock:              clock,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = p.Close() })
+
+	return p, nodes, keys
+}
+
+func TestPool_Ejection(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+	p, nodes, keys := newTestPool(t, clock)
+	require.Equal(t, 2, p.Size())
+
+	nodes[0].SetDown(true)
+	opts := broadcaster.BroadcastOptions{DisableAutoRetry: true}
+
+	for i := 0; i < 2; i++ {
+		_, err := p.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(keys[0].Address, 1)}, "", opts)
+		require.Equal(t, broadcaster.ClassNodeUnavailable, broadcaster.Classify(err), err)
+	}
+	require.Equal(t, 1, p.Healthy())
+
+	stats := p.Stats()
+	require.False(t, stats[0].Healthy)
+	require.Equal(t, uint64(2), stats[0].Failures)
+
+	res, err := p.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(keys[1].Address, 1)}, "", opts)
+	require.NoError(t, err)
+	require.Equal(t, keys[1].Address, res.Signer)
+
+	clock.Advance(time.Minute)
+	require.Equal(t, 2, p.Healthy())
+}
+
+func TestPool_CallerErrors(t *testing.T) {
+	tt := []struct {
+		name string
+		ctx  func() context.Context
+		msgs []sdk.Msg
+	}{
+		{
+			name: "invalid request",
+			ctx:  context.Background,
+		},
+		{
+			name: "canceled",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			msgs: []sdk.Msg{sendMsg(testutil.NewKey("sender").Address, 1)},
+		},
+		{
+			name: "deadline exceeded",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithDeadline(context.Background(), time.Unix(0, 0))
+				cancel()
+				return ctx
+			},
+			msgs: []sdk.Msg{sendMsg(testutil.NewKey("sender").Address, 1)},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p, _, _ := newTestPool(t, testutil.NewFakeClock(time.Now()))
+
+			for i := 0; i < 3; i++ {
+				_, err := p.BroadcastContext(tc.ctx(), tc.msgs, "", broadcaster.BroadcastOptions{})
+				require.Error(t, err)
+			}
+
+			require.Equal(t, 2, p.Healthy())
+			require.Zero(t, p.Stats()[0].Failures)
+		})
+	}
+}
+
+func TestPool_LeastLoaded(t *testing.T) {
+	p, nodes, keys := newTestPool(t, testutil.NewFakeClock(time.Now()))
+
+	// The first member is busy until the second one broadcasts.
+	entered, unblock := make(chan struct{}), make(chan struct{})
+	slowSimulation(nodes[0], 0, func() {
+		close(entered)
+		<-unblock
+	})
+
+	type result struct {
+		res *broadcaster.BroadcastResult
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		res, err := p.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(keys[0].Address, 1)}, "", broadcaster.BroadcastOptions{})
+		done <- result{res, err}
+	}()
+	<-entered
+	require.Equal(t, 1, p.Stats()[0].InFlight)
+
+	res, err := p.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(keys[1].Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, keys[1].Address, res.Signer)
+
+	close(unblock)
+	first := <-done
+	require.NoError(t, first.err)
+	require.Equal(t, keys[0].Address, first.res.Signer)
+
+	stats := p.Stats()
+	require.Equal(t, uint64(1), stats[0].Broadcasts)
+	require.Equal(t, uint64(1), stats[1].Broadcasts)
+}
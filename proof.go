@@ -0,0 +1,137 @@
+package broadcaster
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// ErrProofInvalid is returned when tx inclusion proof doesn't match the trusted header.
+var ErrProofInvalid = errors.New("invalid tx proof")
+
+// HeaderSource provides trusted block headers. NodeHeaders could be used to take them from a node.
+type HeaderSource interface {
+	Header(ctx context.Context, height int64) (*tmtypes.Header, error)
+}
+
+// HeaderSourceFunc is an adapter to use a function as HeaderSource.
+type HeaderSourceFunc func(ctx context.Context, height int64) (*tmtypes.Header, error)
+
+// Header returns header of the block.
+func (f HeaderSourceFunc) Header(ctx context.Context, height int64) (*tmtypes.Header, error) {
+	return f(ctx, height)
+}
+
+// VerifiedTx is tx which inclusion is verified against a trusted header.
+type VerifiedTx struct {
+	Response *sdk.TxResponse
+	// Height is the height of the block containing tx.
+	Height int64
+	// BlockHash is the hash of the trusted header.
+	BlockHash string
+}
+
+// GetTxWithProof returns committed tx and verifies its merkle proof against the header provided by src.
+// When src is nil, the header is fetched from the first of Config.ExtraNodeURIs with NodeHeaders.
+// ErrProofInvalid is returned if the proof doesn't match.
+func (b *broadcaster) GetTxWithProof(ctx context.Context, txHash string, src HeaderSource) (*VerifiedTx, error) {
+	hash, err := hex.DecodeString(txHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx hash: %w", err)
+	}
+
+	if src == nil {
+		if len(b.extraClients) == 0 {
+			return nil, errors.New("header source or extra node is required")
+		}
+		src = b.extraNodeHeaders()
+	}
+
+	node, err := b.ctx.GetNode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	res, err := node.Tx(ctx, hash, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx: %w", err)
+	}
+
+	header, err := src.Header(ctx, res.Height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header: %w", err)
+	}
+
+	if !bytes.Equal(res.Tx.Hash(), hash) || !bytes.Equal(res.Proof.Data, res.Tx) {
+		return nil, fmt.Errorf("%w: proof is made for another tx", ErrProofInvalid)
+	}
+
+	if header.Height != res.Height {
+		return nil, fmt.Errorf("%w: header's height %d differs from tx's one %d", ErrProofInvalid, header.Height, res.Height)
+	}
+
+	if err := res.Proof.Validate(header.DataHash); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProofInvalid, err)
+	}
+
+	return &VerifiedTx{
+		Response:  sdk.NewResponseResultTx(res, nil, ""),
+		Height:    res.Height,
+		BlockHash: header.Hash().String(),
+	}, nil
+}
+
+// extraNodeHeaders returns HeaderSource which fetches headers from the first of Config.ExtraNodeURIs.
+func (b *broadcaster) extraNodeHeaders() HeaderSource {
+	return NodeHeaders(b.extraClients[0].Client(), b.cfg.ChainID)
+}
+
+// NodeHeaders returns HeaderSource which fetches headers from the node. A header is trusted only if its commit
+// is signed by more than 2/3 of voting power of the validator set the header refers to, ErrProofInvalid is returned otherwise.
+func NodeHeaders(node rpcclient.Client, chainID string) HeaderSource {
+	return HeaderSourceFunc(func(ctx context.Context, height int64) (*tmtypes.Header, error) {
+		res, err := node.Commit(ctx, &height)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch commit: %w", err)
+		}
+
+		_, vals, err := fetchValidators(ctx, node, height)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := verifySignedHeader(chainID, res.SignedHeader, vals); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrProofInvalid, err)
+		}
+
+		return res.Header, nil
+	})
+}
+
+// verifySignedHeader checks that the header is committed by the validators.
+func verifySignedHeader(chainID string, sh tmtypes.SignedHeader, vals []*tmtypes.Validator) error {
+	if err := sh.ValidateBasic(chainID); err != nil {
+		return fmt.Errorf("invalid signed header: %w", err)
+	}
+
+	set, err := tmtypes.ValidatorSetFromExistingValidators(vals)
+	if err != nil {
+		return fmt.Errorf("invalid validator set: %w", err)
+	}
+
+	if !bytes.Equal(set.Hash(), sh.ValidatorsHash) {
+		return errors.New("validator set doesn't match the header")
+	}
+
+	if err := set.VerifyCommitLight(chainID, sh.Commit.BlockID, sh.Height, sh.Commit); err != nil {
+		return fmt.Errorf("invalid commit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// tamperingNode is the fake node which alters commits and validator sets it returns.
+type tamperingNode struct {
+	*testutil.FakeNode
+
+	commit     func(sh *tmtypes.SignedHeader)
+	validators func(res *ctypes.ResultValidators)
+}
+
+func (n *tamperingNode) Commit(ctx context.Context, height *int64) (*ctypes.ResultCommit, error) {
+	res, err := n.FakeNode.Commit(ctx, height)
+	if err != nil || n.commit == nil {
+		return res, err
+	}
+
+	header, commit := *res.Header, *res.Commit
+	commit.Signatures = append([]tmtypes.CommitSig(nil), commit.Signatures...)
+	for i, v := range commit.Signatures {
+		commit.Signatures[i].Signature = append([]byte(nil), v.Signature...)
+	}
+
+	sh := tmtypes.SignedHeader{Header: &header, Commit: &commit}
+	n.commit(&sh)
+
+	return ctypes.NewResultCommit(sh.Header, sh.Commit, res.CanonicalCommit), nil
+}
+
+func (n *tamperingNode) Validators(ctx context.Context, height *int64, page, perPage *int) (*ctypes.ResultValidators, error) {
+	res, err := n.FakeNode.Validators(ctx, height, page, perPage)
+	if err != nil || n.validators == nil {
+		return res, err
+	}
+
+	n.validators(res)
+
+	return res, nil
+}
+
+// committedTx returns the fake node with 4 validators and the hash of a committed tx.
+func committedTx(t *testing.T) (*testutil.FakeNode, broadcaster.Config, string) {
+	t.Helper()
+
+	node, key := newFakeChain(t)
+	node.SetValidators(4)
+	node.NextBlock()
+
+	cfg := testConfig(node, key)
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+	node.NextBlock()
+
+	return node, cfg, res.TxHash
+}
+
+func TestGetTxWithProof(t *testing.T) {
+	node, cfg, hash := committedTx(t)
+
+	srv := node.Serve()
+	defer srv.Close()
+	cfg.ExtraNodeURIs = []string{srv.URL}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	committed, ok := node.CommittedTx(hash)
+	require.True(t, ok)
+	commit, err := node.Commit(context.Background(), &committed.Height)
+	require.NoError(t, err)
+
+	for name, src := range map[string]broadcaster.HeaderSource{
+		"extra node": nil,
+		"node":       broadcaster.NodeHeaders(node, testutil.FakeChainID),
+	} {
+		tx, err := b.GetTxWithProof(context.Background(), hash, src)
+		require.NoError(t, err, name)
+		require.Equal(t, hash, tx.Response.TxHash, name)
+		require.Equal(t, committed.Height, tx.Height, name)
+		require.Equal(t, commit.Header.Hash().String(), tx.BlockHash, name)
+	}
+}
+
+func TestGetTxWithProof_Tampered(t *testing.T) {
+	foreign := testutil.NewFakeNode()
+	foreign.SetValidators(4)
+
+	tt := []struct {
+		name       string
+		commit     func(sh *tmtypes.SignedHeader)
+		validators func(res *ctypes.ResultValidators)
+	}{
+		{
+			name:   "data hash",
+			commit: func(sh *tmtypes.SignedHeader) { sh.DataHash = tmtypes.Txs{tmtypes.Tx("forged")}.Hash() },
+		},
+		{
+			name: "data hash with matching block id",
+			commit: func(sh *tmtypes.SignedHeader) {
+				sh.DataHash = tmtypes.Txs{tmtypes.Tx("forged")}.Hash()
+				sh.Commit.BlockID.Hash = sh.Header.Hash()
+			},
+		},
+		{
+			name:   "signature",
+			commit: func(sh *tmtypes.SignedHeader) { sh.Commit.Signatures[0].Signature[0] ^= 0xff },
+		},
+		{
+			name: "not enough signatures",
+			commit: func(sh *tmtypes.SignedHeader) {
+				sh.Commit.Signatures[1] = tmtypes.NewCommitSigAbsent()
+				sh.Commit.Signatures[2] = tmtypes.NewCommitSigAbsent()
+			},
+		},
+		{
+			name: "chain id",
+			commit: func(sh *tmtypes.SignedHeader) {
+				sh.ChainID = "other"
+				sh.Commit.BlockID.Hash = sh.Header.Hash()
+			},
+		},
+		{
+			name:       "validator set",
+			validators: func(res *ctypes.ResultValidators) { res.Validators = foreign.ValidatorSet().Validators },
+		},
+		{
+			name: "validator set with matching header",
+			commit: func(sh *tmtypes.SignedHeader) {
+				sh.ValidatorsHash = foreign.ValidatorSet().Hash()
+				sh.Commit.BlockID.Hash = sh.Header.Hash()
+			},
+			validators: func(res *ctypes.ResultValidators) { res.Validators = foreign.ValidatorSet().Validators },
+		},
+	}
+
+	node, cfg, hash := committedTx(t)
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			src := broadcaster.NodeHeaders(&tamperingNode{FakeNode: node, commit: tc.commit, validators: tc.validators},
+				testutil.FakeChainID)
+
+			_, err := b.GetTxWithProof(context.Background(), hash, src)
+			require.ErrorIs(t, err, broadcaster.ErrProofInvalid)
+		})
+	}
+}
+
+func TestGetTxWithProof_TamperedProof(t *testing.T) {
+	node, cfg, hash := committedTx(t)
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// The trusted header is of the block without the tx.
+	src := broadcaster.HeaderSourceFunc(func(ctx context.Context, height int64) (*tmtypes.Header, error) {
+		res, err := node.Commit(ctx, &height)
+		if err != nil {
+			return nil, err
+		}
+
+		header := *res.Header
+		header.DataHash = tmtypes.Txs{tmtypes.Tx("other")}.Hash()
+		return &header, nil
+	})
+
+	_, err = b.GetTxWithProof(context.Background(), hash, src)
+	require.ErrorIs(t, err, broadcaster.ErrProofInvalid)
+}
@@ -0,0 +1,72 @@
+package broadcaster
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPropagationGracePeriod is the default time given to tx to reach the second node.
+const DefaultPropagationGracePeriod = 5 * time.Second
+
+// verifyPropagation checks in background that tx has reached the first of Config.ExtraNodeURIs
+// after the grace period. Failures are reported to the logger and counted in Stats.
+func (b *broadcaster) verifyPropagation(ctx context.Context, txHash string) {
+	if !b.cfg.VerifyPropagation || len(b.extraClients) == 0 {
+		return
+	}
+
+	hash, err := hex.DecodeString(txHash)
+	if err != nil {
+		return
+	}
+
+	// The check outlives the call, so only the request id is taken from its context.
+	logCtx := WithRequestID(context.Background(), RequestIDFromContext(ctx))
+
+	go func() {
+		timer := b.cfg.clock().NewTimer(b.cfg.propagationGracePeriod())
+		defer timer.Stop()
+
+		select {
+		case <-b.closing:
+			return
+		case <-timer.C():
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), b.cfg.propagationGracePeriod())
+		defer cancel()
+
+		if !b.isVisible(ctx, hash) {
+			atomic.AddUint64(&b.propagationFailures, 1)
+			b.warnf(logCtx, "tx %s is not visible on %s after %s", txHash, b.cfg.ExtraNodeURIs[0],
+				b.cfg.propagationGracePeriod())
+		}
+	}()
+}
+
+// isVisible returns true if the second node has tx in mempool or in a block. The node is asked directly
+// instead of the tracker, since the tracker polls the extra nodes only when the primary one is unavailable.
+func (b *broadcaster) isVisible(ctx context.Context, hash []byte) bool {
+	node := b.extraClients[0].Client()
+
+	if _, err := node.Tx(ctx, hash, false); err == nil {
+		return true
+	}
+
+	limit := 100
+	res, err := node.UnconfirmedTxs(ctx, &limit)
+	if err != nil {
+		return false
+	}
+
+	for _, v := range res.Txs {
+		if bytes.Equal(v.Hash(), hash) {
+			return true
+		}
+	}
+
+	return false
+}
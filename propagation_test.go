@@ -0,0 +1,96 @@
+package broadcaster_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// warnings is broadcaster.Logger which keeps warnings.
+type warnings struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (w *warnings) Infof(string, ...interface{}) {}
+
+func (w *warnings) Warnf(format string, args ...interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.list = append(w.list, fmt.Sprintf(format, args...))
+}
+
+func (w *warnings) get() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string(nil), w.list...)
+}
+
+func TestVerifyPropagation(t *testing.T) {
+	const grace = 5 * time.Second
+
+	for _, propagated := range []bool{false, true} {
+		propagated := propagated
+		t.Run(fmt.Sprintf("propagated=%t", propagated), func(t *testing.T) {
+			primary, key := newFakeChain(t)
+			secondary := testutil.NewFakeNode()
+			secondary.AddAccount(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+			srv := secondary.Serve()
+			defer srv.Close()
+
+			clock := testutil.NewFakeClock(time.Now())
+			logger := &warnings{}
+
+			cfg := testConfig(primary, key)
+			cfg.Clock = clock
+			cfg.Logger = logger
+			cfg.ExtraNodeURIs = []string{srv.URL}
+			cfg.VerifyPropagation = true
+			cfg.PropagationGracePeriod = grace
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+				broadcaster.BroadcastOptions{Gas: 100000})
+			require.NoError(t, err, "the check doesn't block the broadcast")
+
+			if propagated {
+				_, err := secondary.BroadcastTxSync(context.Background(), primary.Mempool()[0].Bytes)
+				require.NoError(t, err)
+			}
+
+			clock.BlockUntil(1)
+			clock.Advance(grace)
+
+			if !propagated {
+				require.Eventually(t, func() bool {
+					return b.Stats().PropagationFailures == 1
+				}, 5*time.Second, 10*time.Millisecond)
+				require.Len(t, logger.get(), 1)
+				require.Contains(t, logger.get()[0], res.TxHash)
+				return
+			}
+
+			// The tx is found in the second node's mempool, which is the last step of the check.
+			require.Eventually(t, func() bool {
+				return secondary.Calls("unconfirmed_txs") == 1
+			}, 5*time.Second, 10*time.Millisecond)
+			time.Sleep(100 * time.Millisecond)
+			require.Zero(t, b.Stats().PropagationFailures)
+			require.Empty(t, logger.get())
+		})
+	}
+}
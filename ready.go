@@ -0,0 +1,80 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Errors returned by Ready.
+var (
+	// ErrNodeUnavailable is returned when the node doesn't respond.
+	ErrNodeUnavailable = errors.New("node is unavailable")
+	// ErrNodeCatchingUp is returned when the node is syncing.
+	ErrNodeCatchingUp = errors.New("node is catching up")
+	// ErrChainIDMismatch is returned when the node belongs to another chain.
+	ErrChainIDMismatch = errors.New("chain id mismatch")
+	// ErrAccountNotFound is returned when the account doesn't exist on chain.
+	ErrAccountNotFound = errors.New("account is not found")
+	// ErrBalanceTooLow is returned when spendable balance is below Config.MinBalance.
+	ErrBalanceTooLow = errors.New("balance is too low")
+)
+
+// Ready checks that the broadcaster is able to broadcast: the node responds and isn't catching up,
+// the chain isn't halted, it belongs to the configured chain, the account exists and its spendable balance isn't below Config.MinBalance.
+// The returned error matches one of the errors above, failed requests of the account and the balance are returned as they are.
+func (b *broadcaster) Ready(ctx context.Context) error {
+	node, err := b.ctx.GetNode()
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNodeUnavailable, err)
+	}
+
+	status, err := node.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNodeUnavailable, err)
+	}
+
+	if status.SyncInfo.CatchingUp {
+		return fmt.Errorf("%w: height %d", ErrNodeCatchingUp, status.SyncInfo.LatestBlockHeight)
+	}
+
+	if b.halt != nil {
+		if _, halted := b.halt.state(); halted {
+			return fmt.Errorf("%w: height %d", ErrChainHalted, status.SyncInfo.LatestBlockHeight)
+		}
+	}
+
+	if status.NodeInfo.Network != b.cfg.ChainID {
+		return fmt.Errorf("%w: node's chain id is %s", ErrChainIDMismatch, status.NodeInfo.Network)
+	}
+
+	if err := b.ctx.AccountRetriever.EnsureExists(b.ctx, b.From()); err != nil {
+		if !isAccountNotFound(err) {
+			return fmt.Errorf("failed to check account: %w", err)
+		}
+		b.setAccountMissing(true)
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, err)
+	}
+
+	// The account has reappeared, e.g. after the chain reset, so its number and sequence are stale.
+	if b.isAccountMissing() {
+		if err := b.RefreshSequence(); err != nil {
+			return err
+		}
+	}
+
+	if b.cfg.MinBalance.Empty() {
+		return nil
+	}
+
+	balance, err := b.GetSpendableBalance(ctx, b.From())
+	if err != nil {
+		return err
+	}
+
+	if !balance.IsAllGTE(b.cfg.MinBalance) {
+		return fmt.Errorf("%w: %s is less than %s", ErrBalanceTooLow, balance, b.cfg.MinBalance)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestReady(t *testing.T) {
+	tt := []struct {
+		name string
+		fail func(node *testutil.FakeNode, key testutil.Key)
+		want error
+	}{
+		{
+			name: "node is down",
+			fail: func(node *testutil.FakeNode, _ testutil.Key) { node.SetDown(true) },
+			want: broadcaster.ErrNodeUnavailable,
+		},
+		{
+			name: "node is catching up",
+			fail: func(node *testutil.FakeNode, _ testutil.Key) { node.SetCatchingUp(true) },
+			want: broadcaster.ErrNodeCatchingUp,
+		},
+		{
+			name: "other chain",
+			fail: func(node *testutil.FakeNode, _ testutil.Key) { node.SetChainID("other") },
+			want: broadcaster.ErrChainIDMismatch,
+		},
+		{
+			name: "account is missing",
+			fail: func(node *testutil.FakeNode, key testutil.Key) { node.RemoveAccount(key.Address) },
+			want: broadcaster.ErrAccountNotFound,
+		},
+		{
+			name: "balance is too low",
+			fail: func(node *testutil.FakeNode, key testutil.Key) {
+				node.SetBalance(key.Address, sdk.NewInt64Coin(testDenom, 999))
+			},
+			want: broadcaster.ErrBalanceTooLow,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			cfg := testConfig(node, key)
+			cfg.MinBalance = sdk.NewCoins(sdk.NewInt64Coin(testDenom, 1000))
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			require.NoError(t, b.Ready(context.Background()))
+
+			tc.fail(node, key)
+			require.ErrorIs(t, b.Ready(context.Background()), tc.want)
+		})
+	}
+}
+
+func TestReady_AccountDisappears(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	var (
+		mu          sync.Mutex
+		transitions []bool
+	)
+	cfg := testConfig(node, key)
+	cfg.OnAccountMissing = func(missing bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		transitions = append(transitions, missing)
+	}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	broadcast := func() (*broadcaster.BroadcastResult, error) {
+		return b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	}
+
+	res, err := broadcast()
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+
+	// The chain is reset, the simulation reports the missing account.
+	node.RemoveAccount(key.Address)
+	_, err = broadcast()
+	require.ErrorIs(t, err, broadcaster.ErrAccountNotFound)
+	require.True(t, b.Stats().AccountMissing)
+	require.ErrorIs(t, b.Ready(context.Background()), broadcaster.ErrAccountNotFound)
+
+	// Failed lookups aren't reported as the missing account.
+	node.FailNext("abci_query", errors.New("connection reset"))
+	err = b.Ready(context.Background())
+	require.Error(t, err)
+	require.NotErrorIs(t, err, broadcaster.ErrAccountNotFound)
+	require.True(t, b.Stats().AccountMissing)
+
+	// The account is funded again with the new number and sequence, which are picked up by Ready.
+	node.AddAccount(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+	node.SetAccountNumber(key.Address, 42)
+	require.NoError(t, b.Ready(context.Background()))
+	require.False(t, b.Stats().AccountMissing)
+
+	res, err = broadcast()
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Attempts)
+	require.Zero(t, res.Sequence)
+	requireCommitted(t, node, res.TxHash)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []bool{true, false}, transitions)
+}
@@ -0,0 +1,84 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultRecentErrors is the default number of broadcast errors kept by RecentErrors.
+const DefaultRecentErrors = 20
+
+// RecentError is a failed broadcast kept for debugging.
+type RecentError struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	// TxHash is the hash of the last attempted tx. It's empty when tx wasn't built.
+	TxHash string `json:"tx_hash,omitempty"`
+	Class  string `json:"class"`
+	Error  string `json:"error"`
+}
+
+// errorRing keeps the last errors.
+type errorRing struct {
+	mu    sync.Mutex
+	items []RecentError
+	next  int
+	full  bool
+}
+
+func newErrorRing(size int) *errorRing {
+	if size <= 0 {
+		size = DefaultRecentErrors
+	}
+
+	return &errorRing{items: make([]RecentError, size)}
+}
+
+// add adds the error replacing the oldest one when the ring is full.
+func (r *errorRing) add(e RecentError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items[r.next] = e
+	if r.next = (r.next + 1) % len(r.items); r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns errors from the oldest to the newest.
+func (r *errorRing) list() []RecentError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]RecentError(nil), r.items[:r.next]...)
+	}
+
+	return append(append([]RecentError(nil), r.items[r.next:]...), r.items[:r.next]...)
+}
+
+// recordError adds the broadcast error to RecentErrors. Errors caused by the caller's context aren't recorded.
+func (b *broadcaster) recordError(ctx context.Context, res *BroadcastResult, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	e := RecentError{
+		Time:      b.cfg.clock().Now(),
+		RequestID: RequestIDFromContext(ctx),
+		Class:     Classify(err).String(),
+		Error:     err.Error(),
+	}
+	if res != nil {
+		e.TxHash = res.TxHash
+	}
+
+	b.recentErrors.add(e)
+}
+
+// RecentErrors returns the last Config.RecentErrors broadcast errors from the oldest to the newest.
+func (b *broadcaster) RecentErrors() []RecentError {
+	return b.recentErrors.list()
+}
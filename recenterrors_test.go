@@ -0,0 +1,64 @@
+package broadcaster_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestRecentErrors(t *testing.T) {
+	const size = 3
+
+	node, key := newFakeChain(t)
+	node.OnCheckTx(func(testutil.FakeTx) error {
+		return sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, "rejected by test")
+	})
+
+	cfg := testConfig(node, key)
+	cfg.RecentErrors = size
+	cfg.DisableAutoRetry = true
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	broadcast := func(ctx context.Context, id string) {
+		_, err := b.BroadcastContext(broadcaster.WithRequestID(ctx, id), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+			broadcaster.BroadcastOptions{})
+		require.Error(t, err)
+	}
+
+	require.Empty(t, b.RecentErrors())
+
+	// The ring isn't full yet.
+	broadcast(context.Background(), "req 0")
+	recent := b.RecentErrors()
+	require.Len(t, recent, 1)
+	require.Equal(t, "req 0", recent[0].RequestID)
+	require.Equal(t, broadcaster.ClassInvalidRequest.String(), recent[0].Class)
+	require.NotEmpty(t, recent[0].TxHash)
+	require.Contains(t, recent[0].Error, "rejected by test")
+
+	// The oldest errors are replaced, the rest are kept from the oldest to the newest.
+	for i := 1; i < 2*size; i++ {
+		broadcast(context.Background(), fmt.Sprint("req ", i))
+	}
+	recent = b.RecentErrors()
+	require.Len(t, recent, size)
+	for i, v := range recent {
+		require.Equal(t, fmt.Sprint("req ", size+i), v.RequestID)
+	}
+
+	// Errors caused by the caller's context aren't recorded.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	broadcast(ctx, "cancelled")
+	require.Equal(t, recent, b.RecentErrors())
+}
@@ -0,0 +1,137 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultReconcileIdleFor is the default time the broadcaster should be idle before the sequence is reconciled.
+const DefaultReconcileIdleFor = time.Minute
+
+// SequenceReconcile configures background reconciliation of the local sequence with the chain.
+// It catches drift caused by txs signed elsewhere with the same key, e.g. from the CLI.
+// The sequence is compared only when the broadcaster is idle and no tracked tx is pending.
+type SequenceReconcile struct {
+	// Interval is an interval of checks. Reconciliation is disabled when it's zero.
+	Interval time.Duration
+	// IdleFor is the time without broadcasts after which the sequence is compared.
+	// DefaultReconcileIdleFor is used by default.
+	IdleFor time.Duration
+	// OnDrift is called when the local sequence differs from the chain's one and is corrected.
+	OnDrift func(local, chain uint64)
+}
+
+// Validate validates the sequence reconcile config.
+func (r SequenceReconcile) Validate() error {
+	if r.Interval < 0 || r.IdleFor < 0 {
+		return errors.New("sequence reconcile interval and idle time should be positive")
+	}
+
+	return nil
+}
+
+// reconciler periodically reconciles the sequence in background.
+type reconciler struct {
+	interval  time.Duration
+	clock     Clock
+	jitter    *jitterSource
+	reconcile func(ctx context.Context)
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newReconciler(interval time.Duration, clock Clock, jitter *jitterSource, reconcile func(ctx context.Context)) *reconciler {
+	r := &reconciler{
+		interval:  interval,
+		clock:     clock,
+		jitter:    jitter,
+		reconcile: reconcile,
+
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go r.run()
+
+	return r
+}
+
+func (r *reconciler) run() {
+	defer close(r.done)
+
+	for {
+		timer := r.clock.NewTimer(r.interval + r.jitter.apply(JitterFull, r.interval/10))
+
+		select {
+		case <-r.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+			r.reconcile(context.Background())
+		}
+	}
+}
+
+// close stops the reconciler and waits for it to exit.
+func (r *reconciler) close() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+}
+
+// touch records broadcaster's activity.
+func (b *broadcaster) touch() {
+	atomic.StoreInt64(&b.lastActive, b.cfg.clock().Now().UnixNano())
+}
+
+// idle returns true if there were no broadcasts for the duration.
+func (b *broadcaster) idle(d time.Duration) bool {
+	if atomic.LoadInt64(&b.inFlight) > 0 {
+		return false
+	}
+
+	return b.cfg.clock().Now().Sub(time.Unix(0, atomic.LoadInt64(&b.lastActive))) >= d
+}
+
+// reconcileSequence adopts the chain's sequence if it differs from the local one. It's skipped unless
+// the broadcaster is idle, and it never waits for broadcasts.
+func (b *broadcaster) reconcileSequence(ctx context.Context) {
+	idleFor := b.cfg.SequenceReconcile.IdleFor
+	if idleFor == 0 {
+		idleFor = DefaultReconcileIdleFor
+	}
+
+	if !b.idle(idleFor) || len(b.PendingTxs()) > 0 {
+		return
+	}
+
+	if !b.mu.TryLock() {
+		return
+	}
+	defer b.mu.Unlock()
+
+	_, seq, err := b.txf.AccountRetriever().GetAccountNumberSequence(b.ctx, b.From())
+	if err != nil {
+		return
+	}
+
+	local := b.acc.sequence()
+	if seq == local {
+		return
+	}
+
+	b.acc.setSequence(seq)
+	_ = b.persistSequence()
+
+	b.infof(ctx, "sequence drift is corrected: local %d, chain %d", local, seq)
+
+	if b.cfg.SequenceReconcile.OnDrift != nil {
+		b.cfg.SequenceReconcile.OnDrift(local, seq)
+	}
+}
@@ -0,0 +1,126 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+type drift struct {
+	local, chain uint64
+}
+
+// reconcileConfig returns config of broadcaster reconciling the sequence every interval after idleFor on the clock.
+func reconcileConfig(
+	node *testutil.FakeNode, key testutil.Key, clock *testutil.FakeClock, interval, idleFor time.Duration, drifts chan<- drift,
+) broadcaster.Config {
+	cfg := testConfig(node, key)
+	cfg.Clock = clock
+	cfg.SequenceReconcile = broadcaster.SequenceReconcile{
+		Interval: interval,
+		IdleFor:  idleFor,
+		OnDrift: func(local, chain uint64) {
+			drifts <- drift{local: local, chain: chain}
+		},
+	}
+
+	return cfg
+}
+
+func TestSequenceReconcile(t *testing.T) {
+	const (
+		interval = 10 * time.Second
+		idleFor  = time.Minute
+	)
+
+	node, key := newFakeChain(t)
+	clock := testutil.NewFakeClock(time.Now())
+	drifts := make(chan drift, 10)
+
+	b, err := broadcaster.New(reconcileConfig(node, key, clock, interval, idleFor, drifts))
+	require.NoError(t, err)
+
+	// check lets the reconciler check the sequence once. Its next timer means the check is done.
+	check := func() {
+		clock.BlockUntil(1)
+		clock.Advance(interval + interval/10)
+		clock.BlockUntil(1)
+	}
+	broadcast := func(seq uint64) {
+		t.Helper()
+
+		res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+			broadcaster.BroadcastOptions{DisableAutoRetry: true})
+		require.NoError(t, err)
+		require.Equal(t, seq, res.Sequence)
+		requireCommitted(t, node, res.TxHash)
+	}
+
+	broadcast(0)
+
+	// The key is used by the CLI.
+	node.SetSequence(key.Address, 5)
+
+	// The broadcaster isn't idle long enough yet.
+	check()
+	check()
+	require.Empty(t, drifts)
+
+	for i := 0; i < 4; i++ {
+		check()
+	}
+	require.Equal(t, drift{local: 1, chain: 5}, <-drifts)
+
+	// The adopted sequence is used without a mismatch.
+	broadcast(5)
+
+	// The sequence in sync isn't reported.
+	for i := 0; i < 7; i++ {
+		check()
+	}
+	require.Empty(t, drifts)
+
+	// The reconciler stops on close.
+	b.Close()
+	require.Zero(t, clock.Waiters())
+}
+
+func TestSequenceReconcile_PendingTxs(t *testing.T) {
+	const interval = 10 * time.Second
+
+	node, key := newFakeChain(t)
+	clock := testutil.NewFakeClock(time.Now())
+	drifts := make(chan drift, 10)
+
+	cfg := reconcileConfig(node, key, clock, interval, interval, drifts)
+	// The tracker doesn't poll within the test, so the tx stays pending.
+	cfg.Tracking = broadcaster.TxTracking{Interval: 1000 * time.Hour}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Len(t, b.PendingTxs(), 1)
+
+	// The chain's sequence doesn't count the tx in the mempool, it isn't adopted while the tx is pending.
+	for i := 0; i < 5; i++ {
+		clock.BlockUntil(2)
+		clock.Advance(interval + interval/10)
+		clock.BlockUntil(2)
+	}
+	require.Empty(t, drifts)
+
+	// The next tx follows the pending one.
+	next, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, res.Sequence+1, next.Sequence)
+	require.Equal(t, 1, next.Attempts)
+}
@@ -0,0 +1,188 @@
+package broadcaster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// TxRecord contains inputs and output of tx construction. It is written to Config.RecordDir
+// and could be replayed with ReplayRecord to detect changes in tx construction.
+type TxRecord struct {
+	ChainID       string            `json:"chain_id"`
+	AccountNumber uint64            `json:"account_number"`
+	Sequence      uint64            `json:"sequence"`
+	Memo          string            `json:"memo"`
+	Fees          string            `json:"fees"`
+	GasPrices     string            `json:"gas_prices,omitempty"`
+	Gas           uint64            `json:"gas"`
+	FeeGranter    string            `json:"fee_granter,omitempty"`
+	TimeoutHeight uint64            `json:"timeout_height,omitempty"`
+	Msgs          []json.RawMessage `json:"msgs"`
+
+	ExtensionOptions            []*codectypes.Any `json:"extension_options,omitempty"`
+	NonCriticalExtensionOptions []*codectypes.Any `json:"non_critical_extension_options,omitempty"`
+
+	TxBytes []byte `json:"tx_bytes"`
+	TxHash  string `json:"tx_hash"`
+}
+
+// LoadRecord reads tx record from the file.
+func LoadRecord(path string) (TxRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TxRecord{}, fmt.Errorf("failed to read record: %w", err)
+	}
+
+	var rec TxRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return TxRecord{}, fmt.Errorf("failed to unmarshal record: %w", err)
+	}
+
+	return rec, nil
+}
+
+// ReplayRecord builds and signs tx from the record's inputs and checks that the result is byte-for-byte equal
+// to the recorded one. The broadcaster should use the same key which the record was made with.
+// The error contains a diff of decoded txs when they differ.
+func (b *broadcaster) ReplayRecord(rec TxRecord) error {
+	msgs := make([]sdk.Msg, len(rec.Msgs))
+	for i, v := range rec.Msgs {
+		if err := b.enc.Marshaler.UnmarshalInterfaceJSON(v, &msgs[i]); err != nil {
+			return fmt.Errorf("failed to unmarshal msg %d: %w", i, err)
+		}
+	}
+
+	ext := extensionOptions{critical: rec.ExtensionOptions, nonCritical: rec.NonCriticalExtensionOptions}
+	if rec.FeeGranter != "" {
+		granter, err := sdk.AccAddressFromBech32(rec.FeeGranter)
+		if err != nil {
+			return fmt.Errorf("invalid fee granter: %w", err)
+		}
+		ext.feeGranter = granter
+	}
+
+	txf := b.txf.
+		WithChainID(rec.ChainID).
+		WithAccountNumber(rec.AccountNumber).
+		WithSequence(rec.Sequence).
+		WithMemo(rec.Memo).
+		WithFees(rec.Fees).
+		WithGasPrices(rec.GasPrices).
+		WithGas(rec.Gas).
+		WithTimeoutHeight(rec.TimeoutHeight)
+
+	txBytes, err := b.buildTx(txf, msgs, ext)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(txBytes, rec.TxBytes) {
+		return nil
+	}
+
+	expected, err := b.txJSON(rec.TxBytes)
+	if err != nil {
+		return fmt.Errorf("recorded tx is invalid: %w", err)
+	}
+
+	actual, err := b.txJSON(txBytes)
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("tx differs from the recorded one:\n%s", diffLines(expected, actual))
+}
+
+// record writes the signed tx with its inputs to Config.RecordDir.
+func (b *broadcaster) record(txf tx.Factory, msgs []sdk.Msg, ext extensionOptions, txBytes []byte) error {
+	rec := TxRecord{
+		ChainID:       txf.ChainID(),
+		AccountNumber: txf.AccountNumber(),
+		Sequence:      txf.Sequence(),
+		Memo:          txf.Memo(),
+		Fees:          txf.Fees().String(),
+		GasPrices:     txf.GasPrices().String(),
+		Gas:           txf.Gas(),
+		TimeoutHeight: txf.TimeoutHeight(),
+		Msgs:          make([]json.RawMessage, len(msgs)),
+
+		ExtensionOptions:            ext.critical,
+		NonCriticalExtensionOptions: ext.nonCritical,
+
+		TxBytes: txBytes,
+		TxHash:  TxHash(txBytes),
+	}
+
+	if !ext.feeGranter.Empty() {
+		rec.FeeGranter = ext.feeGranter.String()
+	}
+
+	for i, msg := range msgs {
+		data, err := b.enc.Marshaler.MarshalInterfaceJSON(msg)
+		if err != nil {
+			return fmt.Errorf("failed to marshal msg %d: %w", i, err)
+		}
+		rec.Msgs[i] = data
+	}
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(b.cfg.RecordDir, rec.TxHash+".json"), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+
+	return nil
+}
+
+// txJSON returns indented JSON representation of tx.
+func (b *broadcaster) txJSON(txBytes []byte) (string, error) {
+	tx, err := b.ctx.TxConfig.TxDecoder()(txBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode tx: %w", err)
+	}
+
+	data, err := b.ctx.TxConfig.TxJSONEncoder()(tx)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tx: %w", err)
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return "", fmt.Errorf("failed to indent tx: %w", err)
+	}
+
+	return out.String(), nil
+}
+
+// diffLines returns lines which differ between expected and actual texts.
+func diffLines(expected, actual string) string {
+	e, a := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+
+	var out strings.Builder
+	for i := 0; i < len(e) || i < len(a); i++ {
+		var el, al string
+		if i < len(e) {
+			el = e[i]
+		}
+		if i < len(a) {
+			al = a[i]
+		}
+
+		if el != al {
+			fmt.Fprintf(&out, "line %d:\n- %s\n+ %s\n", i+1, el, al)
+		}
+	}
+
+	return out.String()
+}
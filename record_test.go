@@ -0,0 +1,171 @@
+package broadcaster_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+var updateRecords = flag.Bool("update-records", false, "rewrite golden tx records in testdata/records")
+
+// recordsDir contains golden tx records signed by recordKey.
+const recordsDir = "testdata/records"
+
+var recordKey = testutil.NewKey("golden")
+
+// recordCases are txs of the golden records.
+var recordCases = []struct {
+	name string
+	msgs []sdk.Msg
+	memo string
+	opts broadcaster.BroadcastOptions
+}{
+	{
+		name: "single_msg",
+		msgs: []sdk.Msg{sendMsg(recordKey.Address, 1)},
+		opts: broadcaster.BroadcastOptions{Gas: 100000, Fees: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 2500))},
+	},
+	{
+		name: "multi_msg",
+		msgs: []sdk.Msg{sendMsg(recordKey.Address, 1), sendMsg(recordKey.Address, 2), sendMsg(recordKey.Address, 3)},
+		memo: "multi;msg=memo",
+		opts: broadcaster.BroadcastOptions{Gas: 300000, Fees: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 7500))},
+	},
+	{
+		name: "fee_granter",
+		msgs: []sdk.Msg{sendMsg(recordKey.Address, 1)},
+		opts: broadcaster.BroadcastOptions{
+			Gas:        100000,
+			Fees:       sdk.NewCoins(sdk.NewInt64Coin(testDenom, 2500)),
+			FeeGranter: testutil.NewKey("granter").Address,
+		},
+	},
+	{
+		name: "timeout_height",
+		msgs: []sdk.Msg{sendMsg(recordKey.Address, 1)},
+		opts: broadcaster.BroadcastOptions{
+			Gas:           100000,
+			GasPrices:     sdk.NewDecCoins(sdk.NewDecCoinFromDec(testDenom, sdk.MustNewDecFromStr("0.025"))),
+			TimeoutHeight: 1000,
+		},
+	},
+}
+
+// newRecordBroadcaster returns broadcaster signing with recordKey which records txs to the directory.
+func newRecordBroadcaster(t *testing.T, recordDir string) interface {
+	BuildAndSign(ctx context.Context, msgs []sdk.Msg, memo string, opts broadcaster.BroadcastOptions) ([]byte, string, error)
+	ReplayRecord(rec broadcaster.TxRecord) error
+	Close() error
+} {
+	t.Helper()
+
+	node := testutil.NewFakeNode()
+	node.AddAccount(recordKey.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+	node.SetSequence(recordKey.Address, 7)
+
+	cfg := testConfig(node, recordKey)
+	cfg.RecordDir = recordDir
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = b.Close() })
+
+	return b
+}
+
+// TestRecords replays the golden records. Run it with -update-records to rewrite them
+// after an intended change of tx construction.
+func TestRecords(t *testing.T) {
+	if *updateRecords {
+		writeRecords(t)
+	}
+
+	b := newRecordBroadcaster(t, "")
+	for _, tc := range recordCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rec, err := broadcaster.LoadRecord(filepath.Join(recordsDir, tc.name+".json"))
+			require.NoError(t, err)
+			require.NoError(t, b.ReplayRecord(rec))
+		})
+	}
+}
+
+func writeRecords(t *testing.T) {
+	t.Helper()
+
+	for _, tc := range recordCases {
+		dir := t.TempDir()
+
+		_, hash, err := newRecordBroadcaster(t, dir).BuildAndSign(context.Background(), tc.msgs, tc.memo, tc.opts)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(dir, hash+".json"))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(recordsDir, tc.name+".json"), append(data, '\n'), 0o600))
+	}
+}
+
+func TestRecords_Recorded(t *testing.T) {
+	dir := t.TempDir()
+	b := newRecordBroadcaster(t, dir)
+
+	for _, tc := range recordCases {
+		_, hash, err := b.BuildAndSign(context.Background(), tc.msgs, tc.memo, tc.opts)
+		require.NoError(t, err)
+
+		rec, err := broadcaster.LoadRecord(filepath.Join(dir, hash+".json"))
+		require.NoError(t, err, tc.name)
+		require.Equal(t, hash, rec.TxHash)
+		require.Equal(t, tc.opts.TimeoutHeight, rec.TimeoutHeight, tc.name)
+		require.Equal(t, tc.opts.FeeGranter.String(), rec.FeeGranter, tc.name)
+		require.NoError(t, b.ReplayRecord(rec), tc.name)
+	}
+}
+
+func TestReplayRecord_Diff(t *testing.T) {
+	b := newRecordBroadcaster(t, "")
+
+	for _, tc := range []struct {
+		name   string
+		record string
+		change func(rec *broadcaster.TxRecord)
+		want   string
+	}{
+		{
+			name:   "memo",
+			record: "multi_msg",
+			change: func(rec *broadcaster.TxRecord) { rec.Memo = "changed" },
+			want:   `"memo": "changed"`,
+		},
+		{
+			name:   "timeout height",
+			record: "timeout_height",
+			change: func(rec *broadcaster.TxRecord) { rec.TimeoutHeight = 1001 },
+			want:   `"timeout_height": "1001"`,
+		},
+		{
+			name:   "fee granter",
+			record: "fee_granter",
+			change: func(rec *broadcaster.TxRecord) { rec.FeeGranter = recordKey.Address.String() },
+			want:   recordKey.Address.String(),
+		},
+	} {
+		rec, err := broadcaster.LoadRecord(filepath.Join(recordsDir, tc.record+".json"))
+		require.NoError(t, err)
+
+		tc.change(&rec)
+		err = b.ReplayRecord(rec)
+		require.ErrorContains(t, err, "tx differs from the recorded one", tc.name)
+		require.ErrorContains(t, err, "+ ", tc.name)
+		require.ErrorContains(t, err, tc.want, tc.name)
+	}
+}
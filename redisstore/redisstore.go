@@ -0,0 +1,192 @@
+// Package redisstore contains broadcaster.SequenceStore backed by redis.
+// It is a separate module, so users of the broadcaster don't depend on the redis client.
+package redisstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+// Default values of options.
+const (
+	DefaultLockTTL       = 30 * time.Second
+	DefaultRetryInterval = 50 * time.Millisecond
+)
+
+// ErrLockExpired is returned on release when the lock was expired and could be taken by someone else.
+var ErrLockExpired = errors.New("sequence lock is expired")
+
+func init() {
+	// The sequence could be used by the one who took the expired lock.
+	broadcaster.RegisterErrorClass(ErrLockExpired, broadcaster.ClassSequenceMismatch)
+}
+
+// releaseScript stores the sequence and removes the lock if it's still held by the caller.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[2]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2])
+	redis.call("DEL", KEYS[2])
+	return 1
+end
+return 0
+`)
+
+// unlockScript removes the lock if it's still held by the caller.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Option configures Store.
+type Option func(*Store)
+
+// WithLockTTL sets ttl of the lock. It should be greater than the longest broadcast.
+func WithLockTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.lockTTL = ttl
+	}
+}
+
+// WithRetryInterval sets an interval of retries to take the lock.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(s *Store) {
+		s.retryInterval = interval
+	}
+}
+
+// WithClock sets clock used to wait between retries to take the lock.
+func WithClock(clock broadcaster.Clock) Option {
+	return func(s *Store) {
+		s.clock = clock
+	}
+}
+
+// Store is broadcaster.SequenceStore backed by redis.
+// The sequence is kept under the key, and the lock is kept under the key with ":lock" suffix.
+type Store struct {
+	client redis.UniversalClient
+	key    string
+
+	lockTTL       time.Duration
+	retryInterval time.Duration
+	clock         broadcaster.Clock
+}
+
+var _ broadcaster.SequenceStore = &Store{}
+
+// New returns new instance of Store. The key should be unique for every account.
+func New(client redis.UniversalClient, key string, opts ...Option) *Store {
+	s := &Store{
+		client: client,
+		key:    key,
+
+		lockTTL:       DefaultLockTTL,
+		retryInterval: DefaultRetryInterval,
+		clock:         broadcaster.RealClock,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Init sets the sequence if the store doesn't have it yet.
+func (s *Store) Init(ctx context.Context, seq uint64) error {
+	if err := s.client.SetNX(ctx, s.key, seq, 0).Err(); err != nil {
+		return fmt.Errorf("failed to set sequence: %w", err)
+	}
+
+	return nil
+}
+
+// Acquire locks and returns the sequence.
+func (s *Store) Acquire(ctx context.Context) (uint64, func(next uint64) error, error) {
+	token, err := newToken()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := s.lock(ctx, token); err != nil {
+		return 0, nil, err
+	}
+
+	seq, err := s.get(ctx)
+	if err != nil {
+		unlockScript.Run(context.Background(), s.client, []string{s.lockKey()}, token)
+		return 0, nil, err
+	}
+
+	return seq, func(next uint64) error {
+		// The sequence should be stored even if the caller's context is done.
+		ok, err := releaseScript.Run(context.Background(), s.client, []string{s.key, s.lockKey()}, token, next).Int()
+		if err != nil {
+			return fmt.Errorf("failed to release lock: %w", err)
+		}
+		if ok == 0 {
+			return ErrLockExpired
+		}
+
+		return nil
+	}, nil
+}
+
+func (s *Store) lock(ctx context.Context, token string) error {
+	for {
+		ok, err := s.client.SetNX(ctx, s.lockKey(), token, s.lockTTL).Result()
+		if err != nil {
+			return fmt.Errorf("failed to take lock: %w", err)
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-s.clock.After(s.retryInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Store) get(ctx context.Context) (uint64, error) {
+	v, err := s.client.Get(ctx, s.key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, errors.New("sequence is not initialized")
+		}
+		return 0, fmt.Errorf("failed to get sequence: %w", err)
+	}
+
+	seq, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse sequence: %w", err)
+	}
+
+	return seq, nil
+}
+
+func (s *Store) lockKey() string {
+	return s.key + ":lock"
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
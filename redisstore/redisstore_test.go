@@ -0,0 +1,145 @@
+package redisstore_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/redisstore"
+)
+
+const key = "sequence"
+
+// newStore returns the store backed by miniredis.
+func newStore(t *testing.T, opts ...redisstore.Option) (*redisstore.Store, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return redisstore.New(client, key, opts...), mr
+}
+
+func TestStore(t *testing.T) {
+	s, mr := newStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.Init(ctx, 5))
+	// The stored sequence isn't overwritten.
+	require.NoError(t, s.Init(ctx, 1))
+
+	seq, release, err := s.Acquire(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), seq)
+	require.True(t, mr.Exists(key+":lock"))
+
+	require.NoError(t, release(6))
+	require.False(t, mr.Exists(key+":lock"))
+
+	seq, release, err = s.Acquire(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), seq)
+	require.NoError(t, release(7))
+}
+
+func TestStore_NotInitialized(t *testing.T) {
+	s, mr := newStore(t)
+
+	_, _, err := s.Acquire(context.Background())
+	require.ErrorContains(t, err, "sequence is not initialized")
+
+	// The lock is removed, so the next call isn't blocked.
+	require.False(t, mr.Exists(key+":lock"))
+}
+
+func TestStore_LockExpired(t *testing.T) {
+	const ttl = time.Second
+
+	s, mr := newStore(t, redisstore.WithLockTTL(ttl), redisstore.WithRetryInterval(time.Millisecond))
+	ctx := context.Background()
+	require.NoError(t, s.Init(ctx, 1))
+
+	_, expired, err := s.Acquire(ctx)
+	require.NoError(t, err)
+
+	// The lock expires and is taken by another holder.
+	mr.FastForward(ttl)
+	seq, release, err := s.Acquire(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), seq)
+
+	// The former holder doesn't own the lock anymore, so neither the sequence nor the lock is touched.
+	require.ErrorIs(t, expired(10), redisstore.ErrLockExpired)
+	require.True(t, mr.Exists(key+":lock"))
+	stored, err := mr.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, "1", stored)
+
+	// The sequence could be used by the new holder, so the broadcast should be retried.
+	require.Equal(t, broadcaster.ClassSequenceMismatch, broadcaster.Classify(expired(10)))
+
+	require.NoError(t, release(2))
+	stored, err = mr.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, "2", stored)
+	require.False(t, mr.Exists(key+":lock"))
+}
+
+func TestStore_ConcurrentHolders(t *testing.T) {
+	const count = 10
+
+	s, _ := newStore(t, redisstore.WithRetryInterval(time.Millisecond))
+	ctx := context.Background()
+	require.NoError(t, s.Init(ctx, 0))
+
+	var (
+		wg      sync.WaitGroup
+		holders int32
+	)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			seq, release, err := s.Acquire(ctx)
+			require.NoError(t, err)
+
+			require.EqualValues(t, 1, atomic.AddInt32(&holders, 1), "lock is held twice")
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&holders, -1)
+
+			require.NoError(t, release(seq+1))
+		}()
+	}
+	wg.Wait()
+
+	// Every holder got the sequence stored by the previous one.
+	seq, release, err := s.Acquire(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(count), seq)
+	require.NoError(t, release(seq))
+}
+
+func TestStore_AcquireCanceled(t *testing.T) {
+	s, _ := newStore(t, redisstore.WithRetryInterval(time.Millisecond))
+	require.NoError(t, s.Init(context.Background(), 0))
+
+	_, release, err := s.Acquire(context.Background())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, release(0)) }()
+
+	// The lock is held, so the caller waits until its context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, _, err = s.Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,219 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Registry errors.
+var (
+	// ErrBroadcasterNotFound is returned when the registry has no broadcaster with the name.
+	ErrBroadcasterNotFound = errors.New("broadcaster not found")
+	// ErrRegistryClosed is returned when the registry is used after Close.
+	ErrRegistryClosed = errors.New("registry is closed")
+)
+
+// Registry keeps named broadcasters, e.g. of different chains. It's safe for concurrent use.
+// Broadcasters are owned by the registry: they're closed by its Close.
+type Registry struct {
+	mu      sync.RWMutex
+	members map[string]Broadcaster
+	closed  bool
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		members: make(map[string]Broadcaster),
+	}
+}
+
+// NewRegistryFromConfigs returns a registry with broadcasters created from the configs by names.
+// Already created broadcasters are closed if any of them fails.
+func NewRegistryFromConfigs(cfgs map[string]Config) (*Registry, error) {
+	r := NewRegistry()
+
+	for name, cfg := range cfgs {
+		b, err := New(cfg)
+		if err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("failed to create broadcaster %s: %w", name, err)
+		}
+
+		if err := r.Register(name, b); err != nil {
+			_ = b.Close()
+			_ = r.Close()
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Register adds the broadcaster with the name. Names should be unique.
+func (r *Registry) Register(name string, b Broadcaster) error {
+	if b == nil {
+		return errors.New("broadcaster is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return ErrRegistryClosed
+	}
+
+	if _, ok := r.members[name]; ok {
+		return fmt.Errorf("broadcaster %s is already registered", name)
+	}
+	r.members[name] = b
+
+	return nil
+}
+
+// Get returns the broadcaster with the name.
+func (r *Registry) Get(name string) (Broadcaster, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		return nil, ErrRegistryClosed
+	}
+
+	b, ok := r.members[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBroadcasterNotFound, name)
+	}
+
+	return b, nil
+}
+
+// Names returns sorted names of the broadcasters.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]string, 0, len(r.members))
+	for name := range r.members {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+// BroadcastOn broadcasts msgs with the broadcaster with the name. Broadcasters which don't support
+// options are used through Broadcast, the result contains only the response then.
+func (r *Registry) BroadcastOn(ctx context.Context, name string, msgs []sdk.Msg, memo string) (*BroadcastResult, error) {
+	b, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if cb, ok := b.(contextBroadcaster); ok {
+		return cb.BroadcastContext(ctx, msgs, memo, BroadcastOptions{})
+	}
+
+	resp, err := b.Broadcast(msgs, memo)
+	if resp == nil {
+		return nil, err
+	}
+
+	return &BroadcastResult{Signer: b.From(), TxHash: resp.TxHash, Response: resp}, err
+}
+
+// readyChecker is implemented by broadcasters with readiness checks, e.g. one returned by New.
+type readyChecker interface {
+	Ready(ctx context.Context) error
+}
+
+// ReadyAll checks readiness of every broadcaster and returns errors by names of not ready ones.
+// Broadcasters without readiness checks are pinged.
+func (r *Registry) ReadyAll(ctx context.Context) map[string]error {
+	r.mu.RLock()
+	members := make(map[string]Broadcaster, len(r.members))
+	for k, v := range r.members {
+		members[k] = v
+	}
+	r.mu.RUnlock()
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		out = make(map[string]error)
+	)
+	for name, b := range members {
+		wg.Add(1)
+		go func(name string, b Broadcaster) {
+			defer wg.Done()
+
+			var err error
+			if rc, ok := b.(readyChecker); ok {
+				err = rc.Ready(ctx)
+			} else {
+				err = b.PingContext(ctx)
+			}
+
+			if err != nil {
+				mu.Lock()
+				out[name] = err
+				mu.Unlock()
+			}
+		}(name, b)
+	}
+	wg.Wait()
+
+	return out
+}
+
+// Ready returns an error if any broadcaster isn't ready. The error wraps the error of the first one by name.
+func (r *Registry) Ready(ctx context.Context) error {
+	errs := r.ReadyAll(ctx)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return fmt.Errorf("%d of broadcasters aren't ready, %s: %w", len(names), names[0], errs[names[0]])
+}
+
+// Close closes all broadcasters. The registry can't be used after it.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	members := r.members
+	r.members = make(map[string]Broadcaster)
+	r.mu.Unlock()
+
+	var errs []error
+	for name, b := range members {
+		c, ok := b.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := c.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to close broadcasters: %v", errs)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/mock"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// closingMock is the mock broadcaster which counts calls of Close.
+type closingMock struct {
+	*mock.MockBroadcaster
+	closed int32
+}
+
+func (m *closingMock) Close() error {
+	atomic.AddInt32(&m.closed, 1)
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	mainnet, key := newFakeChain(t)
+	testnet, _ := newFakeChain(t)
+	testnet.SetChainID("testnet")
+
+	testnetCfg := testConfig(testnet, key)
+	testnetCfg.ChainID = "testnet"
+
+	r, err := broadcaster.NewRegistryFromConfigs(map[string]broadcaster.Config{
+		"mainnet": testConfig(mainnet, key),
+		"testnet": testnetCfg,
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.Equal(t, []string{"mainnet", "testnet"}, r.Names())
+	require.NoError(t, r.Ready(context.Background()))
+
+	// Every broadcaster sends to its own chain.
+	for name, node := range map[string]*testutil.FakeNode{"mainnet": mainnet, "testnet": testnet} {
+		res, err := r.BroadcastOn(context.Background(), name, []sdk.Msg{sendMsg(key.Address, 1)}, "")
+		require.NoError(t, err)
+		requireCommitted(t, node, res.TxHash)
+	}
+	require.Equal(t, 1, mainnet.Calls("broadcast_tx_sync"))
+	require.Equal(t, 1, testnet.Calls("broadcast_tx_sync"))
+
+	_, err = r.BroadcastOn(context.Background(), "devnet", []sdk.Msg{sendMsg(key.Address, 1)}, "")
+	require.ErrorIs(t, err, broadcaster.ErrBroadcasterNotFound)
+	require.Equal(t, broadcaster.ClassNotFound, broadcaster.Classify(err))
+
+	b, err := r.Get("mainnet")
+	require.NoError(t, err)
+	require.ErrorContains(t, r.Register("mainnet", b), "already registered")
+
+	// The broadcaster which isn't ready is reported by its name.
+	testnet.SetDown(true)
+	errs := r.ReadyAll(context.Background())
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs["testnet"], broadcaster.ErrNodeUnavailable)
+	err = r.Ready(context.Background())
+	require.ErrorIs(t, err, broadcaster.ErrNodeUnavailable)
+	require.ErrorContains(t, err, "testnet")
+}
+
+func TestRegistry_Concurrent(t *testing.T) {
+	const broadcasts = 10
+
+	mainnet, key := newFakeChain(t)
+	testnet, _ := newFakeChain(t)
+
+	r, err := broadcaster.NewRegistryFromConfigs(map[string]broadcaster.Config{
+		"mainnet": testConfig(mainnet, key),
+		"testnet": testConfig(testnet, key),
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	ctrl := gomock.NewController(t)
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, 3*broadcasts)
+	)
+	for i := 0; i < broadcasts; i++ {
+		for _, name := range []string{"mainnet", "testnet"} {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+
+				_, err := r.BroadcastOn(context.Background(), name, []sdk.Msg{sendMsg(key.Address, 1)}, "")
+				errs <- err
+			}(name)
+		}
+
+		// Members are added and read while others broadcast.
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			errs <- r.Register(fmt.Sprintf("mock-%d", i), mock.NewMockBroadcaster(ctrl))
+			_ = r.Names()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Len(t, r.Names(), 2+broadcasts)
+	require.Equal(t, broadcasts, mainnet.Calls("broadcast_tx_sync"))
+	require.Equal(t, broadcasts, testnet.Calls("broadcast_tx_sync"))
+}
+
+func TestRegistry_Mock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	m := &closingMock{MockBroadcaster: mock.NewMockBroadcaster(ctrl)}
+	msgs := []sdk.Msg{sendMsg(sdk.AccAddress("sender"), 1)}
+
+	r := broadcaster.NewRegistry()
+	require.NoError(t, r.Register("mock", m))
+	require.Error(t, r.Register("nil", nil))
+
+	// Broadcasters without options are used through Broadcast and pinged for readiness.
+	m.EXPECT().From().Return(sdk.AccAddress("sender"))
+	m.EXPECT().Broadcast(msgs, "").Return(&sdk.TxResponse{TxHash: "AB"}, nil)
+	m.EXPECT().PingContext(gomock.Any()).Return(errors.New("unreachable"))
+
+	res, err := r.BroadcastOn(context.Background(), "mock", msgs, "")
+	require.NoError(t, err)
+	require.Equal(t, "AB", res.TxHash)
+	require.Equal(t, sdk.AccAddress("sender"), res.Signer)
+	require.ErrorContains(t, r.Ready(context.Background()), "unreachable")
+
+	// Members are closed once, the registry can't be used after close.
+	require.NoError(t, r.Close())
+	require.NoError(t, r.Close())
+	require.EqualValues(t, 1, atomic.LoadInt32(&m.closed))
+
+	_, err = r.Get("mock")
+	require.ErrorIs(t, err, broadcaster.ErrRegistryClosed)
+	_, err = r.BroadcastOn(context.Background(), "mock", msgs, "")
+	require.ErrorIs(t, err, broadcaster.ErrRegistryClosed)
+	require.ErrorIs(t, r.Register("mock", m), broadcaster.ErrRegistryClosed)
+	require.Empty(t, r.Names())
+}
+
+func TestNewRegistryFromConfigs_Invalid(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	_, err := broadcaster.NewRegistryFromConfigs(map[string]broadcaster.Config{
+		"mainnet": testConfig(node, key),
+		"testnet": {},
+	})
+	require.ErrorContains(t, err, "failed to create broadcaster testnet")
+}
@@ -0,0 +1,38 @@
+package broadcaster
+
+import (
+	"context"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns context carrying the request id. The id is attached to logs and results of broadcasts
+// made with the context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id carried by ctx.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// infof logs info message with the request id of ctx.
+func (b *broadcaster) infof(ctx context.Context, format string, args ...interface{}) {
+	b.cfg.logger().Infof(withRequestID(ctx, format), args...)
+}
+
+// warnf logs warning with the request id of ctx.
+func (b *broadcaster) warnf(ctx context.Context, format string, args ...interface{}) {
+	b.cfg.logger().Warnf(withRequestID(ctx, format), args...)
+}
+
+// withRequestID prefixes format with the request id of ctx.
+func withRequestID(ctx context.Context, format string) string {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return "request_id=" + id + ": " + format
+	}
+
+	return format
+}
@@ -0,0 +1,143 @@
+package broadcaster_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// syncBuffer is bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+// logLines is broadcaster.Logger which keeps every line.
+type logLines struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *logLines) Infof(format string, args ...interface{}) {
+	l.Warnf(format, args...)
+}
+
+func (l *logLines) Warnf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *logLines) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.lines...)
+}
+
+func TestRequestID(t *testing.T) {
+	const interval = time.Second
+
+	node, key := newFakeChain(t)
+	clock := testutil.NewFakeClock(time.Now())
+
+	var (
+		store syncBuffer
+		logs  logLines
+	)
+	cfg := testConfig(node, key)
+	cfg.Clock = clock
+	cfg.Logger = &logs
+	cfg.ResultStore = broadcaster.NewJSONLinesResultStore(&store)
+	cfg.Tracking = broadcaster.TxTracking{Interval: interval}
+
+	resolved := make(chan broadcaster.TrackedTx, 2)
+	cfg.Tracking.OnResolve = func(tx broadcaster.TrackedTx) { resolved <- tx }
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// The request id is followed from the call through the tracker to the result store.
+	ctx := broadcaster.WithRequestID(context.Background(), "req-1")
+	res, err := b.BroadcastContext(ctx, []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "req-1", res.RequestID)
+
+	node.NextBlock()
+	clock.BlockUntil(1)
+	clock.Advance(interval)
+
+	select {
+	case tx := <-resolved:
+		require.Equal(t, res.TxHash, tx.TxHash)
+		require.Equal(t, "req-1", tx.RequestID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("tx isn't resolved")
+	}
+
+	var record struct {
+		Status    string `json:"status"`
+		TxHash    string `json:"tx_hash"`
+		RequestID string `json:"request_id"`
+	}
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(store.Bytes()), &record))
+	require.Equal(t, "committed", record.Status)
+	require.Equal(t, res.TxHash, record.TxHash)
+	require.Equal(t, "req-1", record.RequestID)
+
+	// Failed call keeps the id in its result, logs and recent errors.
+	node.OnCheckTx(func(testutil.FakeTx) error { return sdkerrors.ErrInsufficientFunds })
+	ctx = broadcaster.WithRequestID(context.Background(), "req-2")
+	res, err = b.BroadcastContext(ctx, []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{GasAdjust: 1})
+	require.Error(t, err)
+	require.NotNil(t, res)
+	require.Equal(t, "req-2", res.RequestID)
+
+	recent := b.RecentErrors()
+	require.NotEmpty(t, recent)
+	require.Equal(t, "req-2", recent[len(recent)-1].RequestID)
+
+	scanner := bufio.NewScanner(bytes.NewReader(store.Bytes()))
+	var ids []string
+	for scanner.Scan() {
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		ids = append(ids, record.RequestID)
+	}
+	require.Equal(t, []string{"req-1", "req-2"}, ids)
+
+	// Every line logged for the call carries the id.
+	lines := logs.get()
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		require.True(t, strings.HasPrefix(line, "request_id=req-2: gas adjustment is 1"), line)
+	}
+}
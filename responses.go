@@ -0,0 +1,141 @@
+package broadcaster
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ErrUnexpectedMsgResponse is returned when msg response doesn't match the requested type.
+var ErrUnexpectedMsgResponse = errors.New("unexpected msg response")
+
+// TxMsgData fields numbers. The legacy encoding stores (msg type url, response bytes) pairs in data field,
+// the newer one stores responses as Any in msg_responses field.
+const (
+	txMsgDataDataField         = 1
+	txMsgDataMsgResponsesField = 2
+)
+
+// UnpackMsgResponses returns responses of tx messages packed into Any.
+func UnpackMsgResponses(resp *sdk.TxResponse) ([]*codectypes.Any, error) {
+	if resp == nil {
+		return nil, errors.New("response is nil")
+	}
+
+	bz, err := hex.DecodeString(resp.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data: %w", err)
+	}
+
+	var legacy, responses []*codectypes.Any
+	for len(bz) > 0 {
+		num, typ, n := protowire.ConsumeTag(bz)
+		if n < 0 {
+			return nil, fmt.Errorf("failed to parse data: %w", protowire.ParseError(n))
+		}
+		bz = bz[n:]
+
+		if typ != protowire.BytesType || (num != txMsgDataDataField && num != txMsgDataMsgResponsesField) {
+			if n = protowire.ConsumeFieldValue(num, typ, bz); n < 0 {
+				return nil, fmt.Errorf("failed to parse data: %w", protowire.ParseError(n))
+			}
+			bz = bz[n:]
+			continue
+		}
+
+		v, n := protowire.ConsumeBytes(bz)
+		if n < 0 {
+			return nil, fmt.Errorf("failed to parse data: %w", protowire.ParseError(n))
+		}
+		bz = bz[n:]
+
+		switch num {
+		case txMsgDataDataField:
+			var data sdk.MsgData
+			if err := data.Unmarshal(v); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal msg data: %w", err)
+			}
+			// Msg service responses are named after the msg.
+			legacy = append(legacy, &codectypes.Any{TypeUrl: data.MsgType + "Response", Value: data.Data})
+		case txMsgDataMsgResponsesField:
+			var any codectypes.Any
+			if err := any.Unmarshal(v); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal msg response: %w", err)
+			}
+			responses = append(responses, &any)
+		}
+	}
+
+	if len(responses) > 0 {
+		return responses, nil
+	}
+
+	return legacy, nil
+}
+
+// MsgResponse is the interface msg service responses are registered under by RegisterMsgResponses.
+type MsgResponse interface {
+	proto.Message
+}
+
+// msgResponseInterfaceName is the protobuf name of MsgResponse, the same as in sdk v0.46 and later.
+const msgResponseInterfaceName = "cosmos.tx.v1beta1.MsgResponse"
+
+// RegisterMsgResponses registers responses of msgs registered in the registry, so they could be resolved
+// by their type urls. Responses are named after their msgs. The registry of broadcasters returned by New
+// has responses of the chain's msgs and Config.RegisterInterfaces registered.
+func RegisterMsgResponses(registry codectypes.InterfaceRegistry) {
+	registry.RegisterInterface(msgResponseInterfaceName, (*MsgResponse)(nil))
+
+	for _, url := range registry.ListImplementations(sdk.MsgInterfaceProtoName) {
+		typ := proto.MessageType(strings.TrimPrefix(url, "/") + "Response")
+		if typ == nil || typ.Kind() != reflect.Ptr {
+			continue
+		}
+
+		if resp, ok := reflect.New(typ.Elem()).Interface().(proto.Message); ok {
+			registry.RegisterImplementations((*MsgResponse)(nil), resp)
+		}
+	}
+}
+
+// UnpackMsgResponse decodes response of the msg with the index into T. The response's type url
+// is resolved with the registry, so T could be an interface implemented by the registered type.
+func UnpackMsgResponse[T proto.Message](registry codectypes.InterfaceRegistry, resp *sdk.TxResponse, index int) (T, error) {
+	var out T
+
+	responses, err := UnpackMsgResponses(resp)
+	if err != nil {
+		return out, err
+	}
+
+	if index < 0 || index >= len(responses) {
+		return out, fmt.Errorf("msg response %d not found: tx has %d responses", index, len(responses))
+	}
+
+	any := responses[index]
+	msg, err := registry.Resolve(any.TypeUrl)
+	if err != nil {
+		return out, fmt.Errorf("failed to resolve msg response: %w", err)
+	}
+
+	out, ok := msg.(T)
+	if !ok {
+		return out, fmt.Errorf("%w: expected %s, got %s",
+			ErrUnexpectedMsgResponse, reflect.TypeOf((*T)(nil)).Elem(), proto.MessageName(msg))
+	}
+
+	if err := proto.Unmarshal(any.Value, out); err != nil {
+		return out, fmt.Errorf("failed to unmarshal %s: %w", any.TypeUrl, err)
+	}
+
+	return out, nil
+}
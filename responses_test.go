@@ -0,0 +1,116 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+// Data of tx with bank MsgSend and gov MsgSubmitProposal (proposal id 7) as it's returned by nodes.
+const (
+	// legacyMsgData is TxMsgData.data of sdk v0.45 and earlier.
+	legacyMsgData = "0A1E0A1C2F636F736D6F732E62616E6B2E763162657461312E4D736753656E640A2B0A252F636F736D6F732E676F762E763162657461312E4D73675375626D697450726F706F73616C12020807"
+	// msgResponsesData is TxMsgData.msg_responses of sdk v0.46 and later.
+	msgResponsesData = "12260A242F636F736D6F732E62616E6B2E763162657461312E4D736753656E64526573706F6E736512330A2D2F636F736D6F732E676F762E763162657461312E4D73675375626D697450726F706F73616C526573706F6E736512020807"
+)
+
+func TestUnpackMsgResponses(t *testing.T) {
+	tt := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "legacy data", data: legacyMsgData},
+		{name: "msg responses", data: msgResponsesData},
+		{name: "invalid hex", data: "zz", wantErr: true},
+		{name: "truncated", data: legacyMsgData[:20], wantErr: true},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &sdk.TxResponse{Data: tc.data}
+
+			responses, err := broadcaster.UnpackMsgResponses(resp)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, responses, 2)
+			require.Equal(t, "/cosmos.bank.v1beta1.MsgSendResponse", responses[0].TypeUrl)
+			require.Equal(t, "/cosmos.gov.v1beta1.MsgSubmitProposalResponse", responses[1].TypeUrl)
+
+			registry := msgRegistry(banktypes.RegisterInterfaces, govtypes.RegisterInterfaces)
+
+			_, err = broadcaster.UnpackMsgResponse[*banktypes.MsgSendResponse](registry, resp, 0)
+			require.NoError(t, err)
+
+			proposal, err := broadcaster.UnpackMsgResponse[*govtypes.MsgSubmitProposalResponse](registry, resp, 1)
+			require.NoError(t, err)
+			require.Equal(t, uint64(7), proposal.ProposalId)
+
+			_, err = broadcaster.UnpackMsgResponse[*banktypes.MsgSendResponse](registry, resp, 1)
+			require.ErrorIs(t, err, broadcaster.ErrUnexpectedMsgResponse)
+			require.ErrorContains(t, err, "cosmos.gov.v1beta1.MsgSubmitProposalResponse")
+
+			_, err = broadcaster.UnpackMsgResponse[*banktypes.MsgSendResponse](registry, resp, 2)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestUnpackMsgResponse_Committed(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = broadcaster.ModeBlock
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	_, err = broadcaster.UnpackMsgResponse[*banktypes.MsgSendResponse](b.ClientContext().InterfaceRegistry, res.Response, 0)
+	require.NoError(t, err)
+}
+
+// msgRegistry returns the registry with msgs of the modules and their responses registered.
+func msgRegistry(register ...func(codectypes.InterfaceRegistry)) codectypes.InterfaceRegistry {
+	registry := codectypes.NewInterfaceRegistry()
+	sdk.RegisterInterfaces(registry)
+	for _, fn := range register {
+		fn(registry)
+	}
+	broadcaster.RegisterMsgResponses(registry)
+
+	return registry
+}
+
+func TestUnpackMsgResponse_Registry(t *testing.T) {
+	resp := &sdk.TxResponse{Data: msgResponsesData}
+
+	// The response of a module which isn't registered can't be resolved.
+	_, err := broadcaster.UnpackMsgResponse[*govtypes.MsgSubmitProposalResponse](msgRegistry(banktypes.RegisterInterfaces), resp, 1)
+	require.ErrorContains(t, err, "failed to resolve msg response")
+	require.NotErrorIs(t, err, broadcaster.ErrUnexpectedMsgResponse)
+
+	// The response is resolved by its type url, so it could be decoded into an interface.
+	registry := msgRegistry(banktypes.RegisterInterfaces, govtypes.RegisterInterfaces)
+	out, err := broadcaster.UnpackMsgResponse[broadcaster.MsgResponse](registry, resp, 1)
+	require.NoError(t, err)
+	require.Equal(t, &govtypes.MsgSubmitProposalResponse{ProposalId: 7}, out)
+
+	require.ElementsMatch(t, []string{"/cosmos.bank.v1beta1.MsgSendResponse", "/cosmos.bank.v1beta1.MsgMultiSendResponse"},
+		msgRegistry(banktypes.RegisterInterfaces).ListImplementations("cosmos.tx.v1beta1.MsgResponse"))
+}
@@ -0,0 +1,38 @@
+package broadcaster
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// BroadcastResult contains the outcome of broadcasting.
+type BroadcastResult struct {
+	// RequestID is the request id carried by the call's context.
+	RequestID string
+	// Signer is the address which signed tx.
+	Signer sdk.AccAddress
+	// MemoNonce is the nonce appended to the memo when Config.UniquifyMemo is set.
+	MemoNonce string
+	// TxHash is the hash of broadcast tx computed locally.
+	TxHash string
+	// Sequence is the account's sequence tx is signed with.
+	Sequence uint64
+	// MsgIndex is the index of the caller's msg in tx shared by BroadcastShared.
+	MsgIndex int
+	// Attempts is the number of attempts made to broadcast tx.
+	Attempts int
+	// History contains attempts which failed, with the nodes used and the corrective actions taken.
+	// The last one is the terminal error when broadcast failed.
+	History []AttemptError
+	// Timings is the time spent in stages of the broadcast.
+	Timings Timings
+	// Response is the node's response. It is nil when the node wasn't reached.
+	Response *sdk.TxResponse
+	// Events contains parsed events of the response. It is nil when the response's log can't be parsed,
+	// the raw log is still available in Response.
+	Events []MsgEvents
+	// ResolvedAt is the time of the terminal outcome by Config.Clock. It's set only for results
+	// passed to Config.ResultStore.
+	ResolvedAt time.Time
+}
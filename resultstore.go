@@ -0,0 +1,170 @@
+package broadcaster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultResultStoreAttempts is the default number of attempts to save a result.
+const DefaultResultStoreAttempts = 3
+
+// ResultStore saves terminal outcomes of broadcasts, e.g. for reconciliation. Committed txs are saved
+// after waiting for commit, txs broadcast without waiting are saved once the tracker resolves them,
+// so Config.Tracking should be enabled for them. Every outcome is passed once.
+type ResultStore interface {
+	// SaveResult saves successfully committed tx.
+	SaveResult(ctx context.Context, res BroadcastResult) error
+}
+
+// FailedResultStore is ResultStore which saves failed outcomes too: txs rejected by the node,
+// failed on commit, expired or lost.
+type FailedResultStore interface {
+	ResultStore
+	// MarkFailed saves tx which failed with the error.
+	MarkFailed(ctx context.Context, res BroadcastResult, err error) error
+}
+
+// NopResultStore is ResultStore which saves nothing.
+type NopResultStore struct{}
+
+// SaveResult implements ResultStore.
+func (NopResultStore) SaveResult(context.Context, BroadcastResult) error {
+	return nil
+}
+
+// jsonLinesResultStore writes results to w as JSON lines.
+type jsonLinesResultStore struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// resultRecord is a JSON line written by the JSON lines store.
+type resultRecord struct {
+	Status    string    `json:"status"`
+	TxHash    string    `json:"tx_hash"`
+	Signer    string    `json:"signer"`
+	Sequence  uint64    `json:"sequence"`
+	RequestID string    `json:"request_id,omitempty"`
+	Height    int64     `json:"height,omitempty"`
+	Codespace string    `json:"codespace,omitempty"`
+	Code      uint32    `json:"code,omitempty"`
+	GasUsed   int64     `json:"gas_used,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// NewJSONLinesResultStore returns FailedResultStore which writes every outcome to w as a JSON line.
+func NewJSONLinesResultStore(w io.Writer) FailedResultStore {
+	return &jsonLinesResultStore{w: w}
+}
+
+// SaveResult implements ResultStore.
+func (s *jsonLinesResultStore) SaveResult(_ context.Context, res BroadcastResult) error {
+	return s.write(newResultRecord("committed", res, nil))
+}
+
+// MarkFailed implements FailedResultStore.
+func (s *jsonLinesResultStore) MarkFailed(_ context.Context, res BroadcastResult, err error) error {
+	return s.write(newResultRecord("failed", res, err))
+}
+
+func (s *jsonLinesResultStore) write(r resultRecord) error {
+	bz, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(append(bz, '\n')); err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+
+	return nil
+}
+
+func newResultRecord(status string, res BroadcastResult, err error) resultRecord {
+	r := resultRecord{
+		Status:    status,
+		TxHash:    res.TxHash,
+		Signer:    res.Signer.String(),
+		Sequence:  res.Sequence,
+		RequestID: res.RequestID,
+		Time:      res.ResolvedAt.UTC(),
+	}
+	if res.Response != nil {
+		r.Height, r.Codespace, r.Code, r.GasUsed = res.Response.Height, res.Response.Codespace, res.Response.Code, res.Response.GasUsed
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	return r
+}
+
+// storeOutcome passes the outcome of tx to Config.ResultStore. Committed tx is passed with nil error.
+// Failures are retried Config.ResultStoreAttempts times and counted, they don't affect the broadcast.
+func (b *broadcaster) storeOutcome(ctx context.Context, res BroadcastResult, outcome error) {
+	store := b.cfg.ResultStore
+	if store == nil {
+		return
+	}
+	res.ResolvedAt = b.cfg.clock().Now()
+
+	save := func() error {
+		return store.SaveResult(ctx, res)
+	}
+	if outcome != nil {
+		failed, ok := store.(FailedResultStore)
+		if !ok {
+			return
+		}
+		save = func() error {
+			return failed.MarkFailed(ctx, res, outcome)
+		}
+	}
+
+	attempts := b.cfg.ResultStoreAttempts
+	if attempts <= 0 {
+		attempts = DefaultResultStoreAttempts
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = save(); err == nil {
+			return
+		}
+	}
+
+	atomic.AddUint64(&b.resultStoreFailures, 1)
+	b.warnf(ctx, "failed to store result of tx %s: %s", res.TxHash, err)
+}
+
+// storeResolved passes the outcome of tx resolved by the tracker to Config.ResultStore.
+// Txs tracked only by OnCommit aren't broadcast by the broadcaster and don't have msgs, so they're skipped.
+func (b *broadcaster) storeResolved(tx TrackedTx) {
+	if len(tx.Msgs) == 0 {
+		return
+	}
+
+	res := BroadcastResult{
+		Signer:    b.From(),
+		TxHash:    tx.TxHash,
+		Sequence:  tx.Sequence,
+		Response:  tx.Response,
+		RequestID: tx.RequestID,
+	}
+	if tx.Response != nil {
+		if events, err := ParseTxEvents(tx.Response); err == nil {
+			res.Events = events
+		}
+	}
+
+	b.storeOutcome(WithRequestID(context.Background(), tx.RequestID), res, trackedError(tx))
+}
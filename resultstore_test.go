@@ -0,0 +1,276 @@
+package broadcaster_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// recordingStore is broadcaster.FailedResultStore which records outcomes by tx hashes.
+// It fails the first fails calls.
+type recordingStore struct {
+	mu     sync.Mutex
+	fails  int
+	calls  int
+	saved  []string
+	failed []string
+}
+
+func (s *recordingStore) SaveResult(_ context.Context, res broadcaster.BroadcastResult) error {
+	return s.record(&s.saved, res.TxHash)
+}
+
+func (s *recordingStore) MarkFailed(_ context.Context, res broadcaster.BroadcastResult, _ error) error {
+	return s.record(&s.failed, res.TxHash)
+}
+
+func (s *recordingStore) record(to *[]string, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls <= s.fails {
+		return errors.New("database is unavailable")
+	}
+	*to = append(*to, hash)
+
+	return nil
+}
+
+// outcomes returns hashes of saved and failed txs.
+func (s *recordingStore) outcomes() (saved, failed []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.saved...), append([]string(nil), s.failed...)
+}
+
+// saveOnlyStore hides MarkFailed of the store.
+type saveOnlyStore struct {
+	broadcaster.ResultStore
+}
+
+func TestResultStore_Commit(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+	writer := &competingWriter{FakeNode: node, address: key.Address}
+	store := &recordingStore{}
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = writer
+	cfg.BroadcastMode = broadcaster.ModeCommit
+	cfg.ResultStore = store
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Committed tx is saved once.
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	saved, failed := store.outcomes()
+	require.Equal(t, []string{res.TxHash}, saved)
+	require.Empty(t, failed)
+
+	// Retried tx is saved once, with the hash of the committed attempt.
+	writer.write()
+	res, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, res.Attempts)
+	saved, _ = store.outcomes()
+	require.Len(t, saved, 2)
+	require.Equal(t, res.TxHash, saved[1])
+
+	// Tx failed on chain is marked failed once.
+	res, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 2_000_000_000)}, "",
+		broadcaster.BroadcastOptions{Gas: 200_000})
+	require.Error(t, err)
+	require.Positive(t, res.Response.Height)
+	saved, failed = store.outcomes()
+	require.Len(t, saved, 2)
+	require.Equal(t, []string{res.TxHash}, failed)
+
+	// Tx rejected by the node is marked failed once, after all attempts.
+	node.OnCheckTx(func(testutil.FakeTx) error { return sdkerrors.ErrInsufficientFunds })
+	res, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{Gas: 200_000})
+	require.Error(t, err)
+	require.Equal(t, broadcaster.DefaultMaxAttempts, res.Attempts)
+	_, failed = store.outcomes()
+	require.Len(t, failed, 2)
+	require.Equal(t, res.TxHash, failed[1])
+}
+
+func TestResultStore_Tracked(t *testing.T) {
+	for _, mode := range []broadcaster.BroadcastMode{broadcaster.ModeSync, broadcaster.ModeCommit} {
+		mode := mode
+		t.Run(string(mode), func(t *testing.T) {
+			node, key := newFakeChain(t)
+			store := &recordingStore{}
+
+			cfg := testConfig(node, key)
+			cfg.BroadcastMode = mode
+			cfg.Tracking = broadcaster.TxTracking{Interval: trackingInterval}
+			cfg.ResultStore = store
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			if mode == broadcaster.ModeCommit {
+				node.SetAutoBlock(true)
+			}
+
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+			node.NextBlock()
+
+			// The tracker resolves tx and it's saved once either it's waited for or not.
+			requireStatus(t, b, res.TxHash, broadcaster.TxCommitted)
+			require.Eventually(t, func() bool {
+				saved, _ := store.outcomes()
+				return len(saved) > 0
+			}, 5*time.Second, time.Millisecond)
+
+			time.Sleep(10 * trackingInterval)
+			saved, failed := store.outcomes()
+			require.Equal(t, []string{res.TxHash}, saved)
+			require.Empty(t, failed)
+		})
+	}
+}
+
+func TestResultStore_Failures(t *testing.T) {
+	tt := []struct {
+		name     string
+		fails    int
+		saved    int
+		failures uint64
+	}{
+		{name: "saved on retry", fails: 1, saved: 1},
+		{name: "attempts exhausted", fails: 2, failures: 1},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			node.SetAutoBlock(true)
+			store := &recordingStore{fails: tc.fails}
+
+			cfg := testConfig(node, key)
+			cfg.BroadcastMode = broadcaster.ModeCommit
+			cfg.ResultStore = store
+			cfg.ResultStoreAttempts = 2
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			// The store doesn't affect the broadcast.
+			_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+
+			saved, _ := store.outcomes()
+			require.Len(t, saved, tc.saved)
+			require.Equal(t, tc.failures, b.Stats().ResultStoreFailures)
+		})
+	}
+}
+
+func TestResultStore_SaveOnly(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+	store := &recordingStore{}
+
+	cfg := testConfig(node, key)
+	cfg.BroadcastMode = broadcaster.ModeCommit
+	cfg.ResultStore = saveOnlyStore{ResultStore: store}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Failed txs aren't passed to the store without MarkFailed.
+	_, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 2_000_000_000)}, "",
+		broadcaster.BroadcastOptions{Gas: 200_000})
+	require.Error(t, err)
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	saved, failed := store.outcomes()
+	require.Equal(t, []string{res.TxHash}, saved)
+	require.Empty(t, failed)
+	require.Zero(t, b.Stats().ResultStoreFailures)
+}
+
+func TestJSONLinesResultStore(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+	var buf bytes.Buffer
+
+	cfg := testConfig(node, key)
+	cfg.Clock = testutil.NewFakeClock(now)
+	cfg.BroadcastMode = broadcaster.ModeCommit
+	cfg.ResultStore = broadcaster.NewJSONLinesResultStore(&buf)
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	committed, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	failed, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 2_000_000_000)}, "",
+		broadcaster.BroadcastOptions{Gas: 200_000})
+	require.Error(t, err)
+
+	type record struct {
+		Status   string    `json:"status"`
+		TxHash   string    `json:"tx_hash"`
+		Signer   string    `json:"signer"`
+		Sequence uint64    `json:"sequence"`
+		Height   int64     `json:"height"`
+		Code     uint32    `json:"code"`
+		GasUsed  int64     `json:"gas_used"`
+		Error    string    `json:"error"`
+		Time     time.Time `json:"time"`
+	}
+
+	var records []record
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var r record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	require.Len(t, records, 2)
+
+	require.Equal(t, record{
+		Status:   "committed",
+		TxHash:   committed.TxHash,
+		Signer:   key.Address.String(),
+		Sequence: committed.Sequence,
+		Height:   committed.Response.Height,
+		GasUsed:  committed.Response.GasUsed,
+		Time:     now,
+	}, records[0])
+
+	require.Equal(t, "failed", records[1].Status)
+	require.Equal(t, failed.TxHash, records[1].TxHash)
+	require.Equal(t, sdkerrors.ErrInsufficientFunds.ABCICode(), records[1].Code)
+	require.Contains(t, records[1].Error, "insufficient funds")
+	require.Equal(t, now, records[1].Time)
+}
@@ -0,0 +1,280 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// DefaultMaxAttempts is the default number of broadcast attempts.
+const DefaultMaxAttempts = 2
+
+// ErrBroadcastDeadlineExceeded is returned when broadcast exceeds Config.MaxBroadcastDuration.
+var ErrBroadcastDeadlineExceeded = errors.New("broadcast deadline exceeded")
+
+// DeadlineError is returned when broadcast exceeds Config.MaxBroadcastDuration.
+// It matches ErrBroadcastDeadlineExceeded and wraps the last attempt's error.
+type DeadlineError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *DeadlineError) Error() string {
+	return fmt.Sprintf("%s after %d attempts: %s", ErrBroadcastDeadlineExceeded, e.Attempts, e.Err)
+}
+
+// Is makes DeadlineError match ErrBroadcastDeadlineExceeded.
+func (e *DeadlineError) Is(target error) bool {
+	return target == ErrBroadcastDeadlineExceeded
+}
+
+func (e *DeadlineError) Unwrap() error {
+	return e.Err
+}
+
+// RetryPolicy defines how failed broadcasts are retried.
+type RetryPolicy struct {
+	// MaxAttempts limits number of attempts including the first one. DefaultMaxAttempts is used by default.
+	// A correction of the sequence after failed simulation counts as an attempt.
+	MaxAttempts int
+	// OutOfGasMultiplier multiplies simulated gas when tx has run out of gas. Gas isn't bumped by default.
+	OutOfGasMultiplier float64
+
+	// Backoff is a delay before the second attempt. It's doubled for every next attempt.
+	// Attempts aren't delayed by default.
+	Backoff time.Duration
+	// MaxBackoff limits the delay between attempts. It isn't limited by default.
+	MaxBackoff time.Duration
+	// Jitter defines how the delay is randomized. JitterFull is used by default.
+	Jitter Jitter
+}
+
+// Jitter defines how retry delays are randomized, so replicas don't retry in lockstep.
+type Jitter int
+
+const (
+	// JitterFull waits random time between zero and the delay.
+	JitterFull Jitter = iota
+	// JitterEqual waits half of the delay plus random time up to the other half.
+	JitterEqual
+	// JitterNone waits exactly the delay.
+	JitterNone
+)
+
+// Validate validates the jitter.
+func (j Jitter) Validate() error {
+	switch j {
+	case JitterFull, JitterEqual, JitterNone:
+		return nil
+	default:
+		return fmt.Errorf("invalid jitter %d", j)
+	}
+}
+
+// backoff returns the delay before the attempt without jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 2; i < attempt && d > 0; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d > p.MaxBackoff {
+			break
+		}
+	}
+
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+
+	return d
+}
+
+// jitterSource randomizes delays. It's seeded per broadcaster.
+type jitterSource struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newJitterSource(seed int64) *jitterSource {
+	return &jitterSource{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// apply randomizes the delay according to the jitter.
+func (s *jitterSource) apply(j Jitter, d time.Duration) time.Duration {
+	if d <= 0 || j == JitterNone {
+		return d
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j == JitterEqual {
+		return d/2 + time.Duration(s.rnd.Int63n(int64(d/2)+1))
+	}
+
+	return time.Duration(s.rnd.Int63n(int64(d) + 1))
+}
+
+// waitBackoff waits the jittered delay before the attempt.
+func (b *broadcaster) waitBackoff(ctx context.Context, attempt int) error {
+	d := b.jitter.apply(b.cfg.RetryPolicy.Jitter, b.cfg.RetryPolicy.backoff(attempt))
+	if d <= 0 {
+		return nil
+	}
+
+	timer := b.cfg.clock().NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// maxAttempts returns configured number of attempts or the default one.
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+
+	return p.MaxAttempts
+}
+
+// isDefault returns true if the policy isn't configured.
+func (p RetryPolicy) isDefault() bool {
+	return p == RetryPolicy{}
+}
+
+// autoRetry returns true if failed broadcasts could be retried.
+func (b *broadcaster) autoRetry(opts BroadcastOptions) bool {
+	return !b.cfg.DisableAutoRetry && !opts.DisableAutoRetry
+}
+
+// retryAction is a corrective action taken before the next attempt.
+type retryAction int
+
+const (
+	// retryFail stops retrying.
+	retryFail retryAction = iota
+	// retryResimulate simulates gas again.
+	retryResimulate
+	// retryFixSequence sets the sequence expected by the node and simulates gas again.
+	retryFixSequence
+	// retryBumpGas simulates gas again and multiplies it.
+	retryBumpGas
+	// retryRefreshAccount verifies the chain id, fetches account number and sequence and simulates gas again.
+	retryRefreshAccount
+	// retryResend sends the same tx bytes again.
+	retryResend
+	// retryFailover sends the same tx bytes to the next extra node.
+	retryFailover
+)
+
+// String implements fmt.Stringer.
+func (a retryAction) String() string {
+	switch a {
+	case retryResimulate:
+		return "resimulate"
+	case retryFixSequence:
+		return "fix sequence"
+	case retryBumpGas:
+		return "bump gas"
+	case retryRefreshAccount:
+		return "refresh account"
+	case retryResend:
+		return "resend"
+	case retryFailover:
+		return "failover"
+	default:
+		return "none"
+	}
+}
+
+// AttemptError is an error of a single broadcast attempt.
+type AttemptError struct {
+	Attempt int
+	NodeURI string
+	// Action is the corrective action taken before the next attempt, e.g. "fix sequence".
+	// It's empty for the last attempt.
+	Action string
+	Err    error
+}
+
+func (e AttemptError) Error() string {
+	if e.Action == "" {
+		return fmt.Sprintf("attempt %d on %s: %s", e.Attempt, e.NodeURI, e.Err)
+	}
+
+	return fmt.Sprintf("attempt %d on %s (%s): %s", e.Attempt, e.NodeURI, e.Action, e.Err)
+}
+
+func (e AttemptError) Unwrap() error {
+	return e.Err
+}
+
+// AttemptsError is returned when broadcast fails after several attempts. It wraps the last attempt's error,
+// so errors.Is and errors.As match it, and keeps errors of all attempts.
+type AttemptsError struct {
+	attempts []AttemptError
+}
+
+// newAttemptsError returns err as the last attempt after the failed ones.
+// The error is returned as is if there were no failed attempts.
+func newAttemptsError(history []AttemptError, nodeURI string, err error) error {
+	if len(history) == 0 {
+		return err
+	}
+
+	attempts := make([]AttemptError, 0, len(history)+1)
+	attempts = append(attempts, history...)
+	attempts = append(attempts, AttemptError{Attempt: len(history) + 1, NodeURI: nodeURI, Err: err})
+
+	return &AttemptsError{attempts: attempts}
+}
+
+// Attempts returns errors of all attempts in order. The last one is the terminal error.
+func (e *AttemptsError) Attempts() []AttemptError {
+	return e.attempts
+}
+
+func (e *AttemptsError) Error() string {
+	last := e.attempts[len(e.attempts)-1]
+
+	parts := make([]string, len(e.attempts)-1)
+	for i, v := range e.attempts[:len(e.attempts)-1] {
+		parts[i] = v.Error()
+	}
+
+	return fmt.Sprintf("%s (after %d attempts: %s)", last.Err, len(e.attempts), strings.Join(parts, "; "))
+}
+
+func (e *AttemptsError) Unwrap() error {
+	return e.attempts[len(e.attempts)-1].Err
+}
+
+// classifyResponse returns an action which should be taken after the failed response.
+// It shares the classification with Classify.
+func classifyResponse(resp *sdk.TxResponse) retryAction {
+	switch responseClass(resp) {
+	case ClassDuplicate:
+		return retryFail
+	case ClassSequenceMismatch:
+		if getNextSequence(resp.RawLog) != 0 {
+			return retryFixSequence
+		}
+		return retryResimulate
+	case ClassOutOfGas:
+		return retryBumpGas
+	case ClassAccountMismatch:
+		return retryRefreshAccount
+	default:
+		return retryResimulate
+	}
+}
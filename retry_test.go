@@ -0,0 +1,135 @@
+package broadcaster
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyResponse(t *testing.T) {
+	tt := []struct {
+		name   string
+		err    *sdkerrors.Error
+		rawLog string
+		action retryAction
+	}{
+		{
+			name:   "sequence mismatch",
+			err:    sdkerrors.ErrWrongSequence,
+			rawLog: "account sequence mismatch, expected 5, got 3: incorrect account sequence",
+			action: retryFixSequence,
+		},
+		{
+			name:   "sequence mismatch without sequence",
+			err:    sdkerrors.ErrWrongSequence,
+			rawLog: "incorrect account sequence",
+			action: retryResimulate,
+		},
+		{
+			name:   "mempool cache",
+			err:    sdkerrors.ErrTxInMempoolCache,
+			rawLog: "tx already exists in cache",
+			action: retryFail,
+		},
+		{
+			name:   "out of gas",
+			err:    sdkerrors.ErrOutOfGas,
+			rawLog: "out of gas in location: WriteFlat; gasWanted: 100, gasUsed: 120: out of gas",
+			action: retryBumpGas,
+		},
+		{
+			name:   "signature verification",
+			err:    sdkerrors.ErrUnauthorized,
+			rawLog: "signature verification failed; please verify account number (1) and chain-id (test): unauthorized",
+			action: retryRefreshAccount,
+		},
+		{
+			name:   "insufficient funds",
+			err:    sdkerrors.ErrInsufficientFunds,
+			rawLog: "1udec is smaller than 2udec: insufficient funds",
+			action: retryResimulate,
+		},
+		{
+			name:   "unknown",
+			err:    sdkerrors.Register("test", 1000, "test error"),
+			rawLog: "test error",
+			action: retryResimulate,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &sdk.TxResponse{Codespace: tc.err.Codespace(), Code: tc.err.ABCICode(), RawLog: tc.rawLog}
+			require.Equal(t, tc.action, classifyResponse(resp))
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	tt := []struct {
+		name   string
+		policy RetryPolicy
+		want   []time.Duration // want are delays before attempts starting from the second one.
+	}{
+		{
+			name:   "disabled",
+			policy: RetryPolicy{},
+			want:   []time.Duration{0, 0, 0},
+		},
+		{
+			name:   "doubled",
+			policy: RetryPolicy{Backoff: time.Second},
+			want:   []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name:   "limited",
+			policy: RetryPolicy{Backoff: time.Second, MaxBackoff: 3 * time.Second},
+			want:   []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for i, want := range tc.want {
+				require.Equal(t, want, tc.policy.backoff(i+2), "attempt %d", i+2)
+			}
+		})
+	}
+}
+
+func TestJitterSource(t *testing.T) {
+	s := newJitterSource(1)
+
+	for i := 0; i < 100; i++ {
+		require.Equal(t, time.Second, s.apply(JitterNone, time.Second))
+
+		d := s.apply(JitterFull, time.Second)
+		require.True(t, d >= 0 && d <= time.Second, d)
+
+		d = s.apply(JitterEqual, time.Second)
+		require.True(t, d >= time.Second/2 && d <= time.Second, d)
+	}
+}
+
+func TestAttemptsError(t *testing.T) {
+	last := errors.New("last")
+	require.Equal(t, last, newAttemptsError(nil, "node", last), "single attempt isn't wrapped")
+
+	err := newAttemptsError([]AttemptError{
+		{Attempt: 1, NodeURI: "node", Action: retryFixSequence.String(), Err: sdkerrors.ErrWrongSequence},
+	}, "node", last)
+	require.ErrorIs(t, err, last)
+	require.NotErrorIs(t, err, sdkerrors.ErrWrongSequence, "only the terminal error is matched")
+	require.EqualError(t, err, "last (after 2 attempts: attempt 1 on node (fix sequence): incorrect account sequence)")
+
+	var attemptsErr *AttemptsError
+	require.ErrorAs(t, err, &attemptsErr)
+	require.Len(t, attemptsErr.Attempts(), 2)
+	require.Empty(t, attemptsErr.Attempts()[1].Action)
+}
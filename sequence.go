@@ -0,0 +1,109 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+)
+
+// SequenceStore keeps account's sequence outside of the broadcaster. It allows several broadcasters,
+// e.g. replicas of a service, to broadcast from the same account without fighting over the sequence.
+//
+// The sequence is held exclusively between Acquire and release, so only one tx of the account is signed
+// and broadcast at a time across all broadcasters sharing the store. The broadcaster releases the sequence
+// of the next tx: it is incremented after successful broadcast and corrected from the chain on sequence mismatch.
+type SequenceStore interface {
+	// Init sets the sequence if the store doesn't have it yet.
+	Init(ctx context.Context, seq uint64) error
+	// Acquire locks and returns the sequence. release should be called with the sequence of the next tx.
+	Acquire(ctx context.Context) (seq uint64, release func(next uint64) error, err error)
+}
+
+// memorySequenceStore is SequenceStore which keeps the sequence in memory.
+type memorySequenceStore struct {
+	lock chan struct{}
+
+	seq uint64
+	set bool
+}
+
+// NewMemorySequenceStore returns SequenceStore which keeps the sequence in memory.
+// It could be shared by broadcasters within a single process.
+func NewMemorySequenceStore() SequenceStore {
+	return &memorySequenceStore{
+		lock: make(chan struct{}, 1),
+	}
+}
+
+// Init sets the sequence if the store doesn't have it yet.
+func (s *memorySequenceStore) Init(ctx context.Context, seq uint64) error {
+	if err := s.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.unlock()
+
+	if !s.set {
+		s.seq, s.set = seq, true
+	}
+
+	return nil
+}
+
+// Acquire locks and returns the sequence.
+func (s *memorySequenceStore) Acquire(ctx context.Context) (uint64, func(next uint64) error, error) {
+	if err := s.acquire(ctx); err != nil {
+		return 0, nil, err
+	}
+
+	if !s.set {
+		s.unlock()
+		return 0, nil, fmt.Errorf("sequence is not initialized")
+	}
+
+	var released bool
+	return s.seq, func(next uint64) error {
+		if released {
+			return fmt.Errorf("sequence is already released")
+		}
+		released = true
+
+		s.seq = next
+		s.unlock()
+
+		return nil
+	}, nil
+}
+
+func (s *memorySequenceStore) acquire(ctx context.Context) error {
+	select {
+	case s.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *memorySequenceStore) unlock() {
+	<-s.lock
+}
+
+// acquireSequence locks the sequence in the store and sets it to the account state. b.mu should be held by caller.
+// The returned func releases the account state's sequence.
+func (b *broadcaster) acquireSequence(ctx context.Context) (func() error, error) {
+	if b.cfg.SequenceStore == nil {
+		return func() error { return nil }, nil
+	}
+
+	seq, release, err := b.cfg.SequenceStore.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire sequence: %w", err)
+	}
+	b.acc.setSequence(seq)
+
+	return func() error {
+		if err := release(b.acc.sequence()); err != nil {
+			return fmt.Errorf("failed to release sequence: %w", err)
+		}
+
+		return nil
+	}, nil
+}
@@ -0,0 +1,115 @@
+package broadcaster_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+func TestSequenceStore_SharedAccount(t *testing.T) {
+	const txs = 10
+
+	node, key := newFakeChain(t)
+	store := broadcaster.NewMemorySequenceStore()
+
+	cfg := testConfig(node, key)
+	cfg.SequenceStore = store
+
+	var replicas []contextBroadcaster
+	for i := 0; i < 2; i++ {
+		b, err := broadcaster.New(cfg)
+		require.NoError(t, err)
+		defer b.Close()
+
+		replicas = append(replicas, b)
+	}
+
+	memos := make([]string, txs)
+	for i := range memos {
+		memos[i] = fmt.Sprintf("tx %d", i)
+	}
+
+	results := make(chan []pipelinedResult, len(replicas))
+	for _, b := range replicas {
+		go func(b contextBroadcaster) {
+			results <- broadcastConcurrently(b, key.Address, memos)
+		}(b)
+	}
+
+	var seqs []uint64
+	for range replicas {
+		for _, r := range <-results {
+			require.NoError(t, r.err, r.memo)
+			seqs = append(seqs, r.res.Sequence)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	for i, seq := range seqs {
+		require.Equal(t, uint64(i), seq)
+	}
+
+	node.NextBlock()
+	require.Equal(t, uint64(2*txs), node.Sequence(key.Address))
+
+	seq, release, err := store.Acquire(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2*txs), seq)
+	require.NoError(t, release(seq))
+	require.Error(t, release(seq))
+}
+
+func TestSequenceStore_CorrectedFromChain(t *testing.T) {
+	node, key := newFakeChain(t)
+	store := broadcaster.NewMemorySequenceStore()
+
+	cfg := testConfig(node, key)
+	cfg.SequenceStore = store
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Somebody else uses the account without the store.
+	node.SetSequence(key.Address, 5)
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), res.Sequence)
+
+	seq, release, err := store.Acquire(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), seq)
+	require.NoError(t, release(seq))
+}
+
+func TestMemorySequenceStore(t *testing.T) {
+	store := broadcaster.NewMemorySequenceStore()
+
+	_, _, err := store.Acquire(context.Background())
+	require.Error(t, err, "uninitialized store")
+
+	require.NoError(t, store.Init(context.Background(), 3))
+	require.NoError(t, store.Init(context.Background(), 7), "store is initialized once")
+
+	seq, release, err := store.Acquire(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), seq)
+
+	// The sequence is held until it's released.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = store.Acquire(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	require.NoError(t, release(4))
+	seq, release, err = store.Acquire(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), seq)
+	require.NoError(t, release(seq))
+}
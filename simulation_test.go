@@ -0,0 +1,93 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/bytes"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// blockingSimulation is the fake node whose simulation blocks until the request is cancelled.
+type blockingSimulation struct {
+	*testutil.FakeNode
+	entered chan struct{}
+}
+
+func (n *blockingSimulation) ABCIQueryWithOptions(
+	ctx context.Context, path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions,
+) (*ctypes.ResultABCIQuery, error) {
+	if path != testutil.SimulateQueryPath {
+		return n.FakeNode.ABCIQueryWithOptions(ctx, path, data, opts)
+	}
+
+	select {
+	case n.entered <- struct{}{}:
+	default:
+	}
+	<-ctx.Done()
+
+	return nil, ctx.Err()
+}
+
+func TestBroadcast_SimulationCancelled(t *testing.T) {
+	tt := []struct {
+		name       string
+		rpcTimeout time.Duration
+		cancel     bool
+		wantErr    error
+	}{
+		{name: "caller context", cancel: true, wantErr: context.Canceled},
+		{name: "rpc timeout", rpcTimeout: 50 * time.Millisecond, wantErr: context.DeadlineExceeded},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			blocking := &blockingSimulation{FakeNode: node, entered: make(chan struct{}, 1)}
+
+			cfg := testConfig(node, key)
+			cfg.RPCClient = blocking
+			cfg.RPCTimeout = tc.rpcTimeout
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := b.BroadcastContext(ctx, []sdk.Msg{sendMsg(key.Address, 1)}, "",
+					broadcaster.BroadcastOptions{DisableAutoRetry: true})
+				done <- err
+			}()
+
+			select {
+			case <-blocking.entered:
+			case <-time.After(5 * time.Second):
+				t.Fatal("simulation isn't requested")
+			}
+			if tc.cancel {
+				cancel()
+			}
+
+			select {
+			case err := <-done:
+				require.ErrorIs(t, err, tc.wantErr)
+			case <-time.After(time.Second):
+				t.Fatal("broadcast isn't interrupted")
+			}
+			require.Empty(t, node.Mempool())
+		})
+	}
+}
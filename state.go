@@ -0,0 +1,71 @@
+package broadcaster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// sequenceState is stored to Config.SequenceFile. It maps addresses to their sequences,
+// so the accounts switched by SwitchKey don't overwrite each other's sequences.
+type sequenceState map[string]uint64
+
+// readSequenceState returns the persisted sequences. Missing or corrupted file is ignored.
+func (b *broadcaster) readSequenceState() sequenceState {
+	data, err := os.ReadFile(b.cfg.SequenceFile)
+	if err != nil {
+		return sequenceState{}
+	}
+
+	var s sequenceState
+	if err := json.Unmarshal(data, &s); err != nil || s == nil {
+		return sequenceState{}
+	}
+
+	return s
+}
+
+// loadSequence returns the persisted sequence of the signing account.
+func (b *broadcaster) loadSequence() (uint64, bool) {
+	seq, ok := b.readSequenceState()[b.From().String()]
+	return seq, ok
+}
+
+// persistSequence writes the current sequence to Config.SequenceFile. b.mu should be held by caller.
+func (b *broadcaster) persistSequence() error {
+	if b.cfg.SequenceFile == "" {
+		return nil
+	}
+
+	s := b.readSequenceState()
+	s[b.From().String()] = b.acc.sequence()
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sequence: %w", err)
+	}
+
+	// The file is replaced atomically, so it is never left half-written.
+	f, err := os.CreateTemp(filepath.Dir(b.cfg.SequenceFile), filepath.Base(b.cfg.SequenceFile)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create sequence file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write sequence file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write sequence file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), b.cfg.SequenceFile); err != nil {
+		return fmt.Errorf("failed to replace sequence file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package broadcaster_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestSequenceFile_Restart(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.SequenceFile = filepath.Join(t.TempDir(), "sequence.json")
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err := b.Broadcast([]sdk.Msg{sendMsg(key.Address, 1)}, "")
+		require.NoError(t, err)
+	}
+	require.NoError(t, b.Close())
+
+	// The txs are still in mempool, so the chain reports the sequence they use.
+	require.Len(t, node.Mempool(), 3)
+
+	b, err = broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+	require.Equal(t, uint64(3), b.TxFactory().Sequence())
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.Sequence)
+	require.Equal(t, 1, res.Attempts)
+}
+
+func TestSequenceFile_Fallback(t *testing.T) {
+	tt := []struct {
+		name string
+		data func(addr sdk.AccAddress) string
+	}{
+		{
+			name: "missing",
+		},
+		{
+			name: "corrupted",
+			data: func(sdk.AccAddress) string { return `{"` },
+		},
+		{
+			name: "another account",
+			data: func(sdk.AccAddress) string {
+				return `{"` + testutil.NewKey("another").Address.String() + `":7}`
+			},
+		},
+		{
+			name: "behind chain",
+			data: func(addr sdk.AccAddress) string { return `{"` + addr.String() + `":1}` },
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			node.SetSequence(key.Address, 2)
+
+			cfg := testConfig(node, key)
+			cfg.SequenceFile = filepath.Join(t.TempDir(), "sequence.json")
+
+			if tc.data != nil {
+				require.NoError(t, os.WriteFile(cfg.SequenceFile, []byte(tc.data(key.Address)), 0o600))
+			}
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+			require.Equal(t, uint64(2), b.TxFactory().Sequence())
+
+			_, err = b.Broadcast([]sdk.Msg{sendMsg(key.Address, 1)}, "")
+			require.NoError(t, err)
+			require.FileExists(t, cfg.SequenceFile)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmquery "github.com/tendermint/tendermint/libs/pubsub/query"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// WaitForEvent waits for tx event matching the query, e.g. "transfer.recipient='decentr1...'", and returns the first
+// matching event of the tx. Only txs committed after the call are matched. The query selects tx events,
+// so it shouldn't contain tm.event condition. Events are received over websocket, the node's tx search is polled
+// when the subscription isn't available or Config.MaxSubscriptions are used.
+func (b *broadcaster) WaitForEvent(ctx context.Context, query string) (Event, error) {
+	q, err := tmquery.New(query)
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid query: %w", err)
+	}
+
+	conds, err := q.Conditions()
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid query: %w", err)
+	}
+	for _, c := range conds {
+		if c.CompositeKey == tmtypes.EventTypeKey {
+			return Event{}, errors.New("invalid query: tm.event condition isn't allowed")
+		}
+	}
+
+	height, err := b.GetHeightFresh(ctx)
+	if err != nil {
+		return Event{}, err
+	}
+
+	subQuery := fmt.Sprintf("%s='%s' AND %s", tmtypes.EventTypeKey, tmtypes.EventTx, query)
+	ch, unsubscribe, err := b.client.subscribe(ctx, subQuery)
+	if err != nil {
+		b.infof(ctx, "subscription is unavailable, tx search is polled: %s", err)
+		return b.pollEvent(ctx, q, conds, query, height)
+	}
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case e, ok := <-ch:
+			// Nothing is matched yet, so polling from the same height doesn't miss events.
+			if !ok {
+				return b.pollEvent(ctx, q, conds, query, height)
+			}
+
+			data, ok := e.Data.(tmtypes.EventDataTx)
+			if !ok {
+				continue
+			}
+
+			hash := TxHash(data.Tx)
+			return matchEvent(q, conds, data.Height, hash, data.Result.Events), nil
+		}
+	}
+}
+
+// pollEvent polls the node's tx search for the first tx matching the query above the height.
+func (b *broadcaster) pollEvent(
+	ctx context.Context, q *tmquery.Query, conds []tmquery.Condition, query string, height uint64,
+) (Event, error) {
+	node, err := b.ctx.GetNode()
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	search := fmt.Sprintf("%s AND %s>%d", query, tmtypes.TxHeightKey, height)
+	page, perPage := 1, 1
+
+	for {
+		res, err := node.TxSearch(ctx, search, false, &page, &perPage, "asc")
+		if err == nil && len(res.Txs) > 0 {
+			tx := res.Txs[0]
+			return matchEvent(q, conds, tx.Height, tx.Hash.String(), tx.TxResult.Events), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case <-b.cfg.clock().After(b.cfg.commitPollInterval()):
+		}
+	}
+}
+
+// matchEvent returns the first event of tx which satisfies conditions of the query on its type.
+// The query matches tx as a whole, so the first event of a queried type is returned if none satisfies them alone.
+func matchEvent(q *tmquery.Query, conds []tmquery.Condition, height int64, hash string, txEvents []abci.Event) Event {
+	events := make([]Event, len(txEvents))
+	for i, v := range txEvents {
+		events[i] = decodeEvent(sdk.StringifyEvent(v))
+	}
+
+	types := make(map[string]bool, len(conds))
+	for _, c := range conds {
+		types[strings.SplitN(c.CompositeKey, ".", 2)[0]] = true
+	}
+
+	var fallback *Event
+	for i, e := range events {
+		if !types[e.Type] {
+			continue
+		}
+		if fallback == nil {
+			fallback = &events[i]
+		}
+
+		// Attributes of the other types are kept, so only conditions on the event's type are checked against it.
+		attrs := map[string][]string{
+			tmtypes.TxHeightKey: {fmt.Sprint(height)},
+			tmtypes.TxHashKey:   {hash},
+		}
+		for _, v := range events {
+			if v.Type != e.Type {
+				addAttributes(attrs, v)
+			}
+		}
+		addAttributes(attrs, e)
+
+		if ok, err := q.Matches(attrs); err == nil && ok {
+			return e
+		}
+	}
+
+	if fallback != nil {
+		return *fallback
+	}
+	if len(events) > 0 {
+		return events[0]
+	}
+
+	return Event{}
+}
+
+// addAttributes adds attributes of the event to the map of composite keys.
+func addAttributes(attrs map[string][]string, e Event) {
+	for _, a := range e.Attributes {
+		key := e.Type + "." + a.Key
+		attrs[key] = append(attrs[key], a.Value)
+	}
+}
@@ -0,0 +1,221 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// noSubscriptions is the fake node which doesn't support websocket subscriptions.
+type noSubscriptions struct {
+	*testutil.FakeNode
+}
+
+var _ rpcclient.Client = (*noSubscriptions)(nil)
+
+func (n *noSubscriptions) Subscribe(context.Context, string, string, ...int) (<-chan ctypes.ResultEvent, error) {
+	return nil, errors.New("websocket is unavailable")
+}
+
+// eventWaiter is a broadcaster waiting for events, e.g. one returned by broadcaster.New.
+type eventWaiter interface {
+	contextBroadcaster
+	WaitForEvent(ctx context.Context, query string) (broadcaster.Event, error)
+}
+
+// waitForEvent starts waiting for the event and returns channels of its outcome.
+func waitForEvent(ctx context.Context, b eventWaiter, query string) (<-chan broadcaster.Event, <-chan error) {
+	events, errs := make(chan broadcaster.Event, 1), make(chan error, 1)
+	go func() {
+		e, err := b.WaitForEvent(ctx, query)
+		if err != nil {
+			errs <- err
+			return
+		}
+		events <- e
+	}()
+
+	return events, errs
+}
+
+// waitCalls waits for the node to get more calls of the method than before.
+func waitCalls(t *testing.T, node *testutil.FakeNode, method string, before int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool { return node.Calls(method) > before }, 5*time.Second, time.Millisecond,
+		"%s isn't called", method)
+}
+
+func TestWaitForEvent(t *testing.T) {
+	tt := []struct {
+		name   string
+		config func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config
+		// waitsFor is the call made by the node once the broadcaster waits for the event.
+		waitsFor string
+	}{
+		{
+			name: "websocket",
+			config: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				srv := node.Serve()
+				t.Cleanup(srv.Close)
+				return uriConfig(node, key, srv.URL)
+			},
+			waitsFor: "subscribe",
+		},
+		{
+			name: "polling",
+			config: func(t *testing.T, node *testutil.FakeNode, key testutil.Key) broadcaster.Config {
+				cfg := testConfig(node, key)
+				cfg.RPCClient = &noSubscriptions{FakeNode: node}
+				cfg.CommitPollInterval = 10 * time.Millisecond
+				return cfg
+			},
+			waitsFor: "tx_search",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			other := testutil.NewKey(t.Name() + "/other")
+			node.AddAccount(other.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+			b, err := broadcaster.New(tc.config(t, node, key))
+			require.NoError(t, err)
+			defer b.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			// The tx committed before the call isn't matched.
+			_, err = b.BroadcastContext(ctx, []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+			node.NextBlock()
+
+			// The event is emitted by the tx of another account.
+			calls := node.Calls(tc.waitsFor)
+			events, errs := waitForEvent(ctx, b, "transfer.sender='"+other.Address.String()+"'")
+			waitCalls(t, node, tc.waitsFor, calls)
+
+			sender, err := broadcaster.New(testConfig(node, other))
+			require.NoError(t, err)
+			defer sender.Close()
+
+			_, err = sender.BroadcastContext(ctx, []sdk.Msg{sendMsg(other.Address, 5)}, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+			node.NextBlock()
+
+			select {
+			case e := <-events:
+				require.Equal(t, "transfer", e.Type)
+				v, ok := broadcaster.FindAttribute([]broadcaster.Event{e}, "transfer", "amount")
+				require.True(t, ok)
+				require.Equal(t, "5udec", v)
+			case err := <-errs:
+				require.NoError(t, err)
+			case <-ctx.Done():
+				t.Fatal("event isn't received")
+			}
+
+			// The subscription is removed.
+			require.Eventually(t, func() bool { return node.Subscriptions() == 0 }, 5*time.Second, time.Millisecond)
+		})
+	}
+}
+
+func TestWaitForEvent_Cancelled(t *testing.T) {
+	node, key := newFakeChain(t)
+	srv := node.Serve()
+	defer srv.Close()
+
+	b, err := broadcaster.New(uriConfig(node, key, srv.URL))
+	require.NoError(t, err)
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, errs := waitForEvent(ctx, b, "transfer.sender='"+key.Address.String()+"'")
+	require.Eventually(t, func() bool { return node.Subscriptions() == 1 }, 5*time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errs:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait isn't interrupted")
+	}
+	require.Eventually(t, func() bool { return node.Subscriptions() == 0 }, 5*time.Second, time.Millisecond)
+}
+
+func TestWaitForEvent_MaxSubscriptions(t *testing.T) {
+	node, key := newFakeChain(t)
+	srv := node.Serve()
+	defer srv.Close()
+
+	cfg := uriConfig(node, key, srv.URL)
+	cfg.MaxSubscriptions = 1
+	cfg.CommitPollInterval = 10 * time.Millisecond
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The first wait uses the only subscription, the second one polls the node.
+	firstEvents, firstErrs := waitForEvent(ctx, b, "transfer.amount='1udec'")
+	require.Eventually(t, func() bool { return node.Subscriptions() == 1 }, 5*time.Second, time.Millisecond)
+
+	searches := node.Calls("tx_search")
+	secondEvents, secondErrs := waitForEvent(ctx, b, "transfer.amount='2udec'")
+	waitCalls(t, node, "tx_search", searches)
+	require.Equal(t, 1, node.Subscriptions())
+
+	_, err = b.BroadcastContext(ctx, []sdk.Msg{sendMsg(key.Address, 1), sendMsg(key.Address, 2)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	node.NextBlock()
+
+	// Both get the transfer event of their msg.
+	for _, ch := range []struct {
+		events <-chan broadcaster.Event
+		errs   <-chan error
+		amount string
+	}{{firstEvents, firstErrs, "1udec"}, {secondEvents, secondErrs, "2udec"}} {
+		select {
+		case e := <-ch.events:
+			v, ok := broadcaster.FindAttribute([]broadcaster.Event{e}, "transfer", "amount")
+			require.True(t, ok)
+			require.Equal(t, ch.amount, v)
+		case err := <-ch.errs:
+			require.NoError(t, err)
+		case <-ctx.Done():
+			t.Fatal("event isn't received")
+		}
+	}
+}
+
+func TestWaitForEvent_InvalidQuery(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	for _, query := range []string{"transfer.sender=", "tm.event='Tx' AND transfer.sender='a'"} {
+		_, err := b.WaitForEvent(context.Background(), query)
+		require.ErrorContains(t, err, "invalid query", query)
+	}
+	require.Zero(t, node.Calls("subscribe"))
+}
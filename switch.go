@@ -0,0 +1,70 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// signerKey is the key used for signing.
+type signerKey struct {
+	name    string
+	address sdk.AccAddress
+}
+
+// signer returns the key used for signing.
+func (b *broadcaster) signer() signerKey {
+	return b.key.Load().(signerKey)
+}
+
+// SwitchKey makes the broadcaster to sign txs with another key of the keyring.
+// It waits for the ongoing broadcast to finish, fetches account number and sequence of the new account
+// and swaps the key, so subsequent broadcasts use the new key. The per-account state, such as
+// the missing account flag and recent sequence mismatches, is reset.
+// It isn't supported in pipelined mode and with sequence store, since their sequences belong to the account.
+func (b *broadcaster) SwitchKey(ctx context.Context, name string) error {
+	if b.pipeline != nil || b.cfg.SequenceStore != nil {
+		return errors.New("key can't be switched in pipelined mode or with sequence store")
+	}
+
+	info, err := b.ctx.Keyring.Key(name)
+	if err != nil {
+		return fmt.Errorf("failed to get key: %w", keyNotFoundError(b.ctx.Keyring, b.cfg, err))
+	}
+
+	if expected, ok := b.cfg.expectedAddress(name); ok {
+		if err := verifyAddress(name, info.GetAddress(), expected); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	num, seq, err := b.ctx.AccountRetriever.GetAccountNumberSequence(b.ctx, info.GetAddress())
+	if err != nil {
+		return fmt.Errorf("failed to get GetAccountNumberSequence: %w", err)
+	}
+
+	prev := b.signer()
+
+	b.key.Store(signerKey{name: info.GetName(), address: info.GetAddress()})
+	if persisted, ok := b.loadSequence(); ok && persisted > seq {
+		seq = persisted
+	}
+	b.acc.set(num, seq)
+	b.shadowSeq = seq
+
+	// The state of the previous account doesn't apply to the new one.
+	b.setAccountMissing(false)
+	if b.mismatches != nil {
+		b.mismatches.reset()
+	}
+
+	b.infof(ctx, "signing key is switched from %s (%s) to %s (%s)",
+		prev.name, prev.address, info.GetName(), info.GetAddress())
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package broadcaster_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// switchConfig returns config of broadcaster with the additional "other" key.
+func switchConfig(node *testutil.FakeNode, key, other testutil.Key) broadcaster.Config {
+	cfg := testConfig(node, key)
+	cfg.Keys = []broadcaster.KeySeed{{Name: "other", PrivKeyHex: other.PrivKeyHex}}
+	return cfg
+}
+
+func TestSwitchKey(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetSequence(key.Address, 5)
+	other := testutil.NewKey("other")
+	node.AddAccount(other.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+	node.SetSequence(other.Address, 2)
+
+	b, err := broadcaster.New(switchConfig(node, key, other))
+	require.NoError(t, err)
+	defer b.Close()
+
+	broadcast := func(from sdk.AccAddress, seq uint64) {
+		t.Helper()
+
+		res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(from, 1)}, "", broadcaster.BroadcastOptions{})
+		require.NoError(t, err)
+		require.Equal(t, seq, res.Sequence)
+		require.Equal(t, 1, res.Attempts)
+		requireCommitted(t, node, res.TxHash)
+	}
+
+	broadcast(key.Address, 5)
+
+	require.NoError(t, b.SwitchKey(context.Background(), "other"))
+	require.Equal(t, other.Address, b.From())
+	broadcast(other.Address, 2)
+	broadcast(other.Address, 3)
+
+	// The sequence of the first key isn't carried over and vice versa.
+	require.NoError(t, b.SwitchKey(context.Background(), "test"))
+	require.Equal(t, key.Address, b.From())
+	broadcast(key.Address, 6)
+
+	require.ErrorIs(t, b.SwitchKey(context.Background(), "missing"), sdkerrors.ErrKeyNotFound)
+	require.Equal(t, key.Address, b.From())
+}
+
+func TestSwitchKey_SequenceFile(t *testing.T) {
+	node, key := newFakeChain(t)
+	other := testutil.NewKey("other")
+	node.AddAccount(other.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+	cfg := switchConfig(node, key, other)
+	cfg.SequenceFile = filepath.Join(t.TempDir(), "sequence.json")
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// The txs stay in mempool, so the chain reports outdated sequences of both accounts.
+	broadcast := func(from sdk.AccAddress, seq uint64) {
+		t.Helper()
+
+		res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(from, 1)}, "", broadcaster.BroadcastOptions{})
+		require.NoError(t, err)
+		require.Equal(t, seq, res.Sequence)
+		require.Equal(t, 1, res.Attempts)
+	}
+
+	broadcast(key.Address, 0)
+	broadcast(key.Address, 1)
+
+	require.NoError(t, b.SwitchKey(context.Background(), "other"))
+	broadcast(other.Address, 0)
+
+	require.NoError(t, b.SwitchKey(context.Background(), "test"))
+	broadcast(key.Address, 2)
+
+	require.NoError(t, b.SwitchKey(context.Background(), "other"))
+	broadcast(other.Address, 1)
+
+	data, err := os.ReadFile(cfg.SequenceFile)
+	require.NoError(t, err)
+	var sequences map[string]uint64
+	require.NoError(t, json.Unmarshal(data, &sequences))
+	require.Equal(t, map[string]uint64{key.Address.String(): 3, other.Address.String(): 2}, sequences)
+}
+
+func TestSwitchKey_ResetsAccountState(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.RemoveAccount(key.Address)
+	other := testutil.NewKey("other")
+	node.AddAccount(other.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+	cfg := switchConfig(node, key, other)
+	cfg.AllowUnfundedAccount = true
+	cfg.SequenceContention = broadcaster.SequenceContention{Window: time.Hour, Threshold: 10}
+
+	var missing []bool
+	cfg.OnAccountMissing = func(v bool) { missing = append(missing, v) }
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+	require.True(t, b.Stats().AccountMissing)
+
+	require.NoError(t, b.SwitchKey(context.Background(), "other"))
+	require.False(t, b.Stats().AccountMissing)
+	require.Equal(t, []bool{true, false}, missing)
+
+	// Another process uses the sequence, so the broadcast is retried.
+	node.SetSequence(other.Address, 1)
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(other.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Sequence)
+	require.Equal(t, 1, b.Stats().SequenceMismatches)
+
+	node.AddAccount(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+	require.NoError(t, b.SwitchKey(context.Background(), "test"))
+	require.Zero(t, b.Stats().SequenceMismatches, "mismatches of the other account don't count")
+	require.False(t, b.Stats().AccountMissing)
+}
@@ -0,0 +1,67 @@
+package testutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := testutil.NewFakeClock(start)
+
+	early := clock.NewTimer(time.Second)
+	late := clock.After(3 * time.Second)
+	stopped := clock.NewTimer(2 * time.Second)
+	require.Equal(t, 3, clock.Waiters())
+
+	require.True(t, stopped.Stop())
+	require.False(t, stopped.Stop())
+	require.Equal(t, 2, clock.Waiters())
+
+	clock.Advance(time.Second)
+	require.Equal(t, start.Add(time.Second), <-early.C())
+	require.Equal(t, 1, clock.Waiters())
+
+	clock.Advance(time.Second)
+	select {
+	case <-late:
+		t.Fatal("timer is fired early")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	require.Equal(t, start.Add(3*time.Second), <-late)
+	require.Equal(t, start.Add(3*time.Second), clock.Now())
+
+	select {
+	case <-stopped.C():
+		t.Fatal("stopped timer is fired")
+	default:
+	}
+
+	// Non-positive durations fire at once.
+	require.Equal(t, clock.Now(), <-clock.After(0))
+}
+
+func TestFakeClock_Sleep(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+
+	done := make(chan struct{})
+	go func() {
+		clock.Sleep(time.Minute)
+		close(done)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sleep isn't finished")
+	}
+}
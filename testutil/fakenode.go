@@ -0,0 +1,976 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/gogo/protobuf/proto"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	tmquery "github.com/tendermint/tendermint/libs/pubsub/query"
+	"github.com/tendermint/tendermint/p2p"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	"github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+)
+
+// Defaults of FakeNode.
+const (
+	FakeChainID = "fake-chain"
+
+	DefaultFakeTxGas  = 50000
+	DefaultFakeMsgGas = 20000
+
+	DefaultFakeBlockInterval = 5 * time.Second
+)
+
+// FakeGenesisTime is the time of the first block of FakeNode.
+var FakeGenesisTime = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// MsgHandler executes a message in DeliverTx. It returns the message's response and events.
+type MsgHandler func(signer sdk.AccAddress, msg *codectypes.Any) (proto.Message, []abci.Event, error)
+
+// CheckHook is called on CheckTx after the ante checks have passed. A non-nil error rejects the tx.
+type CheckHook func(tx FakeTx) error
+
+// FakeNode is an in-memory chain node for tests. It implements the methods of rpcclient.Client used
+// by the broadcaster, so it could be passed as broadcaster.Config.RPCClient.
+//
+// The node checks txs like the SDK's ante handler does: sequences, SIGN_MODE_DIRECT signatures, account numbers,
+// timeout heights, memo length, min gas prices and fees. Gas is deterministic: DefaultFakeTxGas per tx
+// and DefaultFakeMsgGas per message unless SetMsgGas overrides it, so simulation matches execution.
+// Accepted txs wait in the mempool until NextBlock commits them. Blocks are signed by real validator keys,
+// so proofs of inclusion could be verified. Errors mimic the ones returned by a real node.
+type FakeNode struct {
+	rpcclient.Client
+
+	mu sync.Mutex
+
+	chainID    string
+	appVersion string
+	catchingUp bool
+	down       error
+	latency    time.Duration
+	failures   map[string][]error
+	calls      map[string]int
+	autoBlock  bool
+	interval   time.Duration
+
+	accounts    map[string]authtypes.AccountI
+	balances    map[string]sdk.Coins
+	checkSeq    map[string]uint64 // checkSeq is the next sequence accepted by CheckTx, it's ahead of committed txs.
+	nextAccNum  uint64
+	metadata    []banktypes.Metadata
+	minGasPrice sdk.DecCoins
+
+	txGas      uint64
+	msgGas     map[string]uint64
+	handlers   map[string]MsgHandler
+	extOptions map[string]bool
+	queries    map[string]QueryHandler
+	checkHooks []CheckHook
+
+	mempool []FakeTx
+	evicted map[string]bool // evicted are hashes of txs removed from the mempool, they stay in the mempool cache.
+	blocks  []*fakeBlock
+	txs     map[string]*ctypes.ResultTx
+
+	privVals   []types.PrivValidator
+	validators *types.ValidatorSet
+
+	subscriptions map[subscriptionKey]*fakeSubscription
+}
+
+// fakeBlock is a committed block.
+type fakeBlock struct {
+	header     types.Header
+	txs        types.Txs
+	results    []*abci.ResponseDeliverTx
+	validators *types.ValidatorSet
+	privVals   []types.PrivValidator
+	commit     *types.Commit
+}
+
+type subscriptionKey struct {
+	subscriber string
+	query      string
+}
+
+type fakeSubscription struct {
+	query *tmquery.Query
+	ch    chan ctypes.ResultEvent
+}
+
+var _ rpcclient.Client = &FakeNode{}
+
+// NewFakeNode returns new instance of FakeNode with a single validator and the first block committed.
+func NewFakeNode() *FakeNode {
+	n := &FakeNode{
+		chainID:    FakeChainID,
+		appVersion: "v1.6.2",
+		failures:   map[string][]error{},
+		calls:      map[string]int{},
+		interval:   DefaultFakeBlockInterval,
+
+		accounts:   map[string]authtypes.AccountI{},
+		balances:   map[string]sdk.Coins{},
+		checkSeq:   map[string]uint64{},
+		nextAccNum: 1,
+
+		txGas:      DefaultFakeTxGas,
+		msgGas:     map[string]uint64{},
+		handlers:   map[string]MsgHandler{},
+		extOptions: map[string]bool{},
+		queries:    map[string]QueryHandler{},
+
+		evicted: map[string]bool{},
+		txs:     map[string]*ctypes.ResultTx{},
+
+		subscriptions: map[subscriptionKey]*fakeSubscription{},
+	}
+	n.setValidatorsLocked(1)
+	n.commitLocked(FakeGenesisTime)
+
+	return n
+}
+
+// SetChainID sets the chain id of the node. The first block keeps the previous one.
+func (n *FakeNode) SetChainID(chainID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.chainID = chainID
+}
+
+// SetAppVersion sets the application version reported by ABCIInfo.
+func (n *FakeNode) SetAppVersion(v string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.appVersion = v
+}
+
+// SetCatchingUp sets the sync state reported by Status.
+func (n *FakeNode) SetCatchingUp(v bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.catchingUp = v
+}
+
+// SetDown makes every call fail with a connection error until it's called with false.
+func (n *FakeNode) SetDown(down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.down = nil
+	if down {
+		n.down = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+}
+
+// SetLatency delays every call. Delayed calls respect the context.
+func (n *FakeNode) SetLatency(d time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.latency = d
+}
+
+// FailNext makes the next calls of the method fail with the errors in order.
+// Methods are named like rpc endpoints, e.g. "broadcast_tx_sync" or "abci_query".
+func (n *FakeNode) FailNext(method string, errs ...error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.failures[method] = append(n.failures[method], errs...)
+}
+
+// Calls returns the number of calls of the method.
+func (n *FakeNode) Calls(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.calls[method]
+}
+
+// SetAutoBlock makes every accepted tx committed in a new block right away.
+func (n *FakeNode) SetAutoBlock(v bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.autoBlock = v
+}
+
+// SetBlockInterval sets the time between blocks. DefaultFakeBlockInterval is used by default.
+func (n *FakeNode) SetBlockInterval(d time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.interval = d
+}
+
+// SetMinGasPrices sets min gas prices required by CheckTx. Fees aren't required by default.
+func (n *FakeNode) SetMinGasPrices(prices sdk.DecCoins) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.minGasPrice = prices
+}
+
+// SetTxGas sets gas consumed by every tx in addition to its messages.
+func (n *FakeNode) SetTxGas(gas uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.txGas = gas
+}
+
+// SetMsgGas sets gas consumed by messages of the type url.
+func (n *FakeNode) SetMsgGas(typeURL string, gas uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.msgGas[typeURL] = gas
+}
+
+// HandleMsg sets the handler of messages of the type url. Messages without handlers succeed without events,
+// bank's MsgSend and MsgMultiSend transfer coins.
+func (n *FakeNode) HandleMsg(typeURL string, h MsgHandler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.handlers[typeURL] = h
+}
+
+// AllowExtensionOption makes txs with the extension option accepted. Like the SDK's ante handler
+// the node rejects txs with unknown extension options, non-critical ones are ignored.
+func (n *FakeNode) AllowExtensionOption(typeURL string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.extOptions[typeURL] = true
+}
+
+// OnCheckTx adds the hook called when the tx passes the ante checks.
+func (n *FakeNode) OnCheckTx(h CheckHook) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.checkHooks = append(n.checkHooks, h)
+}
+
+// SetDenomMetadata sets metadata returned by the bank's DenomsMetadata query.
+func (n *FakeNode) SetDenomMetadata(metadata ...banktypes.Metadata) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.metadata = metadata
+}
+
+// SetValidators replaces validators with count new ones with the same voting power. Next blocks are signed by them.
+func (n *FakeNode) SetValidators(count int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.setValidatorsLocked(count)
+}
+
+// ValidatorSet returns the current validators.
+func (n *FakeNode) ValidatorSet() *types.ValidatorSet {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.validators.Copy()
+}
+
+func (n *FakeNode) setValidatorsLocked(count int) {
+	vals := make([]*types.Validator, count)
+	privVals := make([]types.PrivValidator, count)
+	for i := range vals {
+		pv := types.NewMockPV()
+		privVals[i] = pv
+		vals[i] = pv.ExtractIntoValidator(10)
+	}
+
+	n.validators = types.NewValidatorSet(vals)
+
+	// Votes of MakeCommit are indexed by validators' order in the set.
+	n.privVals = make([]types.PrivValidator, 0, count)
+	for _, v := range n.validators.Validators {
+		for _, pv := range privVals {
+			if pub, _ := pv.GetPubKey(); pub.Address().String() == v.Address.String() {
+				n.privVals = append(n.privVals, pv)
+			}
+		}
+	}
+}
+
+// AddAccount creates the account with the balance and returns its account number.
+// The existing account gets the balance added.
+func (n *FakeNode) AddAccount(addr sdk.AccAddress, balance ...sdk.Coin) uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	acc := n.ensureAccountLocked(addr)
+	n.balances[addr.String()] = n.balances[addr.String()].Add(balance...)
+
+	return acc.GetAccountNumber()
+}
+
+// PutAccount stores the account as is, e.g. a vesting account. The sequence accepted by CheckTx follows it.
+func (n *FakeNode) PutAccount(acc authtypes.AccountI, balance ...sdk.Coin) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.accounts[acc.GetAddress().String()] = acc
+	n.checkSeq[acc.GetAddress().String()] = acc.GetSequence()
+	n.balances[acc.GetAddress().String()] = sdk.NewCoins(balance...)
+}
+
+// RemoveAccount deletes the account, e.g. to emulate a chain reset. The balance is kept.
+func (n *FakeNode) RemoveAccount(addr sdk.AccAddress) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.accounts, addr.String())
+	delete(n.checkSeq, addr.String())
+}
+
+// SetAccountNumber changes the account number of the account, e.g. to emulate chain export and import.
+func (n *FakeNode) SetAccountNumber(addr sdk.AccAddress, num uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if acc, ok := n.accounts[addr.String()]; ok {
+		_ = acc.SetAccountNumber(num)
+	}
+}
+
+// SetSequence sets both committed sequence of the account and the one accepted by CheckTx,
+// e.g. to emulate txs sent by another process.
+func (n *FakeNode) SetSequence(addr sdk.AccAddress, seq uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if acc, ok := n.accounts[addr.String()]; ok {
+		_ = acc.SetSequence(seq)
+		n.checkSeq[addr.String()] = seq
+	}
+}
+
+// Account returns the account and whether it exists.
+func (n *FakeNode) Account(addr sdk.AccAddress) (authtypes.AccountI, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	acc, ok := n.accounts[addr.String()]
+	return acc, ok
+}
+
+// Sequence returns the committed sequence of the account.
+func (n *FakeNode) Sequence(addr sdk.AccAddress) uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if acc, ok := n.accounts[addr.String()]; ok {
+		return acc.GetSequence()
+	}
+
+	return 0
+}
+
+// Balance returns the balance of the address.
+func (n *FakeNode) Balance(addr sdk.AccAddress) sdk.Coins {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.balances[addr.String()]
+}
+
+// SetBalance replaces the balance of the address.
+func (n *FakeNode) SetBalance(addr sdk.AccAddress, balance ...sdk.Coin) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.balances[addr.String()] = sdk.NewCoins(balance...)
+}
+
+// Mempool returns txs waiting for the next block in order.
+func (n *FakeNode) Mempool() []FakeTx {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]FakeTx(nil), n.mempool...)
+}
+
+// Evict removes tx with the hash from the mempool without committing it, e.g. to emulate a lost tx.
+// Like in Tendermint, the hash stays in the mempool cache, so the same tx bytes are rejected if broadcast again.
+func (n *FakeNode) Evict(hash string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i, v := range n.mempool {
+		if v.Hash == hash {
+			n.mempool = append(n.mempool[:i], n.mempool[i+1:]...)
+			n.evicted[hash] = true
+			return true
+		}
+	}
+
+	return false
+}
+
+// Height returns the height of the latest block.
+func (n *FakeNode) Height() int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.latestLocked().header.Height
+}
+
+// Time returns the time of the latest block.
+func (n *FakeNode) Time() time.Time {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.latestLocked().header.Time
+}
+
+// CommittedTx returns the result of the committed tx with the hash.
+func (n *FakeNode) CommittedTx(hash string) (*ctypes.ResultTx, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	res, ok := n.txs[strings.ToUpper(hash)]
+	return res, ok
+}
+
+// NextBlock commits txs of the mempool in a new block and returns its height.
+func (n *FakeNode) NextBlock() int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.nextBlockLocked()
+}
+
+// NextBlockAt commits txs of the mempool in a new block with the time and returns its height.
+func (n *FakeNode) NextBlockAt(t time.Time) int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.commitLocked(t).header.Height
+}
+
+func (n *FakeNode) nextBlockLocked() int64 {
+	return n.commitLocked(n.latestLocked().header.Time.Add(n.interval)).header.Height
+}
+
+func (n *FakeNode) latestLocked() *fakeBlock {
+	return n.blocks[len(n.blocks)-1]
+}
+
+// commitLocked executes txs of the mempool and commits them in a new block.
+func (n *FakeNode) commitLocked(t time.Time) *fakeBlock {
+	height := int64(len(n.blocks)) + 1
+	block := &fakeBlock{
+		validators: n.validators,
+		privVals:   n.privVals,
+	}
+
+	for _, tx := range n.mempool {
+		res := n.deliverLocked(tx, height)
+		block.txs = append(block.txs, tx.Bytes)
+		block.results = append(block.results, res)
+	}
+	n.mempool = nil
+
+	block.header = types.Header{
+		Version:            tmversion.Consensus{Block: version.BlockProtocol},
+		ChainID:            n.chainID,
+		Height:             height,
+		Time:               t,
+		DataHash:           block.txs.Hash(),
+		ValidatorsHash:     n.validators.Hash(),
+		NextValidatorsHash: n.validators.Hash(),
+		ProposerAddress:    n.validators.GetProposer().Address,
+	}
+	if len(n.blocks) > 0 {
+		block.header.LastBlockID = n.latestLocked().blockID()
+	}
+	n.blocks = append(n.blocks, block)
+
+	for i, tx := range block.txs {
+		res := &ctypes.ResultTx{
+			Hash:     tx.Hash(),
+			Height:   height,
+			Index:    uint32(i),
+			TxResult: *block.results[i],
+			Tx:       tx,
+		}
+		n.txs[res.Hash.String()] = res
+		n.publishLocked(res)
+	}
+
+	// CheckTx accepts sequences of the committed state once the mempool is empty.
+	for addr, acc := range n.accounts {
+		n.checkSeq[addr] = acc.GetSequence()
+	}
+
+	return block
+}
+
+func (b *fakeBlock) blockID() types.BlockID {
+	return types.BlockID{
+		Hash:          b.header.Hash(),
+		PartSetHeader: types.PartSetHeader{Total: 1, Hash: tmhash.Sum(b.header.Hash())},
+	}
+}
+
+// signedHeader returns the header with the commit signed by the block's validators.
+func (b *fakeBlock) signedHeader() (types.SignedHeader, error) {
+	if b.commit == nil {
+		voteSet := types.NewVoteSet(b.header.ChainID, b.header.Height, 0, tmproto.PrecommitType, b.validators)
+		commit, err := types.MakeCommit(b.blockID(), b.header.Height, 0, voteSet, b.privVals, b.header.Time)
+		if err != nil {
+			return types.SignedHeader{}, err
+		}
+		b.commit = commit
+	}
+
+	header := b.header
+	return types.SignedHeader{Header: &header, Commit: b.commit}, nil
+}
+
+func (n *FakeNode) ensureAccountLocked(addr sdk.AccAddress) authtypes.AccountI {
+	if acc, ok := n.accounts[addr.String()]; ok {
+		return acc
+	}
+
+	acc := authtypes.NewBaseAccount(addr, nil, n.nextAccNum, 0)
+	n.nextAccNum++
+	n.accounts[addr.String()] = acc
+	n.checkSeq[addr.String()] = 0
+
+	return acc
+}
+
+// call records the call of the method and returns an error the call should fail with.
+// It sleeps for the latency out of the lock.
+func (n *FakeNode) call(ctx context.Context, method string) error {
+	n.mu.Lock()
+	n.calls[method]++
+	latency, down := n.latency, n.down
+	var err error
+	if errs := n.failures[method]; len(errs) > 0 {
+		err, n.failures[method] = errs[0], errs[1:]
+	}
+	n.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+
+	if down != nil {
+		return down
+	}
+
+	return err
+}
+
+// rpcError returns error in the form returned by the rpc client for errors of the node.
+func rpcError(format string, args ...interface{}) error {
+	return &rpctypes.RPCError{Code: -32603, Message: "Internal error", Data: fmt.Sprintf(format, args...)}
+}
+
+// Start implements rpcclient.Client.
+func (n *FakeNode) Start() error { return nil }
+
+// Stop implements rpcclient.Client.
+func (n *FakeNode) Stop() error { return nil }
+
+// IsRunning implements rpcclient.Client.
+func (n *FakeNode) IsRunning() bool { return true }
+
+// ABCIInfo implements rpcclient.Client.
+func (n *FakeNode) ABCIInfo(ctx context.Context) (*ctypes.ResultABCIInfo, error) {
+	if err := n.call(ctx, "abci_info"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	latest := n.latestLocked()
+	return &ctypes.ResultABCIInfo{Response: abci.ResponseInfo{
+		Data:             "decentr",
+		Version:          n.appVersion,
+		LastBlockHeight:  latest.header.Height,
+		LastBlockAppHash: latest.header.AppHash,
+	}}, nil
+}
+
+// Status implements rpcclient.Client.
+func (n *FakeNode) Status(ctx context.Context) (*ctypes.ResultStatus, error) {
+	if err := n.call(ctx, "status"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	latest := n.latestLocked()
+	return &ctypes.ResultStatus{
+		NodeInfo: p2p.DefaultNodeInfo{
+			Network: n.chainID,
+			Version: "0.34.21",
+			Moniker: "fake",
+		},
+		SyncInfo: ctypes.SyncInfo{
+			LatestBlockHash:   latest.header.Hash(),
+			LatestBlockHeight: latest.header.Height,
+			LatestBlockTime:   latest.header.Time,
+			CatchingUp:        n.catchingUp,
+		},
+	}, nil
+}
+
+// Tx implements rpcclient.Client.
+func (n *FakeNode) Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error) {
+	if err := n.call(ctx, "tx"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	res, ok := n.txs[tmbytes.HexBytes(hash).String()]
+	if !ok {
+		return nil, rpcError("tx (%X) not found", hash)
+	}
+
+	return n.resultTxLocked(res, prove), nil
+}
+
+func (n *FakeNode) resultTxLocked(res *ctypes.ResultTx, prove bool) *ctypes.ResultTx {
+	out := *res
+	if prove {
+		block := n.blocks[res.Height-1]
+		out.Proof = block.txs.Proof(int(res.Index))
+	}
+
+	return &out
+}
+
+// TxSearch implements rpcclient.Client. Txs are matched by the query against their events,
+// tx.hash and tx.height like the node's indexer does.
+func (n *FakeNode) TxSearch(
+	ctx context.Context, query string, prove bool, page, perPage *int, orderBy string,
+) (*ctypes.ResultTxSearch, error) {
+	if err := n.call(ctx, "tx_search"); err != nil {
+		return nil, err
+	}
+
+	q, err := tmquery.New(query)
+	if err != nil {
+		return nil, rpcError("failed to parse query: %s", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var matched []*ctypes.ResultTx
+	for _, block := range n.blocks {
+		for i := range block.txs {
+			res := n.txs[fmt.Sprintf("%X", block.txs[i].Hash())]
+			ok, err := q.Matches(txEvents(res))
+			if err != nil {
+				return nil, rpcError("failed to match query: %s", err)
+			}
+			if ok {
+				matched = append(matched, res)
+			}
+		}
+	}
+
+	switch orderBy {
+	case "", "asc":
+	case "desc":
+		sort.SliceStable(matched, func(i, j int) bool {
+			return matched[i].Height > matched[j].Height ||
+				matched[i].Height == matched[j].Height && matched[i].Index > matched[j].Index
+		})
+	default:
+		return nil, rpcError("expected order_by to be either `asc` or `desc` or empty")
+	}
+
+	p, pp := pagination(page, perPage)
+	out := &ctypes.ResultTxSearch{TotalCount: len(matched)}
+	for i := (p - 1) * pp; i < len(matched) && i < p*pp; i++ {
+		out.Txs = append(out.Txs, n.resultTxLocked(matched[i], prove))
+	}
+
+	return out, nil
+}
+
+// pagination returns page and page size defaulted like the node does.
+func pagination(page, perPage *int) (int, int) {
+	p, pp := 1, 30
+	if page != nil && *page > 0 {
+		p = *page
+	}
+	if perPage != nil && *perPage > 0 {
+		pp = *perPage
+	}
+	if pp > 100 {
+		pp = 100
+	}
+
+	return p, pp
+}
+
+// txEvents returns events of the committed tx in the form matched by queries.
+func txEvents(res *ctypes.ResultTx) map[string][]string {
+	events := map[string][]string{
+		types.EventTypeKey: {types.EventTx},
+		types.TxHashKey:    {res.Hash.String()},
+		types.TxHeightKey:  {fmt.Sprint(res.Height)},
+	}
+	for _, e := range res.TxResult.Events {
+		for _, attr := range e.Attributes {
+			key := e.Type + "." + string(attr.Key)
+			events[key] = append(events[key], string(attr.Value))
+		}
+	}
+
+	return events
+}
+
+// UnconfirmedTxs implements rpcclient.Client. Like the node it returns at most 100 txs.
+func (n *FakeNode) UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
+	if err := n.call(ctx, "unconfirmed_txs"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	l := 30
+	if limit != nil && *limit > 0 {
+		l = *limit
+	}
+	if l > 100 {
+		l = 100
+	}
+
+	out := &ctypes.ResultUnconfirmedTxs{Total: len(n.mempool)}
+	for i := 0; i < len(n.mempool) && i < l; i++ {
+		out.Txs = append(out.Txs, n.mempool[i].Bytes)
+		out.TotalBytes += int64(len(n.mempool[i].Bytes))
+	}
+	out.Count = len(out.Txs)
+
+	return out, nil
+}
+
+// NumUnconfirmedTxs implements rpcclient.Client.
+func (n *FakeNode) NumUnconfirmedTxs(ctx context.Context) (*ctypes.ResultUnconfirmedTxs, error) {
+	if err := n.call(ctx, "num_unconfirmed_txs"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return &ctypes.ResultUnconfirmedTxs{Count: len(n.mempool), Total: len(n.mempool)}, nil
+}
+
+// CheckTx implements rpcclient.Client. Like the node it checks tx without adding it to the mempool.
+func (n *FakeNode) CheckTx(ctx context.Context, tx types.Tx) (*ctypes.ResultCheckTx, error) {
+	if err := n.call(ctx, "check_tx"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	res, _ := n.checkLocked(tx)
+	return &ctypes.ResultCheckTx{ResponseCheckTx: res}, nil
+}
+
+// Commit implements rpcclient.Client.
+func (n *FakeNode) Commit(ctx context.Context, height *int64) (*ctypes.ResultCommit, error) {
+	if err := n.call(ctx, "commit"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	block, err := n.blockLocked(height)
+	if err != nil {
+		return nil, err
+	}
+
+	sh, err := block.signedHeader()
+	if err != nil {
+		return nil, rpcError("failed to sign block: %s", err)
+	}
+
+	return ctypes.NewResultCommit(sh.Header, sh.Commit, true), nil
+}
+
+// Validators implements rpcclient.Client.
+func (n *FakeNode) Validators(ctx context.Context, height *int64, page, perPage *int) (*ctypes.ResultValidators, error) {
+	if err := n.call(ctx, "validators"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	block, err := n.blockLocked(height)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := block.validators.Validators
+	p, pp := pagination(page, perPage)
+	out := &ctypes.ResultValidators{BlockHeight: block.header.Height, Total: len(vals)}
+	for i := (p - 1) * pp; i < len(vals) && i < p*pp; i++ {
+		out.Validators = append(out.Validators, vals[i].Copy())
+	}
+	out.Count = len(out.Validators)
+
+	return out, nil
+}
+
+func (n *FakeNode) blockLocked(height *int64) (*fakeBlock, error) {
+	if height == nil || *height == 0 {
+		return n.latestLocked(), nil
+	}
+
+	if *height < 1 || *height > int64(len(n.blocks)) {
+		return nil, rpcError("height %d must be less than or equal to the current blockchain height %d",
+			*height, len(n.blocks))
+	}
+
+	return n.blocks[*height-1], nil
+}
+
+// Subscribe implements rpcclient.Client. Only tx events are published.
+func (n *FakeNode) Subscribe(
+	ctx context.Context, subscriber, query string, outCapacity ...int,
+) (<-chan ctypes.ResultEvent, error) {
+	if err := n.call(ctx, "subscribe"); err != nil {
+		return nil, err
+	}
+
+	q, err := tmquery.New(query)
+	if err != nil {
+		return nil, rpcError("failed to parse query: %s", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := subscriptionKey{subscriber: subscriber, query: query}
+	if _, ok := n.subscriptions[key]; ok {
+		return nil, rpcError("already subscribed")
+	}
+
+	s := &fakeSubscription{query: q, ch: make(chan ctypes.ResultEvent, 100)}
+	n.subscriptions[key] = s
+
+	return s.ch, nil
+}
+
+// Unsubscribe implements rpcclient.Client.
+func (n *FakeNode) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	if err := n.call(ctx, "unsubscribe"); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := subscriptionKey{subscriber: subscriber, query: query}
+	s, ok := n.subscriptions[key]
+	if !ok {
+		return rpcError("subscription not found")
+	}
+	delete(n.subscriptions, key)
+	close(s.ch)
+
+	return nil
+}
+
+// UnsubscribeAll implements rpcclient.Client.
+func (n *FakeNode) UnsubscribeAll(ctx context.Context, subscriber string) error {
+	if err := n.call(ctx, "unsubscribe_all"); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for key, s := range n.subscriptions {
+		if key.subscriber == subscriber {
+			delete(n.subscriptions, key)
+			close(s.ch)
+		}
+	}
+
+	return nil
+}
+
+// Subscriptions returns the number of active subscriptions.
+func (n *FakeNode) Subscriptions() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.subscriptions)
+}
+
+// publishLocked sends the committed tx to the matching subscriptions. Slow subscribers miss events.
+func (n *FakeNode) publishLocked(res *ctypes.ResultTx) {
+	events := txEvents(res)
+	for key, s := range n.subscriptions {
+		if ok, _ := s.query.Matches(events); !ok {
+			continue
+		}
+
+		select {
+		case s.ch <- ctypes.ResultEvent{
+			Query: key.query,
+			Data: types.EventDataTx{TxResult: abci.TxResult{
+				Height: res.Height,
+				Index:  res.Index,
+				Tx:     res.Tx,
+				Result: res.TxResult,
+			}},
+			Events: events,
+		}:
+		default:
+		}
+	}
+}
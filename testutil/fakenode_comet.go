@@ -0,0 +1,117 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// FakeCometVersion is the version reported by the node served in CometBFT wire format.
+const FakeCometVersion = "0.37.2"
+
+// ServeComet starts http server which serves the node's rpc endpoints in CometBFT 0.37 wire format:
+// the node reports FakeCometVersion and event attributes are plain strings instead of base64. The server should be closed.
+func (n *FakeNode) ServeComet() *httptest.Server {
+	return httptest.NewServer(n.CometHandler())
+}
+
+// CometHandler returns http handler of the node's rpc endpoints in CometBFT 0.37 wire format.
+// Websocket subscriptions are served as they are by Handler.
+func (n *FakeNode) CometHandler() http.Handler {
+	h := n.Handler()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/websocket") {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+
+		body := rec.Body.Bytes()
+		if v, err := cometResponse(body); err == nil {
+			body = v
+		}
+
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(rec.Code)
+		_, _ = w.Write(body)
+	})
+}
+
+// cometResponse converts json rpc response of Tendermint 0.34 into CometBFT 0.37 one.
+func cometResponse(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	// Batch requests are answered with arrays of responses.
+	responses, ok := v.([]interface{})
+	if !ok {
+		responses = []interface{}{v}
+	}
+	for _, res := range responses {
+		if res, ok := res.(map[string]interface{}); ok {
+			if info, ok := lookup(res, "result", "node_info").(map[string]interface{}); ok {
+				info["version"] = FakeCometVersion
+			}
+			decodeAttributes(res["result"])
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// lookup returns the value at the path of nested json objects.
+func lookup(v interface{}, path ...string) interface{} {
+	for _, k := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+
+	return v
+}
+
+// decodeAttributes decodes base64 keys and values of events' attributes in the decoded json.
+func decodeAttributes(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if attrs, ok := v["attributes"].([]interface{}); ok {
+			for _, a := range attrs {
+				attr, ok := a.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for _, k := range []string{"key", "value"} {
+					if s, ok := attr[k].(string); ok {
+						if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+							attr[k] = string(b)
+						}
+					}
+				}
+			}
+		}
+
+		for _, child := range v {
+			decodeAttributes(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			decodeAttributes(child)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServeGRPC starts gRPC server which serves the node's tx service like a real node,
+// so the node could be used as broadcaster.Config.GRPCAddr. It returns the address and the function stopping the server.
+// Calls of the service are counted as "tx_service/Simulate" and "tx_service/BroadcastTx".
+func (n *FakeNode) ServeGRPC() (string, func(), error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	srv := grpc.NewServer()
+	txtypes.RegisterServiceServer(srv, &fakeTxService{node: n})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	return lis.Addr().String(), srv.Stop, nil
+}
+
+// fakeTxService is the gRPC tx service of the node.
+type fakeTxService struct {
+	txtypes.UnimplementedServiceServer
+	node *FakeNode
+}
+
+// Simulate simulates tx like the abci query does. Errors are returned with codes.Unknown like the node does.
+func (s *fakeTxService) Simulate(ctx context.Context, req *txtypes.SimulateRequest) (*txtypes.SimulateResponse, error) {
+	if err := s.node.call(ctx, "tx_service/Simulate"); err != nil {
+		return nil, err
+	}
+
+	if len(req.TxBytes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "empty txBytes is not allowed")
+	}
+
+	data, err := req.Marshal()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	res, err := s.node.ABCIQuery(ctx, SimulateQueryPath, data)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if !res.Response.IsOK() {
+		return nil, status.Error(codes.Unknown, res.Response.Log)
+	}
+
+	var out txtypes.SimulateResponse
+	if err := out.Unmarshal(res.Response.Value); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &out, nil
+}
+
+// BroadcastTx broadcasts tx through the node's rpc and formats the response like the node does.
+func (s *fakeTxService) BroadcastTx(ctx context.Context, req *txtypes.BroadcastTxRequest) (*txtypes.BroadcastTxResponse, error) {
+	if err := s.node.call(ctx, "tx_service/BroadcastTx"); err != nil {
+		return nil, err
+	}
+
+	var (
+		res *sdk.TxResponse
+		err error
+	)
+	switch req.Mode {
+	case txtypes.BroadcastMode_BROADCAST_MODE_SYNC:
+		var r *ctypes.ResultBroadcastTx
+		if r, err = s.node.BroadcastTxSync(ctx, req.TxBytes); err == nil {
+			res = sdk.NewResponseFormatBroadcastTx(r)
+		}
+	case txtypes.BroadcastMode_BROADCAST_MODE_ASYNC:
+		var r *ctypes.ResultBroadcastTx
+		if r, err = s.node.BroadcastTxAsync(ctx, req.TxBytes); err == nil {
+			res = sdk.NewResponseFormatBroadcastTx(r)
+		}
+	case txtypes.BroadcastMode_BROADCAST_MODE_BLOCK:
+		var r *ctypes.ResultBroadcastTxCommit
+		if r, err = s.node.BroadcastTxCommit(ctx, req.TxBytes); err == nil {
+			res = sdk.NewResponseFormatBroadcastTxCommit(r)
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported broadcast mode %s", req.Mode)
+	}
+
+	// Tendermint errors are turned into responses like the node does.
+	if errRes := client.CheckTendermintError(err, req.TxBytes); errRes != nil {
+		return &txtypes.BroadcastTxResponse{TxResponse: errRes}, nil
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+
+	return &txtypes.BroadcastTxResponse{TxResponse: res}, nil
+}
@@ -0,0 +1,174 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/gogo/protobuf/proto"
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Paths of the queries served by FakeNode.
+const (
+	AccountQueryPath        = "/cosmos.auth.v1beta1.Query/Account"
+	AllBalancesQueryPath    = "/cosmos.bank.v1beta1.Query/AllBalances"
+	BalanceQueryPath        = "/cosmos.bank.v1beta1.Query/Balance"
+	DenomsMetadataQueryPath = "/cosmos.bank.v1beta1.Query/DenomsMetadata"
+	SimulateQueryPath       = "/cosmos.tx.v1beta1.Service/Simulate"
+)
+
+// QueryHandler serves the abci query. The request and the response are protobuf encoded. gRPC status errors
+// are converted like the node does, e.g. codes.NotFound becomes "key not found" error.
+type QueryHandler func(req []byte) ([]byte, error)
+
+// HandleQuery sets the handler of the query path. It overrides the built-in handler, nil restores it.
+func (n *FakeNode) HandleQuery(path string, h QueryHandler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if h == nil {
+		delete(n.queries, path)
+		return
+	}
+	n.queries[path] = h
+}
+
+// ABCIQuery implements rpcclient.Client.
+func (n *FakeNode) ABCIQuery(ctx context.Context, path string, data tmbytes.HexBytes) (*ctypes.ResultABCIQuery, error) {
+	return n.ABCIQueryWithOptions(ctx, path, data, rpcclient.DefaultABCIQueryOptions)
+}
+
+// ABCIQueryWithOptions implements rpcclient.Client. Queries are served by the latest state regardless of the height.
+func (n *FakeNode) ABCIQueryWithOptions(
+	ctx context.Context, path string, data tmbytes.HexBytes, opts rpcclient.ABCIQueryOptions,
+) (*ctypes.ResultABCIQuery, error) {
+	if err := n.call(ctx, "abci_query"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	h, ok := n.queries[path]
+	height := n.latestLocked().header.Height
+	n.mu.Unlock()
+
+	// Custom handlers are called out of the lock, so they could use the node.
+	var (
+		value []byte
+		err   error
+	)
+	if ok {
+		value, err = h(data)
+	} else {
+		n.mu.Lock()
+		value, err = n.queryLocked(path, data)
+		n.mu.Unlock()
+	}
+
+	if err != nil {
+		space, code, log := sdkerrors.ABCIInfo(grpcToSDKError(err), false)
+		return &ctypes.ResultABCIQuery{Response: abci.ResponseQuery{
+			Code: code, Codespace: space, Log: log, Height: height,
+		}}, nil
+	}
+
+	return &ctypes.ResultABCIQuery{Response: abci.ResponseQuery{Value: value, Height: height}}, nil
+}
+
+// grpcToSDKError converts errors of gRPC handlers like the node does.
+func grpcToSDKError(err error) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch s.Code() {
+	case codes.NotFound:
+		return sdkerrors.Wrap(sdkerrors.ErrKeyNotFound, err.Error())
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		return sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, err.Error())
+	case codes.Unauthenticated:
+		return sdkerrors.Wrap(sdkerrors.ErrUnauthorized, err.Error())
+	default:
+		return sdkerrors.Wrap(sdkerrors.ErrUnknownRequest, err.Error())
+	}
+}
+
+func (n *FakeNode) queryLocked(path string, data []byte) ([]byte, error) {
+	switch path {
+	case AccountQueryPath:
+		var req authtypes.QueryAccountRequest
+		if err := req.Unmarshal(data); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		acc, ok := n.accounts[req.Address]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "account %s not found", req.Address)
+		}
+
+		any, err := codectypes.NewAnyWithValue(acc)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		return marshal(&authtypes.QueryAccountResponse{Account: any})
+	case AllBalancesQueryPath:
+		var req banktypes.QueryAllBalancesRequest
+		if err := req.Unmarshal(data); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return marshal(&banktypes.QueryAllBalancesResponse{
+			Balances:   n.balances[req.Address],
+			Pagination: &query.PageResponse{Total: uint64(len(n.balances[req.Address]))},
+		})
+	case BalanceQueryPath:
+		var req banktypes.QueryBalanceRequest
+		if err := req.Unmarshal(data); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		coin := sdk.NewCoin(req.Denom, n.balances[req.Address].AmountOf(req.Denom))
+		return marshal(&banktypes.QueryBalanceResponse{Balance: &coin})
+	case DenomsMetadataQueryPath:
+		return marshal(&banktypes.QueryDenomsMetadataResponse{
+			Metadatas:  n.metadata,
+			Pagination: &query.PageResponse{Total: uint64(len(n.metadata))},
+		})
+	case SimulateQueryPath:
+		var req txtypes.SimulateRequest
+		if err := req.Unmarshal(data); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		res, err := n.simulateLocked(req.TxBytes)
+		if err != nil {
+			return nil, status.Error(codes.Unknown, err.Error())
+		}
+
+		return marshal(res)
+	default:
+		return nil, sdkerrors.Wrap(sdkerrors.ErrUnknownRequest, "unknown query path")
+	}
+}
+
+func marshal(m proto.Message) ([]byte, error) {
+	out, err := proto.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	return out, nil
+}
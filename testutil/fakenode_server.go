@@ -0,0 +1,156 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/log"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpcserver "github.com/tendermint/tendermint/rpc/jsonrpc/server"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// Serve starts http server which serves the node's rpc endpoints and websocket subscriptions like a real node,
+// so the node could be reached by uri, e.g. as one of broadcaster.Config.ExtraNodeURIs. The server should be closed.
+// While the node is down, the server drops connections.
+func (n *FakeNode) Serve() *httptest.Server {
+	return httptest.NewServer(n.Handler())
+}
+
+// Handler returns http handler of the node's rpc endpoints.
+func (n *FakeNode) Handler() http.Handler {
+	routes := n.routes()
+
+	mux := http.NewServeMux()
+	rpcserver.RegisterRPCFuncs(mux, routes, log.NewNopLogger())
+
+	ws := rpcserver.NewWebsocketManager(routes, rpcserver.OnDisconnect(func(remoteAddr string) {
+		_ = n.UnsubscribeAll(context.Background(), remoteAddr)
+	}))
+	ws.SetLogger(log.NewNopLogger())
+	mux.HandleFunc("/websocket", ws.WebsocketHandler)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n.mu.Lock()
+		down := n.down != nil
+		n.mu.Unlock()
+
+		if down {
+			if hj, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hj.Hijack(); err == nil {
+					_ = conn.Close()
+					return
+				}
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// routes returns rpc functions of the node. The errors are returned as they are formatted by the node,
+// so the rpc client wraps them once.
+func (n *FakeNode) routes() map[string]*rpcserver.RPCFunc {
+	return map[string]*rpcserver.RPCFunc{
+		"status": rpcserver.NewRPCFunc(func(ctx *rpctypes.Context) (*ctypes.ResultStatus, error) {
+			return result(n.Status(ctx.Context()))
+		}, ""),
+		"abci_info": rpcserver.NewRPCFunc(func(ctx *rpctypes.Context) (*ctypes.ResultABCIInfo, error) {
+			return result(n.ABCIInfo(ctx.Context()))
+		}, ""),
+		"abci_query": rpcserver.NewRPCFunc(func(
+			ctx *rpctypes.Context, path string, data tmbytes.HexBytes, height int64, prove bool,
+		) (*ctypes.ResultABCIQuery, error) {
+			return result(n.ABCIQueryWithOptions(ctx.Context(), path, data, rpcclient.ABCIQueryOptions{Height: height, Prove: prove}))
+		}, "path,data,height,prove"),
+		"broadcast_tx_sync": rpcserver.NewRPCFunc(func(ctx *rpctypes.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+			return result(n.BroadcastTxSync(ctx.Context(), tx))
+		}, "tx"),
+		"broadcast_tx_async": rpcserver.NewRPCFunc(func(ctx *rpctypes.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+			return result(n.BroadcastTxAsync(ctx.Context(), tx))
+		}, "tx"),
+		"broadcast_tx_commit": rpcserver.NewRPCFunc(func(ctx *rpctypes.Context, tx types.Tx) (*ctypes.ResultBroadcastTxCommit, error) {
+			return result(n.BroadcastTxCommit(ctx.Context(), tx))
+		}, "tx"),
+		"check_tx": rpcserver.NewRPCFunc(func(ctx *rpctypes.Context, tx types.Tx) (*ctypes.ResultCheckTx, error) {
+			return result(n.CheckTx(ctx.Context(), tx))
+		}, "tx"),
+		"tx": rpcserver.NewRPCFunc(func(ctx *rpctypes.Context, hash []byte, prove bool) (*ctypes.ResultTx, error) {
+			return result(n.Tx(ctx.Context(), hash, prove))
+		}, "hash,prove"),
+		"tx_search": rpcserver.NewRPCFunc(func(
+			ctx *rpctypes.Context, query string, prove bool, page, perPage *int, orderBy string,
+		) (*ctypes.ResultTxSearch, error) {
+			return result(n.TxSearch(ctx.Context(), query, prove, page, perPage, orderBy))
+		}, "query,prove,page,per_page,order_by"),
+		"unconfirmed_txs": rpcserver.NewRPCFunc(func(ctx *rpctypes.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
+			return result(n.UnconfirmedTxs(ctx.Context(), limit))
+		}, "limit"),
+		"num_unconfirmed_txs": rpcserver.NewRPCFunc(func(ctx *rpctypes.Context) (*ctypes.ResultUnconfirmedTxs, error) {
+			return result(n.NumUnconfirmedTxs(ctx.Context()))
+		}, ""),
+		"commit": rpcserver.NewRPCFunc(func(ctx *rpctypes.Context, height *int64) (*ctypes.ResultCommit, error) {
+			return result(n.Commit(ctx.Context(), height))
+		}, "height"),
+		"validators": rpcserver.NewRPCFunc(func(
+			ctx *rpctypes.Context, height *int64, page, perPage *int,
+		) (*ctypes.ResultValidators, error) {
+			return result(n.Validators(ctx.Context(), height, page, perPage))
+		}, "height,page,per_page"),
+
+		"subscribe":       rpcserver.NewWSRPCFunc(n.serveSubscribe, "query"),
+		"unsubscribe":     rpcserver.NewWSRPCFunc(n.serveUnsubscribe, "query"),
+		"unsubscribe_all": rpcserver.NewWSRPCFunc(n.serveUnsubscribeAll, ""),
+	}
+}
+
+// serveSubscribe subscribes the websocket connection to events like the node does:
+// events are sent to the connection with the id of the subscribe request.
+func (n *FakeNode) serveSubscribe(ctx *rpctypes.Context, query string) (*ctypes.ResultSubscribe, error) {
+	ch, err := n.Subscribe(ctx.Context(), ctx.RemoteAddr(), query)
+	if err != nil {
+		return result(&ctypes.ResultSubscribe{}, err)
+	}
+
+	id, conn := ctx.JSONReq.ID, ctx.WSConn
+	go func() {
+		for e := range ch {
+			e := e
+			writeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			_ = conn.WriteRPCResponse(writeCtx, rpctypes.NewRPCSuccessResponse(id, &e))
+			cancel()
+		}
+	}()
+
+	return &ctypes.ResultSubscribe{}, nil
+}
+
+func (n *FakeNode) serveUnsubscribe(ctx *rpctypes.Context, query string) (*ctypes.ResultUnsubscribe, error) {
+	return &ctypes.ResultUnsubscribe{}, unwrapRPCError(n.Unsubscribe(ctx.Context(), ctx.RemoteAddr(), query))
+}
+
+func (n *FakeNode) serveUnsubscribeAll(ctx *rpctypes.Context) (*ctypes.ResultUnsubscribe, error) {
+	return &ctypes.ResultUnsubscribe{}, unwrapRPCError(n.UnsubscribeAll(ctx.Context(), ctx.RemoteAddr()))
+}
+
+func result[T any](res T, err error) (T, error) {
+	return res, unwrapRPCError(err)
+}
+
+// unwrapRPCError returns the message of the node's error, the server wraps it into rpc error again.
+func unwrapRPCError(err error) error {
+	var rpcErr *rpctypes.RPCError
+	if errors.As(err, &rpcErr) {
+		return errors.New(rpcErr.Data)
+	}
+
+	return err
+}
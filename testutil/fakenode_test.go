@@ -0,0 +1,140 @@
+package testutil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clienttx "github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/simapp"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	"github.com/tendermint/tendermint/types"
+
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+func TestFakeNode_AccountNotFound(t *testing.T) {
+	node := testutil.NewFakeNode()
+
+	req, err := (&authtypes.QueryAccountRequest{Address: testutil.NewKey("missing").Address.String()}).Marshal()
+	require.NoError(t, err)
+
+	res, err := node.ABCIQueryWithOptions(context.Background(), testutil.AccountQueryPath, req, rpcclient.ABCIQueryOptions{})
+	require.NoError(t, err)
+	require.Equal(t, uint32(22), res.Response.Code)
+	require.Contains(t, res.Response.Log, "code = NotFound")
+}
+
+func TestFakeNode_Commit(t *testing.T) {
+	node := testutil.NewFakeNode()
+	node.SetValidators(4)
+	node.NextBlock()
+
+	height := node.Height()
+	res, err := node.Commit(context.Background(), &height)
+	require.NoError(t, err)
+
+	vals := node.ValidatorSet()
+	require.Equal(t, vals.Hash(), res.Header.ValidatorsHash.Bytes())
+	require.NoError(t, vals.VerifyCommitLight(testutil.FakeChainID, res.Commit.BlockID, height, res.Commit))
+}
+
+func TestFakeNode_AccountSequence(t *testing.T) {
+	node := testutil.NewFakeNode()
+	key := testutil.NewKey("account")
+
+	num := node.AddAccount(key.Address, sdk.NewInt64Coin("udec", 10))
+	node.SetSequence(key.Address, 3)
+
+	acc, ok := node.Account(key.Address)
+	require.True(t, ok)
+	require.Equal(t, num, acc.GetAccountNumber())
+	require.Equal(t, uint64(3), node.Sequence(key.Address))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("udec", 10)), node.Balance(key.Address))
+
+	node.RemoveAccount(key.Address)
+	_, ok = node.Account(key.Address)
+	require.False(t, ok)
+}
+
+func TestFakeNode_Serve(t *testing.T) {
+	node := testutil.NewFakeNode()
+	srv := node.Serve()
+	defer srv.Close()
+
+	c, err := rpchttp.New(srv.URL, "/websocket")
+	require.NoError(t, err)
+	require.NoError(t, c.Start())
+	defer c.Stop()
+
+	status, err := c.Status(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, testutil.FakeChainID, status.NodeInfo.Network)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := c.Subscribe(ctx, "test", "tm.event='Tx'")
+	require.NoError(t, err)
+
+	key := testutil.NewKey("sender")
+	node.AddAccount(key.Address)
+	txBytes := signedTx(t, key, 0)
+
+	res, err := c.BroadcastTxSync(ctx, txBytes)
+	require.NoError(t, err)
+	require.Zero(t, res.Code, res.Log)
+
+	_, err = c.BroadcastTxSync(ctx, txBytes)
+	require.ErrorContains(t, err, "tx already exists in cache")
+
+	node.NextBlock()
+	select {
+	case e := <-events:
+		require.Equal(t, txBytes, []byte(e.Data.(types.EventDataTx).Tx))
+	case <-ctx.Done():
+		t.Fatal("event isn't received")
+	}
+
+	_, err = c.Tx(ctx, []byte{1, 2, 3}, false)
+	require.ErrorContains(t, err, "not found")
+
+	node.SetDown(true)
+	_, err = c.Status(ctx)
+	require.Error(t, err)
+}
+
+// signedTx returns tx with a bank transfer signed by the key in direct mode.
+func signedTx(t *testing.T, key testutil.Key, seq uint64) []byte {
+	t.Helper()
+
+	enc := simapp.MakeTestEncodingConfig()
+	txb := enc.TxConfig.NewTxBuilder()
+	require.NoError(t, txb.SetMsgs(banktypes.NewMsgSend(key.Address, key.Address, sdk.NewCoins(sdk.NewInt64Coin("udec", 1)))))
+	txb.SetGasLimit(200000)
+
+	sig := signing.SignatureV2{
+		PubKey:   key.PrivKey.PubKey(),
+		Data:     &signing.SingleSignatureData{SignMode: signing.SignMode_SIGN_MODE_DIRECT},
+		Sequence: seq,
+	}
+	require.NoError(t, txb.SetSignatures(sig))
+
+	sig, err := clienttx.SignWithPrivKey(signing.SignMode_SIGN_MODE_DIRECT, authsigning.SignerData{
+		ChainID: testutil.FakeChainID, AccountNumber: 1, Sequence: seq,
+	}, txb, key.PrivKey, enc.TxConfig, seq)
+	require.NoError(t, err)
+	require.NoError(t, txb.SetSignatures(sig))
+
+	txBytes, err := enc.TxConfig.TxEncoder()(txb.GetTx())
+	require.NoError(t, err)
+
+	return txBytes
+}
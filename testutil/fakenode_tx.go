@@ -0,0 +1,551 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/gogo/protobuf/proto"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/mempool"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// maxMemoCharacters is the memo limit of the auth module's default params.
+const maxMemoCharacters = 256
+
+// FakeTx is a tx decoded by FakeNode.
+type FakeTx struct {
+	Hash  string
+	Bytes []byte
+
+	Msgs          []*codectypes.Any
+	Memo          string
+	TimeoutHeight uint64
+	// ExtensionOptions and NonCriticalExtensionOptions are the options of tx body.
+	ExtensionOptions            []*codectypes.Any
+	NonCriticalExtensionOptions []*codectypes.Any
+
+	Signer    sdk.AccAddress
+	PubKey    *secp256k1.PubKey
+	Sequence  uint64
+	SignMode  signing.SignMode
+	Signature []byte
+
+	Fee        sdk.Coins
+	GasLimit   uint64
+	FeePayer   string
+	FeeGranter string
+
+	bodyBytes     []byte
+	authInfoBytes []byte
+}
+
+// MsgTypes returns type urls of the messages.
+func (t FakeTx) MsgTypes() []string {
+	out := make([]string, len(t.Msgs))
+	for i, v := range t.Msgs {
+		out[i] = v.TypeUrl
+	}
+
+	return out
+}
+
+// DecodeFakeTx decodes protobuf encoded tx signed by a single secp256k1 key.
+func DecodeFakeTx(txBytes []byte) (FakeTx, error) {
+	var raw txtypes.TxRaw
+	if err := raw.Unmarshal(txBytes); err != nil {
+		return FakeTx{}, sdkerrors.Wrap(sdkerrors.ErrTxDecode, err.Error())
+	}
+
+	var body txtypes.TxBody
+	if err := body.Unmarshal(raw.BodyBytes); err != nil {
+		return FakeTx{}, sdkerrors.Wrap(sdkerrors.ErrTxDecode, err.Error())
+	}
+
+	var authInfo txtypes.AuthInfo
+	if err := authInfo.Unmarshal(raw.AuthInfoBytes); err != nil {
+		return FakeTx{}, sdkerrors.Wrap(sdkerrors.ErrTxDecode, err.Error())
+	}
+
+	tx := FakeTx{
+		Hash:  fmt.Sprintf("%X", types.Tx(txBytes).Hash()),
+		Bytes: txBytes,
+
+		Msgs:                        body.Messages,
+		Memo:                        body.Memo,
+		TimeoutHeight:               body.TimeoutHeight,
+		ExtensionOptions:            body.ExtensionOptions,
+		NonCriticalExtensionOptions: body.NonCriticalExtensionOptions,
+
+		bodyBytes:     raw.BodyBytes,
+		authInfoBytes: raw.AuthInfoBytes,
+	}
+
+	if len(body.Messages) == 0 {
+		return FakeTx{}, sdkerrors.Wrap(sdkerrors.ErrInvalidRequest, "must contain at least one message")
+	}
+
+	if authInfo.Fee != nil {
+		tx.Fee = authInfo.Fee.Amount
+		tx.GasLimit = authInfo.Fee.GasLimit
+		tx.FeePayer = authInfo.Fee.Payer
+		tx.FeeGranter = authInfo.Fee.Granter
+	}
+
+	if len(authInfo.SignerInfos) != 1 || len(raw.Signatures) != 1 {
+		return FakeTx{}, sdkerrors.Wrapf(sdkerrors.ErrUnauthorized,
+			"wrong number of signers; expected 1, got %d", len(authInfo.SignerInfos))
+	}
+
+	info := authInfo.SignerInfos[0]
+	if info.PublicKey == nil || info.PublicKey.TypeUrl != "/cosmos.crypto.secp256k1.PubKey" {
+		return FakeTx{}, sdkerrors.Wrap(sdkerrors.ErrInvalidPubKey, "only secp256k1 keys are supported")
+	}
+
+	tx.PubKey = &secp256k1.PubKey{}
+	if err := tx.PubKey.Unmarshal(info.PublicKey.Value); err != nil {
+		return FakeTx{}, sdkerrors.Wrap(sdkerrors.ErrInvalidPubKey, err.Error())
+	}
+	tx.Signer = sdk.AccAddress(tx.PubKey.Address())
+	tx.Sequence = info.Sequence
+	if single := info.ModeInfo.GetSingle(); single != nil {
+		tx.SignMode = single.Mode
+	}
+	tx.Signature = raw.Signatures[0]
+
+	return tx, nil
+}
+
+// gasLocked returns gas consumed by the tx.
+func (n *FakeNode) gasLocked(tx FakeTx) uint64 {
+	gas := n.txGas
+	for _, msg := range tx.Msgs {
+		if v, ok := n.msgGas[msg.TypeUrl]; ok {
+			gas += v
+		} else {
+			gas += DefaultFakeMsgGas
+		}
+	}
+
+	return gas
+}
+
+// anteLocked checks the tx against the sequence expected by the state like the ante handler does.
+// Signature isn't verified in simulation.
+func (n *FakeNode) anteLocked(tx FakeTx, seq uint64, height int64, simulate bool) error {
+	if len(tx.Memo) > maxMemoCharacters {
+		return sdkerrors.Wrapf(sdkerrors.ErrMemoTooLarge,
+			"maximum number of characters is %d but received %d characters", maxMemoCharacters, len(tx.Memo))
+	}
+
+	if tx.TimeoutHeight > 0 && uint64(height) > tx.TimeoutHeight {
+		return sdkerrors.Wrapf(sdkerrors.ErrTxTimeoutHeight,
+			"tx has timed out; timeout height: %d, current height: %d", tx.TimeoutHeight, height)
+	}
+
+	for _, v := range tx.ExtensionOptions {
+		if !n.extOptions[v.TypeUrl] {
+			return sdkerrors.ErrUnknownExtensionOptions
+		}
+	}
+
+	// The ante handler consumes the tx's own gas, messages consume the rest in DeliverTx.
+	if !simulate && tx.GasLimit < n.txGas {
+		return outOfGas(tx.GasLimit, n.txGas)
+	}
+
+	if !simulate && !n.minGasPrice.IsZero() {
+		required := make(sdk.Coins, len(n.minGasPrice))
+		for i, v := range n.minGasPrice {
+			required[i] = sdk.NewCoin(v.Denom, v.Amount.MulInt64(int64(tx.GasLimit)).Ceil().RoundInt())
+		}
+		if !tx.Fee.IsAnyGTE(required) {
+			return sdkerrors.Wrapf(sdkerrors.ErrInsufficientFee, "insufficient fees; got: %s required: %s", tx.Fee, required)
+		}
+	}
+
+	acc, ok := n.accounts[tx.Signer.String()]
+	if !ok {
+		return sdkerrors.Wrapf(sdkerrors.ErrUnknownAddress, "fee payer address: %s does not exist", tx.Signer)
+	}
+
+	payer := tx.Signer.String()
+	if tx.FeeGranter != "" {
+		payer = tx.FeeGranter
+	}
+	if balance := n.balances[payer]; !balance.IsAllGTE(tx.Fee) {
+		return sdkerrors.Wrapf(sdkerrors.ErrInsufficientFunds, "insufficient funds to pay for fees; %s < %s", balance, tx.Fee)
+	}
+
+	if tx.Sequence != seq {
+		return sdkerrors.Wrapf(sdkerrors.ErrWrongSequence, "account sequence mismatch, expected %d, got %d", seq, tx.Sequence)
+	}
+
+	if simulate || tx.SignMode != signing.SignMode_SIGN_MODE_DIRECT {
+		return nil
+	}
+
+	signDoc := txtypes.SignDoc{
+		BodyBytes:     tx.bodyBytes,
+		AuthInfoBytes: tx.authInfoBytes,
+		ChainId:       n.chainID,
+		AccountNumber: acc.GetAccountNumber(),
+	}
+	signBytes, err := signDoc.Marshal()
+	if err != nil {
+		return sdkerrors.Wrap(sdkerrors.ErrTxDecode, err.Error())
+	}
+	if !tx.PubKey.VerifySignature(signBytes, tx.Signature) {
+		return sdkerrors.Wrapf(sdkerrors.ErrUnauthorized,
+			"signature verification failed; please verify account number (%d) and chain-id (%s)",
+			acc.GetAccountNumber(), n.chainID)
+	}
+
+	return nil
+}
+
+// checkLocked runs CheckTx against the check state. The tx is returned if it's accepted.
+func (n *FakeNode) checkLocked(txBytes []byte) (abci.ResponseCheckTx, FakeTx) {
+	tx, err := DecodeFakeTx(txBytes)
+	if err != nil {
+		return sdkerrors.ResponseCheckTx(err, 0, 0, false), FakeTx{}
+	}
+
+	height := n.latestLocked().header.Height
+	if err := n.anteLocked(tx, n.checkSeq[tx.Signer.String()], height, false); err != nil {
+		return sdkerrors.ResponseCheckTx(err, tx.GasLimit, 0, false), FakeTx{}
+	}
+
+	for _, hook := range n.checkHooks {
+		if err := hook(tx); err != nil {
+			return sdkerrors.ResponseCheckTx(err, tx.GasLimit, 0, false), FakeTx{}
+		}
+	}
+
+	return abci.ResponseCheckTx{GasWanted: int64(tx.GasLimit), GasUsed: int64(n.gasLocked(tx))}, tx
+}
+
+// broadcastLocked checks tx and adds it to the mempool.
+func (n *FakeNode) broadcastLocked(txBytes types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	hash := txBytes.Hash()
+	if _, ok := n.txs[fmt.Sprintf("%X", hash)]; ok || n.evicted[fmt.Sprintf("%X", hash)] {
+		return nil, rpcError(mempool.ErrTxInCache.Error())
+	}
+	for _, v := range n.mempool {
+		if v.Hash == fmt.Sprintf("%X", hash) {
+			return nil, rpcError(mempool.ErrTxInCache.Error())
+		}
+	}
+
+	res, tx := n.checkLocked(txBytes)
+	if res.IsOK() {
+		n.checkSeq[tx.Signer.String()]++
+		n.mempool = append(n.mempool, tx)
+		if n.autoBlock {
+			n.nextBlockLocked()
+		}
+	}
+
+	return &ctypes.ResultBroadcastTx{
+		Code:      res.Code,
+		Data:      res.Data,
+		Log:       res.Log,
+		Codespace: res.Codespace,
+		Hash:      hash,
+	}, nil
+}
+
+// BroadcastTxSync implements rpcclient.Client.
+func (n *FakeNode) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	if err := n.call(ctx, "broadcast_tx_sync"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.broadcastLocked(tx)
+}
+
+// BroadcastTxAsync implements rpcclient.Client. The tx is checked like in sync mode, but only its hash is returned.
+func (n *FakeNode) BroadcastTxAsync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	if err := n.call(ctx, "broadcast_tx_async"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, err := n.broadcastLocked(tx); err != nil {
+		return nil, err
+	}
+
+	return &ctypes.ResultBroadcastTx{Hash: tx.Hash()}, nil
+}
+
+// BroadcastTxCommit implements rpcclient.Client. The accepted tx is committed in a new block right away.
+func (n *FakeNode) BroadcastTxCommit(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTxCommit, error) {
+	if err := n.call(ctx, "broadcast_tx_commit"); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	res, err := n.broadcastLocked(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ctypes.ResultBroadcastTxCommit{
+		CheckTx: abci.ResponseCheckTx{Code: res.Code, Log: res.Log, Codespace: res.Codespace},
+		Hash:    res.Hash,
+	}
+	if res.Code != 0 {
+		return out, nil
+	}
+
+	if !n.autoBlock {
+		n.nextBlockLocked()
+	}
+	committed := n.txs[res.Hash.String()]
+	out.DeliverTx = committed.TxResult
+	out.Height = committed.Height
+
+	return out, nil
+}
+
+// deliverLocked executes tx in the block with the height.
+func (n *FakeNode) deliverLocked(tx FakeTx, height int64) *abci.ResponseDeliverTx {
+	signer := tx.Signer.String()
+	acc, ok := n.accounts[signer]
+	var seq uint64
+	if ok {
+		seq = acc.GetSequence()
+	}
+
+	if err := n.anteLocked(tx, seq, height, false); err != nil {
+		space, code, log := sdkerrors.ABCIInfo(err, false)
+		return &abci.ResponseDeliverTx{Code: code, Codespace: space, Log: log, GasWanted: int64(tx.GasLimit)}
+	}
+
+	// Fees are charged and the sequence is incremented even if messages fail.
+	payer := signer
+	if tx.FeeGranter != "" {
+		payer = tx.FeeGranter
+	}
+	n.balances[payer] = n.balances[payer].Sub(tx.Fee)
+	_ = acc.SetSequence(seq + 1)
+
+	events := sdk.Events{
+		sdk.NewEvent(sdk.EventTypeTx, sdk.NewAttribute(sdk.AttributeKeyFee, tx.Fee.String())),
+		sdk.NewEvent(sdk.EventTypeTx, sdk.NewAttribute(sdk.AttributeKeyAccountSequence, fmt.Sprintf("%s/%d", signer, seq))),
+	}
+
+	gas := int64(n.gasLocked(tx))
+	if tx.GasLimit < uint64(gas) {
+		space, code, log := sdkerrors.ABCIInfo(outOfGas(tx.GasLimit, uint64(gas)), false)
+		return &abci.ResponseDeliverTx{
+			Code: code, Codespace: space, Log: log, GasWanted: int64(tx.GasLimit), GasUsed: int64(tx.GasLimit),
+			Events: events.ToABCIEvents(),
+		}
+	}
+
+	res, msgEvents, logs, err := n.runMsgsLocked(tx)
+	if err != nil {
+		space, code, log := sdkerrors.ABCIInfo(err, false)
+		return &abci.ResponseDeliverTx{
+			Code: code, Codespace: space, Log: log, GasWanted: int64(tx.GasLimit), GasUsed: gas,
+			Events: events.ToABCIEvents(),
+		}
+	}
+
+	data, _ := proto.Marshal(res)
+	return &abci.ResponseDeliverTx{
+		Data:      data,
+		Log:       logs.String(),
+		GasWanted: int64(tx.GasLimit),
+		GasUsed:   gas,
+		Events:    append(events, msgEvents...).ToABCIEvents(),
+	}
+}
+
+// runMsgsLocked executes messages of tx. State changes of failed tx are discarded.
+func (n *FakeNode) runMsgsLocked(tx FakeTx) (*sdk.TxMsgData, sdk.Events, sdk.ABCIMessageLogs, error) {
+	balances := make(map[string]sdk.Coins, len(n.balances))
+	for k, v := range n.balances {
+		balances[k] = v
+	}
+
+	var (
+		data   sdk.TxMsgData
+		events sdk.Events
+		logs   sdk.ABCIMessageLogs
+	)
+	for i, msg := range tx.Msgs {
+		res, msgEvents, err := n.runMsgLocked(tx.Signer, msg)
+		if err != nil {
+			n.balances = balances
+			return nil, nil, nil, sdkerrors.Wrapf(err, "failed to execute message; message index: %d", i)
+		}
+
+		msgEvents = append(sdk.Events{sdk.NewEvent(sdk.EventTypeMessage,
+			sdk.NewAttribute(sdk.AttributeKeyAction, msg.TypeUrl),
+		)}, msgEvents...)
+
+		var resBytes []byte
+		if res != nil {
+			resBytes, _ = proto.Marshal(res)
+		}
+		data.Data = append(data.Data, &sdk.MsgData{MsgType: msg.TypeUrl, Data: resBytes})
+		events = append(events, msgEvents...)
+		logs = append(logs, sdk.NewABCIMessageLog(uint32(i), "", msgEvents))
+	}
+
+	return &data, events, logs, nil
+}
+
+func (n *FakeNode) runMsgLocked(signer sdk.AccAddress, msg *codectypes.Any) (proto.Message, sdk.Events, error) {
+	if h, ok := n.handlers[msg.TypeUrl]; ok {
+		res, events, err := h(signer, msg)
+		out := make(sdk.Events, len(events))
+		for i, v := range events {
+			out[i] = sdk.Event(v)
+		}
+
+		return res, out, err
+	}
+
+	switch msg.TypeUrl {
+	case "/cosmos.bank.v1beta1.MsgSend":
+		var m banktypes.MsgSend
+		if err := m.Unmarshal(msg.Value); err != nil {
+			return nil, nil, sdkerrors.Wrap(sdkerrors.ErrTxDecode, err.Error())
+		}
+
+		events, err := n.transferLocked(m.FromAddress, m.ToAddress, m.Amount)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &banktypes.MsgSendResponse{}, append(events, sdk.NewEvent(sdk.EventTypeMessage,
+			sdk.NewAttribute(sdk.AttributeKeySender, m.FromAddress),
+			sdk.NewAttribute(sdk.AttributeKeyModule, banktypes.AttributeValueCategory),
+		)), nil
+	case "/cosmos.bank.v1beta1.MsgMultiSend":
+		var m banktypes.MsgMultiSend
+		if err := m.Unmarshal(msg.Value); err != nil {
+			return nil, nil, sdkerrors.Wrap(sdkerrors.ErrTxDecode, err.Error())
+		}
+
+		var events sdk.Events
+		for _, in := range m.Inputs {
+			if !n.balances[in.Address].IsAllGTE(in.Coins) {
+				return nil, nil, sdkerrors.Wrapf(sdkerrors.ErrInsufficientFunds, "%s is smaller than %s", n.balances[in.Address], in.Coins)
+			}
+			n.balances[in.Address] = n.balances[in.Address].Sub(in.Coins)
+			events = append(events, sdk.NewEvent(sdk.EventTypeMessage,
+				sdk.NewAttribute(sdk.AttributeKeySender, in.Address),
+				sdk.NewAttribute(sdk.AttributeKeyModule, banktypes.AttributeValueCategory),
+			))
+		}
+		for _, out := range m.Outputs {
+			addr, err := sdk.AccAddressFromBech32(out.Address)
+			if err != nil {
+				return nil, nil, sdkerrors.Wrap(sdkerrors.ErrInvalidAddress, err.Error())
+			}
+			n.ensureAccountLocked(addr)
+			n.balances[out.Address] = n.balances[out.Address].Add(out.Coins...)
+			events = append(events, sdk.NewEvent(banktypes.EventTypeTransfer,
+				sdk.NewAttribute(banktypes.AttributeKeyRecipient, out.Address),
+				sdk.NewAttribute(sdk.AttributeKeyAmount, out.Coins.String()),
+			))
+		}
+
+		return &banktypes.MsgMultiSendResponse{}, events, nil
+	default:
+		return nil, nil, nil
+	}
+}
+
+// transferLocked moves coins between accounts. The recipient's account is created if it doesn't exist.
+func (n *FakeNode) transferLocked(from, to string, amount sdk.Coins) (sdk.Events, error) {
+	toAddr, err := sdk.AccAddressFromBech32(to)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrInvalidAddress, err.Error())
+	}
+
+	if balance := n.balances[from]; !balance.IsAllGTE(amount) {
+		return nil, sdkerrors.Wrapf(sdkerrors.ErrInsufficientFunds, "%s is smaller than %s", balance, amount)
+	}
+
+	n.ensureAccountLocked(toAddr)
+	n.balances[from] = n.balances[from].Sub(amount)
+	n.balances[to] = n.balances[to].Add(amount...)
+
+	return sdk.Events{sdk.NewEvent(banktypes.EventTypeTransfer,
+		sdk.NewAttribute(banktypes.AttributeKeyRecipient, to),
+		sdk.NewAttribute(banktypes.AttributeKeySender, from),
+		sdk.NewAttribute(sdk.AttributeKeyAmount, amount.String()),
+	)}, nil
+}
+
+// simulateLocked executes tx against a copy of the committed state like the tx service's Simulate does.
+func (n *FakeNode) simulateLocked(txBytes []byte) (*txtypes.SimulateResponse, error) {
+	tx, err := DecodeFakeTx(txBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	gas := n.gasLocked(tx)
+	fail := func(err error) error {
+		return fmt.Errorf("%s With gas wanted: '%d' and gas used: '%d' ", err, uint64(18446744073709551615), gas)
+	}
+
+	height := n.latestLocked().header.Height
+	if err := n.anteLocked(tx, n.checkSeq[tx.Signer.String()], height, true); err != nil {
+		return nil, fail(err)
+	}
+
+	// Messages could create accounts, they are discarded with the rest of the state.
+	balances, accounts, checkSeq, nextAccNum := n.balances, n.accounts, n.checkSeq, n.nextAccNum
+	defer func() {
+		n.balances, n.accounts, n.checkSeq, n.nextAccNum = balances, accounts, checkSeq, nextAccNum
+	}()
+	n.balances = copyMap(balances)
+	n.accounts = copyMap(accounts)
+	n.checkSeq = copyMap(checkSeq)
+
+	res, events, logs, err := n.runMsgsLocked(tx)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	data, _ := proto.Marshal(res)
+	return &txtypes.SimulateResponse{
+		GasInfo: &sdk.GasInfo{GasWanted: tx.GasLimit, GasUsed: gas},
+		Result:  &sdk.Result{Data: data, Log: logs.String(), Events: events.ToABCIEvents()},
+	}, nil
+}
+
+// outOfGas returns error of tx which gas limit is exceeded.
+func outOfGas(limit, used uint64) error {
+	return sdkerrors.Wrapf(sdkerrors.ErrOutOfGas, "out of gas in location: txSize; gasWanted: %d, gasUsed: %d", limit, used)
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	out := make(map[K]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+
+	return out
+}
@@ -0,0 +1,27 @@
+package testutil
+
+import (
+	"encoding/hex"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Key is a deterministic secp256k1 key for tests. PrivKeyHex could be passed as broadcaster.Config.PrivKeyHex
+// with the "memory" keyring backend.
+type Key struct {
+	PrivKey    *secp256k1.PrivKey
+	PrivKeyHex string
+	Address    sdk.AccAddress
+}
+
+// NewKey returns the key derived from the seed, so the same seed always gives the same key.
+func NewKey(seed string) Key {
+	priv := secp256k1.GenPrivKeyFromSecret([]byte(seed))
+
+	return Key{
+		PrivKey:    priv,
+		PrivKeyHex: hex.EncodeToString(priv.Bytes()),
+		Address:    sdk.AccAddress(priv.PubKey().Address()),
+	}
+}
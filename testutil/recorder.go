@@ -0,0 +1,174 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/bytes"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// Call is a call recorded by RecordingClient.
+type Call struct {
+	Method   string
+	Duration time.Duration
+	Err      error
+}
+
+// RecordingClient is rpc client which records calls of the methods used by the broadcaster
+// and passes them to the wrapped client. It could be passed as broadcaster.Config.RPCClient.
+type RecordingClient struct {
+	rpcclient.Client
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ rpcclient.Client = (*RecordingClient)(nil)
+
+// NewRecordingClient returns new instance of RecordingClient.
+func NewRecordingClient(c rpcclient.Client) *RecordingClient {
+	return &RecordingClient{Client: c}
+}
+
+// Calls returns recorded calls in order.
+func (c *RecordingClient) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Call(nil), c.calls...)
+}
+
+// ResetCalls forgets recorded calls.
+func (c *RecordingClient) ResetCalls() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = nil
+}
+
+func (c *RecordingClient) record(method string, start time.Time, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = append(c.calls, Call{Method: method, Duration: time.Since(start), Err: err})
+}
+
+// ABCIInfo implements rpcclient.Client.
+func (c *RecordingClient) ABCIInfo(ctx context.Context) (*ctypes.ResultABCIInfo, error) {
+	start := time.Now()
+	res, err := c.Client.ABCIInfo(ctx)
+	c.record("abci_info", start, err)
+
+	return res, err
+}
+
+// ABCIQuery implements rpcclient.Client.
+func (c *RecordingClient) ABCIQuery(
+	ctx context.Context, path string, data bytes.HexBytes,
+) (*ctypes.ResultABCIQuery, error) {
+	start := time.Now()
+	res, err := c.Client.ABCIQuery(ctx, path, data)
+	c.record("abci_query", start, err)
+
+	return res, err
+}
+
+// ABCIQueryWithOptions implements rpcclient.Client.
+func (c *RecordingClient) ABCIQueryWithOptions(
+	ctx context.Context, path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions,
+) (*ctypes.ResultABCIQuery, error) {
+	start := time.Now()
+	res, err := c.Client.ABCIQueryWithOptions(ctx, path, data, opts)
+	c.record("abci_query", start, err)
+
+	return res, err
+}
+
+// BroadcastTxCommit implements rpcclient.Client.
+func (c *RecordingClient) BroadcastTxCommit(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTxCommit, error) {
+	start := time.Now()
+	res, err := c.Client.BroadcastTxCommit(ctx, tx)
+	c.record("broadcast_tx_commit", start, err)
+
+	return res, err
+}
+
+// BroadcastTxAsync implements rpcclient.Client.
+func (c *RecordingClient) BroadcastTxAsync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	start := time.Now()
+	res, err := c.Client.BroadcastTxAsync(ctx, tx)
+	c.record("broadcast_tx_async", start, err)
+
+	return res, err
+}
+
+// BroadcastTxSync implements rpcclient.Client.
+func (c *RecordingClient) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	start := time.Now()
+	res, err := c.Client.BroadcastTxSync(ctx, tx)
+	c.record("broadcast_tx_sync", start, err)
+
+	return res, err
+}
+
+// Status implements rpcclient.Client.
+func (c *RecordingClient) Status(ctx context.Context) (*ctypes.ResultStatus, error) {
+	start := time.Now()
+	res, err := c.Client.Status(ctx)
+	c.record("status", start, err)
+
+	return res, err
+}
+
+// Tx implements rpcclient.Client.
+func (c *RecordingClient) Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error) {
+	start := time.Now()
+	res, err := c.Client.Tx(ctx, hash, prove)
+	c.record("tx", start, err)
+
+	return res, err
+}
+
+// TxSearch implements rpcclient.Client.
+func (c *RecordingClient) TxSearch(
+	ctx context.Context, query string, prove bool, page, perPage *int, orderBy string,
+) (*ctypes.ResultTxSearch, error) {
+	start := time.Now()
+	res, err := c.Client.TxSearch(ctx, query, prove, page, perPage, orderBy)
+	c.record("tx_search", start, err)
+
+	return res, err
+}
+
+// UnconfirmedTxs implements rpcclient.Client.
+func (c *RecordingClient) UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
+	start := time.Now()
+	res, err := c.Client.UnconfirmedTxs(ctx, limit)
+	c.record("unconfirmed_txs", start, err)
+
+	return res, err
+}
+
+// Commit implements rpcclient.Client.
+func (c *RecordingClient) Commit(ctx context.Context, height *int64) (*ctypes.ResultCommit, error) {
+	start := time.Now()
+	res, err := c.Client.Commit(ctx, height)
+	c.record("commit", start, err)
+
+	return res, err
+}
+
+// Validators implements rpcclient.Client.
+func (c *RecordingClient) Validators(
+	ctx context.Context, height *int64, page, perPage *int,
+) (*ctypes.ResultValidators, error) {
+	start := time.Now()
+	res, err := c.Client.Validators(ctx, height, page, perPage)
+	c.record("validators", start, err)
+
+	return res, err
+}
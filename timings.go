@@ -0,0 +1,44 @@
+package broadcaster
+
+import (
+	"context"
+	"time"
+)
+
+// Timings is the time spent in stages of a broadcast. Stages repeated by retries are summed up.
+type Timings struct {
+	// Validate is the time of checks and resolving options before tx is built.
+	Validate time.Duration
+	// Simulate is the time of gas simulation.
+	Simulate time.Duration
+	// Sign is the time of building and signing tx.
+	Sign time.Duration
+	// BroadcastRPC is the time of sending tx to the node.
+	BroadcastRPC time.Duration
+	// WaitForCommit is the time of waiting for tx to be committed.
+	WaitForCommit time.Duration
+	// Total is the time of the whole call.
+	Total time.Duration
+}
+
+type timingsKey struct{}
+
+// withTimings returns context carrying timings stages of the call add to.
+func withTimings(ctx context.Context) (context.Context, *Timings) {
+	t := &Timings{}
+	return context.WithValue(ctx, timingsKey{}, t), t
+}
+
+// timingsFromContext returns timings carried by ctx. Timings of calls made without them are discarded.
+func timingsFromContext(ctx context.Context) *Timings {
+	if t, ok := ctx.Value(timingsKey{}).(*Timings); ok {
+		return t
+	}
+
+	return &Timings{}
+}
+
+// since returns time passed since start by the configured clock.
+func (b *broadcaster) since(start time.Time) time.Duration {
+	return b.cfg.clock().Now().Sub(start)
+}
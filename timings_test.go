@@ -0,0 +1,128 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/bytes"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+const (
+	simulateLatency  = time.Second
+	broadcastLatency = 2 * time.Second
+	txLatency        = 3 * time.Second
+)
+
+// slowNode is the node whose calls take time on the fake clock.
+type slowNode struct {
+	rpcclient.Client
+	clock *testutil.FakeClock
+}
+
+func (n *slowNode) ABCIQueryWithOptions(
+	ctx context.Context, path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions,
+) (*ctypes.ResultABCIQuery, error) {
+	if path == testutil.SimulateQueryPath {
+		n.clock.Advance(simulateLatency)
+	}
+
+	return n.Client.ABCIQueryWithOptions(ctx, path, data, opts)
+}
+
+func (n *slowNode) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	n.clock.Advance(broadcastLatency)
+	return n.Client.BroadcastTxSync(ctx, tx)
+}
+
+func (n *slowNode) Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error) {
+	n.clock.Advance(txLatency)
+	return n.Client.Tx(ctx, hash, prove)
+}
+
+func TestBroadcastResult_Timings(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+	clock := testutil.NewFakeClock(time.Now())
+	metrics := &recordingMetrics{}
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = &slowNode{Client: node, clock: clock}
+	cfg.Clock = clock
+	cfg.BroadcastMode = broadcaster.ModeCommit
+	cfg.Metrics = metrics
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Empty(t, res.History)
+
+	// Local stages don't take time on the fake clock.
+	require.Equal(t, broadcaster.Timings{
+		Simulate:      simulateLatency,
+		BroadcastRPC:  broadcastLatency,
+		WaitForCommit: txLatency,
+		Total:         simulateLatency + broadcastLatency + txLatency,
+	}, res.Timings)
+
+	// Metrics report the same latency.
+	attempts, _ := metrics.reset()
+	require.Len(t, attempts, 1)
+	require.Equal(t, broadcastLatency, attempts[0].Latency)
+}
+
+func TestBroadcastResult_History(t *testing.T) {
+	node, key := newFakeChain(t)
+	clock := testutil.NewFakeClock(time.Now())
+	writer := &competingWriter{FakeNode: node, address: key.Address}
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = &slowNode{Client: writer, clock: clock}
+	cfg.Clock = clock
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// The simulation finds the sequence mismatch, it's simulated again and the attempt is in the history.
+	writer.write()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+	require.Equal(t, 2, res.Attempts)
+	require.Len(t, res.History, 1)
+	require.Equal(t, 1, res.History[0].Attempt)
+	require.Equal(t, "fix sequence", res.History[0].Action)
+	require.Equal(t, broadcaster.ClassSequenceMismatch, broadcaster.Classify(res.History[0].Err))
+	require.Equal(t, 2*simulateLatency, res.Timings.Simulate)
+	require.Equal(t, broadcastLatency, res.Timings.BroadcastRPC)
+	require.Zero(t, res.Timings.WaitForCommit)
+	require.Equal(t, res.Timings.Simulate+res.Timings.BroadcastRPC, res.Timings.Total)
+
+	// The result of failed broadcast ends with the terminal error.
+	node.OnCheckTx(func(testutil.FakeTx) error { return sdkerrors.ErrInsufficientFunds })
+
+	res, err = b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "",
+		broadcaster.BroadcastOptions{Gas: 100000})
+	require.ErrorIs(t, err, sdkerrors.ErrInsufficientFunds)
+	require.NotNil(t, res)
+	require.Len(t, res.History, res.Attempts)
+	require.Empty(t, res.History[len(res.History)-1].Action)
+	require.ErrorIs(t, res.History[len(res.History)-1].Err, sdkerrors.ErrInsufficientFunds)
+	require.Zero(t, res.Timings.Simulate)
+	require.Equal(t, time.Duration(res.Attempts)*broadcastLatency, res.Timings.BroadcastRPC)
+	require.Equal(t, res.Timings.BroadcastRPC, res.Timings.Total)
+}
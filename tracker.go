@@ -0,0 +1,520 @@
+package broadcaster
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+)
+
+// DefaultTrackerMaxTxs is the default limit of tracked txs.
+const DefaultTrackerMaxTxs = 10000
+
+// lostPolls is the number of polls in a row tx should be missing from mempool and blocks to be considered lost.
+// Tx accepted by another node could reach the primary node's mempool later.
+const lostPolls = 2
+
+// mempoolLimit is the number of mempool txs fetched by a poll. Nodes don't return more at once.
+const mempoolLimit = 100
+
+// ErrTrackingDisabled is returned when tx tracking is required but Config.Tracking is disabled.
+var ErrTrackingDisabled = errors.New("tx tracking is disabled")
+
+// ErrTxExpired is passed to commit callbacks when tx isn't committed before the deadline.
+var ErrTxExpired = errors.New("tx is expired")
+
+// ErrTxLost is passed to commit callbacks when tx disappears from mempool without being committed.
+var ErrTxLost = errors.New("tx is lost")
+
+// TxTracking configures tracking of broadcast txs. Every successfully broadcast tx is registered
+// and the node is polled until tx is committed, fails, expires or is lost.
+type TxTracking struct {
+	// Interval is an interval of polling the node. Tracking is disabled when it's zero.
+	Interval time.Duration
+	// Timeout is the time given to tx to be committed. Config.CommitTimeout is used by default.
+	Timeout time.Duration
+	// MaxTxs limits the number of tracked txs. The earliest resolved txs are evicted to free space,
+	// new txs aren't tracked when all tracked txs are pending. DefaultTrackerMaxTxs is used by default.
+	MaxTxs int
+	// OnResolve is called in its own goroutine when tracked tx is resolved. Close waits for the calls to return.
+	OnResolve func(TrackedTx)
+}
+
+// Validate validates the tracking config.
+func (t TxTracking) Validate() error {
+	if t.Interval < 0 || t.Timeout < 0 || t.MaxTxs < 0 {
+		return errors.New("tracking interval, timeout and limit should be positive")
+	}
+
+	return nil
+}
+
+// TxStatus is a status of tracked tx.
+type TxStatus int
+
+const (
+	// TxPending is tx which isn't resolved yet.
+	TxPending TxStatus = iota
+	// TxCommitted is tx committed successfully.
+	TxCommitted
+	// TxFailed is tx committed with non-zero code.
+	TxFailed
+	// TxExpired is tx which isn't committed before the deadline.
+	TxExpired
+	// TxLost is tx which has disappeared from mempool without being committed.
+	TxLost
+)
+
+// String implements fmt.Stringer.
+func (s TxStatus) String() string {
+	switch s {
+	case TxCommitted:
+		return "committed"
+	case TxFailed:
+		return "failed"
+	case TxExpired:
+		return "expired"
+	case TxLost:
+		return "lost"
+	default:
+		return "pending"
+	}
+}
+
+// TrackedTx is broadcast tx followed by the tracker.
+type TrackedTx struct {
+	TxHash string
+	// Signer is the address which signed tx. It's empty for txs registered by OnCommit.
+	Signer   sdk.AccAddress
+	Sequence uint64
+	Msgs     []sdk.Msg
+	// RequestID is the request id carried by the context of the broadcast.
+	RequestID string
+	// Deadline is the time tx should be committed before.
+	Deadline time.Time
+	Status   TxStatus
+	// Response is the result of committed or failed tx. It is nil otherwise.
+	Response *sdk.TxResponse
+}
+
+// tracker polls the node for tracked txs in background and resolves them.
+type tracker struct {
+	cfg   TxTracking
+	clock Clock
+	// nodes returns clients of the nodes to poll in order of preference. They're asked on every poll,
+	// so the tracker follows the current clients of the broadcaster.
+	nodes func() []rpcclient.Client
+	// sequence returns the committed sequence of the account.
+	sequence func(ctx context.Context, node rpcclient.Client, addr sdk.AccAddress) (uint64, error)
+
+	mu       sync.Mutex
+	txs      map[string]*TrackedTx
+	resolved []string       // resolved keeps hashes of resolved txs in order of resolution, so they're evicted first.
+	misses   map[string]int // misses counts polls in a row tx is missing from mempool and blocks.
+	// callbacks are called once when tx with the hash is resolved.
+	callbacks map[string][]func(TrackedTx)
+
+	stop      chan struct{}
+	done      chan struct{}
+	stopOnce  sync.Once
+	resolving sync.WaitGroup // resolving counts running TxTracking.OnResolve calls.
+}
+
+func newTracker(
+	cfg TxTracking,
+	clock Clock,
+	nodes func() []rpcclient.Client,
+	sequence func(ctx context.Context, node rpcclient.Client, addr sdk.AccAddress) (uint64, error),
+) *tracker {
+	if cfg.MaxTxs <= 0 {
+		cfg.MaxTxs = DefaultTrackerMaxTxs
+	}
+
+	t := &tracker{
+		cfg:      cfg,
+		clock:    clock,
+		nodes:    nodes,
+		sequence: sequence,
+
+		txs:       make(map[string]*TrackedTx),
+		misses:    make(map[string]int),
+		callbacks: make(map[string][]func(TrackedTx)),
+
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go t.run()
+
+	return t
+}
+
+func (t *tracker) run() {
+	defer close(t.done)
+
+	for {
+		timer := t.clock.NewTimer(t.cfg.Interval)
+
+		select {
+		case <-t.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+			t.poll()
+		}
+	}
+}
+
+// add registers tx. It returns false if there is no space for it.
+func (t *tracker) add(tx TrackedTx) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.addLocked(tx)
+}
+
+// addLocked registers tx. t.mu should be held by caller.
+func (t *tracker) addLocked(tx TrackedTx) bool {
+	if _, ok := t.txs[tx.TxHash]; ok {
+		return true
+	}
+
+	if len(t.txs) >= t.cfg.MaxTxs {
+		if len(t.resolved) == 0 {
+			return false
+		}
+
+		delete(t.txs, t.resolved[0])
+		t.resolved = t.resolved[1:]
+	}
+
+	t.txs[tx.TxHash] = &tx
+
+	return true
+}
+
+// subscribe registers the callback called once tx is resolved. Unknown tx is registered first,
+// the callback of already resolved tx is called immediately. It returns false if there is no space for tx.
+// Callbacks run in their own goroutines, so they don't block the tracker.
+func (t *tracker) subscribe(tx TrackedTx, fn func(TrackedTx)) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.addLocked(tx) {
+		return false
+	}
+
+	if v := t.txs[tx.TxHash]; v.Status != TxPending {
+		go fn(*v)
+		return true
+	}
+
+	t.callbacks[tx.TxHash] = append(t.callbacks[tx.TxHash], fn)
+
+	return true
+}
+
+// poll looks up pending txs and resolves them. The next node is polled when the previous one is unavailable.
+func (t *tracker) poll() {
+	pending := t.pending()
+	if len(pending) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.cfg.Interval)
+	defer cancel()
+
+	for _, node := range t.nodes() {
+		if err := t.pollNode(ctx, node, pending); err == nil || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// pollNode resolves pending txs with the node. It returns error if the node is unavailable.
+func (t *tracker) pollNode(ctx context.Context, node rpcclient.Client, pending []TrackedTx) error {
+	// Mempool is fetched before txs are looked up, so tx committed in between isn't considered lost.
+	mempool, complete, err := t.mempool(ctx, node)
+	if err != nil {
+		return err
+	}
+	now := t.clock.Now()
+	sequences := make(map[string]uint64)
+
+	for _, v := range pending {
+		hash, err := hex.DecodeString(v.TxHash)
+		if err != nil {
+			continue
+		}
+
+		// When mempool is too big to be fetched completely, tx missing from the fetched part is lost
+		// if its sequence is used by another tx. The sequence is fetched before tx is looked up for the same reason.
+		var replaced bool
+		if !complete && !mempool[v.TxHash] && !v.Signer.Empty() {
+			replaced = t.sequenceUsed(ctx, node, v, sequences)
+		}
+
+		// The node returns error until tx is committed.
+		res, err := node.Tx(ctx, hash, false)
+		if err == nil {
+			resp := sdk.NewResponseResultTx(res, nil, "")
+			if resp.Code == 0 {
+				t.resolve(v.TxHash, TxCommitted, resp)
+			} else {
+				t.resolve(v.TxHash, TxFailed, resp)
+			}
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		switch {
+		case replaced && strings.Contains(err.Error(), "not found"):
+			t.resolve(v.TxHash, TxLost, nil)
+		case complete && t.countMiss(v.TxHash, !mempool[v.TxHash]) >= lostPolls:
+			t.resolve(v.TxHash, TxLost, nil)
+		case now.After(v.Deadline):
+			t.resolve(v.TxHash, TxExpired, nil)
+		}
+	}
+
+	return nil
+}
+
+// mempool returns hashes of the first mempoolLimit txs in the node's mempool. The second value is false
+// when mempool has more txs, so missing txs say nothing.
+func (t *tracker) mempool(ctx context.Context, node rpcclient.Client) (map[string]bool, bool, error) {
+	limit := mempoolLimit
+	res, err := node.UnconfirmedTxs(ctx, &limit)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get unconfirmed txs: %w", err)
+	}
+
+	out := make(map[string]bool, len(res.Txs))
+	for _, v := range res.Txs {
+		out[TxHash(v)] = true
+	}
+
+	return out, res.Count == res.Total, nil
+}
+
+// sequenceUsed returns true if the committed sequence of tx's signer is past tx's one. Sequences are cached
+// in the map by signer. False is returned when the sequence can't be fetched.
+func (t *tracker) sequenceUsed(ctx context.Context, node rpcclient.Client, tx TrackedTx, sequences map[string]uint64) bool {
+	seq, ok := sequences[tx.Signer.String()]
+	if !ok {
+		var err error
+		if seq, err = t.sequence(ctx, node, tx.Signer); err != nil {
+			return false
+		}
+		sequences[tx.Signer.String()] = seq
+	}
+
+	return seq > tx.Sequence
+}
+
+// countMiss counts a poll where tx is missing from mempool, or resets the count when it's there.
+// It returns the number of polls in a row tx is missing.
+func (t *tracker) countMiss(hash string, missing bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !missing {
+		delete(t.misses, hash)
+		return 0
+	}
+
+	t.misses[hash]++
+
+	return t.misses[hash]
+}
+
+// resolve sets the final status of tx and calls TxTracking.OnResolve. Resolved and unknown txs are ignored.
+func (t *tracker) resolve(hash string, status TxStatus, resp *sdk.TxResponse) {
+	t.mu.Lock()
+	tx, ok := t.txs[hash]
+	if !ok || tx.Status != TxPending {
+		t.mu.Unlock()
+		return
+	}
+
+	tx.Status, tx.Response = status, resp
+	t.resolved = append(t.resolved, hash)
+	delete(t.misses, hash)
+	callbacks := t.callbacks[hash]
+	delete(t.callbacks, hash)
+	out := *tx
+	t.mu.Unlock()
+
+	for _, fn := range callbacks {
+		go fn(out)
+	}
+
+	if t.cfg.OnResolve != nil {
+		t.resolving.Add(1)
+		go func() {
+			defer t.resolving.Done()
+			t.cfg.OnResolve(out)
+		}()
+	}
+}
+
+// pending returns unresolved txs.
+func (t *tracker) pending() []TrackedTx {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var out []TrackedTx
+	for _, v := range t.txs {
+		if v.Status == TxPending {
+			out = append(out, *v)
+		}
+	}
+
+	return out
+}
+
+// get returns tracked tx with the hash.
+func (t *tracker) get(hash string) (TrackedTx, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tx, ok := t.txs[hash]
+	if !ok {
+		return TrackedTx{}, false
+	}
+
+	return *tx, true
+}
+
+// close stops the tracker and waits for it to exit and for TxTracking.OnResolve calls to return.
+func (t *tracker) close() {
+	t.stopOnce.Do(func() {
+		close(t.stop)
+	})
+	<-t.done
+	t.resolving.Wait()
+}
+
+// track registers successfully broadcast tx in the tracker if tracking is enabled.
+func (b *broadcaster) track(ctx context.Context, res *BroadcastResult, msgs []sdk.Msg) {
+	if b.tracker == nil {
+		return
+	}
+
+	ok := b.tracker.add(TrackedTx{
+		TxHash:    res.TxHash,
+		Signer:    res.Signer,
+		Sequence:  res.Sequence,
+		Msgs:      msgs,
+		RequestID: RequestIDFromContext(ctx),
+		Deadline:  b.trackingDeadline(),
+	})
+	if !ok {
+		b.warnf(ctx, "tx %s isn't tracked, all %d tracked txs are pending", res.TxHash, b.tracker.cfg.MaxTxs)
+	}
+}
+
+// trackerNodes returns clients of the primary node and the extra ones, which are polled when it's unavailable.
+func (b *broadcaster) trackerNodes() []rpcclient.Client {
+	out := make([]rpcclient.Client, 0, 1+len(b.extraClients))
+	out = append(out, b.client.Client())
+	for _, c := range b.extraClients {
+		out = append(out, c.Client())
+	}
+
+	return out
+}
+
+// trackedSequence returns the committed sequence of the account fetched from the node.
+func (b *broadcaster) trackedSequence(_ context.Context, node rpcclient.Client, addr sdk.AccAddress) (uint64, error) {
+	_, seq, err := b.ctx.AccountRetriever.GetAccountNumberSequence(b.ctx.WithClient(node), addr)
+	return seq, err
+}
+
+// trackingDeadline returns the deadline of tx tracked from now.
+func (b *broadcaster) trackingDeadline() time.Time {
+	timeout := b.cfg.Tracking.Timeout
+	if timeout == 0 {
+		timeout = b.cfg.commitTimeout()
+	}
+
+	return b.cfg.clock().Now().Add(timeout)
+}
+
+// OnCommit calls fn once tx with the hash is resolved by the tracker. Committed tx is passed with nil error,
+// failed one with TxError, and ErrTxExpired or ErrTxLost are passed when tx isn't committed.
+// The hash is tracked from now on if the tracker doesn't know it. The callback of already resolved tx is called
+// immediately. Callbacks run in their own goroutines. ErrTrackingDisabled is returned if Config.Tracking is disabled.
+func (b *broadcaster) OnCommit(txHash string, fn func(*sdk.TxResponse, error)) error {
+	if b.tracker == nil {
+		return ErrTrackingDisabled
+	}
+
+	if _, err := hex.DecodeString(txHash); err != nil {
+		return fmt.Errorf("invalid tx hash: %w", err)
+	}
+
+	ok := b.tracker.subscribe(TrackedTx{TxHash: strings.ToUpper(txHash), Deadline: b.trackingDeadline()}, func(tx TrackedTx) {
+		fn(tx.Response, trackedError(tx))
+	})
+	if !ok {
+		return fmt.Errorf("failed to track tx: all %d tracked txs are pending", b.tracker.cfg.MaxTxs)
+	}
+
+	return nil
+}
+
+// trackedError returns the error of resolved tx: TxError for failed one, ErrTxExpired or ErrTxLost
+// for one which isn't committed. It's nil for committed tx.
+func trackedError(tx TrackedTx) error {
+	switch tx.Status {
+	case TxCommitted:
+		return nil
+	case TxFailed:
+		return newTxError(tx.Response)
+	case TxExpired:
+		return fmt.Errorf("%w: %s", ErrTxExpired, tx.TxHash)
+	default:
+		return fmt.Errorf("%w: %s", ErrTxLost, tx.TxHash)
+	}
+}
+
+// resolveTracked resolves tracked tx with the committed response.
+func (b *broadcaster) resolveTracked(resp *sdk.TxResponse) {
+	if b.tracker == nil {
+		return
+	}
+
+	if resp.Code == 0 {
+		b.tracker.resolve(resp.TxHash, TxCommitted, resp)
+	} else {
+		b.tracker.resolve(resp.TxHash, TxFailed, resp)
+	}
+}
+
+// PendingTxs returns tracked txs which aren't resolved yet. It's empty when tracking is disabled.
+func (b *broadcaster) PendingTxs() []TrackedTx {
+	if b.tracker == nil {
+		return nil
+	}
+
+	return b.tracker.pending()
+}
+
+// TrackedTx returns tracked tx with the hash. Resolved txs are kept until they're evicted by new ones.
+func (b *broadcaster) TrackedTx(txHash string) (TrackedTx, bool) {
+	if b.tracker == nil {
+		return TrackedTx{}, false
+	}
+
+	return b.tracker.get(txHash)
+}
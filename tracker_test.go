@@ -0,0 +1,451 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// trackingInterval is the polling interval of the tracker in tests.
+const trackingInterval = 10 * time.Millisecond
+
+// busyNode is the fake node whose mempool looks too big to be listed completely.
+type busyNode struct {
+	*testutil.FakeNode
+}
+
+func (n *busyNode) UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
+	res, err := n.FakeNode.UnconfirmedTxs(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	res.Total += 1000
+
+	return res, nil
+}
+
+// unavailableNode is the fake node which fails tx lookups when it's down, while broadcasts still work.
+type unavailableNode struct {
+	*testutil.FakeNode
+	down int32
+}
+
+func (n *unavailableNode) UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
+	if atomic.LoadInt32(&n.down) == 1 {
+		return nil, errors.New("connection refused")
+	}
+
+	return n.FakeNode.UnconfirmedTxs(ctx, limit)
+}
+
+func (n *unavailableNode) Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error) {
+	if atomic.LoadInt32(&n.down) == 1 {
+		return nil, errors.New("connection refused")
+	}
+
+	return n.FakeNode.Tx(ctx, hash, prove)
+}
+
+// trackedTxs is the part of the broadcaster giving access to tracked txs.
+type trackedTxs interface {
+	TrackedTx(txHash string) (broadcaster.TrackedTx, bool)
+}
+
+// requireStatus waits for the tracked tx to get the status.
+func requireStatus(t *testing.T, b trackedTxs, hash string, status broadcaster.TxStatus) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		tx, _ := b.TrackedTx(hash)
+		return tx.Status == status
+	}, 5*time.Second, time.Millisecond, "tx isn't %s", status)
+}
+
+func TestTracker(t *testing.T) {
+	tt := []struct {
+		name    string
+		timeout time.Duration
+		client  func(node *testutil.FakeNode) rpcclient.Client
+		gas     uint64
+		amount  int64
+		resolve func(t *testing.T, node *testutil.FakeNode, key testutil.Key, hash string)
+		want    broadcaster.TxStatus
+	}{
+		{
+			name: "committed",
+			resolve: func(t *testing.T, node *testutil.FakeNode, _ testutil.Key, _ string) {
+				node.NextBlock()
+			},
+			want: broadcaster.TxCommitted,
+		},
+		{
+			name: "failed",
+			// The gas is fixed, so the failing msg isn't simulated.
+			gas:    200000,
+			amount: 2_000_000_000,
+			resolve: func(t *testing.T, node *testutil.FakeNode, _ testutil.Key, _ string) {
+				node.NextBlock()
+			},
+			want: broadcaster.TxFailed,
+		},
+		{
+			name:    "expired",
+			timeout: 50 * time.Millisecond,
+			resolve: func(*testing.T, *testutil.FakeNode, testutil.Key, string) {},
+			want:    broadcaster.TxExpired,
+		},
+		{
+			name: "lost",
+			resolve: func(t *testing.T, node *testutil.FakeNode, _ testutil.Key, hash string) {
+				require.True(t, node.Evict(hash))
+			},
+			want: broadcaster.TxLost,
+		},
+		{
+			// The tx isn't in the listed part of the mempool, but its sequence is used by another tx.
+			name: "lost on busy node",
+			client: func(node *testutil.FakeNode) rpcclient.Client {
+				return &busyNode{FakeNode: node}
+			},
+			resolve: func(t *testing.T, node *testutil.FakeNode, key testutil.Key, hash string) {
+				require.True(t, node.Evict(hash))
+				node.SetSequence(key.Address, 1)
+			},
+			want: broadcaster.TxLost,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			cfg := testConfig(node, key)
+			if tc.client != nil {
+				cfg.RPCClient = tc.client(node)
+			}
+			resolved := make(chan broadcaster.TrackedTx, 1)
+			cfg.Tracking = broadcaster.TxTracking{
+				Interval:  trackingInterval,
+				Timeout:   tc.timeout,
+				OnResolve: func(tx broadcaster.TrackedTx) { resolved <- tx },
+			}
+			if tc.timeout == 0 {
+				cfg.Tracking.Timeout = time.Minute
+			}
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			amount := tc.amount
+			if amount == 0 {
+				amount = 1
+			}
+			msgs := []sdk.Msg{sendMsg(key.Address, 1), sendMsg(key.Address, amount)}
+			res, err := b.BroadcastContext(context.Background(), msgs, "", broadcaster.BroadcastOptions{Gas: tc.gas})
+			require.NoError(t, err)
+
+			pending := b.PendingTxs()
+			require.Len(t, pending, 1)
+			require.Equal(t, res.TxHash, pending[0].TxHash)
+			require.Equal(t, key.Address, pending[0].Signer)
+			require.Equal(t, res.Sequence, pending[0].Sequence)
+			require.Equal(t, msgs, pending[0].Msgs)
+
+			tc.resolve(t, node, key, res.TxHash)
+
+			select {
+			case tx := <-resolved:
+				require.Equal(t, res.TxHash, tx.TxHash)
+				require.Equal(t, tc.want, tx.Status, tx.Status.String())
+				if tc.want == broadcaster.TxCommitted || tc.want == broadcaster.TxFailed {
+					require.Equal(t, res.TxHash, tx.Response.TxHash)
+				} else {
+					require.Nil(t, tx.Response)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("tx isn't resolved")
+			}
+			require.Empty(t, b.PendingTxs())
+		})
+	}
+}
+
+func TestTracker_BusyNodeKeepsPendingTx(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = &busyNode{FakeNode: node}
+	cfg.Tracking = broadcaster.TxTracking{Interval: trackingInterval, Timeout: time.Minute}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	// The tx could be in the part of the mempool which isn't listed, so it isn't lost while its sequence is unused.
+	// Tx missing from the complete mempool would be lost after two polls.
+	require.True(t, node.Evict(res.TxHash))
+	calls := node.Calls("unconfirmed_txs")
+	require.Eventually(t, func() bool { return node.Calls("unconfirmed_txs") >= calls+3 }, 5*time.Second, time.Millisecond)
+
+	tx, ok := b.TrackedTx(res.TxHash)
+	require.True(t, ok)
+	require.Equal(t, broadcaster.TxPending, tx.Status)
+}
+
+func TestTracker_Failover(t *testing.T) {
+	node, key := newFakeChain(t)
+	primary := &unavailableNode{FakeNode: node}
+
+	srv := node.Serve()
+	defer srv.Close()
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = primary
+	cfg.ExtraNodeURIs = []string{srv.URL}
+	cfg.Tracking = broadcaster.TxTracking{Interval: trackingInterval, Timeout: time.Minute}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	// The primary node goes down after the broadcast, the tx is followed through the extra one.
+	atomic.StoreInt32(&primary.down, 1)
+	node.NextBlock()
+
+	requireStatus(t, b, res.TxHash, broadcaster.TxCommitted)
+}
+
+func TestTracker_OnResolveDoesntBlockPolling(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	release := make(chan struct{})
+	var calls int32
+	cfg := testConfig(node, key)
+	cfg.Tracking = broadcaster.TxTracking{
+		Interval: trackingInterval,
+		Timeout:  time.Minute,
+		OnResolve: func(broadcaster.TrackedTx) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+		},
+	}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+
+	broadcast := func() string {
+		res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+		require.NoError(t, err)
+		node.NextBlock()
+		return res.TxHash
+	}
+
+	// The second tx is resolved while the callback of the first one is blocked.
+	requireStatus(t, b, broadcast(), broadcaster.TxCommitted)
+	requireStatus(t, b, broadcast(), broadcaster.TxCommitted)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, 5*time.Second, time.Millisecond)
+
+	// Close waits for the callbacks.
+	closed := make(chan struct{})
+	go func() {
+		_ = b.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("close doesn't wait for callbacks")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcaster isn't closed")
+	}
+}
+
+func TestTracker_MaxTxs(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.Tracking = broadcaster.TxTracking{Interval: trackingInterval, Timeout: time.Minute, MaxTxs: 1}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	broadcast := func() string {
+		res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+		require.NoError(t, err)
+		return res.TxHash
+	}
+
+	// The pending tx isn't evicted, so the next one isn't tracked.
+	first := broadcast()
+	second := broadcast()
+	_, ok := b.TrackedTx(second)
+	require.False(t, ok)
+
+	// The resolved tx is evicted by the next one.
+	node.NextBlock()
+	requireStatus(t, b, first, broadcaster.TxCommitted)
+
+	third := broadcast()
+	_, ok = b.TrackedTx(first)
+	require.False(t, ok)
+	_, ok = b.TrackedTx(third)
+	require.True(t, ok)
+}
+
+// commitResult is the outcome passed to OnCommit callback.
+type commitResult struct {
+	resp *sdk.TxResponse
+	err  error
+}
+
+func TestOnCommit(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.Tracking = broadcaster.TxTracking{Interval: trackingInterval, Timeout: time.Minute}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	onCommit := func(hash string) <-chan commitResult {
+		out := make(chan commitResult, 1)
+		require.NoError(t, b.OnCommit(hash, func(resp *sdk.TxResponse, err error) {
+			out <- commitResult{resp: resp, err: err}
+		}))
+		return out
+	}
+	wait := func(ch <-chan commitResult) commitResult {
+		select {
+		case res := <-ch:
+			return res
+		case <-time.After(5 * time.Second):
+			t.Fatal("callback isn't called")
+			return commitResult{}
+		}
+	}
+
+	broadcast := func(b contextBroadcaster, opts broadcaster.BroadcastOptions) string {
+		res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", opts)
+		require.NoError(t, err)
+		return res.TxHash
+	}
+
+	// The blocked callback doesn't delay others.
+	hash := broadcast(b, broadcaster.BroadcastOptions{})
+	block := make(chan struct{})
+	defer close(block)
+	require.NoError(t, b.OnCommit(hash, func(*sdk.TxResponse, error) { <-block }))
+	committed := onCommit(hash)
+	node.NextBlock()
+
+	res := wait(committed)
+	require.NoError(t, res.err)
+	require.Equal(t, hash, res.resp.TxHash)
+
+	// The callback of resolved tx is called immediately.
+	res = wait(onCommit(hash))
+	require.NoError(t, res.err)
+	require.Equal(t, hash, res.resp.TxHash)
+
+	// Tx broadcast elsewhere is tracked once it's registered, the hash case doesn't matter.
+	other, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer other.Close()
+
+	hash = broadcast(other, broadcaster.BroadcastOptions{})
+	committed = onCommit(strings.ToLower(hash))
+	node.NextBlock()
+
+	res = wait(committed)
+	require.NoError(t, res.err)
+	require.Equal(t, hash, res.resp.TxHash)
+
+	// Failed tx is passed with its error.
+	hash = broadcast(b, broadcaster.BroadcastOptions{Gas: 200000, Fees: sdk.NewCoins(sdk.NewInt64Coin(testDenom, 1))})
+	failed := onCommit(hash)
+	node.SetBalance(key.Address, sdk.NewInt64Coin(testDenom, 1))
+	node.NextBlock()
+
+	res = wait(failed)
+	var txErr *broadcaster.TxError
+	require.ErrorAs(t, res.err, &txErr)
+	require.ErrorIs(t, res.err, sdkerrors.ErrInsufficientFunds)
+	require.Equal(t, hash, res.resp.TxHash)
+
+	// Lost tx is passed with ErrTxLost.
+	node.SetBalance(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+	hash = broadcast(b, broadcaster.BroadcastOptions{})
+	lost := onCommit(hash)
+	require.True(t, node.Evict(hash))
+
+	res = wait(lost)
+	require.ErrorIs(t, res.err, broadcaster.ErrTxLost)
+	require.Nil(t, res.resp)
+
+	require.Error(t, b.OnCommit("not a hash", func(*sdk.TxResponse, error) {}))
+}
+
+func TestOnCommit_Expired(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	cfg := testConfig(node, key)
+	cfg.Tracking = broadcaster.TxTracking{Interval: trackingInterval, Timeout: 50 * time.Millisecond}
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+
+	errs := make(chan error, 1)
+	require.NoError(t, b.OnCommit(res.TxHash, func(_ *sdk.TxResponse, err error) { errs <- err }))
+
+	select {
+	case err := <-errs:
+		require.ErrorIs(t, err, broadcaster.ErrTxExpired)
+		require.Equal(t, broadcaster.ClassTransient, broadcaster.Classify(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback isn't called")
+	}
+}
+
+func TestOnCommit_TrackingDisabled(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	err = b.OnCommit(fixtureTxHash, func(*sdk.TxResponse, error) {})
+	require.ErrorIs(t, err, broadcaster.ErrTrackingDisabled)
+	require.Empty(t, b.PendingTxs())
+}
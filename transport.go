@@ -0,0 +1,203 @@
+package broadcaster
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	jsonrpcclient "github.com/tendermint/tendermint/rpc/jsonrpc/client"
+)
+
+// ClientOption configures SharedClient.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	proxyURL         string
+	transport        TransportConfig
+	clock            Clock
+	maxSubscriptions int
+}
+
+// TransportConfig tunes connections to the node. Zero values keep defaults of net/http.
+type TransportConfig struct {
+	// MaxIdleConns limits idle connections. It's unlimited by default.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits idle connections to the node. http.DefaultMaxIdleConnsPerHost is used by default.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes connections which are idle for the duration. They aren't closed by default.
+	IdleConnTimeout time.Duration
+	// KeepAlive is the interval of TCP keep-alive probes. Negative value disables them.
+	KeepAlive time.Duration
+	// HTTP2 makes the client use h2 with https nodes. HTTP/1.1 is used by default.
+	HTTP2 bool
+
+	// DNSRefreshInterval is the interval of the node's host re-resolution. The host is also re-resolved
+	// after failed requests. When its addresses change, idle connections are closed. It isn't re-resolved by default.
+	DNSRefreshInterval time.Duration
+	// Resolver resolves the node's host. net.DefaultResolver is used by default.
+	Resolver Resolver
+}
+
+// Validate validates the transport config.
+func (t TransportConfig) Validate() error {
+	if t.MaxIdleConns < 0 || t.MaxIdleConnsPerHost < 0 || t.IdleConnTimeout < 0 || t.DNSRefreshInterval < 0 {
+		return errors.New("transport limits should be positive")
+	}
+
+	return nil
+}
+
+// ConnStats contains stats of connections to the node.
+type ConnStats struct {
+	// Created is the number of new connections.
+	Created uint64
+	// Reused is the number of requests sent over existing connections.
+	Reused uint64
+}
+
+// connStats counts connections through httptrace.
+type connStats struct {
+	created uint64
+	reused  uint64
+}
+
+func (s *connStats) get() ConnStats {
+	return ConnStats{
+		Created: atomic.LoadUint64(&s.created),
+		Reused:  atomic.LoadUint64(&s.reused),
+	}
+}
+
+// tracingTransport counts connections used by requests and reports failed requests.
+// Error statuses of non-JSON responses are turned into errors.
+type tracingTransport struct {
+	base    http.RoundTripper
+	stats   *connStats
+	onError func()
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.AddUint64(&t.stats.reused, 1)
+			} else {
+				atomic.AddUint64(&t.stats.created, 1)
+			}
+		},
+	}
+
+	res, err := t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		if t.onError != nil {
+			t.onError()
+		}
+		return nil, err
+	}
+
+	// Errors of gateways, e.g. 404 page of a disabled endpoint, aren't JSON and the rpc client
+	// reports them only as broken JSON, so the status is returned as the error.
+	if res.StatusCode >= http.StatusBadRequest && !strings.Contains(res.Header.Get("Content-Type"), "json") {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("unexpected response status %s", res.Status)
+	}
+
+	return res, nil
+}
+
+// WithProxyURL makes the client connect to the node through the proxy. Schemes http, https and socks5 are supported.
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used by default.
+func WithProxyURL(proxyURL string) ClientOption {
+	return func(o *clientOptions) {
+		o.proxyURL = proxyURL
+	}
+}
+
+// WithClock sets clock used by the client's background work. RealClock is used by default.
+func WithClock(clock Clock) ClientOption {
+	return func(o *clientOptions) {
+		o.clock = clock
+	}
+}
+
+// WithMaxSubscriptions limits concurrent websocket subscriptions of the client.
+// DefaultMaxSubscriptions is used by default.
+func WithMaxSubscriptions(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxSubscriptions = n
+	}
+}
+
+// WithTransport tunes connections to the node.
+func WithTransport(t TransportConfig) ClientOption {
+	return func(o *clientOptions) {
+		o.transport = t
+	}
+}
+
+// clientOptions returns options of clients created for the config.
+func (c Config) clientOptions() []ClientOption {
+	opts := []ClientOption{WithTransport(c.Transport), WithClock(c.clock()), WithMaxSubscriptions(c.MaxSubscriptions)}
+	if c.ProxyURL != "" {
+		opts = append(opts, WithProxyURL(c.ProxyURL))
+	}
+
+	return opts
+}
+
+// parseProxyURL parses proxy url and checks its scheme.
+func parseProxyURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %s", u.Scheme)
+	}
+}
+
+// newHTTPClient returns http client of the node. Unlike the one used by client.NewClientFromNode it dials
+// the node through the proxy, applies transport tuning and counts connections. Unix sockets are dialed directly.
+func newHTTPClient(nodeURI string, o clientOptions, stats *connStats) (*http.Client, error) {
+	httpClient, err := jsonrpcclient.DefaultHTTPClient(nodeURI)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := httpClient.Transport.(*http.Transport)
+	transport.MaxIdleConns = o.transport.MaxIdleConns
+	transport.MaxIdleConnsPerHost = o.transport.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = o.transport.IdleConnTimeout
+	transport.ForceAttemptHTTP2 = o.transport.HTTP2
+
+	if !strings.HasPrefix(nodeURI, "unix") {
+		proxy := http.ProxyFromEnvironment
+		if o.proxyURL != "" {
+			u, err := parseProxyURL(o.proxyURL)
+			if err != nil {
+				return nil, err
+			}
+			proxy = http.ProxyURL(u)
+		}
+
+		// The default dialer always dials the node, so it's replaced to let the transport dial the proxy.
+		transport.Dial = nil
+		transport.DialContext = dialContext(&net.Dialer{KeepAlive: o.transport.KeepAlive}, o.transport.resolver())
+		transport.Proxy = proxy
+	}
+
+	httpClient.Transport = &tracingTransport{base: transport, stats: stats}
+
+	return httpClient, nil
+}
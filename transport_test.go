@@ -0,0 +1,227 @@
+package broadcaster_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// testProxy is http proxy which records the requests passed through it.
+type testProxy struct {
+	mu        sync.Mutex
+	connects  []string // connects are the addresses of CONNECT requests.
+	forwarded int
+}
+
+func (p *testProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		p.mu.Lock()
+		p.forwarded++
+		p.mu.Unlock()
+
+		r.RequestURI = ""
+		res, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer res.Body.Close()
+
+		for k, v := range res.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(res.StatusCode)
+		_, _ = io.Copy(w, res.Body)
+		return
+	}
+
+	p.mu.Lock()
+	p.connects = append(p.connects, r.Host)
+	p.mu.Unlock()
+
+	upstream, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		_ = upstream.Close()
+		return
+	}
+	_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+
+	go func() {
+		_, _ = io.Copy(upstream, conn)
+		_ = upstream.Close()
+	}()
+	go func() {
+		_, _ = io.Copy(conn, upstream)
+		_ = conn.Close()
+	}()
+}
+
+func (p *testProxy) stats() ([]string, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string(nil), p.connects...), p.forwarded
+}
+
+func TestProxyURL(t *testing.T) {
+	node, key := newFakeChain(t)
+	srv := node.Serve()
+	defer srv.Close()
+
+	p := &testProxy{}
+	proxy := httptest.NewServer(p)
+	defer proxy.Close()
+
+	cfg := uriConfig(node, key, srv.URL)
+	cfg.ProxyURL = proxy.URL
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Requests are passed through the proxy.
+	_, forwarded := p.stats()
+	require.NotZero(t, forwarded)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	recipient := sendMsg(key.Address, 1).ToAddress
+	events := make(chan broadcaster.Event, 1)
+	errs := make(chan error, 1)
+	go func() {
+		e, err := b.WaitForEvent(ctx, "transfer.recipient='"+recipient+"'")
+		if err != nil {
+			errs <- err
+			return
+		}
+		events <- e
+	}()
+
+	for node.Calls("subscribe") == 0 {
+		select {
+		case err := <-errs:
+			require.NoError(t, err)
+		case <-ctx.Done():
+			t.Fatal("websocket subscription isn't made")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	res, err := b.BroadcastContext(ctx, []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+
+	select {
+	case e := <-events:
+		require.Equal(t, "transfer", e.Type)
+	case err := <-errs:
+		require.NoError(t, err)
+	}
+
+	// The websocket connection is tunneled through the proxy.
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	connects, _ := p.stats()
+	require.Equal(t, []string{u.Host}, connects)
+}
+
+func TestConnStats(t *testing.T) {
+	for _, keepAlive := range []bool{true, false} {
+		keepAlive := keepAlive
+		t.Run(fmt.Sprintf("keepalive=%t", keepAlive), func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			var conns int32
+			srv := httptest.NewUnstartedServer(node.Handler())
+			srv.Config.SetKeepAlivesEnabled(keepAlive)
+			srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt32(&conns, 1)
+				}
+			}
+			srv.Start()
+			defer srv.Close()
+
+			cfg := uriConfig(node, key, srv.URL)
+			cfg.Transport = broadcaster.TransportConfig{KeepAlive: 30 * time.Second, IdleConnTimeout: time.Minute}
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			for i := 0; i < 3; i++ {
+				_, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+				require.NoError(t, err)
+			}
+
+			// Every connection accepted by the node is counted, the others are reused.
+			stats := b.Stats().Conns
+			require.EqualValues(t, atomic.LoadInt32(&conns), stats.Created)
+			if keepAlive {
+				require.EqualValues(t, 1, stats.Created)
+				require.NotZero(t, stats.Reused)
+			} else {
+				require.Greater(t, stats.Created, uint64(3))
+				require.Zero(t, stats.Reused)
+			}
+		})
+	}
+}
+
+func BenchmarkBroadcast_KeepAlive(b *testing.B) {
+	for _, keepAlive := range []bool{true, false} {
+		keepAlive := keepAlive
+		b.Run(fmt.Sprintf("keepalive=%t", keepAlive), func(b *testing.B) {
+			node := testutil.NewFakeNode()
+			key := testutil.NewKey(b.Name())
+			node.AddAccount(key.Address, sdk.NewInt64Coin(testDenom, 1_000_000_000))
+
+			srv := httptest.NewUnstartedServer(node.Handler())
+			srv.Config.SetKeepAlivesEnabled(keepAlive)
+			srv.Start()
+			defer srv.Close()
+
+			cfg := uriConfig(node, key, srv.URL)
+			if !keepAlive {
+				cfg.Transport.KeepAlive = -1
+			}
+
+			br, err := broadcaster.New(cfg)
+			require.NoError(b, err)
+			defer br.Close()
+
+			created := br.Stats().Conns.Created
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := br.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.StopTimer()
+			b.ReportMetric(float64(br.Stats().Conns.Created-created)/float64(b.N), "conns/op")
+		})
+	}
+}
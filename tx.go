@@ -0,0 +1,142 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth/legacy/legacytx"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// ErrInvalidSignedTx is returned when signed tx doesn't pass local validation.
+var ErrInvalidSignedTx = errors.New("invalid signed tx")
+
+// TxHash returns hash of encoded tx in the same form as tendermint does (upper-hex sha256).
+func TxHash(txBytes []byte) string {
+	return fmt.Sprintf("%X", tmtypes.Tx(txBytes).Hash())
+}
+
+// BroadcastSignedJSON broadcasts tx signed by CLI (e.g. "decentrd tx sign").
+// Both proto-json and legacy amino-json encodings are supported.
+// Signatures are verified against broadcaster's chain-id before broadcasting.
+func (b *broadcaster) BroadcastSignedJSON(ctx context.Context, txJSON []byte) (*sdk.TxResponse, error) {
+	signedTx, err := b.decodeTxJSON(txJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tx: %w", err)
+	}
+
+	if err := b.verifySignatures(signedTx); err != nil {
+		return nil, err
+	}
+
+	txBytes, err := b.ctx.TxConfig.TxEncoder()(signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tx: %w", err)
+	}
+
+	return b.BroadcastRaw(ctx, txBytes)
+}
+
+// decodeTxJSON decodes proto-json or amino-json encoded tx into tx which could be encoded with TxConfig.
+// Amino-json could be either a bare tx or the request produced by "tx sign --amino".
+func (b *broadcaster) decodeTxJSON(txJSON []byte) (authsigning.Tx, error) {
+	protoTx, protoErr := b.ctx.TxConfig.TxJSONDecoder()(txJSON)
+	if protoErr == nil {
+		signingTx, ok := protoTx.(authsigning.Tx)
+		if !ok {
+			return nil, fmt.Errorf("unexpected tx type %T", protoTx)
+		}
+		return signingTx, nil
+	}
+
+	var stdTx legacytx.StdTx
+	if err := b.ctx.LegacyAmino.UnmarshalJSON(txJSON, &stdTx); err != nil || len(stdTx.Msgs) == 0 {
+		var req struct {
+			Tx legacytx.StdTx `json:"tx"`
+		}
+		if rerr := b.ctx.LegacyAmino.UnmarshalJSON(txJSON, &req); rerr != nil {
+			if err == nil {
+				err = rerr
+			}
+			return nil, fmt.Errorf("neither proto-json (%s) nor amino-json (%s)", protoErr, err)
+		}
+		stdTx = req.Tx
+	}
+
+	builder := b.ctx.TxConfig.NewTxBuilder()
+	if err := tx.CopyTx(stdTx, builder, false); err != nil {
+		return nil, fmt.Errorf("failed to convert amino tx: %w", err)
+	}
+
+	// Amino signatures don't keep the sequence, so it's the signer's current one like the node expects.
+	sigs, err := builder.GetTx().GetSignaturesV2()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signatures: %w", err)
+	}
+	for i, signer := range builder.GetTx().GetSigners() {
+		if i >= len(sigs) {
+			break
+		}
+
+		_, seq, err := b.ctx.AccountRetriever.GetAccountNumberSequence(b.ctx, signer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sequence of %s: %w", signer, err)
+		}
+		sigs[i].Sequence = seq
+	}
+	if err := builder.SetSignatures(sigs...); err != nil {
+		return nil, fmt.Errorf("failed to set signatures: %w", err)
+	}
+
+	return builder.GetTx(), nil
+}
+
+// verifySignatures checks that tx is completely signed for broadcaster's chain-id.
+func (b *broadcaster) verifySignatures(signedTx authsigning.Tx) error {
+	signers := signedTx.GetSigners()
+
+	sigs, err := signedTx.GetSignaturesV2()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get signatures: %s", ErrInvalidSignedTx, err)
+	}
+
+	if len(sigs) == 0 {
+		return fmt.Errorf("%w: tx is not signed", ErrInvalidSignedTx)
+	}
+
+	if len(sigs) != len(signers) {
+		return fmt.Errorf("%w: expected %d signatures, got %d", ErrInvalidSignedTx, len(signers), len(sigs))
+	}
+
+	for i, sig := range sigs {
+		if sig.PubKey == nil {
+			return fmt.Errorf("%w: signature %d has no public key", ErrInvalidSignedTx, i)
+		}
+
+		if !signers[i].Equals(sdk.AccAddress(sig.PubKey.Address())) {
+			return fmt.Errorf("%w: signature %d is made by %s instead of %s",
+				ErrInvalidSignedTx, i, sdk.AccAddress(sig.PubKey.Address()), signers[i])
+		}
+
+		num, _, err := b.ctx.AccountRetriever.GetAccountNumberSequence(b.ctx, signers[i])
+		if err != nil {
+			return fmt.Errorf("failed to get account number of %s: %w", signers[i], err)
+		}
+
+		signerData := authsigning.SignerData{
+			ChainID:       b.ctx.ChainID,
+			AccountNumber: num,
+			Sequence:      sig.Sequence,
+		}
+
+		if err := authsigning.VerifySignature(sig.PubKey, signerData, sig.Data, b.ctx.TxConfig.SignModeHandler(), signedTx); err != nil {
+			return fmt.Errorf("%w: signature %d is not valid for chain-id %s: %s", ErrInvalidSignedTx, i, b.ctx.ChainID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package broadcaster_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+// cliSigner is the address of the key used to sign the fixtures in testdata/cli by decentrd v1.6.2:
+//
+//	decentrd keys add cli --recover --keyring-backend test   # "abandon ... about" mnemonic
+//	decentrd tx bank send $ADDR $ADDR 10udec --generate-only --chain-id fake-chain \
+//		--fees 500udec --gas 200000 --note "cli fixture" > unsigned.json
+//	decentrd tx sign unsigned.json --from cli --offline -a 1 -s 0 --chain-id fake-chain \
+//		--keyring-backend test --output-document direct.json
+//
+// amino_json_mode.json adds --sign-mode amino-json, amino.json adds -s 3 --amino --sign-mode amino-json
+// and wrong_chain.json is signed with --chain-id other-chain.
+const cliSigner = "decentr19rl4cm2hmr8afy4kldpxz3fka4jguq0ahwtf7a"
+
+func TestBroadcastSignedJSON(t *testing.T) {
+	tt := []struct {
+		name    string
+		fixture string
+		seq     uint64
+		wantErr error
+	}{
+		{name: "direct", fixture: "direct.json"},
+		{name: "amino-json sign mode", fixture: "amino_json_mode.json"},
+		{name: "amino encoding", fixture: "amino.json", seq: 3},
+		{name: "unsigned", fixture: "unsigned.json", wantErr: broadcaster.ErrInvalidSignedTx},
+		{name: "wrong chain id", fixture: "wrong_chain.json", wantErr: broadcaster.ErrInvalidSignedTx},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			signer, err := sdk.AccAddressFromBech32(cliSigner)
+			require.NoError(t, err)
+			node.AddAccount(signer, sdk.NewInt64Coin(testDenom, 1_000_000))
+			node.SetAccountNumber(key.Address, 2)
+			node.SetAccountNumber(signer, 1)
+			node.SetSequence(signer, tc.seq)
+
+			b, err := broadcaster.New(testConfig(node, key))
+			require.NoError(t, err)
+			defer b.Close()
+
+			txJSON, err := os.ReadFile(filepath.Join("testdata", "cli", tc.fixture))
+			require.NoError(t, err)
+
+			res, err := b.BroadcastSignedJSON(context.Background(), txJSON)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				require.Zero(t, node.Calls("broadcast_tx_sync"))
+				return
+			}
+			require.NoError(t, err)
+
+			mempool := node.Mempool()
+			require.Len(t, mempool, 1)
+			require.Equal(t, mempool[0].Hash, res.TxHash)
+			require.Equal(t, signer, mempool[0].Signer)
+			require.Equal(t, "cli fixture", mempool[0].Memo)
+			require.Equal(t, tc.seq, mempool[0].Sequence)
+			requireCommitted(t, node, res.TxHash)
+		})
+	}
+}
+
+func TestBroadcastSignedJSON_DecodeError(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	_, err = b.BroadcastSignedJSON(context.Background(), []byte(`{"body":`))
+	require.ErrorContains(t, err, "failed to decode tx")
+	require.Zero(t, node.Calls("broadcast_tx_sync"))
+}
+
+func TestTxHash(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, res.TxHash, res.Response.TxHash)
+
+	mempool := node.Mempool()
+	require.Len(t, mempool, 1)
+	require.Equal(t, broadcaster.TxHash(mempool[0].Bytes), res.TxHash)
+
+	node.NextBlock()
+	committed, ok := node.CommittedTx(res.TxHash)
+	require.True(t, ok)
+	require.Equal(t, res.TxHash, committed.Hash.String())
+}
+
+func TestTxHash_TransportFailure(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.FailNext("broadcast_tx_sync", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")})
+
+	cfg := testConfig(node, key)
+	cfg.DisableAutoRetry = true
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	msgs := []sdk.Msg{sendMsg(key.Address, 1)}
+	res, err := b.BroadcastContext(context.Background(), msgs, "", broadcaster.BroadcastOptions{})
+	require.Error(t, err)
+	require.NotNil(t, res)
+	require.NotEmpty(t, res.TxHash)
+	require.Nil(t, res.Response)
+
+	// The sequence isn't consumed, so the same tx is signed again and could be looked up by the known hash.
+	again, err := b.BroadcastContext(context.Background(), msgs, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, res.TxHash, again.TxHash)
+	requireCommitted(t, node, res.TxHash)
+}
@@ -0,0 +1,147 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// simulatePath is the abci query path of the tx service's Simulate.
+const simulatePath = "/cosmos.tx.v1beta1.Service/Simulate"
+
+// TxServiceMode defines how txs are simulated and broadcast.
+type TxServiceMode string
+
+// Tx service modes.
+const (
+	// TxServiceAuto uses the gRPC tx service if Config.GRPCAddr is set and the node supports it.
+	TxServiceAuto TxServiceMode = ""
+	// TxServiceLegacy simulates through abci queries and broadcasts through tendermint rpc.
+	TxServiceLegacy TxServiceMode = "legacy"
+	// TxServiceGRPC simulates and broadcasts through the gRPC tx service at Config.GRPCAddr.
+	TxServiceGRPC TxServiceMode = "grpc"
+)
+
+// Validate validates the mode.
+func (m TxServiceMode) Validate() error {
+	switch m {
+	case TxServiceAuto, TxServiceLegacy, TxServiceGRPC:
+		return nil
+	default:
+		return fmt.Errorf("invalid tx service mode %s", m)
+	}
+}
+
+// dialTxService connects to the gRPC tx service according to Config.TxService.
+// It returns nil if the legacy path should be used.
+func (b *broadcaster) dialTxService(ctx context.Context) (*grpc.ClientConn, error) {
+	if b.cfg.GRPCAddr == "" || b.cfg.TxService == TxServiceLegacy {
+		return nil, nil
+	}
+
+	conn, err := grpc.Dial(b.cfg.GRPCAddr, grpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", b.cfg.GRPCAddr, err)
+	}
+
+	if b.cfg.TxService == TxServiceGRPC {
+		return conn, nil
+	}
+
+	// Empty request is rejected as invalid by nodes which support the service.
+	_, err = txtypes.NewServiceClient(conn).Simulate(ctx, &txtypes.SimulateRequest{})
+	if code := status.Code(err); code == codes.Unimplemented || code == codes.Unavailable {
+		b.cfg.logger().Warnf("gRPC tx service is unavailable, legacy path is used: %s", err)
+		_ = conn.Close()
+		return nil, nil
+	}
+
+	return conn, nil
+}
+
+// simulateTxService simulates tx through the gRPC tx service.
+func (b *broadcaster) simulateTxService(ctx context.Context, txf tx.Factory, msgs []sdk.Msg, ext extensionOptions) (uint64, error) {
+	txBytes, err := b.buildSimTx(txf, msgs, ext)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := txtypes.NewServiceClient(b.txService).Simulate(ctx, &txtypes.SimulateRequest{TxBytes: txBytes})
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(txf.GasAdjustment() * float64(res.GasInfo.GasUsed)), nil
+}
+
+// simulateABCI simulates tx through abci query. Unlike tx.CalculateGas it respects ctx.
+func (b *broadcaster) simulateABCI(ctx context.Context, txf tx.Factory, msgs []sdk.Msg, ext extensionOptions) (uint64, error) {
+	txBytes, err := b.buildSimTx(txf, msgs, ext)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := (&txtypes.SimulateRequest{TxBytes: txBytes}).Marshal()
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	node, err := b.ctx.GetNode()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	res, err := node.ABCIQueryWithOptions(ctx, simulatePath, req, rpcclient.ABCIQueryOptions{})
+	if err != nil {
+		return 0, err
+	}
+	if !res.Response.IsOK() {
+		return 0, sdkerrors.ABCIError(res.Response.Codespace, res.Response.Code, res.Response.Log)
+	}
+
+	var simRes txtypes.SimulateResponse
+	if err := simRes.Unmarshal(res.Response.Value); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return uint64(txf.GasAdjustment() * float64(simRes.GasInfo.GasUsed)), nil
+}
+
+// sendTx broadcasts tx to the primary node through the gRPC tx service if it's used, otherwise through rpc.
+func (b *broadcaster) sendTx(ctx context.Context, clientCtx client.Context, txBytes []byte) (*sdk.TxResponse, error) {
+	if b.txService == nil {
+		return broadcastTx(ctx, clientCtx, txBytes)
+	}
+
+	var mode txtypes.BroadcastMode
+	switch clientCtx.BroadcastMode {
+	case flags.BroadcastSync:
+		mode = txtypes.BroadcastMode_BROADCAST_MODE_SYNC
+	case flags.BroadcastAsync:
+		mode = txtypes.BroadcastMode_BROADCAST_MODE_ASYNC
+	case flags.BroadcastBlock:
+		mode = txtypes.BroadcastMode_BROADCAST_MODE_BLOCK
+	default:
+		return nil, fmt.Errorf("unsupported broadcast mode %s", clientCtx.BroadcastMode)
+	}
+
+	res, err := txtypes.NewServiceClient(b.txService).BroadcastTx(ctx, &txtypes.BroadcastTxRequest{
+		TxBytes: txBytes,
+		Mode:    mode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.TxResponse, nil
+}
@@ -0,0 +1,171 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// txServiceBackends are the ways txs reach the fake node: the calls made for simulation and broadcast through them.
+var txServiceBackends = []struct {
+	name      string
+	mode      broadcaster.TxServiceMode
+	simulate  string
+	broadcast string
+}{
+	{name: "legacy", mode: broadcaster.TxServiceLegacy, simulate: "abci_query", broadcast: "broadcast_tx_sync"},
+	{name: "grpc", mode: broadcaster.TxServiceGRPC, simulate: "tx_service/Simulate", broadcast: "tx_service/BroadcastTx"},
+	{name: "auto", mode: broadcaster.TxServiceAuto, simulate: "tx_service/Simulate", broadcast: "tx_service/BroadcastTx"},
+}
+
+// txServiceConfig returns config of broadcaster talking to the node through the tx service mode.
+func txServiceConfig(t *testing.T, node *testutil.FakeNode, key testutil.Key, mode broadcaster.TxServiceMode) broadcaster.Config {
+	t.Helper()
+
+	addr, stop, err := node.ServeGRPC()
+	require.NoError(t, err)
+	t.Cleanup(stop)
+
+	cfg := testConfig(node, key)
+	cfg.GRPCAddr = addr
+	cfg.TxService = mode
+	return cfg
+}
+
+func TestTxService_Backends(t *testing.T) {
+	tt := []struct {
+		name    string
+		prepare func(node *testutil.FakeNode, key testutil.Key)
+		opts    broadcaster.BroadcastOptions
+		check   func(t *testing.T, res *broadcaster.BroadcastResult, err error)
+	}{
+		{
+			name: "success",
+			check: func(t *testing.T, res *broadcaster.BroadcastResult, err error) {
+				require.NoError(t, err)
+				require.Zero(t, res.Response.Code)
+			},
+		},
+		{
+			name: "rejected",
+			prepare: func(node *testutil.FakeNode, _ testutil.Key) {
+				node.OnCheckTx(func(testutil.FakeTx) error { return sdkerrors.ErrInsufficientFunds })
+			},
+			opts: broadcaster.BroadcastOptions{Gas: 100000, DisableAutoRetry: true},
+			check: func(t *testing.T, _ *broadcaster.BroadcastResult, err error) {
+				require.ErrorIs(t, err, sdkerrors.ErrInsufficientFunds)
+				require.Equal(t, broadcaster.ClassInsufficientFunds, broadcaster.Classify(err))
+			},
+		},
+		{
+			name: "sequence mismatch",
+			prepare: func(node *testutil.FakeNode, key testutil.Key) {
+				node.SetSequence(key.Address, 3)
+			},
+			check: func(t *testing.T, res *broadcaster.BroadcastResult, err error) {
+				require.NoError(t, err)
+				require.Zero(t, res.Response.Code)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// Every backend gets the same chain, so the same tx is built and the responses are comparable.
+			var responses []*sdk.TxResponse
+			for _, backend := range txServiceBackends {
+				node, key := newFakeChain(t)
+				node.SetTxGas(60000)
+
+				b, err := broadcaster.New(txServiceConfig(t, node, key, backend.mode))
+				require.NoError(t, err, backend.name)
+				defer b.Close()
+
+				if tc.prepare != nil {
+					tc.prepare(node, key)
+				}
+				simulations, broadcasts := node.Calls(backend.simulate), node.Calls(backend.broadcast)
+
+				res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", tc.opts)
+				tc.check(t, res, err)
+				if err == nil {
+					requireCommitted(t, node, res.TxHash)
+					responses = append(responses, res.Response)
+				} else {
+					var txErr *broadcaster.TxError
+					require.ErrorAs(t, err, &txErr)
+					responses = append(responses, txErr.Response)
+				}
+
+				// The calls are made through the backend only.
+				if tc.opts.Gas == 0 {
+					require.Greater(t, node.Calls(backend.simulate), simulations, backend.name)
+				}
+				require.Greater(t, node.Calls(backend.broadcast), broadcasts, backend.name)
+				if backend.mode == broadcaster.TxServiceLegacy {
+					require.Zero(t, node.Calls("tx_service/BroadcastTx"), backend.name)
+				} else {
+					// The fake service broadcasts through the node's rpc, so every rpc broadcast comes from it.
+					require.Equal(t, node.Calls("tx_service/BroadcastTx"), node.Calls("broadcast_tx_sync"), backend.name)
+				}
+			}
+
+			require.Len(t, responses, len(txServiceBackends))
+			for _, res := range responses[1:] {
+				require.Equal(t, responses[0].TxHash, res.TxHash)
+				require.Equal(t, responses[0].Code, res.Code)
+				require.Equal(t, responses[0].Codespace, res.Codespace)
+				require.Equal(t, responses[0].RawLog, res.RawLog)
+			}
+		})
+	}
+}
+
+func TestTxService_SimulatedGas(t *testing.T) {
+	for _, backend := range txServiceBackends {
+		backend := backend
+		t.Run(backend.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+			node.SetTxGas(60000)
+
+			cfg := txServiceConfig(t, node, key, backend.mode)
+			cfg.GasAdjust = 1.5
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+			require.NoError(t, err)
+
+			txs := node.Mempool()
+			require.Len(t, txs, 1)
+			require.Equal(t, res.TxHash, txs[0].Hash)
+			require.Equal(t, uint64(1.5*float64(60000+testutil.DefaultFakeMsgGas)), txs[0].GasLimit)
+		})
+	}
+}
+
+func TestTxService_AutoFallback(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	// Nothing listens at the address, so the legacy path is used.
+	cfg := testConfig(node, key)
+	cfg.GRPCAddr = "127.0.0.1:1"
+
+	b, err := broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	requireCommitted(t, node, res.TxHash)
+	require.NotZero(t, node.Calls("broadcast_tx_sync"))
+}
@@ -0,0 +1,85 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/tendermint/spm/cosmoscmd"
+
+	"github.com/Decentr-net/decentr/app"
+)
+
+// contextBroadcaster is implemented by broadcasters supporting options, e.g. one returned by New.
+type contextBroadcaster interface {
+	BroadcastContext(ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions) (*BroadcastResult, error)
+}
+
+// clientContexter is implemented by broadcasters exposing their client context, e.g. one returned by New.
+type clientContexter interface {
+	ClientContext() client.Context
+}
+
+var (
+	defaultRegistryOnce sync.Once
+	defaultRegistry     codectypes.InterfaceRegistry
+)
+
+// interfaceRegistry returns the registry of b. Broadcasters without a client context get the registry
+// of the chain's msgs and their responses.
+func interfaceRegistry(b Broadcaster) codectypes.InterfaceRegistry {
+	if cc, ok := b.(clientContexter); ok {
+		if registry := cc.ClientContext().InterfaceRegistry; registry != nil {
+			return registry
+		}
+	}
+
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry = cosmoscmd.MakeEncodingConfig(app.ModuleBasics).InterfaceRegistry
+		RegisterMsgResponses(defaultRegistry)
+	})
+
+	return defaultRegistry
+}
+
+// BroadcastTyped broadcasts msg, waits for it to be committed and returns its response decoded into TResp.
+// The error matches ErrUnexpectedMsgResponse when the response has another type.
+// Broadcasters which don't support options are used through BroadcastMsg, so their broadcast mode
+// should wait for commit.
+func BroadcastTyped[TResp proto.Message](
+	ctx context.Context, b Broadcaster, msg sdk.Msg, memo string,
+) (TResp, *BroadcastResult, error) {
+	var out TResp
+
+	var (
+		res *BroadcastResult
+		err error
+	)
+	if cb, ok := b.(contextBroadcaster); ok {
+		res, err = cb.BroadcastContext(ctx, []sdk.Msg{msg}, memo, BroadcastOptions{Mode: ModeCommit})
+	} else {
+		var resp *sdk.TxResponse
+		if resp, err = b.BroadcastMsg(msg, memo); resp != nil {
+			res = &BroadcastResult{Signer: b.From(), TxHash: resp.TxHash, Response: resp}
+		}
+	}
+	if err != nil {
+		return out, res, err
+	}
+
+	if res == nil || res.Response == nil || res.Response.Height == 0 {
+		return out, res, errors.New("tx isn't committed, its msg response is unavailable")
+	}
+
+	if out, err = UnpackMsgResponse[TResp](interfaceRegistry(b), res.Response, 0); err != nil {
+		return out, res, fmt.Errorf("failed to unpack response of %s: %w", sdk.MsgTypeURL(msg), err)
+	}
+
+	return out, res, nil
+}
@@ -0,0 +1,57 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/mock"
+)
+
+func TestBroadcastTyped(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetAutoBlock(true)
+
+	// The commit is awaited regardless of the configured mode.
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	resp, res, err := broadcaster.BroadcastTyped[*banktypes.MsgSendResponse](context.Background(), b, sendMsg(key.Address, 1), "")
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	require.Positive(t, res.Response.Height)
+
+	// The response of another type is reported, the result is returned anyway.
+	_, res, err = broadcaster.BroadcastTyped[*govtypes.MsgSubmitProposalResponse](context.Background(), b, sendMsg(key.Address, 1), "")
+	require.ErrorIs(t, err, broadcaster.ErrUnexpectedMsgResponse)
+	require.ErrorContains(t, err, "cosmos.bank.v1beta1.MsgSendResponse")
+	require.NotNil(t, res)
+	require.Positive(t, res.Response.Height)
+}
+
+func TestBroadcastTyped_Mock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	m := mock.NewMockBroadcaster(ctrl)
+	msg := sendMsg(sdk.AccAddress("sender"), 1)
+
+	m.EXPECT().From().Return(sdk.AccAddress("sender")).AnyTimes()
+	m.EXPECT().BroadcastMsg(msg, "memo").Return(&sdk.TxResponse{TxHash: "AB", Height: 10, Data: msgResponsesData}, nil)
+
+	resp, res, err := broadcaster.BroadcastTyped[*banktypes.MsgSendResponse](context.Background(), m, msg, "memo")
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	require.Equal(t, "AB", res.TxHash)
+
+	// Response of uncommitted tx has no msg responses.
+	m.EXPECT().BroadcastMsg(msg, "").Return(&sdk.TxResponse{TxHash: "AB"}, nil)
+
+	_, _, err = broadcaster.BroadcastTyped[*banktypes.MsgSendResponse](context.Background(), m, msg, "")
+	require.ErrorContains(t, err, "isn't committed")
+}
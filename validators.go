@@ -0,0 +1,113 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// validatorsPerPage is the maximal page size allowed by the node.
+const validatorsPerPage = 100
+
+// Validator is a member of the validator set.
+type Validator struct {
+	Address     string
+	VotingPower int64
+}
+
+// ValidatorSet is the validator set at the height.
+type ValidatorSet struct {
+	Height           int64
+	Validators       []Validator
+	TotalVotingPower int64
+}
+
+// GetValidators returns the validator set at the height. The latest set is returned if the height is 0.
+func (b *broadcaster) GetValidators(ctx context.Context, height int64) (ValidatorSet, error) {
+	node, err := b.ctx.GetNode()
+	if err != nil {
+		return ValidatorSet{}, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	height, vals, err := fetchValidators(ctx, node, height)
+	if err != nil {
+		return ValidatorSet{}, err
+	}
+
+	out := ValidatorSet{Height: height}
+	for _, v := range vals {
+		out.Validators = append(out.Validators, Validator{
+			Address:     v.Address.String(),
+			VotingPower: v.VotingPower,
+		})
+		out.TotalVotingPower += v.VotingPower
+	}
+
+	return out, nil
+}
+
+// fetchValidators returns the height and all validators of the set at the height. The latest set is returned if the height is 0.
+func fetchValidators(ctx context.Context, node rpcclient.Client, height int64) (int64, []*tmtypes.Validator, error) {
+	var h *int64
+	if height > 0 {
+		h = &height
+	}
+
+	var out []*tmtypes.Validator
+	perPage := validatorsPerPage
+	for page := 1; ; page++ {
+		res, err := node.Validators(ctx, h, &page, &perPage)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to fetch validators page %d: %w", page, err)
+		}
+
+		// Next pages should be taken at the same height even if new blocks appear meanwhile.
+		height = res.BlockHeight
+		h = &height
+
+		out = append(out, res.Validators...)
+
+		if len(res.Validators) == 0 || len(out) >= res.Total {
+			return height, out, nil
+		}
+	}
+}
+
+// VotingPowerOnline returns the fraction of voting power which signed the last commit.
+func (b *broadcaster) VotingPowerOnline(ctx context.Context) (float64, error) {
+	node, err := b.ctx.GetNode()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	commit, err := node.Commit(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch commit: %w", err)
+	}
+
+	set, err := b.GetValidators(ctx, commit.Height)
+	if err != nil {
+		return 0, err
+	}
+	if set.TotalVotingPower == 0 {
+		return 0, nil
+	}
+
+	signed := make(map[string]struct{}, len(commit.Commit.Signatures))
+	for _, v := range commit.Commit.Signatures {
+		if v.BlockIDFlag == tmtypes.BlockIDFlagCommit {
+			signed[v.ValidatorAddress.String()] = struct{}{}
+		}
+	}
+
+	var power int64
+	for _, v := range set.Validators {
+		if _, ok := signed[v.Address]; ok {
+			power += v.VotingPower
+		}
+	}
+
+	return float64(power) / float64(set.TotalVotingPower), nil
+}
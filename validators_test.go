@@ -0,0 +1,136 @@
+package broadcaster_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+	"github.com/Decentr-net/go-broadcaster/testutil"
+)
+
+// cannedValidators is the fake node which answers validators requests with the canned set.
+// The latest height grows with every request, so pages requested without the height would be inconsistent.
+type cannedValidators struct {
+	*testutil.FakeNode
+
+	set     []*tmtypes.Validator
+	latest  int64
+	pages   []int
+	heights []int64
+}
+
+func (c *cannedValidators) Validators(_ context.Context, height *int64, page, perPage *int) (*ctypes.ResultValidators, error) {
+	c.latest++
+	h := c.latest
+	if height != nil {
+		h = *height
+	}
+	c.pages = append(c.pages, *page)
+	c.heights = append(c.heights, h)
+
+	res := &ctypes.ResultValidators{BlockHeight: h, Total: len(c.set)}
+	for i := (*page - 1) * *perPage; i < len(c.set) && i < *page**perPage; i++ {
+		res.Validators = append(res.Validators, c.set[i])
+	}
+	res.Count = len(res.Validators)
+
+	return res, nil
+}
+
+func TestGetValidators_Pagination(t *testing.T) {
+	tt := []struct {
+		name  string
+		count int
+		pages []int
+	}{
+		{name: "single page", count: 3, pages: []int{1}},
+		{name: "full page", count: 100, pages: []int{1}},
+		{name: "several pages", count: 250, pages: []int{1, 2, 3}},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			node, key := newFakeChain(t)
+
+			canned := &cannedValidators{FakeNode: node, latest: 41}
+			var total int64
+			for i := 0; i < tc.count; i++ {
+				v := tmtypes.NewValidator(ed25519.GenPrivKey().PubKey(), int64(i+1))
+				canned.set = append(canned.set, v)
+				total += v.VotingPower
+			}
+
+			cfg := testConfig(node, key)
+			cfg.RPCClient = canned
+
+			b, err := broadcaster.New(cfg)
+			require.NoError(t, err)
+			defer b.Close()
+
+			set, err := b.GetValidators(context.Background(), 0)
+			require.NoError(t, err)
+			require.Equal(t, int64(42), set.Height)
+			require.Equal(t, total, set.TotalVotingPower)
+			require.Len(t, set.Validators, tc.count)
+			for i, v := range set.Validators {
+				require.Equal(t, canned.set[i].Address.String(), v.Address)
+				require.Equal(t, canned.set[i].VotingPower, v.VotingPower)
+			}
+
+			// Pages are stitched at the height of the first one.
+			require.Equal(t, tc.pages, canned.pages)
+			for _, h := range canned.heights {
+				require.Equal(t, int64(42), h)
+			}
+
+			set, err = b.GetValidators(context.Background(), 7)
+			require.NoError(t, err)
+			require.Equal(t, int64(7), set.Height)
+		})
+	}
+}
+
+// absentCommit is the fake node which reports the first validator absent from the last commit.
+type absentCommit struct {
+	*testutil.FakeNode
+}
+
+func (c absentCommit) Commit(ctx context.Context, height *int64) (*ctypes.ResultCommit, error) {
+	res, err := c.FakeNode.Commit(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	res.Commit.Signatures[0] = tmtypes.NewCommitSigAbsent()
+
+	return res, nil
+}
+
+func TestVotingPowerOnline(t *testing.T) {
+	node, key := newFakeChain(t)
+	node.SetValidators(4)
+	node.NextBlock()
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	online, err := b.VotingPowerOnline(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1.0, online)
+
+	cfg := testConfig(node, key)
+	cfg.RPCClient = absentCommit{node}
+	b, err = broadcaster.New(cfg)
+	require.NoError(t, err)
+	defer b.Close()
+
+	online, err = b.VotingPowerOnline(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0.75, online)
+}
@@ -0,0 +1,140 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedNodeVersion is returned by New when the node's version is out of the supported range
+// and Config.StrictVersionCheck is set.
+var ErrUnsupportedNodeVersion = errors.New("unsupported node version")
+
+// NodeVersion contains versions of the node software.
+type NodeVersion struct {
+	// Tendermint is the version of the node's Tendermint.
+	Tendermint string
+	// App is the version of the application, e.g. decentr.
+	App string
+}
+
+// checkNodeVersion fetches the node's versions and compares the app version with
+// Config.MinNodeVersion and Config.MaxNodeVersion.
+func (b *broadcaster) checkNodeVersion(ctx context.Context) error {
+	node, err := b.ctx.GetNode()
+	if err != nil {
+		return fmt.Errorf("failed to get node: %w", err)
+	}
+
+	status, err := node.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch status: %w", err)
+	}
+
+	info, err := node.ABCIInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch ABCIInfo: %w", err)
+	}
+
+	b.nodeVersion = NodeVersion{
+		Tendermint: status.NodeInfo.Version,
+		App:        info.Response.Version,
+	}
+
+	if err := checkVersionRange(b.nodeVersion.App, b.cfg.MinNodeVersion, b.cfg.MaxNodeVersion); err != nil {
+		if b.cfg.StrictVersionCheck {
+			return err
+		}
+		b.cfg.logger().Warnf("%s", err)
+	}
+
+	return nil
+}
+
+// checkVersionRange returns ErrUnsupportedNodeVersion if the version is out of [min, max].
+// Empty bounds aren't checked.
+func checkVersionRange(version, min, max string) error {
+	if min == "" && max == "" {
+		return nil
+	}
+
+	v, err := parseVersion(version)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnsupportedNodeVersion, err)
+	}
+
+	if min != "" {
+		if m, _ := parseVersion(min); v.compare(m) < 0 {
+			return fmt.Errorf("%w: %s is older than %s", ErrUnsupportedNodeVersion, version, min)
+		}
+	}
+
+	if max != "" {
+		if m, _ := parseVersion(max); v.compare(m) > 0 {
+			return fmt.Errorf("%w: %s is newer than %s", ErrUnsupportedNodeVersion, version, max)
+		}
+	}
+
+	return nil
+}
+
+// semver is a parsed semantic version. Missing minor and patch parts are zeros.
+type semver struct {
+	parts      [3]int
+	prerelease string
+}
+
+// parseVersion parses version like v1.6.2 or 1.6.2-rc1. Build metadata is ignored.
+func parseVersion(s string) (semver, error) {
+	var v semver
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, v.prerelease = s[:i], s[i+1:]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return semver{}, fmt.Errorf("invalid version %q", s)
+	}
+
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return semver{}, fmt.Errorf("invalid version %q", s)
+		}
+		v.parts[i] = n
+	}
+
+	return v, nil
+}
+
+// compare returns -1, 0 or 1 if v is older, the same or newer than o. Pre-release is older than the release.
+func (v semver) compare(o semver) int {
+	for i := range v.parts {
+		switch {
+		case v.parts[i] < o.parts[i]:
+			return -1
+		case v.parts[i] > o.parts[i]:
+			return 1
+		}
+	}
+
+	switch {
+	case v.prerelease == o.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case o.prerelease == "":
+		return -1
+	case v.prerelease < o.prerelease:
+		return -1
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,110 @@
+package broadcaster
+
+import (
+	"context"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// View is a broadcaster with its own default options. Account, sequence and the rpc client are shared
+// with the parent broadcaster, so views could be used concurrently by different subsystems.
+type View struct {
+	b        *broadcaster
+	defaults BroadcastOptions
+}
+
+var _ Broadcaster = &View{}
+
+// WithDefaults returns a view which applies opts to every broadcast. Options of a call override view's ones.
+func (b *broadcaster) WithDefaults(opts BroadcastOptions) *View {
+	return &View{
+		b:        b,
+		defaults: opts,
+	}
+}
+
+// From returns address of broadcaster.
+func (v *View) From() sdk.AccAddress {
+	return v.b.From()
+}
+
+// GetHeight returns current height.
+func (v *View) GetHeight(ctx context.Context) (uint64, error) {
+	return v.b.GetHeight(ctx)
+}
+
+// LatestBlock returns height, time and hash of the latest block.
+func (v *View) LatestBlock(ctx context.Context) (BlockInfo, error) {
+	return v.b.LatestBlock(ctx)
+}
+
+// GetHeightFresh returns current height bypassing the cache.
+func (v *View) GetHeightFresh(ctx context.Context) (uint64, error) {
+	return v.b.GetHeightFresh(ctx)
+}
+
+// BroadcastMsg broadcasts alone message.
+func (v *View) BroadcastMsg(msg sdk.Msg, memo string) (*sdk.TxResponse, error) {
+	return v.Broadcast([]sdk.Msg{msg}, memo)
+}
+
+// Broadcast broadcasts messages.
+func (v *View) Broadcast(msgs []sdk.Msg, memo string) (*sdk.TxResponse, error) {
+	res, err := v.BroadcastContext(context.Background(), msgs, memo, BroadcastOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Response, nil
+}
+
+// BroadcastContext broadcasts messages with view's options overridden by opts.
+func (v *View) BroadcastContext(ctx context.Context, msgs []sdk.Msg, memo string, opts BroadcastOptions) (*BroadcastResult, error) {
+	return v.b.BroadcastContext(ctx, msgs, memo, mergeOptions(v.defaults, opts))
+}
+
+// PingContext pings node.
+func (v *View) PingContext(ctx context.Context) error {
+	return v.b.PingContext(ctx)
+}
+
+// mergeOptions returns base options overridden by non-zero fields of override.
+// Fee related fields are overridden together, so fees and gas prices don't conflict.
+func mergeOptions(base, override BroadcastOptions) BroadcastOptions {
+	out := base
+
+	if override.Fees != nil || override.FeesStr != "" || override.GasPrices != nil || override.GasPricesStr != "" {
+		out.Fees, out.FeesStr = override.Fees, override.FeesStr
+		out.GasPrices, out.GasPricesStr = override.GasPrices, override.GasPricesStr
+	}
+	if override.Gas != 0 {
+		out.Gas = override.Gas
+	}
+	if override.GasPerMsgType != nil {
+		out.GasPerMsgType = override.GasPerMsgType
+	}
+	if override.GasAdjust != 0 {
+		out.GasAdjust = override.GasAdjust
+	}
+	if override.Mode != "" {
+		out.Mode = override.Mode
+	}
+	if override.MemoPrefix != "" {
+		out.MemoPrefix = override.MemoPrefix
+	}
+
+	out.Hedge = base.Hedge || override.Hedge
+	out.Speculative = base.Speculative || override.Speculative
+	out.DisableAutoRetry = base.DisableAutoRetry || override.DisableAutoRetry
+	out.AlwaysSimulate = base.AlwaysSimulate || override.AlwaysSimulate
+	out.IdempotencyKey = override.IdempotencyKey
+
+	if override.ExtensionOptions != nil {
+		out.ExtensionOptions = override.ExtensionOptions
+	}
+	if override.NonCriticalExtensionOptions != nil {
+		out.NonCriticalExtensionOptions = override.NonCriticalExtensionOptions
+	}
+
+	return out
+}
@@ -0,0 +1,133 @@
+package broadcaster_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	broadcaster "github.com/Decentr-net/go-broadcaster"
+)
+
+func TestWithDefaults_Interleaving(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	views := map[string]*broadcaster.View{
+		"rewards:": b.WithDefaults(broadcaster.BroadcastOptions{MemoPrefix: "rewards:", Gas: 150000}),
+		"posts:":   b.WithDefaults(broadcaster.BroadcastOptions{MemoPrefix: "posts:", Gas: 250000}),
+	}
+
+	const perView = 10
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = map[string][]*broadcaster.BroadcastResult{}
+		errs    = make(chan error, 2*perView)
+	)
+	for prefix, v := range views {
+		prefix, v := prefix, v
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perView; i++ {
+				res, err := v.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, fmt.Sprint(i),
+					broadcaster.BroadcastOptions{})
+				if err != nil {
+					errs <- err
+					continue
+				}
+				mu.Lock()
+				results[prefix] = append(results[prefix], res)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Both views share the sequence, so every tx got its own one at the first attempt.
+	sequences := map[uint64]bool{}
+	for _, rr := range results {
+		for _, res := range rr {
+			require.Equal(t, 1, res.Attempts)
+			require.False(t, sequences[res.Sequence], "sequence %d is reused", res.Sequence)
+			sequences[res.Sequence] = true
+		}
+	}
+	require.Len(t, sequences, 2*perView)
+	require.Equal(t, uint64(2*perView), b.TxFactory().Sequence())
+
+	mempool := map[string]struct {
+		memo string
+		gas  uint64
+	}{}
+	for _, tx := range node.Mempool() {
+		mempool[tx.Hash] = struct {
+			memo string
+			gas  uint64
+		}{tx.Memo, tx.GasLimit}
+	}
+
+	// Every tx has the prefix and gas of its own view only.
+	for prefix, rr := range results {
+		for _, res := range rr {
+			tx, ok := mempool[res.TxHash]
+			require.True(t, ok)
+			require.True(t, strings.HasPrefix(tx.memo, prefix), tx.memo)
+			for other := range views {
+				if other != prefix {
+					require.NotContains(t, tx.memo, other)
+				}
+			}
+			if prefix == "rewards:" {
+				require.Equal(t, uint64(150000), tx.gas)
+			} else {
+				require.Equal(t, uint64(250000), tx.gas)
+			}
+		}
+	}
+
+	// The parent doesn't have the prefix of the views.
+	res, err := b.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "parent", broadcaster.BroadcastOptions{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2*perView), res.Sequence)
+	for _, tx := range node.Mempool() {
+		if tx.Hash == res.TxHash {
+			require.Equal(t, "parent", tx.Memo)
+		}
+	}
+}
+
+func TestWithDefaults_Override(t *testing.T) {
+	node, key := newFakeChain(t)
+
+	b, err := broadcaster.New(testConfig(node, key))
+	require.NoError(t, err)
+	defer b.Close()
+
+	v := b.WithDefaults(broadcaster.BroadcastOptions{MemoPrefix: "view:", Gas: 150000})
+	require.Equal(t, b.From(), v.From())
+	require.NoError(t, v.PingContext(context.Background()))
+
+	res, err := v.BroadcastContext(context.Background(), []sdk.Msg{sendMsg(key.Address, 1)}, "memo",
+		broadcaster.BroadcastOptions{MemoPrefix: "call:", Gas: 180000})
+	require.NoError(t, err)
+
+	mempool := node.Mempool()
+	require.Len(t, mempool, 1)
+	require.Equal(t, res.TxHash, mempool[0].Hash)
+	require.Equal(t, "call:memo", mempool[0].Memo)
+	require.Equal(t, uint64(180000), mempool[0].GasLimit)
+}
@@ -0,0 +1,334 @@
+package broadcaster
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	tmpubsub "github.com/tendermint/tendermint/libs/pubsub"
+	"github.com/tendermint/tendermint/libs/service"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	jsonrpcclient "github.com/tendermint/tendermint/rpc/jsonrpc/client"
+	"golang.org/x/net/proxy"
+)
+
+// resubscribeDelay gives the node time to restart before subscriptions are redone after an error.
+const resubscribeDelay = time.Second
+
+// errEventsNotRunning is returned by subscriptions of the stopped client.
+var errEventsNotRunning = errors.New("client is not running, use Start method to start")
+
+// eventsClient is rpc client whose websocket subscriptions are made by wsEvents.
+// The Tendermint client dials websocket connections itself, ignoring the transport of its http client.
+type eventsClient struct {
+	rpcclient.Client
+	events *wsEvents
+}
+
+var _ rpcclient.Client = (*eventsClient)(nil)
+
+// withWSEvents returns the client making websocket connections with the dial function.
+func withWSEvents(
+	c rpcclient.Client, nodeURI string, dial func(network, addr string) (net.Conn, error), clock Clock,
+) (*eventsClient, error) {
+	events, err := newWSEvents(nodeURI, dial, clock)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventsClient{Client: c, events: events}, nil
+}
+
+// Start implements service.Service.
+func (c *eventsClient) Start() error { return c.events.Start() }
+
+// Stop implements service.Service.
+func (c *eventsClient) Stop() error { return c.events.Stop() }
+
+// IsRunning implements service.Service.
+func (c *eventsClient) IsRunning() bool { return c.events.IsRunning() }
+
+// Quit implements service.Service.
+func (c *eventsClient) Quit() <-chan struct{} { return c.events.Quit() }
+
+// Subscribe implements rpcclient.EventsClient.
+func (c *eventsClient) Subscribe(
+	ctx context.Context, subscriber, query string, outCapacity ...int,
+) (<-chan ctypes.ResultEvent, error) {
+	return c.events.Subscribe(ctx, subscriber, query, outCapacity...)
+}
+
+// Unsubscribe implements rpcclient.EventsClient.
+func (c *eventsClient) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	return c.events.Unsubscribe(ctx, subscriber, query)
+}
+
+// UnsubscribeAll implements rpcclient.EventsClient.
+func (c *eventsClient) UnsubscribeAll(ctx context.Context, subscriber string) error {
+	return c.events.UnsubscribeAll(ctx, subscriber)
+}
+
+// wsEvents delivers events of websocket subscriptions like the Tendermint client does,
+// but its connections are dialed by the given function.
+type wsEvents struct {
+	service.BaseService
+	ws    *jsonrpcclient.WSClient
+	clock Clock
+
+	mu            sync.RWMutex
+	subscriptions map[string]chan ctypes.ResultEvent // subscriptions are output channels by query.
+}
+
+func newWSEvents(nodeURI string, dial func(network, addr string) (net.Conn, error), clock Clock) (*wsEvents, error) {
+	w := &wsEvents{clock: clock, subscriptions: make(map[string]chan ctypes.ResultEvent)}
+	w.BaseService = *service.NewBaseService(nil, "wsEvents", w)
+
+	var err error
+	w.ws, err = jsonrpcclient.NewWS(nodeURI, "/websocket", jsonrpcclient.OnReconnect(func() {
+		w.resubscribe()
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create websocket client: %w", err)
+	}
+	w.ws.Dialer = dial
+
+	return w, nil
+}
+
+// OnStart implements service.Service.
+func (w *wsEvents) OnStart() error {
+	if err := w.ws.Start(); err != nil {
+		return err
+	}
+
+	go w.listen()
+
+	return nil
+}
+
+// OnStop implements service.Service.
+func (w *wsEvents) OnStop() {
+	_ = w.ws.Stop()
+}
+
+// Subscribe subscribes to the query. The returned channel has capacity 1 by default, it's never closed.
+func (w *wsEvents) Subscribe(
+	ctx context.Context, _, query string, outCapacity ...int,
+) (<-chan ctypes.ResultEvent, error) {
+	if !w.IsRunning() {
+		return nil, errEventsNotRunning
+	}
+
+	if err := w.ws.Subscribe(ctx, query); err != nil {
+		return nil, err
+	}
+
+	outCap := 1
+	if len(outCapacity) > 0 {
+		outCap = outCapacity[0]
+	}
+
+	out := make(chan ctypes.ResultEvent, outCap)
+	w.mu.Lock()
+	// The subscriber is ignored, since the node replaces it with the client's address.
+	w.subscriptions[query] = out
+	w.mu.Unlock()
+
+	return out, nil
+}
+
+// Unsubscribe unsubscribes from the query.
+func (w *wsEvents) Unsubscribe(ctx context.Context, _, query string) error {
+	if !w.IsRunning() {
+		return errEventsNotRunning
+	}
+
+	if err := w.ws.Unsubscribe(ctx, query); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	delete(w.subscriptions, query)
+	w.mu.Unlock()
+
+	return nil
+}
+
+// UnsubscribeAll unsubscribes from all the queries.
+func (w *wsEvents) UnsubscribeAll(ctx context.Context, _ string) error {
+	if !w.IsRunning() {
+		return errEventsNotRunning
+	}
+
+	if err := w.ws.UnsubscribeAll(ctx); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.subscriptions = make(map[string]chan ctypes.ResultEvent)
+	w.mu.Unlock()
+
+	return nil
+}
+
+// resubscribe redoes subscriptions, which are lost when the connection is reestablished.
+func (w *wsEvents) resubscribe() {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	for q := range w.subscriptions {
+		if err := w.ws.Subscribe(context.Background(), q); err != nil {
+			w.Logger.Error("Failed to resubscribe", "err", err)
+		}
+	}
+}
+
+// listen routes events to the channels of their queries. Events are dropped when the channel is full.
+func (w *wsEvents) listen() {
+	for {
+		select {
+		case res, ok := <-w.ws.ResponsesCh:
+			if !ok {
+				return
+			}
+
+			if res.Error != nil {
+				// Errors other than duplicate subscription mean the node failed or restarted.
+				if !strings.Contains(res.Error.Error(), tmpubsub.ErrAlreadySubscribed.Error()) {
+					select {
+					case <-w.clock.After(resubscribeDelay):
+						w.resubscribe()
+					case <-w.Quit():
+						return
+					}
+				}
+				continue
+			}
+
+			var e ctypes.ResultEvent
+			if err := tmjson.Unmarshal(res.Result, &e); err != nil {
+				w.Logger.Error("Failed to unmarshal event", "err", err)
+				continue
+			}
+
+			w.mu.RLock()
+			if out, ok := w.subscriptions[e.Query]; ok {
+				select {
+				case out <- e:
+				default:
+					w.Logger.Error("Event is dropped, channel is full", "query", e.Query)
+				}
+			}
+			w.mu.RUnlock()
+		case <-w.Quit():
+			return
+		}
+	}
+}
+
+// wsDialer returns function dialing websocket connections. The address is dialed through the proxy when it's set.
+// Proxies of HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are handled by the websocket client,
+// which passes their address to the function.
+func wsDialer(o clientOptions) (func(network, addr string) (net.Conn, error), error) {
+	dial := dialContext(&net.Dialer{KeepAlive: o.transport.KeepAlive}, o.transport.resolver())
+
+	if o.proxyURL == "" {
+		return func(network, addr string) (net.Conn, error) {
+			return dial(context.Background(), network, addr)
+		}, nil
+	}
+
+	u, err := parseProxyURL(o.proxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "socks5" {
+		d, err := proxy.FromURL(u, contextDialer(dial))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create socks5 dialer: %w", err)
+		}
+		return d.Dial, nil
+	}
+
+	return func(network, addr string) (net.Conn, error) {
+		return dialConnect(context.Background(), dial, u, addr)
+	}, nil
+}
+
+// contextDialer adapts dial function to proxy.Dialer.
+type contextDialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Dial implements proxy.Dialer.
+func (d contextDialer) Dial(network, addr string) (net.Conn, error) {
+	return d(context.Background(), network, addr)
+}
+
+// DialContext implements proxy.ContextDialer.
+func (d contextDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return d(ctx, network, addr)
+}
+
+// dialConnect dials the address through the http(s) proxy with CONNECT request.
+func dialConnect(
+	ctx context.Context, dial func(ctx context.Context, network, addr string) (net.Conn, error), proxyURL *url.URL, addr string,
+) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+
+	conn, err := dial(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial proxy: %w", err)
+	}
+	if proxyURL.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT request: %w", err)
+	}
+
+	// The node doesn't send anything before the handshake, so nothing is buffered past the response.
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to read CONNECT response: %w", err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy refused CONNECT: %s", res.Status)
+	}
+
+	return conn, nil
+}